@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+// DefaultBundleURL is the bundle of the open source PxL scripts, which is also used by the Pixie CLI.
+const DefaultBundleURL = "https://artifacts.px.dev/pxl_scripts/bundle.json"
+
+// BundleScript is a PxL script from a script bundle.
+type BundleScript struct {
+	Name     string
+	ShortDoc string
+	LongDoc  string
+	PxL      string
+	// Vis is the vis spec of the script, which defines the arguments it takes.
+	Vis *vispb.Vis
+}
+
+// bundleScript is a script, as it is stored in the bundle json.
+type bundleScript struct {
+	Pxl      string `json:"pxl"`
+	Vis      string `json:"vis"`
+	ShortDoc string `json:"ShortDoc"`
+	LongDoc  string `json:"LongDoc"`
+	OrgID    string `json:"orgID"`
+}
+
+type bundle struct {
+	Scripts map[string]*bundleScript `json:"scripts"`
+}
+
+// Bundle is a catalog of PxL scripts, read from the same script bundles that the Pixie CLI uses.
+type Bundle struct {
+	scripts map[string]*bundleScript
+}
+
+// LoadBundle reads the script bundles from the given files or URLs. Scripts that belong to an org are
+// skipped. A script replaces any script with the same name from an earlier bundle.
+func LoadBundle(ctx context.Context, bundleFiles ...string) (*Bundle, error) {
+	b := &Bundle{scripts: make(map[string]*bundleScript)}
+	for _, bundleFile := range bundleFiles {
+		bundle, err := readBundle(ctx, bundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle '%s': %w", bundleFile, err)
+		}
+		for name, s := range bundle.Scripts {
+			if s == nil || s.OrgID != "" || strings.HasPrefix(name, "org_id/") {
+				continue
+			}
+			b.scripts[name] = s
+		}
+	}
+	return b, nil
+}
+
+func readBundle(ctx context.Context, bundleFile string) (*bundle, error) {
+	var r io.Reader
+	if u, err := url.Parse(bundleFile); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(bundleFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var b bundle
+	err := json.NewDecoder(r).Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ScriptNames returns the names of the scripts in the bundle, in sorted order.
+func (b *Bundle) ScriptNames() []string {
+	names := make([]string, 0, len(b.scripts))
+	for name := range b.scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetScript returns the script with the given name, such as "px/namespace".
+func (b *Bundle) GetScript(name string) (*BundleScript, error) {
+	s, ok := b.scripts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errdefs.ErrScriptNotFound, name)
+	}
+	spec, err := vis.ParseSpec(s.Vis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the vis spec of script '%s': %w", name, err)
+	}
+	return &BundleScript{
+		Name:     name,
+		ShortDoc: s.ShortDoc,
+		LongDoc:  s.LongDoc,
+		PxL:      s.Pxl,
+		Vis:      spec,
+	}, nil
+}
+
+// ExecuteBundleScript looks up the script by name in the bundle, and runs it on vizier with the passed in arguments.
+func (v *VizierClient) ExecuteBundleScript(ctx context.Context, b *Bundle, name string, args ScriptArgs, mux TableMuxer) (*ScriptResults, error) {
+	s, err := b.GetScript(name)
+	if err != nil {
+		return nil, err
+	}
+	return v.ExecuteScriptWithArgs(ctx, s.PxL, s.Vis, args, mux)
+}
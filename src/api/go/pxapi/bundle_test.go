@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+const testBundle = `{
+  "scripts": {
+    "px/namespace": {
+      "pxl": "import px",
+      "vis": "{\"variables\":[{\"name\":\"namespace\",\"type\":\"PX_NAMESPACE\"}],\"globalFuncs\":[{\"outputName\":\"pods\",\"func\":{\"name\":\"pods_for_namespace\",\"args\":[{\"name\":\"namespace\",\"variable\":\"namespace\"}]}}]}",
+      "ShortDoc": "Namespace overview"
+    },
+    "px/cluster": {
+      "pxl": "import px",
+      "vis": ""
+    },
+    "org_id/abc/private": {
+      "pxl": "import px",
+      "orgID": "abc"
+    }
+  }
+}`
+
+func TestLoadBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	require.NoError(t, os.WriteFile(path, []byte(testBundle), 0o600))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"scripts": {"px/cluster": {"pxl": "import px\npx.display()"}}}`))
+	}))
+	defer srv.Close()
+
+	b, err := LoadBundle(context.Background(), path, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"px/cluster", "px/namespace"}, b.ScriptNames())
+
+	s, err := b.GetScript("px/namespace")
+	require.NoError(t, err)
+	assert.Equal(t, "Namespace overview", s.ShortDoc)
+	require.Len(t, s.Vis.Variables, 1)
+	assert.Equal(t, "namespace", s.Vis.Variables[0].Name)
+
+	// The later bundle replaces the script.
+	s, err = b.GetScript("px/cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "import px\npx.display()", s.PxL)
+
+	_, err = b.GetScript("org_id/abc/private")
+	assert.True(t, errors.Is(err, errdefs.ErrScriptNotFound))
+
+	_, err = LoadBundle(context.Background(), filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestExecuteBundleScript(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	require.NoError(t, os.WriteFile(path, []byte(testBundle), 0o600))
+	b, err := LoadBundle(context.Background(), path)
+	require.NoError(t, err)
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{
+		cloud:    &Client{},
+		vizierID: "abc",
+		vzClient: vzClient,
+	}
+
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			assert.Equal(t, "import px", req.QueryStr)
+			require.Len(t, req.ExecFuncs, 1)
+			assert.Equal(t, "pods_for_namespace", req.ExecFuncs[0].FuncName)
+			assert.Equal(t, "default", req.ExecFuncs[0].ArgValues[0].Value)
+			return mock_vizierpb.NewMockVizierService_ExecuteScriptClient(ctrl), nil
+		})
+
+	_, err = v.ExecuteBundleScript(context.Background(), b, "px/namespace", ScriptArgs{"namespace": "default"}, nil)
+	require.NoError(t, err)
+
+	_, err = v.ExecuteBundleScript(context.Background(), b, "px/namespace", nil, nil)
+	assert.True(t, errors.Is(err, errdefs.ErrMissingRequiredArgument))
+}
@@ -48,6 +48,14 @@ type TableRecordHandler interface {
 	HandleDone(ctx context.Context) error
 }
 
+// TableBatchHandler is an optional interface that can be implemented by a TableRecordHandler to process
+// a table batch-wise. If implemented, HandleBatch is called for every row batch instead of calling HandleRecord
+// for each row. The batch data is only valid until HandleBatch returns.
+type TableBatchHandler interface {
+	// HandleBatch is called whenever a new batch of the data is available.
+	HandleBatch(ctx context.Context, batch *types.RowBatch) error
+}
+
 // TableMuxer is an interface to route tables to the correct handler.
 type TableMuxer interface {
 	// AcceptTable is passed the table information, if nil is returned then the table stream is ignored.
@@ -64,6 +72,9 @@ type Client struct {
 	disableTLSVerification bool
 	insecureDirect         bool
 
+	retryPolicy      *RetryPolicy
+	reconnectHandler ReconnectHandler
+
 	grpcConn *grpc.ClientConn
 	cmClient cloudpb.VizierClusterInfoClient
 	vizier   vizierpb.VizierServiceClient
@@ -76,6 +87,7 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 		useEncryption:          true,
 		insecureDirect:         false,
 		disableTLSVerification: false,
+		retryPolicy:            DefaultRetryPolicy(),
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// px_prometheus_exporter periodically runs PxL scripts on a cluster and serves selected output
+// columns as Prometheus metrics on /metrics.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/promexporter"
+)
+
+var (
+	configPath    = flag.String("config", "", "Path to the YAML exporter config.")
+	listenAddr    = flag.String("listen_addr", ":9464", "Address to serve /metrics on.")
+	cloudAddr     = flag.String("cloud_addr", "work.withpixie.ai:443", "The address of Pixie Cloud.")
+	clusterID     = flag.String("cluster_id", "", "ID of the cluster to run the scripts on.")
+	directAddr    = flag.String("direct_addr", "", "Address of a standalone PEM to connect to directly, instead of going through Pixie Cloud.")
+	e2eEncryption = flag.Bool("e2e_encryption", true, "Whether to use end-to-end encryption for the script results.")
+	scriptTimeout = flag.Duration("script_timeout", 0, "Maximum duration of a single script run. Defaults to the interval of the script.")
+)
+
+const shutdownPeriod = 10 * time.Second
+
+func newVizierClient(ctx context.Context) (*pxapi.VizierClient, error) {
+	var opts []pxapi.ClientOption
+	if *directAddr != "" {
+		opts = append(opts, pxapi.WithDirectAddr(*directAddr), pxapi.WithDirectCredsInsecure(), pxapi.WithE2EEncryption(false))
+		if *clusterID == "" {
+			*clusterID = "localhost"
+		}
+	} else {
+		apiKey, ok := os.LookupEnv("PX_API_KEY")
+		if !ok {
+			return nil, errors.New("please set PX_API_KEY")
+		}
+		if *clusterID == "" {
+			return nil, errors.New("please set --cluster_id")
+		}
+		opts = append(opts, pxapi.WithCloudAddr(*cloudAddr), pxapi.WithAPIKey(apiKey), pxapi.WithE2EEncryption(*e2eEncryption))
+	}
+
+	client, err := pxapi.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewVizierClient(ctx, *clusterID)
+}
+
+func main() {
+	flag.Parse()
+	if *configPath == "" {
+		log.Fatal("please set --config")
+	}
+	data, err := os.ReadFile(*configPath)
+	if err != nil {
+		log.Fatalf("failed to read config: %v", err)
+	}
+	cfg, err := promexporter.ParseConfig(data)
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	vz, err := newVizierClient(ctx)
+	if err != nil {
+		log.Fatalf("failed to create vizier client: %v", err)
+	}
+	var opts []promexporter.ExporterOption
+	if *scriptTimeout > 0 {
+		opts = append(opts, promexporter.WithScriptTimeout(*scriptTimeout))
+	}
+	e, err := promexporter.NewExporter(vz, cfg, opts...)
+	if err != nil {
+		log.Fatalf("failed to create exporter: %v", err)
+	}
+	h, err := e.Handler()
+	if err != nil {
+		log.Fatalf("failed to create metrics handler: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", h)
+	srv := &http.Server{Addr: *listenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to serve metrics: %v", err)
+		}
+	}()
+	log.Printf("Serving metrics on %s/metrics\n", *listenAddr)
+
+	e.Run(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownPeriod)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shutdown metrics server: %v\n", err)
+	}
+}
@@ -33,6 +33,10 @@ var (
 
 	// ErrInvalidArgument specifies an unknown internal error has occurred.
 	ErrInvalidArgument = errors.New("invalid/missing arguments")
+	// ErrMissingRequiredArgument occurs when a script variable without a default value has not been given a value.
+	ErrMissingRequiredArgument = errors.New("missing required argument")
+	// ErrScriptNotFound occurs when a script could not be found in a script bundle.
+	ErrScriptNotFound = errors.New("script not found")
 
 	// ErrMissingDecryptionKey occurs if vizier sends encrypted table data without being asked to do so.
 	ErrMissingDecryptionKey = errors.New("missing decryption key but got encrypted data")
@@ -45,6 +49,8 @@ var (
 	ErrInternalDuplicateTableMetadata = createInternalError("duplicate table metadata")
 	// ErrInternalMismatchedType specifies an internal error has occurred where the table types don't match up between metadata and the various batches.
 	ErrInternalMismatchedType = createInternalError("types don't match between metadata and row batch data")
+	// ErrInternalMismatchedLength specifies an internal error has occurred where a column's length doesn't match the number of rows in the batch.
+	ErrInternalMismatchedLength = createInternalError("column length doesn't match the number of rows in the row batch")
 	// ErrInternalUnImplementedType specifies an internal error has occurred where the types used by the Pixie API are not supported by this client version.
 	// Most likely a client version update will fix the problem.
 	ErrInternalUnImplementedType = createInternalError("unimplemented type")
@@ -56,6 +62,17 @@ var (
 
 	// ErrMissingArtifact occurs when an artifact could not be found.
 	ErrMissingArtifact = errors.New("missing artifact")
+
+	// ErrMissingColumn occurs when a column that was asked for is not part of the table.
+	ErrMissingColumn = errors.New("missing column")
+	// ErrIncompatibleColumnType occurs when a column can't be converted to the requested type.
+	ErrIncompatibleColumnType = errors.New("incompatible column type")
+
+	// ErrMutationPending occurs when a script's mutations (ie. tracepoints) have been accepted but are not running yet.
+	// The script has to be re-run once they are ready.
+	ErrMutationPending = errors.New("mutation pending")
+	// ErrMutationFailed occurs when a script's mutations could not be deployed.
+	ErrMutationFailed = errors.New("mutation failed")
 )
 
 // MultiError is an interface to allow access to groups of errors.
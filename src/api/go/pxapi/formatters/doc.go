@@ -17,4 +17,5 @@
  */
 
 // Package formatters contains implementations of table handles that can format data into tables, json, etc.
+// It also contains file sinks that write tables to parquet or rolling newline-delimited JSON files.
 package formatters
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/types"
+)
+
+var invalidFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// FileNameForTable converts a table name into a name that is safe to use as a file name.
+func FileNameForTable(name string) string {
+	return invalidFileNameChars.ReplaceAllString(name, "_")
+}
+
+// RollingFileWriter writes to a sequence of files named "<prefix>.<seq><ext>", moving on to the next file
+// once the current file exceeds the max size or age. Files are only rolled between calls to Write, so
+// a single write is never split across files.
+type RollingFileWriter struct {
+	prefix   string
+	ext      string
+	maxBytes int64
+	maxAge   time.Duration
+	now      func() time.Time
+
+	f       *os.File
+	seq     int
+	written int64
+	opened  time.Time
+}
+
+// RollingFileOption configures options on the rolling file writer.
+type RollingFileOption func(*RollingFileWriter)
+
+// WithMaxFileBytes rolls to a new file once the current file has at least the given number of bytes.
+func WithMaxFileBytes(maxBytes int64) RollingFileOption {
+	return func(r *RollingFileWriter) {
+		r.maxBytes = maxBytes
+	}
+}
+
+// WithMaxFileAge rolls to a new file once the current file was opened longer ago than the given duration.
+func WithMaxFileAge(maxAge time.Duration) RollingFileOption {
+	return func(r *RollingFileWriter) {
+		r.maxAge = maxAge
+	}
+}
+
+// NewRollingFileWriter creates a RollingFileWriter. Files are created lazily on the first write.
+func NewRollingFileWriter(prefix, ext string, opts ...RollingFileOption) *RollingFileWriter {
+	r := &RollingFileWriter{
+		prefix: prefix,
+		ext:    ext,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *RollingFileWriter) shouldRoll() bool {
+	if r.f == nil {
+		return true
+	}
+	if r.maxBytes > 0 && r.written >= r.maxBytes {
+		return true
+	}
+	if r.maxAge > 0 && r.now().Sub(r.opened) >= r.maxAge {
+		return true
+	}
+	return false
+}
+
+func (r *RollingFileWriter) roll() error {
+	if r.f != nil {
+		if err := r.f.Close(); err != nil {
+			return err
+		}
+		r.seq++
+	}
+	f, err := os.Create(fmt.Sprintf("%s.%05d%s", r.prefix, r.seq, r.ext))
+	if err != nil {
+		return err
+	}
+	r.f = f
+	r.written = 0
+	r.opened = r.now()
+	return nil
+}
+
+// Write writes the data to the current file, rolling to a new file first if needed.
+func (r *RollingFileWriter) Write(p []byte) (int, error) {
+	if r.shouldRoll() {
+		if err := r.roll(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.written += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (r *RollingFileWriter) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// NDJSONFileWriter writes a table as newline-delimited JSON to rolling files.
+type NDJSONFileWriter struct {
+	*JSONFormatter
+	w *RollingFileWriter
+}
+
+// NewNDJSONFileWriter creates a NDJSONFileWriter that writes to files named "<prefix>.<seq>.ndjson".
+func NewNDJSONFileWriter(prefix string, opts ...RollingFileOption) (*NDJSONFileWriter, error) {
+	w := NewRollingFileWriter(prefix, ".ndjson", opts...)
+	j, err := NewJSONFormatter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONFileWriter{
+		JSONFormatter: j,
+		w:             w,
+	}, nil
+}
+
+// NDJSONFileHandlerFunc returns a handler func for muxes.RegexTableMux that writes each table
+// to "<dir>/<table name>.<seq>.ndjson".
+func NDJSONFileHandlerFunc(dir string, opts ...RollingFileOption) func(types.TableMetadata) (pxapi.TableRecordHandler, error) {
+	return func(md types.TableMetadata) (pxapi.TableRecordHandler, error) {
+		return NewNDJSONFileWriter(filepath.Join(dir, FileNameForTable(md.Name)), opts...)
+	}
+}
+
+// HandleDone is called when all data has been streamed.
+func (n *NDJSONFileWriter) HandleDone(ctx context.Context) error {
+	if err := n.JSONFormatter.HandleDone(ctx); err != nil {
+		return err
+	}
+	return n.w.Close()
+}
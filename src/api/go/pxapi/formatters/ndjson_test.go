@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+func TestRollingFileWriter_MaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	w := NewRollingFileWriter(filepath.Join(dir, "out"), ".txt", WithMaxFileBytes(4))
+	for _, s := range []string{"ab", "cd", "ef", "ghij", "k"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	expected := map[string]string{
+		"out.00000.txt": "abcd",
+		"out.00001.txt": "efghij",
+		"out.00002.txt": "k",
+	}
+	for name, contents := range expected {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, contents, string(b))
+	}
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, len(expected))
+}
+
+func TestRollingFileWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(0, 0)
+	w := NewRollingFileWriter(filepath.Join(dir, "out"), ".txt", WithMaxFileAge(time.Minute))
+	w.now = func() time.Time { return now }
+
+	for _, s := range []string{"a", "b"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		now = now.Add(40 * time.Second)
+	}
+	_, err := w.Write([]byte("c"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	expected := map[string]string{
+		"out.00000.txt": "ab",
+		"out.00001.txt": "c",
+	}
+	for name, contents := range expected {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, contents, string(b))
+	}
+}
+
+func TestNDJSONFileHandlerFunc(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	md := types.TableMetadata{
+		Name: "http/events",
+		ColInfo: []types.ColSchema{
+			{Name: "req_path", Type: vizierpb.STRING},
+		},
+	}
+	h, err := NDJSONFileHandlerFunc(dir)(md)
+	require.NoError(t, err)
+	require.NoError(t, h.HandleInit(ctx, md))
+
+	r := types.NewRecord(&md)
+	for _, path := range []string{"/a", "/b"} {
+		r.Data[0].(*types.StringValue).ScanString(path)
+		require.NoError(t, h.HandleRecord(ctx, r))
+	}
+	require.NoError(t, h.HandleDone(ctx))
+
+	b, err := os.ReadFile(filepath.Join(dir, "http_events.00000.ndjson"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"_tableName_\":\"http/events\",\"req_path\":\"/a\"}\n{\"_tableName_\":\"http/events\",\"req_path\":\"/b\"}\n", string(b))
+}
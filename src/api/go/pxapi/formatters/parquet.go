@@ -0,0 +1,380 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatters
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow/go/v11/parquet"
+	"github.com/apache/arrow/go/v11/parquet/compress"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/metadata"
+	"github.com/apache/arrow/go/v11/parquet/schema"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// ParquetSemanticTypeKeyPrefix is the prefix of the parquet key-value metadata entries that store
+// the semantic type of each column, ie. "px.semantic_type.upid" => "ST_UPID".
+const ParquetSemanticTypeKeyPrefix = "px.semantic_type."
+
+// ParquetTableNameKey is the parquet key-value metadata key that stores the name of the table.
+const ParquetTableNameKey = "px.table_name"
+
+const defaultParquetMaxRowGroupRows = 64 * 1024
+
+// ParquetFormatter writes a table to a parquet file. UINT128 columns are written as UUIDs
+// (16 big-endian bytes) and TIME64NS columns as nanosecond precision UTC timestamps.
+type ParquetFormatter struct {
+	w               io.Writer
+	maxRowGroupRows int
+	compression     compress.Compression
+
+	metadata types.TableMetadata
+	fw       *file.Writer
+	rgw      file.BufferedRowGroupWriter
+	rgRows   int
+	// err is set once writing the table has failed, after which the writer has been closed.
+	err error
+	// onAbort is called after the writer is closed because of a failure.
+	onAbort func()
+}
+
+// ParquetFormatterOption configures options on the formatter.
+type ParquetFormatterOption func(*ParquetFormatter)
+
+// WithParquetMaxRowGroupRows sets the maximum number of rows buffered in memory before a row group is written out.
+func WithParquetMaxRowGroupRows(rows int) ParquetFormatterOption {
+	return func(p *ParquetFormatter) {
+		p.maxRowGroupRows = rows
+	}
+}
+
+// WithParquetCompression sets the compression codec of the parquet file.
+func WithParquetCompression(codec compress.Compression) ParquetFormatterOption {
+	return func(p *ParquetFormatter) {
+		p.compression = codec
+	}
+}
+
+// NewParquetFormatter creates a ParquetFormatter. The writer is closed when the table is done, if it implements io.Closer.
+func NewParquetFormatter(w io.Writer, opts ...ParquetFormatterOption) (*ParquetFormatter, error) {
+	p := &ParquetFormatter{
+		w:               w,
+		maxRowGroupRows: defaultParquetMaxRowGroupRows,
+		compression:     compress.Codecs.Snappy,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// ParquetFileHandlerFunc returns a handler func for muxes.RegexTableMux that writes each table
+// to "<dir>/<table name>.parquet".
+func ParquetFileHandlerFunc(dir string, opts ...ParquetFormatterOption) func(types.TableMetadata) (pxapi.TableRecordHandler, error) {
+	return func(md types.TableMetadata) (pxapi.TableRecordHandler, error) {
+		path := filepath.Join(dir, FileNameForTable(md.Name)+".parquet")
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		p, err := NewParquetFormatter(f, opts...)
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, err
+		}
+		// Don't leave a truncated parquet file behind if writing the table fails.
+		p.onAbort = func() { os.Remove(path) }
+		return p, nil
+	}
+}
+
+func parquetNode(col types.ColSchema) (schema.Node, error) {
+	switch col.Type {
+	case vizierpb.BOOLEAN:
+		return schema.NewPrimitiveNode(col.Name, parquet.Repetitions.Required, parquet.Types.Boolean, -1, -1)
+	case vizierpb.INT64:
+		if col.SemanticType == vizierpb.ST_TIME_NS {
+			return schema.NewPrimitiveNodeLogical(col.Name, parquet.Repetitions.Required,
+				schema.NewTimestampLogicalType(true, schema.TimeUnitNanos), parquet.Types.Int64, -1, -1)
+		}
+		return schema.NewPrimitiveNodeLogical(col.Name, parquet.Repetitions.Required,
+			schema.NewIntLogicalType(64, true), parquet.Types.Int64, -1, -1)
+	case vizierpb.TIME64NS:
+		return schema.NewPrimitiveNodeLogical(col.Name, parquet.Repetitions.Required,
+			schema.NewTimestampLogicalType(true, schema.TimeUnitNanos), parquet.Types.Int64, -1, -1)
+	case vizierpb.FLOAT64:
+		return schema.NewPrimitiveNode(col.Name, parquet.Repetitions.Required, parquet.Types.Double, -1, -1)
+	case vizierpb.STRING:
+		return schema.NewPrimitiveNodeLogical(col.Name, parquet.Repetitions.Required,
+			schema.StringLogicalType{}, parquet.Types.ByteArray, -1, -1)
+	case vizierpb.UINT128:
+		return schema.NewPrimitiveNodeLogical(col.Name, parquet.Repetitions.Required,
+			schema.UUIDLogicalType{}, parquet.Types.FixedLenByteArray, 16, -1)
+	default:
+		return nil, fmt.Errorf("%w: unsupported column type %s", errdefs.ErrInvalidArgument, col.Type)
+	}
+}
+
+// HandleInit is called when the table metadata is available.
+func (p *ParquetFormatter) HandleInit(ctx context.Context, md types.TableMetadata) error {
+	if p.fw != nil {
+		return fmt.Errorf("%w: did not expect init to be called more than once", errdefs.ErrInternalDuplicateTableMetadata)
+	}
+	if err := p.init(md); err != nil {
+		// HandleDone only closes the writer once the parquet writer exists, so close it here.
+		return p.abort(err)
+	}
+	return nil
+}
+
+// abort closes the writer after a failure, so that the file isn't left open when the stream stops.
+func (p *ParquetFormatter) abort(err error) error {
+	p.err = err
+	if c, ok := p.w.(io.Closer); ok {
+		c.Close()
+	}
+	if p.onAbort != nil {
+		p.onAbort()
+	}
+	return err
+}
+
+func (p *ParquetFormatter) init(md types.TableMetadata) error {
+	fields := make(schema.FieldList, len(md.ColInfo))
+	kv := metadata.NewKeyValueMetadata()
+	if err := kv.Append(ParquetTableNameKey, md.Name); err != nil {
+		return err
+	}
+	for idx, col := range md.ColInfo {
+		node, err := parquetNode(col)
+		if err != nil {
+			return err
+		}
+		fields[idx] = node
+		if err := kv.Append(ParquetSemanticTypeKeyPrefix+col.Name, col.SemanticType.String()); err != nil {
+			return err
+		}
+	}
+	sc, err := schema.NewGroupNode("schema", parquet.Repetitions.Required, fields, -1)
+	if err != nil {
+		return err
+	}
+
+	p.metadata = md
+	props := parquet.NewWriterProperties(parquet.WithCompression(p.compression))
+	p.fw = file.NewParquetWriter(p.w, sc, file.WithWriterProps(props), file.WithWriteMetadata(kv))
+	return nil
+}
+
+func (p *ParquetFormatter) rowGroup() file.BufferedRowGroupWriter {
+	if p.rgw == nil {
+		p.rgw = p.fw.AppendBufferedRowGroup()
+		p.rgRows = 0
+	}
+	return p.rgw
+}
+
+func (p *ParquetFormatter) finishRows(n int) error {
+	p.rgRows += n
+	if p.rgRows < p.maxRowGroupRows {
+		return nil
+	}
+	err := p.rgw.Close()
+	p.rgw = nil
+	return err
+}
+
+// HandleBatch is called for each row batch of the table.
+func (p *ParquetFormatter) HandleBatch(ctx context.Context, b *types.RowBatch) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.fw == nil {
+		return errdefs.ErrInternalMissingTableMetadata
+	}
+	if err := p.writeBatch(b); err != nil {
+		return p.abort(err)
+	}
+	return nil
+}
+
+func (p *ParquetFormatter) writeBatch(b *types.RowBatch) error {
+	rgw := p.rowGroup()
+	for idx := 0; idx < b.NumCols(); idx++ {
+		cw, err := rgw.Column(idx)
+		if err != nil {
+			return err
+		}
+		if err := writeParquetColumn(cw, b, idx); err != nil {
+			return err
+		}
+	}
+	return p.finishRows(int(b.NumRows))
+}
+
+func writeParquetColumn(cw file.ColumnChunkWriter, b *types.RowBatch, idx int) error {
+	var err error
+	switch w := cw.(type) {
+	case *file.BooleanColumnChunkWriter:
+		var data []bool
+		if data, err = b.BooleanColumn(idx); err == nil {
+			_, err = w.WriteBatch(data, nil, nil)
+		}
+	case *file.Int64ColumnChunkWriter:
+		var data []int64
+		if b.TableMetadata.ColInfo[idx].Type == vizierpb.TIME64NS {
+			data, err = b.Time64NSColumn(idx)
+		} else {
+			data, err = b.Int64Column(idx)
+		}
+		if err == nil {
+			_, err = w.WriteBatch(data, nil, nil)
+		}
+	case *file.Float64ColumnChunkWriter:
+		var data []float64
+		if data, err = b.Float64Column(idx); err == nil {
+			_, err = w.WriteBatch(data, nil, nil)
+		}
+	case *file.ByteArrayColumnChunkWriter:
+		var data [][]byte
+		if data, err = b.StringColumn(idx); err == nil {
+			values := make([]parquet.ByteArray, len(data))
+			for i, d := range data {
+				values[i] = d
+			}
+			_, err = w.WriteBatch(values, nil, nil)
+		}
+	case *file.FixedLenByteArrayColumnChunkWriter:
+		var data []*vizierpb.UInt128
+		if data, err = b.UInt128Column(idx); err == nil {
+			buf := make([]byte, 16*len(data))
+			values := make([]parquet.FixedLenByteArray, len(data))
+			for i, d := range data {
+				values[i] = buf[16*i : 16*(i+1)]
+				binary.BigEndian.PutUint64(values[i], d.High)
+				binary.BigEndian.PutUint64(values[i][8:], d.Low)
+			}
+			_, err = w.WriteBatch(values, nil, nil)
+		}
+	default:
+		err = errdefs.ErrInternalUnImplementedType
+	}
+	return err
+}
+
+// HandleRecord is called for each record of the table.
+func (p *ParquetFormatter) HandleRecord(ctx context.Context, r *types.Record) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.fw == nil {
+		return errdefs.ErrInternalMissingTableMetadata
+	}
+	if len(r.Data) != len(p.metadata.ColInfo) {
+		return fmt.Errorf("%w: mismatch in header and data sizes", errdefs.ErrInvalidArgument)
+	}
+	if err := p.writeRecord(r); err != nil {
+		return p.abort(err)
+	}
+	return nil
+}
+
+func (p *ParquetFormatter) writeRecord(r *types.Record) error {
+	rgw := p.rowGroup()
+	for idx, d := range r.Data {
+		cw, err := rgw.Column(idx)
+		if err != nil {
+			return err
+		}
+		if err := writeParquetDatum(cw, d); err != nil {
+			return err
+		}
+	}
+	return p.finishRows(1)
+}
+
+func writeParquetDatum(cw file.ColumnChunkWriter, d types.Datum) error {
+	var err error
+	ok := false
+	switch v := d.(type) {
+	case *types.BooleanValue:
+		var w *file.BooleanColumnChunkWriter
+		if w, ok = cw.(*file.BooleanColumnChunkWriter); ok {
+			_, err = w.WriteBatch([]bool{v.Value()}, nil, nil)
+		}
+	case *types.Int64Value:
+		var w *file.Int64ColumnChunkWriter
+		if w, ok = cw.(*file.Int64ColumnChunkWriter); ok {
+			_, err = w.WriteBatch([]int64{v.Value()}, nil, nil)
+		}
+	case *types.Time64NSValue:
+		var w *file.Int64ColumnChunkWriter
+		if w, ok = cw.(*file.Int64ColumnChunkWriter); ok {
+			_, err = w.WriteBatch([]int64{v.Value().UnixNano()}, nil, nil)
+		}
+	case *types.Float64Value:
+		var w *file.Float64ColumnChunkWriter
+		if w, ok = cw.(*file.Float64ColumnChunkWriter); ok {
+			_, err = w.WriteBatch([]float64{v.Value()}, nil, nil)
+		}
+	case *types.StringValue:
+		var w *file.ByteArrayColumnChunkWriter
+		if w, ok = cw.(*file.ByteArrayColumnChunkWriter); ok {
+			_, err = w.WriteBatch([]parquet.ByteArray{[]byte(v.Value())}, nil, nil)
+		}
+	case *types.UInt128Value:
+		var w *file.FixedLenByteArrayColumnChunkWriter
+		if w, ok = cw.(*file.FixedLenByteArrayColumnChunkWriter); ok {
+			buf := make(parquet.FixedLenByteArray, 16)
+			copy(buf, v.Value())
+			_, err = w.WriteBatch([]parquet.FixedLenByteArray{buf}, nil, nil)
+		}
+	default:
+		return errdefs.ErrInternalUnImplementedType
+	}
+	if !ok {
+		return errdefs.ErrInternalMismatchedType
+	}
+	return err
+}
+
+// HandleDone is called when all data has been streamed.
+func (p *ParquetFormatter) HandleDone(ctx context.Context) error {
+	if p.err != nil || p.fw == nil {
+		return nil
+	}
+	if p.rgw != nil {
+		if err := p.rgw.Close(); err != nil {
+			return err
+		}
+		p.rgw = nil
+	}
+	return p.fw.Close()
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/v11/parquet"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/formatters"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+var parquetTestMetadata = types.TableMetadata{
+	Name: "http_events",
+	ColInfo: []types.ColSchema{
+		{Name: "time_", Type: vizierpb.TIME64NS, SemanticType: vizierpb.ST_NONE},
+		{Name: "upid", Type: vizierpb.UINT128, SemanticType: vizierpb.ST_UPID},
+		{Name: "latency", Type: vizierpb.INT64, SemanticType: vizierpb.ST_DURATION_NS},
+		{Name: "req_path", Type: vizierpb.STRING, SemanticType: vizierpb.ST_NONE},
+	},
+}
+
+func TestParquetFormatter(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	p, err := formatters.NewParquetFormatter(buf, formatters.WithParquetMaxRowGroupRows(2))
+	require.NoError(t, err)
+	require.NoError(t, p.HandleInit(ctx, parquetTestMetadata))
+
+	// Write one batch and one record to cover both paths.
+	b, err := types.NewRowBatch(&parquetTestMetadata, 2, []*vizierpb.Column{
+		{ColData: &vizierpb.Column_Time64NsData{Time64NsData: &vizierpb.Time64NSColumn{Data: []int64{10, 20}}}},
+		{ColData: &vizierpb.Column_Uint128Data{Uint128Data: &vizierpb.UInt128Column{Data: []*vizierpb.UInt128{
+			{High: 1, Low: 2}, {High: 3, Low: 4},
+		}}}},
+		{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: []int64{100, 200}}}},
+		{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: [][]byte{[]byte("/a"), []byte("/b")}}}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, p.HandleBatch(ctx, b))
+
+	r := types.NewRecord(&parquetTestMetadata)
+	r.Data[0].(*types.Time64NSValue).ScanInt64(30)
+	r.Data[1].(*types.UInt128Value).ScanUInt128(&vizierpb.UInt128{High: 5, Low: 6})
+	r.Data[2].(*types.Int64Value).ScanInt64(300)
+	r.Data[3].(*types.StringValue).ScanString("/c")
+	require.NoError(t, p.HandleRecord(ctx, r))
+	require.NoError(t, p.HandleDone(ctx))
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	assert.Equal(t, int64(3), reader.NumRows())
+	assert.Equal(t, 2, reader.NumRowGroups())
+
+	sc := reader.MetaData().Schema
+	assert.Equal(t, schema.NewTimestampLogicalType(true, schema.TimeUnitNanos), sc.Column(0).LogicalType())
+	assert.Equal(t, schema.UUIDLogicalType{}, sc.Column(1).LogicalType())
+	assert.Equal(t, parquet.Types.FixedLenByteArray, sc.Column(1).PhysicalType())
+	assert.Equal(t, schema.StringLogicalType{}, sc.Column(3).LogicalType())
+
+	kv := reader.MetaData().KeyValueMetadata()
+	assert.Equal(t, "http_events", *kv.FindValue(formatters.ParquetTableNameKey))
+	assert.Equal(t, "ST_UPID", *kv.FindValue(formatters.ParquetSemanticTypeKeyPrefix + "upid"))
+	assert.Equal(t, "ST_DURATION_NS", *kv.FindValue(formatters.ParquetSemanticTypeKeyPrefix + "latency"))
+
+	var times []int64
+	var upids [][]byte
+	for rg := 0; rg < reader.NumRowGroups(); rg++ {
+		rgr := reader.RowGroup(rg)
+		cr, err := rgr.Column(0)
+		require.NoError(t, err)
+		vals := make([]int64, 2)
+		_, n, err := cr.(*file.Int64ColumnChunkReader).ReadBatch(2, vals, nil, nil)
+		require.NoError(t, err)
+		times = append(times, vals[:n]...)
+
+		cr, err = rgr.Column(1)
+		require.NoError(t, err)
+		flba := make([]parquet.FixedLenByteArray, 2)
+		_, n, err = cr.(*file.FixedLenByteArrayColumnChunkReader).ReadBatch(2, flba, nil, nil)
+		require.NoError(t, err)
+		for _, v := range flba[:n] {
+			upids = append(upids, append([]byte{}, v...))
+		}
+	}
+	assert.Equal(t, []int64{10, 20, 30}, times)
+	assert.Equal(t, [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2},
+		{0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 4},
+		{0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, 6},
+	}, upids)
+}
+
+type closeTracker struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestParquetFormatter_InitErrorClosesWriter(t *testing.T) {
+	w := &closeTracker{}
+	p, err := formatters.NewParquetFormatter(w)
+	require.NoError(t, err)
+	err = p.HandleInit(context.Background(), types.TableMetadata{
+		Name:    "bad",
+		ColInfo: []types.ColSchema{{Name: "a", Type: vizierpb.DATA_TYPE_UNKNOWN}},
+	})
+	assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+	assert.True(t, w.closed)
+}
+
+func TestParquetFormatter_RecordTypeMismatchClosesWriter(t *testing.T) {
+	ctx := context.Background()
+	w := &closeTracker{}
+	p, err := formatters.NewParquetFormatter(w)
+	require.NoError(t, err)
+	require.NoError(t, p.HandleInit(ctx, parquetTestMetadata))
+
+	r := types.NewRecord(&parquetTestMetadata)
+	r.Data[0] = types.NewStringValue(&parquetTestMetadata.ColInfo[0])
+	err = p.HandleRecord(ctx, r)
+	assert.ErrorIs(t, err, errdefs.ErrInternalMismatchedType)
+	assert.True(t, w.closed)
+
+	// The formatter keeps failing once the writer is closed.
+	assert.ErrorIs(t, p.HandleRecord(ctx, types.NewRecord(&parquetTestMetadata)), errdefs.ErrInternalMismatchedType)
+	assert.NoError(t, p.HandleDone(ctx))
+}
+
+func TestParquetFileHandlerFunc_BatchErrorRemovesFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	rh, err := formatters.ParquetFileHandlerFunc(dir)(parquetTestMetadata)
+	require.NoError(t, err)
+	h, ok := rh.(*formatters.ParquetFormatter)
+	require.True(t, ok)
+	require.NoError(t, h.HandleInit(ctx, parquetTestMetadata))
+	path := filepath.Join(dir, "http_events.parquet")
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	// A batch whose first column is a string can't be written to the timestamp column.
+	md := parquetTestMetadata
+	md.ColInfo = append([]types.ColSchema{}, md.ColInfo...)
+	md.ColInfo[0].Type = vizierpb.STRING
+	b, err := types.NewRowBatch(&md, 1, []*vizierpb.Column{
+		{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: [][]byte{[]byte("bad")}}}},
+		{ColData: &vizierpb.Column_Uint128Data{Uint128Data: &vizierpb.UInt128Column{Data: []*vizierpb.UInt128{{High: 1, Low: 2}}}}},
+		{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: []int64{100}}}},
+		{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: [][]byte{[]byte("/a")}}}},
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, h.HandleBatch(ctx, b), errdefs.ErrInternalMismatchedType)
+
+	_, err = os.Stat(path)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,342 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+const (
+	// ClusterIDColumnName is the name of the column prepended to every table returned by a MultiVizierClient
+	// that holds the ID of the cluster the row came from.
+	ClusterIDColumnName = "cluster_id"
+	// ClusterNameColumnName is the name of the column prepended to every table returned by a MultiVizierClient
+	// that holds the name of the cluster the row came from.
+	ClusterNameColumnName = "cluster_name"
+
+	defaultMaxConcurrency = 8
+)
+
+// MultiVizierOption configures options on the MultiVizierClient.
+type MultiVizierOption func(m *MultiVizierClient)
+
+// WithMaxConcurrency sets the maximum number of clusters a script is run on concurrently.
+func WithMaxConcurrency(n int) MultiVizierOption {
+	return func(m *MultiVizierClient) {
+		m.maxConcurrency = n
+	}
+}
+
+type clusterClient struct {
+	info *VizierInfo
+	vz   *VizierClient
+}
+
+// MultiVizierClient runs scripts across multiple viziers.
+type MultiVizierClient struct {
+	clusters       []*clusterClient
+	maxConcurrency int
+}
+
+// NewMultiVizierClient creates a client that runs scripts on all of the passed in viziers. If no viziers are
+// passed in, all healthy viziers returned by ListViziers are used.
+func (c *Client) NewMultiVizierClient(ctx context.Context, viziers []*VizierInfo, opts ...MultiVizierOption) (*MultiVizierClient, error) {
+	if len(viziers) == 0 {
+		all, err := c.ListViziers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range all {
+			if v.Status == VizierStatusHealthy {
+				viziers = append(viziers, v)
+			}
+		}
+	}
+	if len(viziers) == 0 {
+		return nil, errdefs.ErrClusterNotFound
+	}
+
+	m := &MultiVizierClient{
+		maxConcurrency: defaultMaxConcurrency,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.maxConcurrency < 1 {
+		return nil, fmt.Errorf("%w: max concurrency must be at least 1", errdefs.ErrInvalidArgument)
+	}
+
+	for _, info := range viziers {
+		vz, err := c.NewVizierClient(ctx, info.ID)
+		if err != nil {
+			return nil, err
+		}
+		m.clusters = append(m.clusters, &clusterClient{info: info, vz: vz})
+	}
+	return m, nil
+}
+
+// Viziers returns the viziers the client runs scripts on.
+func (m *MultiVizierClient) Viziers() []*VizierInfo {
+	viziers := make([]*VizierInfo, len(m.clusters))
+	for i, c := range m.clusters {
+		viziers[i] = c.info
+	}
+	return viziers
+}
+
+// ExecuteScript prepares the script to be run on all of the viziers. The script is run once Stream is called.
+// Every table passed to the mux has the ClusterIDColumnName and ClusterNameColumnName columns prepended.
+// AcceptTable is called for each table of each cluster, and may be called concurrently.
+func (m *MultiVizierClient) ExecuteScript(ctx context.Context, pxl string, mux TableMuxer) (*MultiScriptResults, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &MultiScriptResults{
+		m:      m,
+		ctx:    ctx,
+		cancel: cancel,
+		pxl:    pxl,
+		mux:    mux,
+		stats:  make(map[string]*ResultsStats),
+	}, nil
+}
+
+// ClusterError is the error that running a script on a single cluster resulted in.
+type ClusterError struct {
+	// ClusterID is the ID of the cluster.
+	ClusterID string
+	// ClusterName is the name of the cluster.
+	ClusterName string
+	// Err is the error returned by the cluster.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ClusterError) Error() string {
+	return fmt.Sprintf("cluster '%s' (%s): %s", e.ClusterName, e.ClusterID, e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *ClusterError) Unwrap() error {
+	return e.Err
+}
+
+// MultiScriptResults tracks the results of a script run across multiple clusters.
+type MultiScriptResults struct {
+	m      *MultiVizierClient
+	ctx    context.Context
+	cancel context.CancelFunc
+	pxl    string
+	mux    TableMuxer
+
+	mu    sync.Mutex
+	errs  []*ClusterError
+	stats map[string]*ResultsStats
+}
+
+// Stream runs the script on every cluster and streams the results, running on at most the configured number
+// of clusters at once. Errors on individual clusters don't stop the script from running on the other clusters,
+// they are available through Errors. An error is only returned if the script failed on every cluster.
+func (r *MultiScriptResults) Stream() error {
+	sem := make(chan struct{}, r.m.maxConcurrency)
+	var wg sync.WaitGroup
+	for _, c := range r.m.clusters {
+		select {
+		case <-r.ctx.Done():
+			r.addError(c.info, r.ctx.Err())
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(c *clusterClient) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := r.runOnCluster(c); err != nil {
+				r.addError(c.info, err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) == len(r.m.clusters) {
+		return fmt.Errorf("script failed on all %d clusters, first error: %w", len(r.errs), r.errs[0])
+	}
+	return nil
+}
+
+func (r *MultiScriptResults) runOnCluster(c *clusterClient) error {
+	mux := &clusterTaggingMux{cluster: c.info, mux: r.mux}
+	sr, err := c.vz.ExecuteScript(r.ctx, r.pxl, mux)
+	if err != nil {
+		return err
+	}
+	defer sr.Close()
+	err = sr.Stream()
+
+	r.mu.Lock()
+	r.stats[c.info.ID] = sr.Stats()
+	r.mu.Unlock()
+	return err
+}
+
+func (r *MultiScriptResults) addError(info *VizierInfo, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, &ClusterError{
+		ClusterID:   info.ID,
+		ClusterName: info.Name,
+		Err:         err,
+	})
+}
+
+// Errors returns the errors of the clusters the script failed on.
+func (r *MultiScriptResults) Errors() []*ClusterError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := make([]*ClusterError, len(r.errs))
+	copy(errs, r.errs)
+	return errs
+}
+
+// Stats returns the execution and script stats of each cluster, keyed by cluster ID.
+func (r *MultiScriptResults) Stats() map[string]*ResultsStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]*ResultsStats, len(r.stats))
+	for id, s := range r.stats {
+		stats[id] = s
+	}
+	return stats
+}
+
+// Close cancels the script on all clusters.
+func (r *MultiScriptResults) Close() error {
+	r.cancel()
+	return nil
+}
+
+// clusterTaggingMux prepends the cluster ID and name columns to all tables of a single cluster.
+type clusterTaggingMux struct {
+	cluster *VizierInfo
+	mux     TableMuxer
+}
+
+// AcceptTable implements the TableMuxer interface.
+func (m *clusterTaggingMux) AcceptTable(ctx context.Context, metadata types.TableMetadata) (TableRecordHandler, error) {
+	if m.mux == nil {
+		return nil, nil
+	}
+	tagged := withClusterColumns(metadata)
+	h, err := m.mux.AcceptTable(ctx, tagged)
+	if err != nil || h == nil {
+		return nil, err
+	}
+	return &clusterTaggingHandler{
+		cluster: m.cluster,
+		handler: h,
+		md:      &tagged,
+	}, nil
+}
+
+func withClusterColumns(md types.TableMetadata) types.TableMetadata {
+	cols := make([]*vizierpb.Relation_ColumnInfo, 0, len(md.ColInfo)+2)
+	for _, name := range []string{ClusterIDColumnName, ClusterNameColumnName} {
+		cols = append(cols, &vizierpb.Relation_ColumnInfo{
+			ColumnName:         name,
+			ColumnType:         vizierpb.STRING,
+			ColumnSemanticType: vizierpb.ST_NONE,
+		})
+	}
+	for _, col := range md.ColInfo {
+		cols = append(cols, &vizierpb.Relation_ColumnInfo{
+			ColumnName:         col.Name,
+			ColumnType:         col.Type,
+			ColumnSemanticType: col.SemanticType,
+		})
+	}
+	return types.NewTableMetadata(md.Name, &vizierpb.Relation{Columns: cols})
+}
+
+// clusterTaggingHandler prepends the cluster ID and name to every row of a table.
+type clusterTaggingHandler struct {
+	cluster *VizierInfo
+	handler TableRecordHandler
+	md      *types.TableMetadata
+}
+
+// HandleInit implements the TableRecordHandler interface.
+func (h *clusterTaggingHandler) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	return h.handler.HandleInit(ctx, *h.md)
+}
+
+// HandleRecord implements the TableRecordHandler interface.
+func (h *clusterTaggingHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	id := types.NewStringValue(&h.md.ColInfo[0])
+	id.ScanString(h.cluster.ID)
+	name := types.NewStringValue(&h.md.ColInfo[1])
+	name.ScanString(h.cluster.Name)
+
+	data := make([]types.Datum, 0, len(r.Data)+2)
+	data = append(data, id, name)
+	data = append(data, r.Data...)
+	return h.handler.HandleRecord(ctx, &types.Record{
+		Data:          data,
+		TableMetadata: h.md,
+	})
+}
+
+// HandleBatch implements the TableBatchHandler interface.
+func (h *clusterTaggingHandler) HandleBatch(ctx context.Context, b *types.RowBatch) error {
+	cols := make([]*vizierpb.Column, 0, b.NumCols()+2)
+	cols = append(cols, repeatedStringColumn(h.cluster.ID, b.NumRows), repeatedStringColumn(h.cluster.Name, b.NumRows))
+	for i := 0; i < b.NumCols(); i++ {
+		cols = append(cols, b.Column(i))
+	}
+	tagged, err := types.NewRowBatch(h.md, b.NumRows, cols)
+	if err != nil {
+		return err
+	}
+	return DispatchBatch(ctx, h.handler, tagged)
+}
+
+// HandleDone implements the TableRecordHandler interface.
+func (h *clusterTaggingHandler) HandleDone(ctx context.Context) error {
+	return h.handler.HandleDone(ctx)
+}
+
+// repeatedStringColumn creates a column with the value repeated for each row.
+func repeatedStringColumn(val string, numRows int64) *vizierpb.Column {
+	b := []byte(val)
+	data := make([][]byte, numRows)
+	for i := range data {
+		data[i] = b
+	}
+	return &vizierpb.Column{
+		ColData: &vizierpb.Column_StringData{
+			StringData: &vizierpb.StringColumn{Data: data},
+		},
+	}
+}
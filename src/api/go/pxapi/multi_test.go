@@ -0,0 +1,274 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/go/pxapi/utils"
+	"px.dev/pixie/src/api/proto/cloudpb"
+	mock_cloudpb "px.dev/pixie/src/api/proto/cloudpb/mock"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+type taggedRow struct {
+	clusterID   string
+	clusterName string
+	value       int64
+}
+
+// taggedRowMux collects the rows of all tables, and is safe for concurrent use.
+type taggedRowMux struct {
+	mu   sync.Mutex
+	cols [][]string
+	rows []taggedRow
+}
+
+func (m *taggedRowMux) AcceptTable(ctx context.Context, md types.TableMetadata) (TableRecordHandler, error) {
+	return m, nil
+}
+
+func (m *taggedRowMux) HandleInit(ctx context.Context, md types.TableMetadata) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cols := make([]string, len(md.ColInfo))
+	for i, c := range md.ColInfo {
+		cols[i] = c.Name
+	}
+	m.cols = append(m.cols, cols)
+	return nil
+}
+
+func (m *taggedRowMux) HandleRecord(ctx context.Context, r *types.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rows = append(m.rows, taggedRow{
+		clusterID:   r.GetDatum(ClusterIDColumnName).String(),
+		clusterName: r.GetDatum(ClusterNameColumnName).String(),
+		value:       r.GetDatum("http_status").(*types.Int64Value).Value(),
+	})
+	return nil
+}
+
+func (m *taggedRowMux) HandleDone(ctx context.Context) error {
+	return nil
+}
+
+// fakeClusters creates a MultiVizierClient for clusters that all return the given rows. The number of
+// clusters concurrently streaming is tracked in active.
+func fakeClusters(t *testing.T, ctrl *gomock.Controller, names []string, failing map[string]bool, active *int32, maxActive *int32) *MultiVizierClient {
+	m := &MultiVizierClient{maxConcurrency: defaultMaxConcurrency}
+	for i, name := range names {
+		name := name
+		value := int64(i)
+		vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+		if failing[name] {
+			vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).Return(nil, errors.New("cluster unreachable"))
+		} else {
+			vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+					assert.Equal(t, name+"-id", req.ClusterID)
+					n := atomic.AddInt32(active, 1)
+					for {
+						cur := atomic.LoadInt32(maxActive)
+						if n <= cur || atomic.CompareAndSwapInt32(maxActive, cur, n) {
+							break
+						}
+					}
+
+					table := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+					stream := mock_vizierpb.NewMockVizierService_ExecuteScriptClient(ctrl)
+					stream.EXPECT().Context().Return(ctx).AnyTimes()
+					gomock.InOrder(
+						stream.EXPECT().Recv().Return(withQueryID(table.MetadataResponse(), "q"), nil),
+						stream.EXPECT().Recv().Return(withQueryID(table.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{value})}, 1), "q"), nil),
+						stream.EXPECT().Recv().Return(withQueryID(table.EndResponse(), "q"), nil),
+						stream.EXPECT().Recv().DoAndReturn(func() (*vizierpb.ExecuteScriptResponse, error) {
+							atomic.AddInt32(active, -1)
+							return nil, io.EOF
+						}),
+					)
+					return stream, nil
+				})
+		}
+		m.clusters = append(m.clusters, &clusterClient{
+			info: &VizierInfo{ID: name + "-id", Name: name, Status: VizierStatusHealthy},
+			vz: &VizierClient{
+				cloud:    &Client{},
+				vizierID: name + "-id",
+				vzClient: vzClient,
+			},
+		})
+	}
+	return m
+}
+
+func TestMultiVizierClientTagsRecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var active, maxActive int32
+	m := fakeClusters(t, ctrl, []string{"a", "b", "c"}, nil, &active, &maxActive)
+	m.maxConcurrency = 2
+
+	mux := &taggedRowMux{}
+	res, err := m.ExecuteScript(context.Background(), "import px", mux)
+	require.NoError(t, err)
+	require.NoError(t, res.Stream())
+	assert.Empty(t, res.Errors())
+	assert.Len(t, res.Stats(), 3)
+	assert.LessOrEqual(t, maxActive, int32(2))
+
+	require.Len(t, mux.cols, 3)
+	assert.Equal(t, []string{ClusterIDColumnName, ClusterNameColumnName, "http_status"}, mux.cols[0])
+	sort.Slice(mux.rows, func(i, j int) bool { return mux.rows[i].value < mux.rows[j].value })
+	assert.Equal(t, []taggedRow{
+		{clusterID: "a-id", clusterName: "a", value: 0},
+		{clusterID: "b-id", clusterName: "b", value: 1},
+		{clusterID: "c-id", clusterName: "c", value: 2},
+	}, mux.rows)
+}
+
+type taggedBatchHandler struct {
+	clusterIDs [][]byte
+	values     []int64
+}
+
+func (h *taggedBatchHandler) AcceptTable(ctx context.Context, md types.TableMetadata) (TableRecordHandler, error) {
+	return h, nil
+}
+
+func (h *taggedBatchHandler) HandleInit(ctx context.Context, md types.TableMetadata) error {
+	return nil
+}
+
+func (h *taggedBatchHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	return errors.New("HandleRecord should not be called for batch handlers")
+}
+
+func (h *taggedBatchHandler) HandleBatch(ctx context.Context, b *types.RowBatch) error {
+	ids, err := b.StringColumn(int(b.TableMetadata.IndexOf(ClusterIDColumnName)))
+	if err != nil {
+		return err
+	}
+	values, err := b.Int64Column(int(b.TableMetadata.IndexOf("http_status")))
+	if err != nil {
+		return err
+	}
+	h.clusterIDs = append(h.clusterIDs, ids...)
+	h.values = append(h.values, values...)
+	return nil
+}
+
+func (h *taggedBatchHandler) HandleDone(ctx context.Context) error {
+	return nil
+}
+
+func TestMultiVizierClientTagsBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var active, maxActive int32
+	m := fakeClusters(t, ctrl, []string{"a"}, nil, &active, &maxActive)
+
+	h := &taggedBatchHandler{}
+	res, err := m.ExecuteScript(context.Background(), "import px", h)
+	require.NoError(t, err)
+	require.NoError(t, res.Stream())
+
+	assert.Equal(t, [][]byte{[]byte("a-id")}, h.clusterIDs)
+	assert.Equal(t, []int64{0}, h.values)
+}
+
+func TestMultiVizierClientCollectsErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var active, maxActive int32
+	m := fakeClusters(t, ctrl, []string{"a", "b", "c"}, map[string]bool{"b": true}, &active, &maxActive)
+	m.maxConcurrency = 1
+
+	mux := &taggedRowMux{}
+	res, err := m.ExecuteScript(context.Background(), "import px", mux)
+	require.NoError(t, err)
+	require.NoError(t, res.Stream())
+	assert.Equal(t, int32(1), maxActive)
+	assert.Len(t, mux.rows, 2)
+
+	errs := res.Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, "b-id", errs[0].ClusterID)
+	assert.Equal(t, "b", errs[0].ClusterName)
+	assert.EqualError(t, errs[0], "cluster 'b' (b-id): cluster unreachable")
+}
+
+func TestMultiVizierClientAllFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var active, maxActive int32
+	m := fakeClusters(t, ctrl, []string{"a", "b"}, map[string]bool{"a": true, "b": true}, &active, &maxActive)
+
+	res, err := m.ExecuteScript(context.Background(), "import px", &taggedRowMux{})
+	require.NoError(t, err)
+	err = res.Stream()
+	require.Error(t, err)
+	var clusterErr *ClusterError
+	assert.True(t, errors.As(err, &clusterErr))
+	assert.Len(t, res.Errors(), 2)
+}
+
+func TestNewMultiVizierClientUsesHealthyViziers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	healthyID := "7ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	unhealthyID := "8ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	cmClient := mock_cloudpb.NewMockVizierClusterInfoClient(ctrl)
+	cmClient.EXPECT().GetClusterInfo(gomock.Any(), gomock.Any()).Return(&cloudpb.GetClusterInfoResponse{
+		Clusters: []*cloudpb.ClusterInfo{
+			{ID: utils.ProtoFromUUIDStrOrNil(healthyID), ClusterName: "healthy", Status: cloudpb.CS_HEALTHY},
+			{ID: utils.ProtoFromUUIDStrOrNil(unhealthyID), ClusterName: "unhealthy", Status: cloudpb.CS_UNHEALTHY},
+		},
+	}, nil)
+
+	c := &Client{cmClient: cmClient}
+	m, err := c.NewMultiVizierClient(context.Background(), nil, WithMaxConcurrency(4))
+	require.NoError(t, err)
+	require.Len(t, m.Viziers(), 1)
+	assert.Equal(t, "healthy", m.Viziers()[0].Name)
+	assert.Equal(t, healthyID, m.clusters[0].vz.vizierID)
+	assert.Equal(t, 4, m.maxConcurrency)
+
+	_, err = c.NewMultiVizierClient(context.Background(), m.Viziers(), WithMaxConcurrency(0))
+	assert.Error(t, err)
+}
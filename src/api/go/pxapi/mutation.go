@@ -0,0 +1,254 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+const (
+	// DefaultMutationTimeout is how long DeployTracepoint waits for the mutations to be running by default.
+	DefaultMutationTimeout = 2 * time.Minute
+	// DefaultMutationPollInterval is how often DeployTracepoint re-runs the script while the mutations are pending by default.
+	DefaultMutationPollInterval = 5 * time.Second
+
+	tracepointStatusTable = "tracepoint_status"
+	listTracepointsScript = `import px
+px.display(px.GetTracepointStatus(), '` + tracepointStatusTable + `')
+`
+)
+
+type mutationOptions struct {
+	timeout      time.Duration
+	pollInterval time.Duration
+}
+
+// MutationOption configures how DeployTracepoint waits for the mutations.
+type MutationOption func(o *mutationOptions)
+
+// WithMutationTimeout is the option to specify how long to wait for the mutations to be running.
+func WithMutationTimeout(timeout time.Duration) MutationOption {
+	return func(o *mutationOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithMutationPollInterval is the option to specify how often the script is re-run while the mutations are pending.
+func WithMutationPollInterval(interval time.Duration) MutationOption {
+	return func(o *mutationOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// TracepointStatus is the state of a tracepoint deployed on the cluster.
+type TracepointStatus struct {
+	ID    string
+	Name  string
+	State vizierpb.LifeCycleState
+	// Statuses holds the messages reported for the tracepoint when it isn't healthy.
+	Statuses []string
+	// OutputTables are the tables the tracepoint writes to.
+	OutputTables []string
+}
+
+// DeployTracepoint runs a script that deploys tracepoints (using pxtrace) and waits until all of them are running.
+// While the tracepoints are pending, the script is re-run on every poll interval. Once they are running, the tables
+// of the final run are streamed to mux, which may be nil. Returns the mutation info of the last run, with an error
+// wrapping errdefs.ErrMutationFailed if a tracepoint failed to deploy, or errdefs.ErrMutationPending if they are
+// still not running after the timeout.
+func (v *VizierClient) DeployTracepoint(ctx context.Context, pxl string, mux TableMuxer, opts ...MutationOption) (*vizierpb.MutationInfo, error) {
+	o := &mutationOptions{
+		timeout:      DefaultMutationTimeout,
+		pollInterval: DefaultMutationPollInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	timeout := time.NewTimer(o.timeout)
+	defer timeout.Stop()
+	for {
+		mi, err := v.runMutation(ctx, pxl, mux)
+		if err == nil || !errors.Is(err, errdefs.ErrMutationPending) {
+			return mi, err
+		}
+		if err := checkMutationStates(mi); err != nil {
+			return mi, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return mi, ctx.Err()
+		case <-timeout.C:
+			return mi, fmt.Errorf("timed out waiting for mutations to be running: %w", err)
+		case <-time.After(o.pollInterval):
+		}
+	}
+}
+
+// ListTracepoints returns the status of all the tracepoints on the cluster.
+func (v *VizierClient) ListTracepoints(ctx context.Context) ([]*TracepointStatus, error) {
+	h := &tracepointStatusHandler{}
+	sr, err := v.ExecuteScript(ctx, listTracepointsScript, h)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+	if err := sr.Stream(); err != nil {
+		return nil, err
+	}
+	return h.tracepoints, nil
+}
+
+// RemoveTracepoints removes the tracepoints with the given names from the cluster.
+func (v *VizierClient) RemoveTracepoints(ctx context.Context, names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("%w: no tracepoint names specified", errdefs.ErrInvalidArgument)
+	}
+	var sb strings.Builder
+	sb.WriteString("import pxtrace\n")
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("%w: tracepoint name is empty", errdefs.ErrInvalidArgument)
+		}
+		fmt.Fprintf(&sb, "pxtrace.DeleteTracepoint(%s)\n", strconv.Quote(name))
+	}
+	_, err := v.runMutation(ctx, sb.String(), nil)
+	return err
+}
+
+func (v *VizierClient) runMutation(ctx context.Context, pxl string, mux TableMuxer) (*vizierpb.MutationInfo, error) {
+	sr, err := v.ExecuteScript(ctx, pxl, mux)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+	err = sr.Stream()
+	return sr.MutationInfo(), err
+}
+
+// checkMutationStates returns an error if any of the mutations failed to deploy.
+func checkMutationStates(mi *vizierpb.MutationInfo) error {
+	if mi == nil {
+		return nil
+	}
+	var failed []string
+	for _, s := range mi.States {
+		if s.State == vizierpb.FAILED_STATE {
+			failed = append(failed, s.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", errdefs.ErrMutationFailed, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// parseTracepointState converts the state reported by px.GetTracepointStatus to its LifeCycleState.
+func parseTracepointState(s string) vizierpb.LifeCycleState {
+	switch s {
+	case "pending":
+		return vizierpb.PENDING_STATE
+	case "running":
+		return vizierpb.RUNNING_STATE
+	case "failed":
+		return vizierpb.FAILED_STATE
+	case "terminating", "terminated":
+		return vizierpb.TERMINATED_STATE
+	default:
+		return vizierpb.UNKNOWN_STATE
+	}
+}
+
+// tracepointStatusHandler collects the rows of the table output by listTracepointsScript.
+type tracepointStatusHandler struct {
+	tracepoints []*TracepointStatus
+}
+
+// AcceptTable implements the TableMuxer interface.
+func (h *tracepointStatusHandler) AcceptTable(ctx context.Context, metadata types.TableMetadata) (TableRecordHandler, error) {
+	if metadata.Name != tracepointStatusTable {
+		return nil, nil
+	}
+	return h, nil
+}
+
+// HandleInit implements the TableRecordHandler interface.
+func (h *tracepointStatusHandler) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	return nil
+}
+
+// HandleRecord implements the TableRecordHandler interface.
+func (h *tracepointStatusHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	id, err := r.ColumnAsString("tracepoint_id")
+	if err != nil {
+		return err
+	}
+	name, err := r.ColumnAsString("name")
+	if err != nil {
+		return err
+	}
+	state, err := r.ColumnAsString("state")
+	if err != nil {
+		return err
+	}
+	tp := &TracepointStatus{
+		ID:    id,
+		Name:  name,
+		State: parseTracepointState(state),
+	}
+	if tp.Statuses, err = jsonStringListColumn(r, "status"); err != nil {
+		return err
+	}
+	if tp.OutputTables, err = jsonStringListColumn(r, "output_tables"); err != nil {
+		return err
+	}
+	h.tracepoints = append(h.tracepoints, tp)
+	return nil
+}
+
+// HandleDone implements the TableRecordHandler interface.
+func (h *tracepointStatusHandler) HandleDone(ctx context.Context) error {
+	return nil
+}
+
+func jsonStringListColumn(r *types.Record, colName string) ([]string, error) {
+	s, err := r.ColumnAsString(colName)
+	if err != nil {
+		return nil, err
+	}
+	var l []string
+	if s == "" {
+		return l, nil
+	}
+	if err := json.Unmarshal([]byte(s), &l); err != nil {
+		return nil, fmt.Errorf("%w: column '%s' is not a JSON list of strings: %v", errdefs.ErrIncompatibleColumnType, colName, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+func makeMutationInfoResponse(code codes.Code, states ...vizierpb.LifeCycleState) *vizierpb.ExecuteScriptResponse {
+	mi := &vizierpb.MutationInfo{
+		Status: &vizierpb.Status{Code: int32(code)},
+	}
+	if code == codes.Unavailable {
+		mi.Status.Message = "probe installation in progress"
+	}
+	for i, s := range states {
+		mi.States = append(mi.States, &vizierpb.MutationInfo_MutationState{
+			ID:    string(rune('a' + i)),
+			Name:  "tp" + string(rune('0'+i)),
+			State: s,
+		})
+	}
+	return &vizierpb.ExecuteScriptResponse{
+		QueryID:      "query",
+		MutationInfo: mi,
+	}
+}
+
+// pendingMutationStream returns a mocked results stream that only returns the mutation info of a pending mutation.
+// The client stops reading after it, since vizier ends the query at that point.
+func pendingMutationStream(ctrl *gomock.Controller, ctx context.Context, states ...vizierpb.LifeCycleState) vizierpb.VizierService_ExecuteScriptClient {
+	stream := mock_vizierpb.NewMockVizierService_ExecuteScriptClient(ctrl)
+	stream.EXPECT().Context().Return(ctx).AnyTimes()
+	stream.EXPECT().Recv().Return(makeMutationInfoResponse(codes.Unavailable, states...), nil)
+	return stream
+}
+
+func TestScriptResultsMutationPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		Return(pendingMutationStream(ctrl, ctx, vizierpb.RUNNING_STATE, vizierpb.PENDING_STATE), nil)
+
+	sr, err := v.ExecuteScript(ctx, "import pxtrace", nil)
+	require.NoError(t, err)
+	assert.Nil(t, sr.MutationInfo())
+	err = sr.Stream()
+	require.ErrorIs(t, err, errdefs.ErrMutationPending)
+	require.NoError(t, sr.Close())
+
+	mi := sr.MutationInfo()
+	require.NotNil(t, mi)
+	assert.Equal(t, int32(codes.Unavailable), mi.Status.Code)
+	require.Len(t, mi.States, 2)
+	assert.Equal(t, vizierpb.RUNNING_STATE, mi.States[0].State)
+	assert.Equal(t, vizierpb.PENDING_STATE, mi.States[1].State)
+}
+
+func TestDeployTracepointWaitsUntilRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	table := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+
+	gomock.InOrder(
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			Return(pendingMutationStream(ctrl, ctx, vizierpb.PENDING_STATE, vizierpb.PENDING_STATE), nil),
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			Return(pendingMutationStream(ctrl, ctx, vizierpb.RUNNING_STATE, vizierpb.PENDING_STATE), nil),
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+				assert.Equal(t, "import pxtrace", req.QueryStr)
+				return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+					makeMutationInfoResponse(codes.OK, vizierpb.RUNNING_STATE, vizierpb.RUNNING_STATE),
+					table.MetadataResponse(),
+					table.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{200})}, 1),
+					table.EndResponse(),
+				}, io.EOF), nil
+			}),
+	)
+
+	tm := newTableMux()
+	mi, err := v.DeployTracepoint(ctx, "import pxtrace", tm, WithMutationPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	require.Len(t, mi.States, 2)
+	for _, s := range mi.States {
+		assert.Equal(t, vizierpb.RUNNING_STATE, s.State)
+	}
+	assert.Equal(t, []int64{200}, tm.Tables["http_table"].Data)
+}
+
+func TestDeployTracepointFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		Return(pendingMutationStream(ctrl, ctx, vizierpb.RUNNING_STATE, vizierpb.FAILED_STATE), nil)
+
+	mi, err := v.DeployTracepoint(ctx, "import pxtrace", nil, WithMutationPollInterval(time.Millisecond))
+	require.ErrorIs(t, err, errdefs.ErrMutationFailed)
+	assert.Contains(t, err.Error(), "tp1")
+	require.NotNil(t, mi)
+}
+
+func TestDeployTracepointTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			return pendingMutationStream(ctrl, ctx, vizierpb.PENDING_STATE), nil
+		}).MinTimes(1)
+
+	_, err := v.DeployTracepoint(ctx, "import pxtrace", nil,
+		WithMutationTimeout(20*time.Millisecond), WithMutationPollInterval(time.Millisecond))
+	require.ErrorIs(t, err, errdefs.ErrMutationPending)
+}
+
+func TestListTracepoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	table := NewFakeTable(tracepointStatusTable, "abc", &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			noSemTypeColInfo("tracepoint_id", vizierpb.UINT128),
+			noSemTypeColInfo("name", vizierpb.STRING),
+			noSemTypeColInfo("state", vizierpb.STRING),
+			noSemTypeColInfo("status", vizierpb.STRING),
+			noSemTypeColInfo("output_tables", vizierpb.STRING),
+		},
+	})
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			assert.Equal(t, listTracepointsScript, req.QueryStr)
+			return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+				table.MetadataResponse(),
+				table.RowBatchResponse([]*vizierpb.Column{
+					{
+						ColData: &vizierpb.Column_Uint128Data{
+							Uint128Data: &vizierpb.UInt128Column{
+								Data: []*vizierpb.UInt128{
+									{High: 0x11285cdd1de94ab1, Low: 0xae6a0ba08c8c676c},
+									{High: 0, Low: 1},
+								},
+							},
+						},
+					},
+					makeStringColumn([]string{"http_probe", "broken_probe"}),
+					makeStringColumn([]string{"running", "failed"}),
+					makeStringColumn([]string{"[]", `["no such binary"]`}),
+					makeStringColumn([]string{`["http_data"]`, "[]"}),
+				}, 2),
+				table.EndResponse(),
+			}, io.EOF), nil
+		})
+
+	tps, err := v.ListTracepoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, tps, 2)
+
+	assert.Equal(t, &TracepointStatus{
+		ID:           "11285cdd-1de9-4ab1-ae6a-0ba08c8c676c",
+		Name:         "http_probe",
+		State:        vizierpb.RUNNING_STATE,
+		Statuses:     []string{},
+		OutputTables: []string{"http_data"},
+	}, tps[0])
+	assert.Equal(t, "broken_probe", tps[1].Name)
+	assert.Equal(t, vizierpb.FAILED_STATE, tps[1].State)
+	assert.Equal(t, []string{"no such binary"}, tps[1].Statuses)
+	assert.Empty(t, tps[1].OutputTables)
+}
+
+func TestRemoveTracepoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{cloud: &Client{}, vzClient: vzClient}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			assert.Equal(t, "import pxtrace\npxtrace.DeleteTracepoint(\"http_probe\")\npxtrace.DeleteTracepoint(\"dns_probe\")\n", req.QueryStr)
+			return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+				makeMutationInfoResponse(codes.OK),
+			}, io.EOF), nil
+		})
+
+	require.NoError(t, v.RemoveTracepoints(ctx, "http_probe", "dns_probe"))
+
+	err := v.RemoveTracepoints(ctx)
+	require.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+}
@@ -82,3 +82,17 @@ func WithDirectCredsInsecure() ClientOption {
 		c.insecureDirect = true
 	}
 }
+
+// WithRetryPolicy is the option to specify how result streams are reconnected to their query when they break.
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithReconnectHandler is the option to specify a handler that is called whenever a result stream is reconnected to its query.
+func WithReconnectHandler(handler ReconnectHandler) ClientOption {
+	return func(c *Client) {
+		c.reconnectHandler = handler
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package promexporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+)
+
+// MetricType is the Prometheus type a column is exported as.
+type MetricType string
+
+const (
+	// MetricTypeGauge exports the most recent value seen for each label set.
+	MetricTypeGauge MetricType = "gauge"
+	// MetricTypeCounter accumulates the values seen for each label set across script runs.
+	// The value column should therefore contain the increment over the script's window.
+	MetricTypeCounter MetricType = "counter"
+)
+
+const (
+	// DefaultTableName is the table read when a metric doesn't specify one. It matches the name
+	// px.display uses when no name is given.
+	DefaultTableName = "output"
+	// DefaultInterval is how often a script is run when it doesn't specify an interval.
+	DefaultInterval = time.Minute
+)
+
+// Config is the declarative spec of which scripts to run and how their output maps to metrics.
+type Config struct {
+	// Namespace is prepended to every exported metric name.
+	Namespace string `yaml:"namespace"`
+	// Scripts is the list of scripts to run.
+	Scripts []*ScriptSpec `yaml:"scripts"`
+}
+
+// ScriptSpec describes a single PxL script and the metrics derived from its output.
+type ScriptSpec struct {
+	// Name identifies the script in logs and in the exporter's own metrics.
+	Name string `yaml:"name"`
+	// PxL is the script source.
+	PxL string `yaml:"pxl"`
+	// Interval is how often the script is run.
+	Interval time.Duration `yaml:"interval"`
+	// Metrics are the metrics produced from the script's output tables.
+	Metrics []*MetricSpec `yaml:"metrics"`
+}
+
+// MetricSpec maps a column of an output table to a Prometheus metric.
+type MetricSpec struct {
+	// Name of the metric, before the namespace prefix and the unit suffix are added.
+	Name string `yaml:"name"`
+	// Help is the metric's help text.
+	Help string `yaml:"help"`
+	// Type of the metric. Defaults to a gauge.
+	Type MetricType `yaml:"type"`
+	// Table is the output table to read from. Defaults to DefaultTableName.
+	Table string `yaml:"table"`
+	// ValueColumn is the numeric column holding the metric value. Values are converted to base
+	// units based on the column's semantic type, e.g. ST_DURATION_NS is exported in seconds.
+	ValueColumn string `yaml:"value_column"`
+	// LabelColumns are the columns used as labels. The column name is used as the label name.
+	LabelColumns []string `yaml:"label_columns"`
+}
+
+// ParseConfig parses a YAML encoded config, fills in defaults and validates it.
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse config: %s", errdefs.ErrInvalidArgument, err.Error())
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate fills in defaults and checks that the config is well formed.
+func (c *Config) Validate() error {
+	if len(c.Scripts) == 0 {
+		return fmt.Errorf("%w: no scripts specified", errdefs.ErrInvalidArgument)
+	}
+	if c.Namespace != "" && !model.IsValidMetricName(model.LabelValue(c.Namespace)) {
+		return fmt.Errorf("%w: invalid namespace '%s'", errdefs.ErrInvalidArgument, c.Namespace)
+	}
+
+	scriptNames := make(map[string]bool)
+	metricNames := make(map[string]bool)
+	for i, s := range c.Scripts {
+		if s.Name == "" {
+			return fmt.Errorf("%w: scripts[%d] is missing a name", errdefs.ErrInvalidArgument, i)
+		}
+		if scriptNames[s.Name] {
+			return fmt.Errorf("%w: duplicate script name '%s'", errdefs.ErrInvalidArgument, s.Name)
+		}
+		scriptNames[s.Name] = true
+		if s.PxL == "" {
+			return fmt.Errorf("%w: script '%s' is missing pxl", errdefs.ErrInvalidArgument, s.Name)
+		}
+		if s.Interval == 0 {
+			s.Interval = DefaultInterval
+		}
+		if s.Interval < 0 {
+			return fmt.Errorf("%w: script '%s' has a negative interval", errdefs.ErrInvalidArgument, s.Name)
+		}
+		if len(s.Metrics) == 0 {
+			return fmt.Errorf("%w: script '%s' has no metrics", errdefs.ErrInvalidArgument, s.Name)
+		}
+
+		for _, m := range s.Metrics {
+			if err := m.validate(s.Name); err != nil {
+				return err
+			}
+			if metricNames[m.Name] {
+				return fmt.Errorf("%w: duplicate metric name '%s'", errdefs.ErrInvalidArgument, m.Name)
+			}
+			metricNames[m.Name] = true
+		}
+	}
+	return nil
+}
+
+func (m *MetricSpec) validate(scriptName string) error {
+	if !model.IsValidMetricName(model.LabelValue(m.Name)) {
+		return fmt.Errorf("%w: script '%s' has invalid metric name '%s'", errdefs.ErrInvalidArgument, scriptName, m.Name)
+	}
+	switch m.Type {
+	case "":
+		m.Type = MetricTypeGauge
+	case MetricTypeGauge, MetricTypeCounter:
+	default:
+		return fmt.Errorf("%w: metric '%s' has unknown type '%s'", errdefs.ErrInvalidArgument, m.Name, m.Type)
+	}
+	if m.Table == "" {
+		m.Table = DefaultTableName
+	}
+	if m.ValueColumn == "" {
+		return fmt.Errorf("%w: metric '%s' is missing a value column", errdefs.ErrInvalidArgument, m.Name)
+	}
+	if m.Help == "" {
+		m.Help = fmt.Sprintf("Column '%s' of table '%s' produced by PxL script '%s'.", m.ValueColumn, m.Table, scriptName)
+	}
+	seen := make(map[string]bool)
+	for _, l := range m.LabelColumns {
+		if !model.LabelName(l).IsValid() || l == model.MetricNameLabel {
+			return fmt.Errorf("%w: metric '%s' has invalid label column '%s'", errdefs.ErrInvalidArgument, m.Name, l)
+		}
+		if seen[l] {
+			return fmt.Errorf("%w: metric '%s' has duplicate label column '%s'", errdefs.ErrInvalidArgument, m.Name, l)
+		}
+		seen[l] = true
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package promexporter
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"px.dev/pixie/src/api/go/pxapi"
+)
+
+// ScriptExecutor runs PxL scripts. It is implemented by pxapi.VizierClient.
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, pxl string, mux pxapi.TableMuxer) (*pxapi.ScriptResults, error)
+}
+
+// ErrorHandler is called whenever a script run fails.
+type ErrorHandler func(scriptName string, err error)
+
+// ExporterOption configures options on the Exporter.
+type ExporterOption func(e *Exporter)
+
+// WithErrorHandler sets the function called when a script run fails. By default errors are logged.
+func WithErrorHandler(h ErrorHandler) ExporterOption {
+	return func(e *Exporter) {
+		e.errorHandler = h
+	}
+}
+
+// WithScriptTimeout sets the maximum amount of time a single script run may take. Defaults to
+// the interval of the script.
+func WithScriptTimeout(timeout time.Duration) ExporterOption {
+	return func(e *Exporter) {
+		e.scriptTimeout = timeout
+	}
+}
+
+type scriptState struct {
+	spec    *ScriptSpec
+	metrics []*metricState
+	// mu prevents overlapping runs of the same script.
+	mu sync.Mutex
+}
+
+// Exporter runs the configured scripts and exposes their output as Prometheus metrics.
+// It implements the prometheus.Collector interface.
+type Exporter struct {
+	vz            ScriptExecutor
+	scripts       []*scriptState
+	errorHandler  ErrorHandler
+	scriptTimeout time.Duration
+
+	runs     *prometheus.CounterVec
+	duration *prometheus.GaugeVec
+	lastRun  *prometheus.GaugeVec
+}
+
+// NewExporter creates a new exporter for the given config. The config is validated and
+// defaults are filled in.
+func NewExporter(vz ScriptExecutor, cfg *Config, opts ...ExporterOption) (*Exporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Exporter{
+		vz: vz,
+		errorHandler: func(scriptName string, err error) {
+			log.Printf("PxL script '%s' failed: %v\n", scriptName, err)
+		},
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Name:      "pxl_script_runs_total",
+			Help:      "Number of PxL script runs by script and result.",
+		}, []string{"script", "result"}),
+		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Name:      "pxl_script_last_run_duration_seconds",
+			Help:      "Duration of the last run of the PxL script.",
+		}, []string{"script"}),
+		lastRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Name:      "pxl_script_last_success_timestamp_seconds",
+			Help:      "Time of the last successful run of the PxL script.",
+		}, []string{"script"}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	for _, s := range cfg.Scripts {
+		ss := &scriptState{spec: s}
+		for _, m := range s.Metrics {
+			ss.metrics = append(ss.metrics, newMetricState(cfg.Namespace, m))
+		}
+		e.scripts = append(e.scripts, ss)
+	}
+	return e, nil
+}
+
+// Describe implements the prometheus.Collector interface. The script output metrics are unchecked
+// since their names depend on the semantic types of the script output.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	e.runs.Describe(ch)
+	e.duration.Describe(ch)
+	e.lastRun.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.runs.Collect(ch)
+	e.duration.Collect(ch)
+	e.lastRun.Collect(ch)
+	for _, s := range e.scripts {
+		for _, m := range s.metrics {
+			m.collect(ch)
+		}
+	}
+}
+
+// Handler returns an http.Handler serving the exported metrics in the Prometheus exposition format.
+func (e *Exporter) Handler() (http.Handler, error) {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		return nil, err
+	}
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{}), nil
+}
+
+// RunOnce runs every script once, concurrently, and waits for them to complete.
+func (e *Exporter) RunOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range e.scripts {
+		wg.Add(1)
+		go func(s *scriptState) {
+			defer wg.Done()
+			e.runScript(ctx, s)
+		}(s)
+	}
+	wg.Wait()
+}
+
+// Run runs every script on its interval until the context is cancelled. Scripts are run once
+// immediately.
+func (e *Exporter) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range e.scripts {
+		wg.Add(1)
+		go func(s *scriptState) {
+			defer wg.Done()
+			t := time.NewTicker(s.spec.Interval)
+			defer t.Stop()
+			for {
+				e.runScript(ctx, s)
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+}
+
+func (e *Exporter) runScript(ctx context.Context, s *scriptState) {
+	if !s.mu.TryLock() {
+		// The previous run is still in progress.
+		e.runs.WithLabelValues(s.spec.Name, "skipped").Inc()
+		return
+	}
+	defer s.mu.Unlock()
+
+	timeout := e.scriptTimeout
+	if timeout == 0 {
+		timeout = s.spec.Interval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.execute(ctx, s)
+	e.duration.WithLabelValues(s.spec.Name).Set(time.Since(start).Seconds())
+	if err != nil {
+		e.runs.WithLabelValues(s.spec.Name, "error").Inc()
+		e.errorHandler(s.spec.Name, err)
+		return
+	}
+	e.runs.WithLabelValues(s.spec.Name, "success").Inc()
+	e.lastRun.WithLabelValues(s.spec.Name).SetToCurrentTime()
+}
+
+func (e *Exporter) execute(ctx context.Context, s *scriptState) error {
+	mux := &scriptMux{metrics: s.metrics}
+	res, err := e.vz.ExecuteScript(ctx, s.spec.PxL, mux)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	if err := res.Stream(); err != nil {
+		return err
+	}
+	mux.commit()
+	return nil
+}
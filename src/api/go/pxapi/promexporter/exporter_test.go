@@ -0,0 +1,343 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package promexporter_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/promexporter"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+const testConfig = `
+namespace: px
+scripts:
+- name: http_stats
+  interval: 30s
+  pxl: |
+    import px
+    px.display(px.DataFrame('http_events'))
+  metrics:
+  - name: http_request_latency
+    value_column: latency
+    label_columns: [service]
+  - name: http_requests
+    type: counter
+    value_column: count
+    label_columns: [service]
+  - name: http_response_size
+    table: sizes
+    value_column: bytes
+`
+
+func okStatus() *vizierpb.Status {
+	return &vizierpb.Status{Code: 0}
+}
+
+func metadataResponse(name, id string, cols ...*vizierpb.Relation_ColumnInfo) *vizierpb.ExecuteScriptResponse {
+	return &vizierpb.ExecuteScriptResponse{
+		Status: okStatus(),
+		Result: &vizierpb.ExecuteScriptResponse_MetaData{
+			MetaData: &vizierpb.QueryMetadata{
+				Name:     name,
+				ID:       id,
+				Relation: &vizierpb.Relation{Columns: cols},
+			},
+		},
+	}
+}
+
+func batchResponse(id string, numRows int64, eos bool, cols ...*vizierpb.Column) *vizierpb.ExecuteScriptResponse {
+	return &vizierpb.ExecuteScriptResponse{
+		Status: okStatus(),
+		Result: &vizierpb.ExecuteScriptResponse_Data{
+			Data: &vizierpb.QueryData{
+				Batch: &vizierpb.RowBatchData{
+					TableID: id,
+					Cols:    cols,
+					NumRows: numRows,
+					Eow:     eos,
+					Eos:     eos,
+				},
+			},
+		},
+	}
+}
+
+func colInfo(name string, dataType vizierpb.DataType, st vizierpb.SemanticType) *vizierpb.Relation_ColumnInfo {
+	return &vizierpb.Relation_ColumnInfo{
+		ColumnName:         name,
+		ColumnType:         dataType,
+		ColumnSemanticType: st,
+	}
+}
+
+func int64Column(data ...int64) *vizierpb.Column {
+	return &vizierpb.Column{
+		ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: data}},
+	}
+}
+
+func stringColumn(data ...string) *vizierpb.Column {
+	b := make([][]byte, len(data))
+	for i, d := range data {
+		b[i] = []byte(d)
+	}
+	return &vizierpb.Column{
+		ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: b}},
+	}
+}
+
+func scriptResponses(services []string, latencies []int64, counts []int64) []*vizierpb.ExecuteScriptResponse {
+	n := int64(len(services))
+	return []*vizierpb.ExecuteScriptResponse{
+		metadataResponse("output", "1",
+			colInfo("service", vizierpb.STRING, vizierpb.ST_SERVICE_NAME),
+			colInfo("latency", vizierpb.INT64, vizierpb.ST_DURATION_NS),
+			colInfo("count", vizierpb.INT64, vizierpb.ST_NONE),
+		),
+		metadataResponse("sizes", "2", colInfo("bytes", vizierpb.INT64, vizierpb.ST_BYTES)),
+		batchResponse("1", n, false, stringColumn(services...), int64Column(latencies...), int64Column(counts...)),
+		batchResponse("1", 0, true),
+		batchResponse("2", 1, false, int64Column(2048)),
+		batchResponse("2", 0, true),
+	}
+}
+
+// startVizier starts a grpc server backed by the vizier service mock and returns a client connected to it.
+func startVizier(t *testing.T, vzServer vizierpb.VizierServiceServer) *pxapi.VizierClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	vizierpb.RegisterVizierServiceServer(s, vzServer)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	ctx := context.Background()
+	client, err := pxapi.NewClient(ctx,
+		pxapi.WithDirectAddr(lis.Addr().String()),
+		pxapi.WithDirectCredsInsecure(),
+		pxapi.WithE2EEncryption(false),
+	)
+	require.NoError(t, err)
+	vz, err := client.NewVizierClient(ctx, "test-cluster")
+	require.NoError(t, err)
+	return vz
+}
+
+func scrape(t *testing.T, e *promexporter.Exporter) string {
+	h, err := e.Handler()
+	require.NoError(t, err)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExporter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runs := [][]*vizierpb.ExecuteScriptResponse{
+		scriptResponses([]string{"frontend", "backend"}, []int64{1500000000, 2000000}, []int64{10, 3}),
+		scriptResponses([]string{"frontend"}, []int64{500000000}, []int64{5}),
+	}
+
+	vzServer := mock_vizierpb.NewMockVizierServiceServer(ctrl)
+	for _, responses := range runs {
+		responses := responses
+		vzServer.EXPECT().
+			ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(req *vizierpb.ExecuteScriptRequest, srv vizierpb.VizierService_ExecuteScriptServer) error {
+				assert.Equal(t, "test-cluster", req.ClusterID)
+				assert.Contains(t, req.QueryStr, "http_events")
+				for _, resp := range responses {
+					if err := srv.Send(resp); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+	}
+
+	cfg, err := promexporter.ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	e, err := promexporter.NewExporter(startVizier(t, vzServer), cfg, promexporter.WithErrorHandler(func(name string, err error) {
+		t.Errorf("script '%s' failed: %v", name, err)
+	}))
+	require.NoError(t, err)
+
+	e.RunOnce(context.Background())
+	body := scrape(t, e)
+	assert.Contains(t, body, "# TYPE px_http_request_latency_seconds gauge")
+	assert.Contains(t, body, `px_http_request_latency_seconds{service="frontend"} 1.5`)
+	assert.Contains(t, body, `px_http_request_latency_seconds{service="backend"} 0.002`)
+	assert.Contains(t, body, "# TYPE px_http_requests_total counter")
+	assert.Contains(t, body, `px_http_requests_total{service="frontend"} 10`)
+	assert.Contains(t, body, `px_http_requests_total{service="backend"} 3`)
+	assert.Contains(t, body, "px_http_response_size_bytes 2048")
+	assert.Contains(t, body, `px_pxl_script_runs_total{result="success",script="http_stats"} 1`)
+
+	e.RunOnce(context.Background())
+	body = scrape(t, e)
+	// Gauges only report the label sets from the latest run, counters accumulate.
+	assert.Contains(t, body, `px_http_request_latency_seconds{service="frontend"} 0.5`)
+	assert.NotContains(t, body, `px_http_request_latency_seconds{service="backend"}`)
+	assert.Contains(t, body, `px_http_requests_total{service="frontend"} 15`)
+	assert.Contains(t, body, `px_http_requests_total{service="backend"} 3`)
+	assert.Contains(t, body, `px_pxl_script_runs_total{result="success",script="http_stats"} 2`)
+}
+
+func TestExporterKeepsValuesOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vzServer := mock_vizierpb.NewMockVizierServiceServer(ctrl)
+	gomock.InOrder(
+		vzServer.EXPECT().
+			ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(req *vizierpb.ExecuteScriptRequest, srv vizierpb.VizierService_ExecuteScriptServer) error {
+				for _, resp := range scriptResponses([]string{"frontend"}, []int64{1000000000}, []int64{1}) {
+					if err := srv.Send(resp); err != nil {
+						return err
+					}
+				}
+				return nil
+			}),
+		vzServer.EXPECT().
+			ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(req *vizierpb.ExecuteScriptRequest, srv vizierpb.VizierService_ExecuteScriptServer) error {
+				responses := scriptResponses([]string{"frontend"}, []int64{2000000000}, []int64{1})
+				for _, resp := range responses[:3] {
+					if err := srv.Send(resp); err != nil {
+						return err
+					}
+				}
+				return srv.Send(&vizierpb.ExecuteScriptResponse{
+					Status: &vizierpb.Status{Code: 3, Message: "bad script"},
+				})
+			}),
+	)
+
+	cfg, err := promexporter.ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	var errs []error
+	e, err := promexporter.NewExporter(startVizier(t, vzServer), cfg, promexporter.WithErrorHandler(func(name string, err error) {
+		errs = append(errs, err)
+	}))
+	require.NoError(t, err)
+
+	e.RunOnce(context.Background())
+	e.RunOnce(context.Background())
+	require.Len(t, errs, 1)
+
+	body := scrape(t, e)
+	assert.Contains(t, body, `px_http_request_latency_seconds{service="frontend"} 1`)
+	assert.Contains(t, body, `px_http_requests_total{service="frontend"} 1`)
+	assert.Contains(t, body, `px_pxl_script_runs_total{result="error",script="http_stats"} 1`)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := promexporter.ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	require.Len(t, cfg.Scripts, 1)
+	metrics := cfg.Scripts[0].Metrics
+	assert.Equal(t, promexporter.MetricTypeGauge, metrics[0].Type)
+	assert.Equal(t, promexporter.DefaultTableName, metrics[0].Table)
+	assert.Equal(t, promexporter.MetricTypeCounter, metrics[1].Type)
+	assert.Equal(t, "sizes", metrics[2].Table)
+	assert.NotEmpty(t, metrics[0].Help)
+
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{
+			name:   "no scripts",
+			config: `namespace: px`,
+		},
+		{
+			name: "missing pxl",
+			config: `
+scripts:
+- name: a
+  metrics: [{name: m, value_column: v}]`,
+		},
+		{
+			name: "bad metric name",
+			config: `
+scripts:
+- name: a
+  pxl: px.display(df)
+  metrics: [{name: "bad-name", value_column: v}]`,
+		},
+		{
+			name: "bad type",
+			config: `
+scripts:
+- name: a
+  pxl: px.display(df)
+  metrics: [{name: m, type: histogram, value_column: v}]`,
+		},
+		{
+			name: "bad label",
+			config: `
+scripts:
+- name: a
+  pxl: px.display(df)
+  metrics: [{name: m, value_column: v, label_columns: [__name__]}]`,
+		},
+		{
+			name: "duplicate metric",
+			config: `
+scripts:
+- name: a
+  pxl: px.display(df)
+  metrics: [{name: m, value_column: v}]
+- name: b
+  pxl: px.display(df)
+  metrics: [{name: m, value_column: v}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := promexporter.ParseConfig([]byte(test.config))
+			assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+		})
+	}
+}
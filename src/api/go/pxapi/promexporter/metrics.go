@@ -0,0 +1,278 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package promexporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// unit describes how a value with a given semantic type is converted to Prometheus base units.
+type unit struct {
+	suffix string
+	scale  float64
+}
+
+var unitsBySemanticType = map[vizierpb.SemanticType]unit{
+	vizierpb.ST_TIME_NS:                 {suffix: "_timestamp_seconds", scale: 1e-9},
+	vizierpb.ST_DURATION_NS:             {suffix: "_seconds", scale: 1e-9},
+	vizierpb.ST_BYTES:                   {suffix: "_bytes", scale: 1},
+	vizierpb.ST_PERCENT:                 {suffix: "_ratio", scale: 1},
+	vizierpb.ST_THROUGHPUT_PER_NS:       {suffix: "_per_second", scale: 1e9},
+	vizierpb.ST_THROUGHPUT_BYTES_PER_NS: {suffix: "_bytes_per_second", scale: 1e9},
+}
+
+func unitForColumn(col types.ColSchema) unit {
+	if u, ok := unitsBySemanticType[col.SemanticType]; ok {
+		return u
+	}
+	if col.Type == vizierpb.TIME64NS {
+		return unitsBySemanticType[vizierpb.ST_TIME_NS]
+	}
+	return unit{scale: 1}
+}
+
+// metricName builds the fully qualified metric name following the Prometheus naming conventions.
+func metricName(namespace string, spec *MetricSpec, u unit) string {
+	name := spec.Name
+	if !strings.HasSuffix(name, u.suffix) {
+		name += u.suffix
+	}
+	if spec.Type == MetricTypeCounter && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return prometheus.BuildFQName(namespace, "", name)
+}
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// samples holds one sample per label set, keyed by the joined label values.
+type samples map[string]*sample
+
+func (s samples) add(labels []string, value float64, metricType MetricType) {
+	key := strings.Join(labels, "\xff")
+	if cur, ok := s[key]; ok {
+		if metricType == MetricTypeCounter {
+			cur.value += value
+		} else {
+			cur.value = value
+		}
+		return
+	}
+	s[key] = &sample{labels: labels, value: value}
+}
+
+// metricState holds the exported values of a single metric.
+type metricState struct {
+	spec      *MetricSpec
+	namespace string
+
+	mu   sync.Mutex
+	desc *prometheus.Desc
+	// fqName is only known once the semantic type of the value column has been seen.
+	fqName  string
+	samples samples
+}
+
+func newMetricState(namespace string, spec *MetricSpec) *metricState {
+	return &metricState{
+		spec:      spec,
+		namespace: namespace,
+		samples:   make(samples),
+	}
+}
+
+// commit publishes the samples collected in a successful script run. Gauges are replaced so that
+// label sets that are no longer reported go away, while counters accumulate.
+func (m *metricState) commit(fqName string, pending samples) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fqName != m.fqName {
+		m.fqName = fqName
+		m.desc = prometheus.NewDesc(fqName, m.spec.Help, m.spec.LabelColumns, nil)
+		m.samples = make(samples)
+	}
+	if m.spec.Type == MetricTypeGauge {
+		m.samples = pending
+		return
+	}
+	for _, s := range pending {
+		m.samples.add(s.labels, s.value, MetricTypeCounter)
+	}
+}
+
+func (m *metricState) collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.desc == nil {
+		return
+	}
+	valueType := prometheus.GaugeValue
+	if m.spec.Type == MetricTypeCounter {
+		valueType = prometheus.CounterValue
+	}
+	for _, s := range m.samples {
+		ch <- prometheus.MustNewConstMetric(m.desc, valueType, s.value, s.labels...)
+	}
+}
+
+// metricHandler converts the records of a single table into samples for a single metric.
+type metricHandler struct {
+	state   *metricState
+	fqName  string
+	unit    unit
+	isTime  bool
+	pending samples
+}
+
+// HandleInit implements the pxapi.TableRecordHandler interface.
+func (h *metricHandler) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	spec := h.state.spec
+	idx := metadata.IndexOf(spec.ValueColumn)
+	if idx < 0 {
+		return fmt.Errorf("%w: metric '%s' specified value column '%s', but no such column in table '%s'",
+			errdefs.ErrMissingColumn, spec.Name, spec.ValueColumn, metadata.Name)
+	}
+	col := metadata.ColInfo[idx]
+	switch col.Type {
+	case vizierpb.INT64, vizierpb.FLOAT64:
+	case vizierpb.TIME64NS:
+		h.isTime = true
+	default:
+		return fmt.Errorf("%w: metric '%s' specified value column '%s', but that column is not numeric",
+			errdefs.ErrIncompatibleColumnType, spec.Name, spec.ValueColumn)
+	}
+	for _, l := range spec.LabelColumns {
+		if metadata.IndexOf(l) < 0 {
+			return fmt.Errorf("%w: metric '%s' specified label column '%s', but no such column in table '%s'",
+				errdefs.ErrMissingColumn, spec.Name, l, metadata.Name)
+		}
+	}
+	h.unit = unitForColumn(col)
+	h.fqName = metricName(h.state.namespace, spec, h.unit)
+	h.pending = make(samples)
+	return nil
+}
+
+// HandleRecord implements the pxapi.TableRecordHandler interface.
+func (h *metricHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	spec := h.state.spec
+	var value float64
+	if h.isTime {
+		t, err := r.ColumnAsTime(spec.ValueColumn)
+		if err != nil {
+			return err
+		}
+		value = float64(t.UnixNano())
+	} else {
+		v, err := r.ColumnAsFloat(spec.ValueColumn)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	labels := make([]string, len(spec.LabelColumns))
+	for i, l := range spec.LabelColumns {
+		v, err := r.ColumnAsString(l)
+		if err != nil {
+			return err
+		}
+		labels[i] = v
+	}
+	h.pending.add(labels, value*h.unit.scale, spec.Type)
+	return nil
+}
+
+// HandleDone implements the pxapi.TableRecordHandler interface.
+func (h *metricHandler) HandleDone(ctx context.Context) error {
+	return nil
+}
+
+// tableHandler fans the records of a table out to the handlers of every metric reading from it.
+type tableHandler struct {
+	handlers []*metricHandler
+}
+
+// HandleInit implements the pxapi.TableRecordHandler interface.
+func (t *tableHandler) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	for _, h := range t.handlers {
+		if err := h.HandleInit(ctx, metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleRecord implements the pxapi.TableRecordHandler interface.
+func (t *tableHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	for _, h := range t.handlers {
+		if err := h.HandleRecord(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleDone implements the pxapi.TableRecordHandler interface.
+func (t *tableHandler) HandleDone(ctx context.Context) error {
+	return nil
+}
+
+// scriptMux routes the output tables of a single script run to the metrics reading from them.
+// The collected samples are only committed once the whole script has completed successfully.
+type scriptMux struct {
+	metrics  []*metricState
+	handlers []*metricHandler
+}
+
+// AcceptTable implements the pxapi.TableMuxer interface.
+func (s *scriptMux) AcceptTable(ctx context.Context, metadata types.TableMetadata) (pxapi.TableRecordHandler, error) {
+	th := &tableHandler{}
+	for _, m := range s.metrics {
+		if m.spec.Table != metadata.Name {
+			continue
+		}
+		h := &metricHandler{state: m}
+		th.handlers = append(th.handlers, h)
+		s.handlers = append(s.handlers, h)
+	}
+	if len(th.handlers) == 0 {
+		return nil, nil
+	}
+	return th, nil
+}
+
+func (s *scriptMux) commit() {
+	for _, h := range s.handlers {
+		h.state.commit(h.fqName, h.pending)
+	}
+}
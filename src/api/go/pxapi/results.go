@@ -23,12 +23,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/go/pxapi/errdefs"
 	"px.dev/pixie/src/api/go/pxapi/types"
@@ -38,6 +36,7 @@ import (
 
 type tableTracker struct {
 	md      types.TableMetadata
+	qmd     *vizierpb.QueryMetadata
 	handler TableRecordHandler
 	done    bool
 }
@@ -69,6 +68,10 @@ type ScriptResults struct {
 	v       *VizierClient
 	queryID string
 	origCtx context.Context
+
+	mutationInfo *vizierpb.MutationInfo
+	// stateMu guards the query state that is accessed by Checkpoint while the results are being streamed.
+	stateMu sync.Mutex
 }
 
 func newScriptResults() *ScriptResults {
@@ -123,6 +126,9 @@ func (s *ScriptResults) handleGRPCMsg(ctx context.Context, resp *vizierpb.Execut
 	if err := errdefs.ParseStatus(resp.Status); err != nil {
 		return err
 	}
+	if resp.MutationInfo != nil {
+		return s.handleMutationInfo(resp.MutationInfo)
+	}
 	switch v := resp.Result.(type) {
 	case *vizierpb.ExecuteScriptResponse_MetaData:
 		return s.handleTableMetadata(ctx, v)
@@ -143,18 +149,16 @@ func (s *ScriptResults) handleGRPCMsg(ctx context.Context, resp *vizierpb.Execut
 	return errdefs.ErrInternalUnImplementedType
 }
 
-func isTransientGRPCError(err error) bool {
-	s, ok := status.FromError(err)
-	if !ok {
-		return false
-	}
-	if s.Code() == codes.Internal && strings.Contains(s.Message(), "RST_STREAM") {
-		return true
-	}
-	if s.Code() == codes.Internal && strings.Contains(s.Message(), "server closed the stream without sending trailers") {
-		return true
+func (s *ScriptResults) handleMutationInfo(mi *vizierpb.MutationInfo) error {
+	s.stateMu.Lock()
+	s.mutationInfo = mi
+	s.stateMu.Unlock()
+
+	// Vizier ends the query after sending the mutation info when the mutations aren't ready yet.
+	if mi.Status != nil && mi.Status.Code == int32(codes.Unavailable) {
+		return fmt.Errorf("%w: %s", errdefs.ErrMutationPending, mi.Status.Message)
 	}
-	return false
+	return errdefs.ParseStatus(mi.Status)
 }
 
 func (s *ScriptResults) reconnect() error {
@@ -166,6 +170,8 @@ func (s *ScriptResults) reconnect() error {
 		QueryID:           s.queryID,
 		EncryptionOptions: s.v.encOpts,
 	}
+	// Release the context of the broken stream.
+	s.cancel()
 	ctx, cancel := context.WithCancel(s.origCtx)
 	res, err := s.v.vzClient.ExecuteScript(s.v.cloud.cloudCtxWithMD(ctx), req)
 	if err != nil {
@@ -179,6 +185,8 @@ func (s *ScriptResults) reconnect() error {
 
 func (s *ScriptResults) run() error {
 	ctx := s.c.Context()
+	// The number of consecutive reconnect attempts made without receiving any data.
+	attempt := 0
 	for {
 		resp, err := s.c.Recv()
 
@@ -187,9 +195,9 @@ func (s *ScriptResults) run() error {
 				// Stream has terminated.
 				return nil
 			}
-			if isTransientGRPCError(err) {
+			if s.retryPolicy().isRetryable(err) {
 				origErr := err
-				err = s.reconnect()
+				err = s.reconnectWithRetries(origErr, &attempt)
 				if err != nil {
 					return fmt.Errorf("streaming failed: %w, error occurred while reconnecting: %v", origErr, err)
 				}
@@ -201,8 +209,11 @@ func (s *ScriptResults) run() error {
 		if resp == nil {
 			return nil
 		}
+		attempt = 0
 		if s.queryID == "" {
+			s.stateMu.Lock()
 			s.queryID = resp.QueryID
+			s.stateMu.Unlock()
 		}
 		if err := s.handleGRPCMsg(ctx, resp); err != nil {
 			return err
@@ -218,23 +229,8 @@ func (s *ScriptResults) handleTableMetadata(ctx context.Context, md *vizierpb.Ex
 		return errdefs.ErrInternalDuplicateTableMetadata
 	}
 
-	colInfo := make([]types.ColSchema, len(qmd.Relation.Columns))
-	colIdxByName := make(map[string]int64)
-	for idx, col := range qmd.Relation.Columns {
-		colInfo[idx] = types.ColSchema{
-			Name:         col.ColumnName,
-			Type:         col.ColumnType,
-			SemanticType: col.ColumnSemanticType,
-		}
-		colIdxByName[col.ColumnName] = int64(idx)
-	}
-
 	// Create the table schema.
-	tableMD := types.TableMetadata{
-		Name:         qmd.Name,
-		ColInfo:      colInfo,
-		ColIdxByName: colIdxByName,
-	}
+	tableMD := types.NewTableMetadata(qmd.Name, qmd.Relation)
 
 	// Check to see where to route the table, or if it should be dropped.
 	var handler TableRecordHandler
@@ -252,8 +248,11 @@ func (s *ScriptResults) handleTableMetadata(ctx context.Context, md *vizierpb.Ex
 		}
 	}
 
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
 	s.tableIDToTracker[qmd.ID] = &tableTracker{
 		md:      tableMD,
+		qmd:     qmd,
 		handler: handler,
 		done:    false,
 	}
@@ -288,96 +287,47 @@ func (s *ScriptResults) handleTableRowbatch(ctx context.Context, b *vizierpb.Row
 	}
 
 	handler := tracker.handler
-	// Loop through records, do the type conversion and call HandleRecord for each row of data.
-	numCols := int64(len(b.Cols))
-	row := make([]types.Datum, numCols)
-	record := &types.Record{
-		Data:          row,
-		TableMetadata: &tracker.md,
-	}
-
-	// Go through every column and produce the correct value type for each column.
-	for colIdx := int64(0); colIdx < numCols; colIdx++ {
-		colSchema := tracker.md.ColInfo[colIdx]
-		// This code is a bit ugly because the API ships the data in types columns.
-		// So we lookup the data type and then create a row pointer that corresponds to the correct
-		// value type.
-		switch tracker.md.ColInfo[colIdx].Type {
-		case vizierpb.BOOLEAN:
-			row[colIdx] = types.NewBooleanValue(&colSchema)
-		case vizierpb.INT64:
-			row[colIdx] = types.NewInt64Value(&colSchema)
-		case vizierpb.TIME64NS:
-			row[colIdx] = types.NewTime64NSValue(&colSchema)
-		case vizierpb.FLOAT64:
-			row[colIdx] = types.NewFloat64Value(&colSchema)
-		case vizierpb.STRING:
-			row[colIdx] = types.NewStringValue(&colSchema)
-		case vizierpb.UINT128:
-			row[colIdx] = types.NewUint128Value(&colSchema)
-		}
-	}
-
-	// Now loop through the rows, covert the values at each column and call the handler.
-	for rowIdx := int64(0); rowIdx < b.NumRows; rowIdx++ {
-		for colIdx := int64(0); colIdx < numCols; colIdx++ {
-			if err := extractDataFromCol(b.Cols, rowIdx, colIdx, row); err != nil {
-				return err
-			}
-		}
-		if err := handler.HandleRecord(ctx, record); err != nil {
+	// Batches without any columns carry no data, ie. the final EOS batch.
+	if len(b.Cols) > 0 {
+		if err := s.dispatchRowBatch(ctx, tracker, b); err != nil {
 			return err
 		}
 	}
 
 	// This table has been completely streamed.
 	if b.Eos {
+		s.stateMu.Lock()
 		tracker.done = true
+		s.stateMu.Unlock()
 		return handler.HandleDone(ctx)
 	}
 	return nil
 }
 
-func extractDataFromCol(colData []*vizierpb.Column, rowIdx, colIdx int64, row []types.Datum) error {
-	switch colTyped := colData[colIdx].ColData.(type) {
-	case *vizierpb.Column_BooleanData:
-		dCasted, ok := row[colIdx].(*types.BooleanValue)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
-		}
-		dCasted.ScanBool(colTyped.BooleanData.Data[rowIdx])
-	case *vizierpb.Column_Int64Data:
-		dCasted, ok := row[colIdx].(*types.Int64Value)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
-		}
-		dCasted.ScanInt64(colTyped.Int64Data.Data[rowIdx])
-	case *vizierpb.Column_Time64NsData:
-		dCasted, ok := row[colIdx].(*types.Time64NSValue)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
-		}
-		dCasted.ScanInt64(colTyped.Time64NsData.Data[rowIdx])
-	case *vizierpb.Column_Float64Data:
-		dCasted, ok := row[colIdx].(*types.Float64Value)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
-		}
-		dCasted.ScanFloat64(colTyped.Float64Data.Data[rowIdx])
-	case *vizierpb.Column_StringData:
-		dCasted, ok := row[colIdx].(*types.StringValue)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
+func (s *ScriptResults) dispatchRowBatch(ctx context.Context, tracker *tableTracker, b *vizierpb.RowBatchData) error {
+	batch, err := types.NewRowBatch(&tracker.md, b.NumRows, b.Cols)
+	if err != nil {
+		return err
+	}
+	return DispatchBatch(ctx, tracker.handler, batch)
+}
+
+// DispatchBatch passes the batch to the handler, either as a whole if the handler implements TableBatchHandler
+// or row by row. It can be used to feed handlers with batches that were not received through ScriptResults.
+func DispatchBatch(ctx context.Context, handler TableRecordHandler, batch *types.RowBatch) error {
+	if batchHandler, ok := handler.(TableBatchHandler); ok {
+		return batchHandler.HandleBatch(ctx, batch)
+	}
+
+	// Loop through the rows, convert the values at each column and call HandleRecord for each row of data.
+	record := types.NewRecord(batch.TableMetadata)
+	for rowIdx := int64(0); rowIdx < batch.NumRows; rowIdx++ {
+		if err := batch.ScanRow(rowIdx, record); err != nil {
+			return err
 		}
-		dCasted.ScanString(string(colTyped.StringData.Data[rowIdx]))
-	case *vizierpb.Column_Uint128Data:
-		dCasted, ok := row[colIdx].(*types.UInt128Value)
-		if !ok {
-			return errdefs.ErrInternalMismatchedType
+		if err := handler.HandleRecord(ctx, record); err != nil {
+			return err
 		}
-		dCasted.ScanUInt128(colTyped.Uint128Data.Data[rowIdx])
-	default:
-		return errdefs.ErrInternalUnImplementedType
 	}
 	return nil
 }
@@ -390,6 +340,14 @@ func (s *ScriptResults) handleStats(ctx context.Context, qes *vizierpb.QueryExec
 	return nil
 }
 
+// MutationInfo returns the state of the mutations (ie. tracepoints) requested by the script, or nil if the script
+// doesn't contain any mutations or vizier hasn't reported on them yet.
+func (s *ScriptResults) MutationInfo() *vizierpb.MutationInfo {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.mutationInfo
+}
+
 // Stats returns the execution and script stats.
 func (s *ScriptResults) Stats() *ResultsStats {
 	return s.stats
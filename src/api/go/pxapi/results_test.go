@@ -23,7 +23,11 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/memory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 
 	"px.dev/pixie/src/api/go/pxapi/errdefs"
@@ -404,6 +408,178 @@ func TestProcessTwoTables(t *testing.T) {
 	assert.Equal(t, []int64{7, 8, 9, 10}, table2Data.Data)
 }
 
+type arrowBatchHandler struct {
+	NumBatches int
+	Status     []int64
+	Path       []string
+	Done       bool
+}
+
+func (h *arrowBatchHandler) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	return nil
+}
+
+func (h *arrowBatchHandler) HandleRecord(ctx context.Context, r *types.Record) error {
+	return errors.New("HandleRecord should not be called for batch handlers")
+}
+
+func (h *arrowBatchHandler) HandleBatch(ctx context.Context, b *types.RowBatch) error {
+	h.NumBatches++
+	rec, err := b.ArrowRecord(memory.DefaultAllocator)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+
+	h.Status = append(h.Status, rec.Column(0).(*array.Int64).Int64Values()...)
+	paths := rec.Column(1).(*array.String)
+	for i := 0; i < paths.Len(); i++ {
+		h.Path = append(h.Path, paths.Value(i))
+	}
+	return nil
+}
+
+func (h *arrowBatchHandler) HandleDone(ctx context.Context) error {
+	h.Done = true
+	return nil
+}
+
+type arrowBatchMux struct {
+	handler *arrowBatchHandler
+}
+
+func (m *arrowBatchMux) AcceptTable(ctx context.Context, metadata types.TableMetadata) (TableRecordHandler, error) {
+	return m.handler, nil
+}
+
+func TestProcessBatchHandler(t *testing.T) {
+	results := newScriptResults()
+	tm := &arrowBatchMux{handler: &arrowBatchHandler{}}
+	results.tm = tm
+
+	relation := &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			noSemTypeColInfo("http_status", vizierpb.INT64),
+			noSemTypeColInfo("req_path", vizierpb.STRING),
+		},
+	}
+
+	table := NewFakeTable("http_table", "abc", relation)
+
+	messages := []*vizierpb.ExecuteScriptResponse{
+		table.MetadataResponse(),
+		table.RowBatchResponse([]*vizierpb.Column{
+			makeInt64Column([]int64{200, 404}),
+			makeStringColumn([]string{"/a", "/b"}),
+		}, 2),
+		table.RowBatchResponse([]*vizierpb.Column{
+			makeInt64Column([]int64{500}),
+			makeStringColumn([]string{"/c"}),
+		}, 1),
+		table.EndResponse(),
+	}
+
+	ctx := context.Background()
+	for _, msg := range messages {
+		require.NoError(t, results.handleGRPCMsg(ctx, msg))
+	}
+
+	assert.Equal(t, 2, tm.handler.NumBatches)
+	assert.Equal(t, []int64{200, 404, 500}, tm.handler.Status)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, tm.handler.Path)
+	assert.True(t, tm.handler.Done)
+}
+
+func TestRowBatchArrowRecord(t *testing.T) {
+	md := &types.TableMetadata{
+		Name: "table",
+		ColInfo: []types.ColSchema{
+			{Name: "time_", Type: vizierpb.TIME64NS, SemanticType: vizierpb.ST_NONE},
+			{Name: "upid", Type: vizierpb.UINT128, SemanticType: vizierpb.ST_UPID},
+			{Name: "latency", Type: vizierpb.FLOAT64, SemanticType: vizierpb.ST_DURATION_NS},
+			{Name: "ok", Type: vizierpb.BOOLEAN, SemanticType: vizierpb.ST_NONE},
+		},
+	}
+	cols := []*vizierpb.Column{
+		{ColData: &vizierpb.Column_Time64NsData{Time64NsData: &vizierpb.Time64NSColumn{Data: []int64{10, 20}}}},
+		{ColData: &vizierpb.Column_Uint128Data{Uint128Data: &vizierpb.UInt128Column{Data: []*vizierpb.UInt128{
+			{High: 1, Low: 2},
+			{High: 0x0102030405060708, Low: 0x090a0b0c0d0e0f10},
+		}}}},
+		{ColData: &vizierpb.Column_Float64Data{Float64Data: &vizierpb.Float64Column{Data: []float64{1.5, 2.5}}}},
+		{ColData: &vizierpb.Column_BooleanData{BooleanData: &vizierpb.BooleanColumn{Data: []bool{true, false}}}},
+	}
+
+	b, err := types.NewRowBatch(md, 2, cols)
+	require.NoError(t, err)
+
+	_, err = b.Int64Column(0)
+	assert.Equal(t, errdefs.ErrInternalMismatchedType, err)
+	times, err := b.Time64NSColumn(0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 20}, times)
+
+	rec, err := b.ArrowRecord(memory.DefaultAllocator)
+	require.NoError(t, err)
+	defer rec.Release()
+
+	assert.Equal(t, int64(2), rec.NumRows())
+	assert.Equal(t, arrow.FixedWidthTypes.Timestamp_ns, rec.Schema().Field(0).Type)
+	fieldMD := rec.Schema().Field(1).Metadata
+	keyIdx := fieldMD.FindKey(types.ArrowSemanticTypeKey)
+	require.NotEqual(t, -1, keyIdx)
+	assert.Equal(t, "ST_UPID", fieldMD.Values()[keyIdx])
+
+	assert.Equal(t, []arrow.Timestamp{10, 20}, rec.Column(0).(*array.Timestamp).TimestampValues())
+	upids := rec.Column(1).(*array.FixedSizeBinary)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}, upids.Value(0))
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, upids.Value(1))
+	assert.Equal(t, []float64{1.5, 2.5}, rec.Column(2).(*array.Float64).Float64Values())
+	bools := rec.Column(3).(*array.Boolean)
+	assert.True(t, bools.Value(0))
+	assert.False(t, bools.Value(1))
+}
+
+func TestRowBatchMismatchedColumns(t *testing.T) {
+	md := &types.TableMetadata{
+		Name: "table",
+		ColInfo: []types.ColSchema{
+			{Name: "a", Type: vizierpb.INT64},
+		},
+	}
+	_, err := types.NewRowBatch(md, 1, []*vizierpb.Column{makeStringColumn([]string{"a"})})
+	assert.Equal(t, errdefs.ErrInternalMismatchedType, err)
+	_, err = types.NewRowBatch(md, 1, []*vizierpb.Column{})
+	assert.Equal(t, errdefs.ErrInternalMismatchedType, err)
+}
+
+func TestRowBatchMismatchedLength(t *testing.T) {
+	md := &types.TableMetadata{
+		Name: "table",
+		ColInfo: []types.ColSchema{
+			{Name: "a", Type: vizierpb.STRING},
+			{Name: "b", Type: vizierpb.STRING},
+		},
+	}
+	_, err := types.NewRowBatch(md, 2, []*vizierpb.Column{
+		makeStringColumn([]string{"a", "b"}),
+		makeStringColumn([]string{"a"}),
+	})
+	assert.Equal(t, errdefs.ErrInternalMismatchedLength, err)
+	_, err = types.NewRowBatch(md, 1, []*vizierpb.Column{
+		makeStringColumn([]string{"a", "b"}),
+		makeStringColumn([]string{"a", "b"}),
+	})
+	assert.Equal(t, errdefs.ErrInternalMismatchedLength, err)
+
+	rb, err := types.NewRowBatch(md, 2, []*vizierpb.Column{
+		makeStringColumn([]string{"a", "b"}),
+		makeStringColumn([]string{"c", "d"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rb.NumRows)
+}
+
 func TestExecuteScriptGetsScriptError(t *testing.T) {
 	results := newScriptResults()
 	results.tm = newTableMux()
@@ -0,0 +1,226 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+const (
+	defaultMaxReconnectAttempts = 5
+	defaultInitialBackoff       = 100 * time.Millisecond
+	defaultMaxBackoff           = 10 * time.Second
+	defaultBackoffMultiplier    = 2.0
+)
+
+// RetryPolicy configures how the results stream of a query is reconnected to the query after it breaks.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of consecutive reconnect attempts. Zero disables reconnecting.
+	// The count is reset once the reconnected stream returns data.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first reconnect attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time to wait between reconnect attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the backoff grows by after every attempt.
+	Multiplier float64
+	// IsRetryable decides which stream errors trigger a reconnect. Defaults to IsTransientError.
+	IsRetryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is specified.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    defaultMaxReconnectAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Multiplier:     defaultBackoffMultiplier,
+		IsRetryable:    IsTransientError,
+	}
+}
+
+func (p *RetryPolicy) isRetryable(err error) bool {
+	if p.IsRetryable == nil {
+		return IsTransientError(err)
+	}
+	return p.IsRetryable(err)
+}
+
+// backoff returns the time to wait before the given (1-indexed) attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+// IsTransientError returns true for stream errors that are expected to go away after reconnecting,
+// such as the connection to vizier being reset or vizier being temporarily unavailable.
+func IsTransientError(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable:
+		return true
+	case codes.Internal:
+		return strings.Contains(s.Message(), "RST_STREAM") ||
+			strings.Contains(s.Message(), "server closed the stream without sending trailers")
+	}
+	return false
+}
+
+// ReconnectEvent describes a successful reconnect to a running query.
+type ReconnectEvent struct {
+	// QueryID is the ID of the query that was reconnected to.
+	QueryID string
+	// Attempt is the number of attempts it took to reconnect.
+	Attempt int
+	// Err is the error that broke the previous stream.
+	Err error
+}
+
+// ReconnectHandler is called whenever the results stream of a query has been reconnected.
+type ReconnectHandler func(ctx context.Context, ev *ReconnectEvent)
+
+// QueryCheckpoint contains the state needed to attach to a running query, possibly from a different
+// process. It can be serialized as JSON to persist it across restarts.
+type QueryCheckpoint struct {
+	// QueryID is the ID of the query.
+	QueryID string `json:"queryID"`
+	// Tables contains the metadata of the tables that are still being streamed. Vizier only sends the
+	// table metadata at the start of the query, so it needs to be restored when attaching.
+	Tables []*vizierpb.QueryMetadata `json:"tables"`
+}
+
+// QueryID returns the ID of the query. It is empty until the first response from vizier has been received.
+func (s *ScriptResults) QueryID() string {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.queryID
+}
+
+// Checkpoint returns the state needed to attach to the query with VizierClient.AttachToQuery. It is safe
+// to call while the results are being streamed. Data that is in flight when the stream breaks is not resent.
+func (s *ScriptResults) Checkpoint() (*QueryCheckpoint, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.queryID == "" {
+		return nil, fmt.Errorf("%w: query has not returned a QueryID yet", errdefs.ErrInvalidArgument)
+	}
+	cp := &QueryCheckpoint{QueryID: s.queryID}
+	for _, tracker := range s.tableIDToTracker {
+		if tracker.done {
+			continue
+		}
+		cp.Tables = append(cp.Tables, tracker.qmd)
+	}
+	return cp, nil
+}
+
+// AttachToQuery attaches to a query that is already running on vizier and streams its remaining results.
+// The tables in the checkpoint are passed to the mux before any data is streamed. Vizier cancels queries
+// that don't have a consumer for a while, so the query must be attached to shortly after the previous
+// consumer went away.
+func (v *VizierClient) AttachToQuery(ctx context.Context, cp *QueryCheckpoint, mux TableMuxer) (*ScriptResults, error) {
+	if cp == nil || cp.QueryID == "" {
+		return nil, fmt.Errorf("%w: missing QueryID", errdefs.ErrInvalidArgument)
+	}
+	req := &vizierpb.ExecuteScriptRequest{
+		ClusterID:         v.vizierID,
+		QueryID:           cp.QueryID,
+		EncryptionOptions: v.encOpts,
+	}
+	sr, err := v.executeScriptRequest(ctx, req, mux)
+	if err != nil {
+		return nil, err
+	}
+	sr.queryID = cp.QueryID
+	for _, qmd := range cp.Tables {
+		if err := sr.handleTableMetadata(ctx, &vizierpb.ExecuteScriptResponse_MetaData{MetaData: qmd}); err != nil {
+			sr.Close()
+			return nil, err
+		}
+	}
+	return sr, nil
+}
+
+func (s *ScriptResults) retryPolicy() *RetryPolicy {
+	if s.v == nil || s.v.cloud == nil || s.v.cloud.retryPolicy == nil {
+		return DefaultRetryPolicy()
+	}
+	return s.v.cloud.retryPolicy
+}
+
+// reconnectWithRetries reconnects to the query following the retry policy. attempt is the number of
+// consecutive attempts so far, and is updated with the attempts made.
+func (s *ScriptResults) reconnectWithRetries(cause error, attempt *int) error {
+	if s.queryID == "" {
+		return errors.New("cannot reconnect to query that hasn't returned a QueryID yet")
+	}
+	if s.v == nil || s.v.cloud == nil {
+		return errors.New("cannot reconnect to query without a vizier client")
+	}
+	p := s.retryPolicy()
+	for {
+		if *attempt >= p.MaxAttempts {
+			return fmt.Errorf("giving up after %d reconnect attempts", *attempt)
+		}
+		*attempt++
+		select {
+		case <-s.origCtx.Done():
+			return s.origCtx.Err()
+		case <-time.After(p.backoff(*attempt)):
+		}
+
+		err := s.reconnect()
+		if err == nil {
+			break
+		}
+		if !p.isRetryable(err) {
+			return err
+		}
+	}
+
+	if s.v.cloud.reconnectHandler != nil {
+		s.v.cloud.reconnectHandler(s.origCtx, &ReconnectEvent{
+			QueryID: s.queryID,
+			Attempt: *attempt,
+			Err:     cause,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+func testRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+func withQueryID(resp *vizierpb.ExecuteScriptResponse, queryID string) *vizierpb.ExecuteScriptResponse {
+	resp.QueryID = queryID
+	return resp
+}
+
+// mockStream returns a mocked results stream that returns the given responses followed by err.
+func mockStream(ctrl *gomock.Controller, ctx context.Context, responses []*vizierpb.ExecuteScriptResponse, err error) vizierpb.VizierService_ExecuteScriptClient {
+	stream := mock_vizierpb.NewMockVizierService_ExecuteScriptClient(ctrl)
+	stream.EXPECT().Context().Return(ctx).AnyTimes()
+	calls := make([]*gomock.Call, 0, len(responses)+1)
+	for _, resp := range responses {
+		calls = append(calls, stream.EXPECT().Recv().Return(resp, nil))
+	}
+	calls = append(calls, stream.EXPECT().Recv().Return(nil, err))
+	gomock.InOrder(calls...)
+	return stream
+}
+
+func int64Relation(colName string) *vizierpb.Relation {
+	return &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			noSemTypeColInfo(colName, vizierpb.INT64),
+		},
+	}
+}
+
+func TestReconnectOnTransientError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	table := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+
+	var events []*ReconnectEvent
+	v := &VizierClient{
+		cloud: &Client{
+			retryPolicy: testRetryPolicy(3),
+			reconnectHandler: func(ctx context.Context, ev *ReconnectEvent) {
+				events = append(events, ev)
+			},
+		},
+		vizierID: "cluster",
+		vzClient: vzClient,
+	}
+
+	gomock.InOrder(
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+				assert.Equal(t, "", req.QueryID)
+				return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+					withQueryID(table.MetadataResponse(), "query"),
+					withQueryID(table.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{1, 2})}, 2), "query"),
+				}, status.Error(codes.Unavailable, "connection reset")), nil
+			}),
+		// The first reconnect attempt fails before a stream is established.
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			Return(nil, status.Error(codes.Unavailable, "no connection")),
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+				assert.Equal(t, "query", req.QueryID)
+				assert.Equal(t, "cluster", req.ClusterID)
+				return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+					withQueryID(table.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{3})}, 1), "query"),
+					withQueryID(table.EndResponse(), "query"),
+				}, io.EOF), nil
+			}),
+	)
+
+	tm := newTableMux()
+	sr, err := v.ExecuteScript(ctx, "import px", tm)
+	require.NoError(t, err)
+	require.NoError(t, sr.Stream())
+
+	assert.Equal(t, []int64{1, 2, 3}, tm.Tables["http_table"].Data)
+	require.Len(t, events, 1)
+	assert.Equal(t, "query", events[0].QueryID)
+	assert.Equal(t, 2, events[0].Attempt)
+	assert.Equal(t, codes.Unavailable, status.Code(events[0].Err))
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	table := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{
+		cloud:    &Client{retryPolicy: testRetryPolicy(2)},
+		vzClient: vzClient,
+	}
+
+	gomock.InOrder(
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			Return(mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+				withQueryID(table.MetadataResponse(), "query"),
+			}, status.Error(codes.Unavailable, "connection reset")), nil),
+		vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+			Return(nil, status.Error(codes.Unavailable, "no connection")).Times(2),
+	)
+
+	sr, err := v.ExecuteScript(ctx, "import px", newTableMux())
+	require.NoError(t, err)
+	err = sr.Stream()
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(errors.Unwrap(err)))
+	assert.Contains(t, err.Error(), "giving up after 2 reconnect attempts")
+}
+
+func TestReconnectWithoutClient(t *testing.T) {
+	sr := newScriptResults()
+	sr.queryID = "query"
+	attempt := 0
+	err := sr.reconnectWithRetries(status.Error(codes.Unavailable, "connection reset"), &attempt)
+	require.Error(t, err)
+	assert.Equal(t, 0, attempt)
+}
+
+func TestNoReconnectOnOtherErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	table := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{
+		cloud:    &Client{retryPolicy: testRetryPolicy(2)},
+		vzClient: vzClient,
+	}
+
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		Return(mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+			withQueryID(table.MetadataResponse(), "query"),
+		}, status.Error(codes.PermissionDenied, "denied")), nil)
+
+	sr, err := v.ExecuteScript(ctx, "import px", newTableMux())
+	require.NoError(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(sr.Stream()))
+}
+
+func TestAttachToQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	httpTable := NewFakeTable("http_table", "abc", int64Relation("http_status"))
+	doneTable := NewFakeTable("done_table", "def", int64Relation("count"))
+
+	// Stream the start of the query and checkpoint it.
+	results := newScriptResults()
+	results.tm = newTableMux()
+	for _, msg := range []*vizierpb.ExecuteScriptResponse{
+		httpTable.MetadataResponse(),
+		doneTable.MetadataResponse(),
+		httpTable.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{1})}, 1),
+		doneTable.EndResponse(),
+	} {
+		require.NoError(t, results.handleGRPCMsg(ctx, msg))
+	}
+	_, err := results.Checkpoint()
+	assert.Error(t, err)
+	results.queryID = "query"
+	cp, err := results.Checkpoint()
+	require.NoError(t, err)
+
+	// Persist the checkpoint and attach to the query with a new client.
+	b, err := json.Marshal(cp)
+	require.NoError(t, err)
+	restored := &QueryCheckpoint{}
+	require.NoError(t, json.Unmarshal(b, restored))
+	assert.Equal(t, "query", restored.QueryID)
+	require.Len(t, restored.Tables, 1)
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{
+		cloud:    &Client{},
+		vizierID: "cluster",
+		vzClient: vzClient,
+	}
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			assert.Equal(t, "query", req.QueryID)
+			assert.Equal(t, "cluster", req.ClusterID)
+			assert.Empty(t, req.QueryStr)
+			return mockStream(ctrl, ctx, []*vizierpb.ExecuteScriptResponse{
+				withQueryID(httpTable.RowBatchResponse([]*vizierpb.Column{makeInt64Column([]int64{2, 3})}, 2), "query"),
+				withQueryID(httpTable.EndResponse(), "query"),
+			}, io.EOF), nil
+		})
+
+	tm := newTableMux()
+	sr, err := v.AttachToQuery(ctx, restored, tm)
+	require.NoError(t, err)
+	assert.Equal(t, "query", sr.QueryID())
+	require.NoError(t, sr.Stream())
+
+	require.Len(t, tm.Tables, 1)
+	assert.Equal(t, "http_status", tm.Tables["http_table"].ColumnName)
+	assert.Equal(t, []int64{2, 3}, tm.Tables["http_table"].Data)
+
+	_, err = v.AttachToQuery(ctx, &QueryCheckpoint{}, tm)
+	assert.Error(t, err)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(5))
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(status.Error(codes.Unavailable, "unavailable")))
+	assert.True(t, IsTransientError(status.Error(codes.Internal, "stream terminated by RST_STREAM with error code: PROTOCOL_ERROR")))
+	assert.False(t, IsTransientError(status.Error(codes.Internal, "internal")))
+	assert.False(t, IsTransientError(status.Error(codes.InvalidArgument, "bad")))
+	assert.False(t, IsTransientError(errors.New("other")))
+}
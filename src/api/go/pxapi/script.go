@@ -0,0 +1,155 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// ScriptArgs maps the name of a vis variable to its value. Values can be strings or native Go
+// types (bool, ints, floats, time.Duration and []string), which are converted to their PxL form.
+type ScriptArgs map[string]interface{}
+
+// ExecuteScriptWithArgs runs the script on vizier, executing the functions defined in the vis spec
+// with the passed in arguments. Variables that are not in args fall back to their default value.
+func (v *VizierClient) ExecuteScriptWithArgs(ctx context.Context, pxl string, vis *vispb.Vis, args ScriptArgs, mux TableMuxer) (*ScriptResults, error) {
+	execFuncs, err := GetFuncsToExecute(vis, args)
+	if err != nil {
+		return nil, err
+	}
+	req := &vizierpb.ExecuteScriptRequest{
+		ClusterID:         v.vizierID,
+		QueryStr:          pxl,
+		ExecFuncs:         execFuncs,
+		EncryptionOptions: v.encOpts,
+	}
+	return v.executeScriptRequest(ctx, req, mux)
+}
+
+// GetFuncsToExecute returns the funcs to execute for the vis spec, binding the vis variables to the passed in arguments.
+func GetFuncsToExecute(visSpec *vispb.Vis, args ScriptArgs) ([]*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
+	if visSpec == nil {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("%w: script does not take any arguments", errdefs.ErrInvalidArgument)
+		}
+		return []*vizierpb.ExecuteScriptRequest_FuncToExecute{}, nil
+	}
+
+	variables := make(map[string]bool)
+	argValues := make(map[string]string)
+	for _, variable := range visSpec.Variables {
+		variables[variable.Name] = true
+		val, ok := args[variable.Name]
+		if !ok {
+			if variable.DefaultValue == nil {
+				return nil, fmt.Errorf("%w: %w '%s'", errdefs.ErrInvalidArgument, errdefs.ErrMissingRequiredArgument, variable.Name)
+			}
+			argValues[variable.Name] = variable.DefaultValue.Value
+			continue
+		}
+		strVal, err := formatArgValue(val)
+		if err != nil {
+			return nil, fmt.Errorf("%w: argument '%s': %v", errdefs.ErrInvalidArgument, variable.Name, err)
+		}
+		strVal, err = vis.ValidateArgValue(variable, strVal)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errdefs.ErrInvalidArgument, err)
+		}
+		argValues[variable.Name] = strVal
+	}
+	for name := range args {
+		if !variables[name] {
+			return nil, fmt.Errorf("%w: unknown argument '%s'", errdefs.ErrInvalidArgument, name)
+		}
+	}
+
+	execFuncs, err := vis.FuncsToExecute(visSpec, argValues)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errdefs.ErrInvalidArgument, err)
+	}
+	return execFuncs, nil
+}
+
+// formatArgValue converts an argument value to the string form expected by the PxL compiler.
+func formatArgValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "True", nil
+		}
+		return "False", nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Duration:
+		return formatDuration(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, elm := range v {
+			quoted[i] = strconv.Quote(elm)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(quoted, ",")), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported argument type %T", val)
+	}
+}
+
+// formatDuration converts the duration to the PxL duration format (ie. "-5m", "30s"),
+// using the largest unit that represents the duration exactly. PxL durations have millisecond precision.
+func formatDuration(d time.Duration) (string, error) {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d/u.unit, u.suffix), nil
+		}
+	}
+	return "", fmt.Errorf("duration %v is not a whole number of milliseconds", d)
+}
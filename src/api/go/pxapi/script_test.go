@@ -0,0 +1,179 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+)
+
+func namespaceVis() *vispb.Vis {
+	return &vispb.Vis{
+		Variables: []*vispb.Vis_Variable{
+			{
+				Name: "namespace",
+				Type: vispb.PX_NAMESPACE,
+			},
+			{
+				Name:         "start_time",
+				Type:         vispb.PX_DURATION,
+				DefaultValue: &types.StringValue{Value: "-5m"},
+			},
+		},
+		GlobalFuncs: []*vispb.Vis_GlobalFunc{
+			{
+				OutputName: "pods",
+				Func: &vispb.Widget_Func{
+					Name: "pods_for_namespace",
+					Args: []*vispb.Widget_Func_FuncArg{
+						{
+							Name:  "namespace",
+							Input: &vispb.Widget_Func_FuncArg_Variable{Variable: "namespace"},
+						},
+						{
+							Name:  "start_time",
+							Input: &vispb.Widget_Func_FuncArg_Variable{Variable: "start_time"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetFuncsToExecute(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ScriptArgs
+		expectedArgs  map[string]string
+		expectedError error
+	}{
+		{
+			name:         "default values",
+			args:         ScriptArgs{"namespace": "pl"},
+			expectedArgs: map[string]string{"namespace": "pl", "start_time": "-5m"},
+		},
+		{
+			name:         "typed values",
+			args:         ScriptArgs{"namespace": "pl", "start_time": -30 * time.Second},
+			expectedArgs: map[string]string{"namespace": "pl", "start_time": "-30s"},
+		},
+		{
+			name:          "missing required",
+			args:          ScriptArgs{},
+			expectedError: errdefs.ErrMissingRequiredArgument,
+		},
+		{
+			name:          "unknown argument",
+			args:          ScriptArgs{"namespace": "pl", "foo": "bar"},
+			expectedError: errdefs.ErrInvalidArgument,
+		},
+		{
+			name:          "invalid duration",
+			args:          ScriptArgs{"namespace": "pl", "start_time": "5 minutes"},
+			expectedError: errdefs.ErrInvalidArgument,
+		},
+		{
+			name:          "unsupported type",
+			args:          ScriptArgs{"namespace": struct{}{}},
+			expectedError: errdefs.ErrInvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			funcs, err := GetFuncsToExecute(namespaceVis(), test.args)
+			if test.expectedError != nil {
+				assert.True(t, errors.Is(err, test.expectedError))
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, funcs, 1)
+			assert.Equal(t, "pods_for_namespace", funcs[0].FuncName)
+			assert.Equal(t, "pods", funcs[0].OutputTablePrefix)
+			actualArgs := make(map[string]string)
+			for _, arg := range funcs[0].ArgValues {
+				actualArgs[arg.Name] = arg.Value
+			}
+			assert.Equal(t, test.expectedArgs, actualArgs)
+		})
+	}
+}
+
+func TestFormatArgValue(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{"foo", "foo"},
+		{true, "True"},
+		{int64(-12), "-12"},
+		{1.5, "1.5"},
+		{2 * time.Hour, "2h"},
+		{-1500 * time.Millisecond, "-1500ms"},
+		{[]string{"a", "b"}, `["a","b"]`},
+	}
+	for _, test := range tests {
+		val, err := formatArgValue(test.val)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, val)
+	}
+
+	_, err := formatArgValue(time.Microsecond)
+	assert.Error(t, err)
+}
+
+func TestExecuteScriptWithArgs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vzClient := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	v := &VizierClient{
+		cloud:    &Client{},
+		vizierID: "abc",
+		vzClient: vzClient,
+	}
+
+	vzClient.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, opts ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			assert.Equal(t, "abc", req.ClusterID)
+			assert.Equal(t, "import px", req.QueryStr)
+			require.Len(t, req.ExecFuncs, 1)
+			assert.Equal(t, "pods_for_namespace", req.ExecFuncs[0].FuncName)
+			assert.Equal(t, "default", req.ExecFuncs[0].ArgValues[0].Value)
+			return mock_vizierpb.NewMockVizierService_ExecuteScriptClient(ctrl), nil
+		})
+
+	sr, err := v.ExecuteScriptWithArgs(context.Background(), "import px", namespaceVis(), ScriptArgs{"namespace": "default"}, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, sr)
+}
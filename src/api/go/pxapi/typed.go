@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+
+	"px.dev/pixie/src/api/go/pxapi/types"
+)
+
+// TypedTableHandler is a TableRecordHandler that converts the rows of a table into structs of type T and passes
+// them on in batches. See types.StructTagKey for how struct fields are mapped to columns. HandleInit returns an
+// error if the table doesn't match T, ie. a column is missing or has an incompatible type.
+type TypedTableHandler[T any] struct {
+	fn      func(ctx context.Context, rows []T) error
+	scanner *types.StructScanner[T]
+}
+
+// NewTypedTableHandler creates a handler that calls fn with the rows of every batch of the table.
+func NewTypedTableHandler[T any](fn func(ctx context.Context, rows []T) error) *TypedTableHandler[T] {
+	return &TypedTableHandler[T]{fn: fn}
+}
+
+// HandleInit implements the TableRecordHandler interface.
+func (h *TypedTableHandler[T]) HandleInit(ctx context.Context, metadata types.TableMetadata) error {
+	scanner, err := types.NewStructScanner[T](&metadata)
+	if err != nil {
+		return err
+	}
+	h.scanner = scanner
+	return nil
+}
+
+// HandleRecord implements the TableRecordHandler interface.
+func (h *TypedTableHandler[T]) HandleRecord(ctx context.Context, r *types.Record) error {
+	rows := make([]T, 1)
+	if err := h.scanner.Scan(r, &rows[0]); err != nil {
+		return err
+	}
+	return h.fn(ctx, rows)
+}
+
+// HandleBatch implements the TableBatchHandler interface.
+func (h *TypedTableHandler[T]) HandleBatch(ctx context.Context, b *types.RowBatch) error {
+	rows, err := h.scanner.ScanBatch(b)
+	if err != nil {
+		return err
+	}
+	return h.fn(ctx, rows)
+}
+
+// HandleDone implements the TableRecordHandler interface.
+func (h *TypedTableHandler[T]) HandleDone(ctx context.Context) error {
+	return nil
+}
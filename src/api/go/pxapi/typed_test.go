@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pxapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+type httpRow struct {
+	Status int    `pxl:"http_status"`
+	Path   string `pxl:"req_path"`
+}
+
+type typedMux[T any] struct {
+	batches [][]T
+}
+
+func (m *typedMux[T]) AcceptTable(ctx context.Context, metadata types.TableMetadata) (TableRecordHandler, error) {
+	return NewTypedTableHandler(func(ctx context.Context, rows []T) error {
+		m.batches = append(m.batches, rows)
+		return nil
+	}), nil
+}
+
+func TestTypedTableHandler(t *testing.T) {
+	results := newScriptResults()
+	tm := &typedMux[httpRow]{}
+	results.tm = tm
+
+	relation := &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			noSemTypeColInfo("http_status", vizierpb.INT64),
+			noSemTypeColInfo("req_path", vizierpb.STRING),
+		},
+	}
+	table := NewFakeTable("http_table", "abc", relation)
+
+	ctx := context.Background()
+	for _, msg := range []*vizierpb.ExecuteScriptResponse{
+		table.MetadataResponse(),
+		table.RowBatchResponse([]*vizierpb.Column{
+			makeInt64Column([]int64{200, 404}),
+			makeStringColumn([]string{"/a", "/b"}),
+		}, 2),
+		table.RowBatchResponse([]*vizierpb.Column{
+			makeInt64Column([]int64{500}),
+			makeStringColumn([]string{"/c"}),
+		}, 1),
+		table.EndResponse(),
+	} {
+		require.NoError(t, results.handleGRPCMsg(ctx, msg))
+	}
+
+	assert.Equal(t, [][]httpRow{
+		{{Status: 200, Path: "/a"}, {Status: 404, Path: "/b"}},
+		{{Status: 500, Path: "/c"}},
+	}, tm.batches)
+}
+
+func TestTypedTableHandlerMissingColumn(t *testing.T) {
+	results := newScriptResults()
+	results.tm = &typedMux[httpRow]{}
+
+	relation := &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			noSemTypeColInfo("http_status", vizierpb.INT64),
+		},
+	}
+	table := NewFakeTable("http_table", "abc", relation)
+	err := results.handleGRPCMsg(context.Background(), table.MetadataResponse())
+	assert.ErrorIs(t, err, errdefs.ErrMissingColumn)
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"encoding/binary"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+const (
+	// ArrowSemanticTypeKey is the arrow field metadata key that stores the pixie semantic type of a column.
+	ArrowSemanticTypeKey = "px.semantic_type"
+	// ArrowTableNameKey is the arrow schema metadata key that stores the name of the table.
+	ArrowTableNameKey = "px.table_name"
+)
+
+// ArrowUInt128Type is the arrow type used for UINT128 columns. Values are stored as 16 big-endian bytes,
+// high bits first.
+var ArrowUInt128Type = &arrow.FixedSizeBinaryType{ByteWidth: 16}
+
+// ArrowDataType returns the arrow type used to represent columns of the given pixie data type.
+func ArrowDataType(t DataType) (arrow.DataType, error) {
+	switch t {
+	case vizierpb.BOOLEAN:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case vizierpb.INT64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case vizierpb.TIME64NS:
+		return arrow.FixedWidthTypes.Timestamp_ns, nil
+	case vizierpb.FLOAT64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case vizierpb.STRING:
+		return arrow.BinaryTypes.String, nil
+	case vizierpb.UINT128:
+		return ArrowUInt128Type, nil
+	default:
+		return nil, errdefs.ErrInternalUnImplementedType
+	}
+}
+
+// ArrowSchema converts the table metadata to an arrow schema. The table name is stored in the schema
+// metadata and the semantic type of each column is stored in the field metadata.
+func ArrowSchema(md *TableMetadata) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(md.ColInfo))
+	for idx, col := range md.ColInfo {
+		dt, err := ArrowDataType(col.Type)
+		if err != nil {
+			return nil, err
+		}
+		fields[idx] = arrow.Field{
+			Name:     col.Name,
+			Type:     dt,
+			Metadata: arrow.NewMetadata([]string{ArrowSemanticTypeKey}, []string{col.SemanticType.String()}),
+		}
+	}
+	schemaMD := arrow.NewMetadata([]string{ArrowTableNameKey}, []string{md.Name})
+	return arrow.NewSchema(fields, &schemaMD), nil
+}
+
+// ArrowRecord converts the batch to an arrow record. INT64, TIME64NS and FLOAT64 columns reference
+// the batch data without copying, the remaining columns are built using the given allocator.
+// The caller must call Release on the returned record.
+func (b *RowBatch) ArrowRecord(mem memory.Allocator) (arrow.Record, error) {
+	schema, err := ArrowSchema(b.TableMetadata)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]arrow.Array, len(b.cols))
+	defer func() {
+		for _, c := range cols {
+			if c != nil {
+				c.Release()
+			}
+		}
+	}()
+	for idx := range b.cols {
+		cols[idx], err = b.arrowColumn(mem, idx, schema.Field(idx).Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return array.NewRecord(schema, cols, b.NumRows), nil
+}
+
+func (b *RowBatch) arrowColumn(mem memory.Allocator, idx int, dt arrow.DataType) (arrow.Array, error) {
+	switch colTyped := b.cols[idx].ColData.(type) {
+	case *vizierpb.Column_BooleanData:
+		builder := array.NewBooleanBuilder(mem)
+		defer builder.Release()
+		builder.AppendValues(colTyped.BooleanData.Data, nil)
+		return builder.NewArray(), nil
+	case *vizierpb.Column_Int64Data:
+		return fixedWidthArray(dt, len(colTyped.Int64Data.Data), arrow.Int64Traits.CastToBytes(colTyped.Int64Data.Data)), nil
+	case *vizierpb.Column_Time64NsData:
+		return fixedWidthArray(dt, len(colTyped.Time64NsData.Data), arrow.Int64Traits.CastToBytes(colTyped.Time64NsData.Data)), nil
+	case *vizierpb.Column_Float64Data:
+		return fixedWidthArray(dt, len(colTyped.Float64Data.Data), arrow.Float64Traits.CastToBytes(colTyped.Float64Data.Data)), nil
+	case *vizierpb.Column_StringData:
+		builder := array.NewStringBuilder(mem)
+		defer builder.Release()
+		// Append the raw bytes to avoid converting every value to a string.
+		builder.BinaryBuilder.AppendValues(colTyped.StringData.Data, nil)
+		return builder.NewArray(), nil
+	case *vizierpb.Column_Uint128Data:
+		builder := array.NewFixedSizeBinaryBuilder(mem, ArrowUInt128Type)
+		defer builder.Release()
+		builder.Reserve(len(colTyped.Uint128Data.Data))
+		var buf [16]byte
+		for _, v := range colTyped.Uint128Data.Data {
+			putUInt128(buf[:], v)
+			builder.Append(buf[:])
+		}
+		return builder.NewArray(), nil
+	default:
+		return nil, errdefs.ErrInternalUnImplementedType
+	}
+}
+
+func fixedWidthArray(dt arrow.DataType, length int, data []byte) arrow.Array {
+	d := array.NewData(dt, length, []*memory.Buffer{nil, memory.NewBufferBytes(data)}, nil, 0, 0)
+	defer d.Release()
+	return array.MakeFromData(d)
+}
+
+func putUInt128(buf []byte, v *vizierpb.UInt128) {
+	binary.BigEndian.PutUint64(buf, v.High)
+	binary.BigEndian.PutUint64(buf[8:], v.Low)
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// RowBatch stores a batch of rows of a table column-wise. The column slices reference the data
+// received from vizier without any copies, so they are only valid until the handler returns.
+type RowBatch struct {
+	// TableMetadata stores a pointer to underlying table metadata.
+	TableMetadata *TableMetadata
+	// NumRows is the number of rows in the batch.
+	NumRows int64
+
+	cols []*vizierpb.Column
+}
+
+// NewRowBatch creates a RowBatch, checking that the columns match the types in the table metadata
+// and that every column holds numRows values.
+func NewRowBatch(md *TableMetadata, numRows int64, cols []*vizierpb.Column) (*RowBatch, error) {
+	if len(cols) != len(md.ColInfo) {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	for idx, col := range cols {
+		if colDataType(col) != md.ColInfo[idx].Type {
+			return nil, errdefs.ErrInternalMismatchedType
+		}
+		if int64(colLength(col)) != numRows {
+			return nil, errdefs.ErrInternalMismatchedLength
+		}
+	}
+	return &RowBatch{
+		TableMetadata: md,
+		NumRows:       numRows,
+		cols:          cols,
+	}, nil
+}
+
+func colDataType(col *vizierpb.Column) DataType {
+	switch col.ColData.(type) {
+	case *vizierpb.Column_BooleanData:
+		return vizierpb.BOOLEAN
+	case *vizierpb.Column_Int64Data:
+		return vizierpb.INT64
+	case *vizierpb.Column_Uint128Data:
+		return vizierpb.UINT128
+	case *vizierpb.Column_Time64NsData:
+		return vizierpb.TIME64NS
+	case *vizierpb.Column_Float64Data:
+		return vizierpb.FLOAT64
+	case *vizierpb.Column_StringData:
+		return vizierpb.STRING
+	default:
+		return vizierpb.DATA_TYPE_UNKNOWN
+	}
+}
+
+func colLength(col *vizierpb.Column) int {
+	switch c := col.ColData.(type) {
+	case *vizierpb.Column_BooleanData:
+		return len(c.BooleanData.Data)
+	case *vizierpb.Column_Int64Data:
+		return len(c.Int64Data.Data)
+	case *vizierpb.Column_Uint128Data:
+		return len(c.Uint128Data.Data)
+	case *vizierpb.Column_Time64NsData:
+		return len(c.Time64NsData.Data)
+	case *vizierpb.Column_Float64Data:
+		return len(c.Float64Data.Data)
+	case *vizierpb.Column_StringData:
+		return len(c.StringData.Data)
+	default:
+		return 0
+	}
+}
+
+// NumCols returns the number of columns in the batch.
+func (b *RowBatch) NumCols() int {
+	return len(b.cols)
+}
+
+// Column returns the raw column at the given index.
+func (b *RowBatch) Column(idx int) *vizierpb.Column {
+	return b.cols[idx]
+}
+
+// BooleanColumn returns the values of a BOOLEAN column.
+func (b *RowBatch) BooleanColumn(idx int) ([]bool, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_BooleanData)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.BooleanData.Data, nil
+}
+
+// Int64Column returns the values of an INT64 column.
+func (b *RowBatch) Int64Column(idx int) ([]int64, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_Int64Data)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.Int64Data.Data, nil
+}
+
+// Time64NSColumn returns the values of a TIME64NS column, as nanoseconds since the unix epoch.
+func (b *RowBatch) Time64NSColumn(idx int) ([]int64, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_Time64NsData)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.Time64NsData.Data, nil
+}
+
+// Float64Column returns the values of a FLOAT64 column.
+func (b *RowBatch) Float64Column(idx int) ([]float64, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_Float64Data)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.Float64Data.Data, nil
+}
+
+// StringColumn returns the values of a STRING column as raw bytes.
+func (b *RowBatch) StringColumn(idx int) ([][]byte, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_StringData)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.StringData.Data, nil
+}
+
+// UInt128Column returns the values of a UINT128 column.
+func (b *RowBatch) UInt128Column(idx int) ([]*vizierpb.UInt128, error) {
+	c, ok := b.cols[idx].ColData.(*vizierpb.Column_Uint128Data)
+	if !ok {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	return c.Uint128Data.Data, nil
+}
+
+// NewRecord creates a record that can be filled with rows of tables with the given metadata using ScanRow.
+func NewRecord(md *TableMetadata) *Record {
+	row := make([]Datum, len(md.ColInfo))
+	for colIdx := range md.ColInfo {
+		colSchema := &md.ColInfo[colIdx]
+		switch colSchema.Type {
+		case vizierpb.BOOLEAN:
+			row[colIdx] = NewBooleanValue(colSchema)
+		case vizierpb.INT64:
+			row[colIdx] = NewInt64Value(colSchema)
+		case vizierpb.TIME64NS:
+			row[colIdx] = NewTime64NSValue(colSchema)
+		case vizierpb.FLOAT64:
+			row[colIdx] = NewFloat64Value(colSchema)
+		case vizierpb.STRING:
+			row[colIdx] = NewStringValue(colSchema)
+		case vizierpb.UINT128:
+			row[colIdx] = NewUint128Value(colSchema)
+		}
+	}
+	return &Record{
+		Data:          row,
+		TableMetadata: md,
+	}
+}
+
+// ScanRow fills the record, which must have been created with NewRecord, with the row at the given index.
+func (b *RowBatch) ScanRow(rowIdx int64, r *Record) error {
+	for colIdx, col := range b.cols {
+		if err := scanDatum(col, rowIdx, r.Data[colIdx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanDatum(col *vizierpb.Column, rowIdx int64, d Datum) error {
+	switch colTyped := col.ColData.(type) {
+	case *vizierpb.Column_BooleanData:
+		dCasted, ok := d.(*BooleanValue)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanBool(colTyped.BooleanData.Data[rowIdx])
+	case *vizierpb.Column_Int64Data:
+		dCasted, ok := d.(*Int64Value)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanInt64(colTyped.Int64Data.Data[rowIdx])
+	case *vizierpb.Column_Time64NsData:
+		dCasted, ok := d.(*Time64NSValue)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanInt64(colTyped.Time64NsData.Data[rowIdx])
+	case *vizierpb.Column_Float64Data:
+		dCasted, ok := d.(*Float64Value)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanFloat64(colTyped.Float64Data.Data[rowIdx])
+	case *vizierpb.Column_StringData:
+		dCasted, ok := d.(*StringValue)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanString(string(colTyped.StringData.Data[rowIdx]))
+	case *vizierpb.Column_Uint128Data:
+		dCasted, ok := d.(*UInt128Value)
+		if !ok {
+			return errdefs.ErrInternalMismatchedType
+		}
+		dCasted.ScanUInt128(colTyped.Uint128Data.Data[rowIdx])
+	default:
+		return errdefs.ErrInternalUnImplementedType
+	}
+	return nil
+}
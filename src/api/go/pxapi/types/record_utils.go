@@ -0,0 +1,88 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// ColumnAsTime returns the value of the named TIME64NS or INT64 column as a time.Time.
+func (r *Record) ColumnAsTime(colName string) (time.Time, error) {
+	d, err := r.getColumn(colName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch d.Type() {
+	case vizierpb.TIME64NS:
+		return d.(*Time64NSValue).Value(), nil
+	case vizierpb.INT64:
+		return time.Unix(0, d.(*Int64Value).Value()), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: column '%s' is not a TIME64NS or INT64 value", errdefs.ErrIncompatibleColumnType, colName)
+	}
+}
+
+// ColumnAsFloat returns the value of the named INT64 or FLOAT64 column as a float64.
+func (r *Record) ColumnAsFloat(colName string) (float64, error) {
+	d, err := r.getColumn(colName)
+	if err != nil {
+		return 0, err
+	}
+	switch d.Type() {
+	case vizierpb.INT64:
+		return float64(d.(*Int64Value).Value()), nil
+	case vizierpb.FLOAT64:
+		return d.(*Float64Value).Value(), nil
+	default:
+		return 0, fmt.Errorf("%w: column '%s' is not an INT64 or FLOAT64 value", errdefs.ErrIncompatibleColumnType, colName)
+	}
+}
+
+// ColumnAsInt64 returns the value of the named INT64 column.
+func (r *Record) ColumnAsInt64(colName string) (int64, error) {
+	d, err := r.getColumn(colName)
+	if err != nil {
+		return 0, err
+	}
+	if d.Type() != vizierpb.INT64 {
+		return 0, fmt.Errorf("%w: column '%s' is not an INT64 value", errdefs.ErrIncompatibleColumnType, colName)
+	}
+	return d.(*Int64Value).Value(), nil
+}
+
+// ColumnAsString returns the string representation of the named column.
+func (r *Record) ColumnAsString(colName string) (string, error) {
+	d, err := r.getColumn(colName)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+func (r *Record) getColumn(colName string) (Datum, error) {
+	d := r.GetDatum(colName)
+	if d == nil {
+		return nil, fmt.Errorf("%w: no column named '%s' in table '%s'", errdefs.ErrMissingColumn, colName, r.TableMetadata.Name)
+	}
+	return d, nil
+}
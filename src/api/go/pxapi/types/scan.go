@@ -0,0 +1,318 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// StructTagKey is the struct tag used to map struct fields to columns, e.g. `pxl:"latency"`.
+// Adding ",optional" to the tag allows the column to be missing from the table, in which case the
+// field is left untouched. Fields without a tag, or with the tag "-", are ignored.
+const StructTagKey = "pxl"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	upidType     = reflect.TypeOf(UPID{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// fieldKind is the conversion used to store a column value in a struct field.
+type fieldKind int
+
+const (
+	kindBool fieldKind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+	kindBytes
+	kindDuration
+	kindTime
+	kindUPID
+)
+
+type fieldScanner struct {
+	name   string
+	index  []int
+	colIdx int
+	kind   fieldKind
+}
+
+// structScanner holds the mapping of the fields of a struct type to the columns of a table.
+type structScanner struct {
+	t      reflect.Type
+	md     *TableMetadata
+	fields []fieldScanner
+}
+
+func newStructScanner(t reflect.Type, md *TableMetadata) (*structScanner, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: can only scan records into structs, got %s", errdefs.ErrInvalidArgument, t)
+	}
+	s := &structScanner{t: t, md: md}
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup(StructTagKey)
+		if !ok || tag == "-" {
+			continue
+		}
+		colName, opts, _ := strings.Cut(tag, ",")
+		optional := opts == "optional"
+		if colName == "" {
+			colName = f.Name
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("%w: field '%s' of %s is tagged but not exported", errdefs.ErrInvalidArgument, f.Name, t)
+		}
+		if err := checkFieldPath(t, f.Index); err != nil {
+			return nil, err
+		}
+
+		colIdx := md.IndexOf(colName)
+		if colIdx < 0 {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("%w: field '%s' of %s expects column '%s', but table '%s' has no such column",
+				errdefs.ErrMissingColumn, f.Name, t, colName, md.Name)
+		}
+		col := md.ColInfo[colIdx]
+		kind, ok := fieldKindFor(f.Type, col)
+		if !ok {
+			return nil, fmt.Errorf("%w: field '%s' of type %s can't be scanned from column '%s' of type %s (%s)",
+				errdefs.ErrIncompatibleColumnType, f.Name, f.Type, colName, col.Type, col.SemanticType)
+		}
+		s.fields = append(s.fields, fieldScanner{
+			name:   f.Name,
+			index:  f.Index,
+			colIdx: int(colIdx),
+			kind:   kind,
+		})
+	}
+	return s, nil
+}
+
+// checkFieldPath makes sure that a promoted field is not reached through an embedded pointer, since
+// those can be nil.
+func checkFieldPath(t reflect.Type, index []int) error {
+	for i := 0; i < len(index)-1; i++ {
+		f := t.Field(index[i])
+		if f.Type.Kind() == reflect.Pointer {
+			return fmt.Errorf("%w: fields of embedded pointer '%s' of %s can't be scanned into", errdefs.ErrInvalidArgument, f.Name, t)
+		}
+		t = f.Type
+	}
+	return nil
+}
+
+// fieldKindFor determines how a column is stored in a field of type ft, and whether that's possible at all.
+func fieldKindFor(ft reflect.Type, col ColSchema) (fieldKind, bool) {
+	switch ft {
+	case durationType:
+		return kindDuration, col.Type == vizierpb.INT64
+	case timeType:
+		return kindTime, col.Type == vizierpb.TIME64NS || (col.Type == vizierpb.INT64 && col.SemanticType == vizierpb.ST_TIME_NS)
+	case upidType:
+		return kindUPID, col.Type == vizierpb.UINT128 || (col.Type == vizierpb.STRING && col.SemanticType == vizierpb.ST_UPID)
+	case bytesType:
+		return kindBytes, col.Type == vizierpb.STRING
+	}
+
+	switch ft.Kind() {
+	case reflect.Bool:
+		return kindBool, col.Type == vizierpb.BOOLEAN
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return kindInt, col.Type == vizierpb.INT64 || col.Type == vizierpb.TIME64NS
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return kindUint, col.Type == vizierpb.INT64
+	case reflect.Float32, reflect.Float64:
+		return kindFloat, col.Type == vizierpb.FLOAT64 || col.Type == vizierpb.INT64
+	case reflect.String:
+		// Every column has a string representation.
+		return kindString, true
+	}
+	return 0, false
+}
+
+func (s *structScanner) scan(r *Record, dst reflect.Value) error {
+	if len(r.Data) != len(s.md.ColInfo) {
+		return errdefs.ErrInternalMismatchedType
+	}
+	for _, f := range s.fields {
+		if err := f.set(dst.FieldByIndex(f.index), r.Data[f.colIdx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fieldScanner) incompatible(d Datum) error {
+	return fmt.Errorf("%w: can't scan %T into field '%s'", errdefs.ErrIncompatibleColumnType, d, f.name)
+}
+
+func (f *fieldScanner) set(v reflect.Value, d Datum) error {
+	switch f.kind {
+	case kindBool:
+		b, ok := d.(*BooleanValue)
+		if !ok {
+			return f.incompatible(d)
+		}
+		v.SetBool(b.Value())
+	case kindInt:
+		var i int64
+		switch val := d.(type) {
+		case *Time64NSValue:
+			i = val.Value().UnixNano()
+		case *Int64Value:
+			i = val.Value()
+		default:
+			return f.incompatible(d)
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%w: value %d overflows field '%s' of type %s", errdefs.ErrIncompatibleColumnType, i, f.name, v.Type())
+		}
+		v.SetInt(i)
+	case kindUint:
+		val, ok := d.(*Int64Value)
+		if !ok {
+			return f.incompatible(d)
+		}
+		i := val.Value()
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%w: value %d overflows field '%s' of type %s", errdefs.ErrIncompatibleColumnType, i, f.name, v.Type())
+		}
+		v.SetUint(uint64(i))
+	case kindFloat:
+		switch val := d.(type) {
+		case *Float64Value:
+			v.SetFloat(val.Value())
+		case *Int64Value:
+			v.SetFloat(float64(val.Value()))
+		default:
+			return f.incompatible(d)
+		}
+	case kindString:
+		if u, ok := d.(*UInt128Value); ok && u.SemanticType() == vizierpb.ST_UPID {
+			v.SetString(u.UPID().String())
+		} else {
+			v.SetString(d.String())
+		}
+	case kindBytes:
+		val, ok := d.(*StringValue)
+		if !ok {
+			return f.incompatible(d)
+		}
+		v.SetBytes([]byte(val.Value()))
+	case kindDuration:
+		val, ok := d.(*Int64Value)
+		if !ok {
+			return f.incompatible(d)
+		}
+		v.SetInt(val.Value())
+	case kindTime:
+		var t time.Time
+		switch val := d.(type) {
+		case *Time64NSValue:
+			t = val.Value()
+		case *Int64Value:
+			t = time.Unix(0, val.Value())
+		default:
+			return f.incompatible(d)
+		}
+		v.Set(reflect.ValueOf(t))
+	case kindUPID:
+		var u UPID
+		switch val := d.(type) {
+		case *UInt128Value:
+			u = val.UPID()
+		case *StringValue:
+			var err error
+			if u, err = UPIDFromString(val.Value()); err != nil {
+				return err
+			}
+		default:
+			return f.incompatible(d)
+		}
+		v.Set(reflect.ValueOf(u))
+	}
+	return nil
+}
+
+// ScanStruct stores the values of the record in the tagged fields of the struct pointed to by dst.
+// See StructTagKey for how fields are mapped to columns. Semantic types are taken into account, so
+// for example ST_DURATION_NS columns can be scanned into time.Duration fields, ST_TIME_NS columns into
+// time.Time fields and ST_UPID columns into UPID fields. To scan many records of the same table, use a
+// StructScanner instead.
+func (r *Record) ScanStruct(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("%w: can only scan records into non-nil struct pointers, got %T", errdefs.ErrInvalidArgument, dst)
+	}
+	s, err := newStructScanner(v.Elem().Type(), r.TableMetadata)
+	if err != nil {
+		return err
+	}
+	return s.scan(r, v.Elem())
+}
+
+// StructScanner scans the records of a table into structs of type T. The mapping of fields to columns
+// is computed, and checked, once when the scanner is created.
+type StructScanner[T any] struct {
+	s *structScanner
+}
+
+// NewStructScanner creates a scanner for tables with the given metadata. It returns an error if a tagged
+// field has no matching column, or if the column can't be converted to the type of the field.
+func NewStructScanner[T any](md *TableMetadata) (*StructScanner[T], error) {
+	s, err := newStructScanner(reflect.TypeOf((*T)(nil)).Elem(), md)
+	if err != nil {
+		return nil, err
+	}
+	return &StructScanner[T]{s: s}, nil
+}
+
+// Scan stores the values of the record in dst.
+func (s *StructScanner[T]) Scan(r *Record, dst *T) error {
+	return s.s.scan(r, reflect.ValueOf(dst).Elem())
+}
+
+// ScanBatch converts all of the rows in the batch into structs.
+func (s *StructScanner[T]) ScanBatch(b *RowBatch) ([]T, error) {
+	if b.NumCols() != len(s.s.md.ColInfo) {
+		return nil, errdefs.ErrInternalMismatchedType
+	}
+	rows := make([]T, b.NumRows)
+	record := NewRecord(b.TableMetadata)
+	for i := range rows {
+		if err := b.ScanRow(int64(i), record); err != nil {
+			return nil, err
+		}
+		if err := s.s.scan(record, reflect.ValueOf(&rows[i]).Elem()); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
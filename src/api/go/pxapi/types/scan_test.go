@@ -0,0 +1,215 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+func colInfo(name string, dataType vizierpb.DataType, st vizierpb.SemanticType) *vizierpb.Relation_ColumnInfo {
+	return &vizierpb.Relation_ColumnInfo{
+		ColumnName:         name,
+		ColumnType:         dataType,
+		ColumnSemanticType: st,
+	}
+}
+
+func testBatch(t *testing.T) *types.RowBatch {
+	md := types.NewTableMetadata("http_events", &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			colInfo("time_", vizierpb.TIME64NS, vizierpb.ST_TIME_NS),
+			colInfo("upid", vizierpb.UINT128, vizierpb.ST_UPID),
+			colInfo("latency", vizierpb.INT64, vizierpb.ST_DURATION_NS),
+			colInfo("service", vizierpb.STRING, vizierpb.ST_SERVICE_NAME),
+			colInfo("status", vizierpb.INT64, vizierpb.ST_NONE),
+			colInfo("ratio", vizierpb.FLOAT64, vizierpb.ST_PERCENT),
+			colInfo("ok", vizierpb.BOOLEAN, vizierpb.ST_NONE),
+		},
+	})
+	cols := []*vizierpb.Column{
+		{ColData: &vizierpb.Column_Time64NsData{Time64NsData: &vizierpb.Time64NSColumn{Data: []int64{1000, 2000}}}},
+		{ColData: &vizierpb.Column_Uint128Data{Uint128Data: &vizierpb.UInt128Column{Data: []*vizierpb.UInt128{
+			{High: 1<<32 | 123, Low: 456},
+			{High: 2<<32 | 789, Low: 10},
+		}}}},
+		{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: []int64{1500000, 3000000}}}},
+		{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: [][]byte{[]byte("frontend"), []byte("backend")}}}},
+		{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: []int64{200, 500}}}},
+		{ColData: &vizierpb.Column_Float64Data{Float64Data: &vizierpb.Float64Column{Data: []float64{0.5, 0.25}}}},
+		{ColData: &vizierpb.Column_BooleanData{BooleanData: &vizierpb.BooleanColumn{Data: []bool{true, false}}}},
+	}
+	b, err := types.NewRowBatch(&md, 2, cols)
+	require.NoError(t, err)
+	return b
+}
+
+type httpEvent struct {
+	Time      time.Time     `pxl:"time_"`
+	UPID      types.UPID    `pxl:"upid"`
+	UPIDStr   string        `pxl:"upid"`
+	Latency   time.Duration `pxl:"latency"`
+	LatencyNS int64         `pxl:"latency"`
+	Service   string        `pxl:"service"`
+	Status    int32         `pxl:"status"`
+	Ratio     float64       `pxl:"ratio"`
+	OK        bool          `pxl:"ok"`
+	Missing   string        `pxl:"missing,optional"`
+	Ignored   string
+}
+
+func TestStructScannerScanBatch(t *testing.T) {
+	b := testBatch(t)
+	s, err := types.NewStructScanner[httpEvent](b.TableMetadata)
+	require.NoError(t, err)
+
+	rows, err := s.ScanBatch(b)
+	require.NoError(t, err)
+	assert.Equal(t, []httpEvent{
+		{
+			Time:      time.Unix(0, 1000),
+			UPID:      types.UPID{ASID: 1, PID: 123, StartTS: 456},
+			UPIDStr:   "1:123:456",
+			Latency:   1500 * time.Microsecond,
+			LatencyNS: 1500000,
+			Service:   "frontend",
+			Status:    200,
+			Ratio:     0.5,
+			OK:        true,
+		},
+		{
+			Time:      time.Unix(0, 2000),
+			UPID:      types.UPID{ASID: 2, PID: 789, StartTS: 10},
+			UPIDStr:   "2:789:10",
+			Latency:   3 * time.Millisecond,
+			LatencyNS: 3000000,
+			Service:   "backend",
+			Status:    500,
+			Ratio:     0.25,
+			OK:        false,
+		},
+	}, rows)
+}
+
+type embeddedCommon struct {
+	Service string `pxl:"service"`
+}
+
+type embeddingEvent struct {
+	embeddedCommon
+	Status int64 `pxl:"status"`
+}
+
+func TestRecordScanStruct(t *testing.T) {
+	b := testBatch(t)
+	r := types.NewRecord(b.TableMetadata)
+	require.NoError(t, b.ScanRow(1, r))
+
+	ev := &embeddingEvent{}
+	require.NoError(t, r.ScanStruct(ev))
+	assert.Equal(t, "backend", ev.Service)
+	assert.Equal(t, int64(500), ev.Status)
+
+	assert.ErrorIs(t, r.ScanStruct(embeddingEvent{}), errdefs.ErrInvalidArgument)
+	assert.ErrorIs(t, r.ScanStruct((*embeddingEvent)(nil)), errdefs.ErrInvalidArgument)
+}
+
+func TestStructScannerErrors(t *testing.T) {
+	b := testBatch(t)
+
+	type missingColumn struct {
+		Path string `pxl:"req_path"`
+	}
+	_, err := types.NewStructScanner[missingColumn](b.TableMetadata)
+	assert.ErrorIs(t, err, errdefs.ErrMissingColumn)
+	assert.Contains(t, err.Error(), "req_path")
+
+	type wrongType struct {
+		Latency time.Duration `pxl:"service"`
+	}
+	_, err = types.NewStructScanner[wrongType](b.TableMetadata)
+	assert.ErrorIs(t, err, errdefs.ErrIncompatibleColumnType)
+	assert.Contains(t, err.Error(), "field 'Latency' of type time.Duration can't be scanned from column 'service' of type STRING")
+
+	type overflow struct {
+		Latency int8 `pxl:"latency"`
+	}
+	s, err := types.NewStructScanner[overflow](b.TableMetadata)
+	require.NoError(t, err)
+	_, err = s.ScanBatch(b)
+	assert.ErrorIs(t, err, errdefs.ErrIncompatibleColumnType)
+
+	type unexported struct {
+		service string `pxl:"service"`
+	}
+	_, err = types.NewStructScanner[unexported](b.TableMetadata)
+	assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+
+	_, err = types.NewStructScanner[int](b.TableMetadata)
+	assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+}
+
+func TestStructScannerMismatchedRecord(t *testing.T) {
+	b := testBatch(t)
+
+	type event struct {
+		OK      bool          `pxl:"ok"`
+		Status  uint16        `pxl:"status"`
+		Latency time.Duration `pxl:"latency"`
+	}
+	s, err := types.NewStructScanner[event](b.TableMetadata)
+	require.NoError(t, err)
+
+	// A record of another table with the same number of columns, but different types.
+	md := types.NewTableMetadata("other", &vizierpb.Relation{
+		Columns: []*vizierpb.Relation_ColumnInfo{
+			colInfo("a", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("b", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("c", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("d", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("e", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("f", vizierpb.STRING, vizierpb.ST_NONE),
+			colInfo("g", vizierpb.STRING, vizierpb.ST_NONE),
+		},
+	})
+	ev := &event{}
+	err = s.Scan(types.NewRecord(&md), ev)
+	assert.ErrorIs(t, err, errdefs.ErrIncompatibleColumnType)
+}
+
+func TestUPIDFromString(t *testing.T) {
+	u, err := types.UPIDFromString("1:123:456")
+	require.NoError(t, err)
+	assert.Equal(t, types.UPID{ASID: 1, PID: 123, StartTS: 456}, u)
+	assert.Equal(t, uint64(1<<32|123), u.High())
+	assert.Equal(t, uint64(456), u.Low())
+	assert.Equal(t, u, types.UPIDFromUInt128(u.High(), u.Low()))
+
+	_, err = types.UPIDFromString("1:123")
+	assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+	_, err = types.UPIDFromString("a:b:c")
+	assert.ErrorIs(t, err, errdefs.ErrInvalidArgument)
+}
@@ -18,6 +18,10 @@
 
 package types
 
+import (
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
 // TableMetadata contains the table metadata state.
 type TableMetadata struct {
 	// Name of the TableMetadata.
@@ -28,6 +32,25 @@ type TableMetadata struct {
 	ColIdxByName map[string]int64
 }
 
+// NewTableMetadata creates the table metadata for a table with the given name and relation.
+func NewTableMetadata(name string, relation *vizierpb.Relation) TableMetadata {
+	colInfo := make([]ColSchema, len(relation.Columns))
+	colIdxByName := make(map[string]int64)
+	for idx, col := range relation.Columns {
+		colInfo[idx] = ColSchema{
+			Name:         col.ColumnName,
+			Type:         col.ColumnType,
+			SemanticType: col.ColumnSemanticType,
+		}
+		colIdxByName[col.ColumnName] = int64(idx)
+	}
+	return TableMetadata{
+		Name:         name,
+		ColInfo:      colInfo,
+		ColIdxByName: colIdxByName,
+	}
+}
+
 // IndexOf returns the index of a column by name. -1 is returned if the column does not exist.
 func (t *TableMetadata) IndexOf(colName string) int64 {
 	idx, ok := t.ColIdxByName[colName]
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"px.dev/pixie/src/api/go/pxapi/errdefs"
+)
+
+// UPID is a unique process ID. It uses the same layout as the UPID helpers in src/shared/k8s/upid.go:
+// the high 64 bits hold the ASID and PID, the low 64 bits hold the process start time.
+type UPID struct {
+	// ASID is the ID of the agent the process runs on.
+	ASID uint32
+	// PID is the process ID.
+	PID uint32
+	// StartTS is the start time of the process.
+	StartTS uint64
+}
+
+// UPIDFromUInt128 creates a UPID from the high and low 64 bits of its 128 bit representation.
+func UPIDFromUInt128(high, low uint64) UPID {
+	return UPID{
+		ASID:    uint32(high >> 32),
+		PID:     uint32(high),
+		StartTS: low,
+	}
+}
+
+// UPIDFromString parses a UPID in the "asid:pid:start_ts" form.
+func UPIDFromString(s string) (UPID, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return UPID{}, fmt.Errorf("%w: UPID string malformed: '%s'", errdefs.ErrInvalidArgument, s)
+	}
+	asid, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return UPID{}, fmt.Errorf("%w: UPID string malformed: '%s'", errdefs.ErrInvalidArgument, s)
+	}
+	pid, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return UPID{}, fmt.Errorf("%w: UPID string malformed: '%s'", errdefs.ErrInvalidArgument, s)
+	}
+	ts, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return UPID{}, fmt.Errorf("%w: UPID string malformed: '%s'", errdefs.ErrInvalidArgument, s)
+	}
+	return UPID{ASID: uint32(asid), PID: uint32(pid), StartTS: ts}, nil
+}
+
+// High returns the high 64 bits of the UPID.
+func (u UPID) High() uint64 {
+	return uint64(u.ASID)<<32 | uint64(u.PID)
+}
+
+// Low returns the low 64 bits of the UPID.
+func (u UPID) Low() uint64 {
+	return u.StartTS
+}
+
+// String returns the UPID in the "asid:pid:start_ts" form.
+func (u UPID) String() string {
+	return fmt.Sprintf("%d:%d:%d", u.ASID, u.PID, u.StartTS)
+}
+
+// UPID returns the value as a UPID.
+func (v UInt128Value) UPID() UPID {
+	return UPIDFromUInt128(binary.BigEndian.Uint64(v.b), binary.BigEndian.Uint64(v.b[8:]))
+}
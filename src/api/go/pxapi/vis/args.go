@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vis
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+// ErrInvalidArgument specifies that a script argument does not match the type of its variable.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// durationRegex matches the relative time format accepted by the PxL compiler.
+var durationRegex = regexp.MustCompile(`^-?[0-9]+(ms|s|m|h|d)$`)
+
+// ArgValidator validates a raw argument value and returns the value in the form expected by the PxL compiler.
+type ArgValidator func(value string) (string, error)
+
+// ValidateInt64 validates an int64 argument.
+func ValidateInt64(value string) (string, error) {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("'%s' is not a valid int64", value)
+	}
+	return value, nil
+}
+
+// ValidateFloat64 validates a float argument.
+func ValidateFloat64(value string) (string, error) {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "", fmt.Errorf("'%s' is not a valid float", value)
+	}
+	return value, nil
+}
+
+// ParseBool parses a boolean the same way as the PxL compiler (absl::SimpleAtob).
+func ParseBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "t", "yes", "y", "1":
+		return true, true
+	case "false", "f", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// ValidateBool validates a boolean argument, and converts it to the PxL spelling.
+func ValidateBool(value string) (string, error) {
+	b, ok := ParseBool(value)
+	if !ok {
+		return "", fmt.Errorf("'%s' is not a valid boolean", value)
+	}
+	// PxL booleans follow the Python spelling.
+	if b {
+		return "True", nil
+	}
+	return "False", nil
+}
+
+// ValidateDuration validates a relative time argument, such as "-5m".
+func ValidateDuration(value string) (string, error) {
+	if !durationRegex.MatchString(value) {
+		return "", fmt.Errorf("'%s' is not a valid duration, expected a value such as '-5m' or '30s'", value)
+	}
+	return value, nil
+}
+
+// ValidateList converts a comma separated list (ie. "a,b") to a PxL list of strings. Values that are
+// already PxL lists are passed through.
+func ValidateList(value string) (string, error) {
+	if strings.HasPrefix(value, "[") {
+		if !strings.HasSuffix(value, "]") {
+			return "", fmt.Errorf("'%s' is not a valid list", value)
+		}
+		return value, nil
+	}
+	elms := strings.Split(value, ",")
+	quoted := make([]string, len(elms))
+	for i, elm := range elms {
+		quoted[i] = strconv.Quote(strings.TrimSpace(elm))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(quoted, ",")), nil
+}
+
+// EnumValidator returns a validator that only accepts the given values.
+func EnumValidator(validValues []string) ArgValidator {
+	return func(value string) (string, error) {
+		for _, v := range validValues {
+			if v == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("'%s' is not one of the allowed values: %s", value, strings.Join(validValues, ", "))
+	}
+}
+
+// ValidateString accepts any string argument.
+func ValidateString(value string) (string, error) {
+	return value, nil
+}
+
+// ValidatorForVariable returns the validator for the type of the given vis variable.
+func ValidatorForVariable(v *vispb.Vis_Variable) ArgValidator {
+	if len(v.ValidValues) > 0 {
+		return EnumValidator(v.ValidValues)
+	}
+	switch v.Type {
+	case vispb.PX_BOOLEAN:
+		return ValidateBool
+	case vispb.PX_INT64:
+		return ValidateInt64
+	case vispb.PX_FLOAT64:
+		return ValidateFloat64
+	case vispb.PX_DURATION:
+		return ValidateDuration
+	case vispb.PX_LIST, vispb.PX_STRING_LIST:
+		return ValidateList
+	default:
+		return ValidateString
+	}
+}
+
+// ValidateArgValue checks that the value matches the type of the vis variable, and returns the value in the
+// form expected by the PxL compiler. Empty values are always accepted, since they denote an optional argument.
+func ValidateArgValue(v *vispb.Vis_Variable, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	val, err := ValidatorForVariable(v)(value)
+	if err != nil {
+		return "", fmt.Errorf("%w '%s': %v", ErrInvalidArgument, v.Name, err)
+	}
+	return val, nil
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vis_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+func TestValidateArgValue_Bool(t *testing.T) {
+	v := &vispb.Vis_Variable{Name: "verbose", Type: vispb.PX_BOOLEAN}
+	for value, expected := range map[string]string{"true": "True", "Y": "True", "1": "True", "no": "False", "F": "False"} {
+		val, err := vis.ValidateArgValue(v, value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, val)
+	}
+	_, err := vis.ValidateArgValue(v, "on")
+	assert.True(t, errors.Is(err, vis.ErrInvalidArgument))
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vis
+
+import (
+	"fmt"
+
+	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+func makeFuncToExecute(f *vispb.Widget_Func, argValues map[string]string, name string) (*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
+	execFunc := &vizierpb.ExecuteScriptRequest_FuncToExecute{
+		FuncName:          f.Name,
+		ArgValues:         make([]*vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue, len(f.Args)),
+		OutputTablePrefix: "widget",
+	}
+	if name != "" {
+		execFunc.OutputTablePrefix = name
+	}
+	for idx, arg := range f.Args {
+		var value string
+		switch x := arg.Input.(type) {
+		case *vispb.Widget_Func_FuncArg_Value:
+			value = x.Value
+		case *vispb.Widget_Func_FuncArg_Variable:
+			v, ok := argValues[x.Variable]
+			if !ok {
+				return nil, fmt.Errorf("variable '%s' not found", x.Variable)
+			}
+			value = v
+		default:
+			return nil, fmt.Errorf("no value for func arg '%s'", arg.Name)
+		}
+		execFunc.ArgValues[idx] = &vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue{
+			Name:  arg.Name,
+			Value: value,
+		}
+	}
+	return execFunc, nil
+}
+
+// FuncsToExecute returns the funcs to execute for the global funcs and widgets of the vis spec. The
+// argValues map the name of each vis variable to its validated value.
+func FuncsToExecute(vis *vispb.Vis, argValues map[string]string) ([]*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
+	execFuncs := []*vizierpb.ExecuteScriptRequest_FuncToExecute{}
+	if vis == nil {
+		return execFuncs, nil
+	}
+	for _, f := range vis.GlobalFuncs {
+		execFunc, err := makeFuncToExecute(f.Func, argValues, f.OutputName)
+		if err != nil {
+			return nil, err
+		}
+		execFuncs = append(execFuncs, execFunc)
+	}
+	// Find function definitions within widgets.
+	for _, w := range vis.Widgets {
+		x, ok := w.FuncOrRef.(*vispb.Widget_Func_)
+		if !ok {
+			// Skip if it's not a function definition.
+			continue
+		}
+		execFunc, err := makeFuncToExecute(x.Func, argValues, w.Name)
+		if err != nil {
+			return nil, err
+		}
+		execFuncs = append(execFuncs, execFunc)
+	}
+	return execFuncs, nil
+}
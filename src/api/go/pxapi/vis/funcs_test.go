@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+func TestFuncsToExecute(t *testing.T) {
+	spec := &vispb.Vis{
+		GlobalFuncs: []*vispb.Vis_GlobalFunc{
+			{
+				OutputName: "pods",
+				Func: &vispb.Widget_Func{
+					Name: "pods_for_namespace",
+					Args: []*vispb.Widget_Func_FuncArg{
+						{Name: "namespace", Input: &vispb.Widget_Func_FuncArg_Variable{Variable: "namespace"}},
+						{Name: "limit", Input: &vispb.Widget_Func_FuncArg_Value{Value: "10"}},
+					},
+				},
+			},
+		},
+		Widgets: []*vispb.Widget{
+			{
+				FuncOrRef: &vispb.Widget_Func_{
+					Func: &vispb.Widget_Func{Name: "services"},
+				},
+			},
+			{
+				Name:      "ref",
+				FuncOrRef: &vispb.Widget_GlobalFuncOutputName{GlobalFuncOutputName: "pods"},
+			},
+		},
+	}
+
+	funcs, err := vis.FuncsToExecute(spec, map[string]string{"namespace": "default"})
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	assert.Equal(t, "pods", funcs[0].OutputTablePrefix)
+	assert.Equal(t, "default", funcs[0].ArgValues[0].Value)
+	assert.Equal(t, "10", funcs[0].ArgValues[1].Value)
+	assert.Equal(t, "services", funcs[1].FuncName)
+	assert.Equal(t, "widget", funcs[1].OutputTablePrefix)
+
+	_, err = vis.FuncsToExecute(spec, map[string]string{})
+	assert.Error(t, err)
+}
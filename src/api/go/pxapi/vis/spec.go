@@ -0,0 +1,42 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vis
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gogo/protobuf/jsonpb"
+
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+var jsonUnmarshaler = &jsonpb.Unmarshaler{
+	AllowUnknownFields: true,
+}
+
+// ParseSpec parses the JSON form of a vis spec. An empty spec is parsed as a vis without variables or funcs.
+func ParseSpec(specJSON string) (*vispb.Vis, error) {
+	var pb vispb.Vis
+	err := jsonUnmarshaler.Unmarshal(strings.NewReader(specJSON), &pb)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &pb, nil
+}
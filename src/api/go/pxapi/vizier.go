@@ -42,6 +42,10 @@ func (v *VizierClient) ExecuteScript(ctx context.Context, pxl string, mux TableM
 		QueryStr:          pxl,
 		EncryptionOptions: v.encOpts,
 	}
+	return v.executeScriptRequest(ctx, req, mux)
+}
+
+func (v *VizierClient) executeScriptRequest(ctx context.Context, req *vizierpb.ExecuteScriptRequest, mux TableMuxer) (*ScriptResults, error) {
 	origCtx := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	res, err := v.vzClient.ExecuteScript(v.cloud.cloudCtxWithMD(ctx), req)
@@ -289,6 +289,21 @@ func (p *requestProxyer) processNatsMsg(msg *nats.Msg) error {
 		if err != nil {
 			return fmt.Errorf("Failed to send GenerateOTelScriptResp message: %w", err)
 		}
+	case *cvmsgspb.V2CAPIStreamResponse_ListQueriesResp:
+		err = p.srv.SendMsg(parsed.ListQueriesResp)
+		if err != nil {
+			return fmt.Errorf("Failed to send ListQueriesResp message: %w", err)
+		}
+	case *cvmsgspb.V2CAPIStreamResponse_GetQueryResp:
+		err = p.srv.SendMsg(parsed.GetQueryResp)
+		if err != nil {
+			return fmt.Errorf("Failed to send GetQueryResp message: %w", err)
+		}
+	case *cvmsgspb.V2CAPIStreamResponse_CancelQueryResp:
+		err = p.srv.SendMsg(parsed.CancelQueryResp)
+		if err != nil {
+			return fmt.Errorf("Failed to send CancelQueryResp message: %w", err)
+		}
 	case *cvmsgspb.V2CAPIStreamResponse_DebugLogResp:
 		err = p.srv.SendMsg(parsed.DebugLogResp)
 		if err != nil {
@@ -97,31 +97,33 @@ func (v *VizierPassThroughProxy) DebugLog(req *vizierpb.DebugLogRequest, srv viz
 	return rp.Run()
 }
 
-// generateOTelScriptStream is a stream fake that fits into the request proxyer interface.
-type generateOTelScriptStream struct {
-	resp *vizierpb.GenerateOTelScriptResponse
+// unaryStream is a stream fake that fits into the request proxyer interface, and captures the
+// single response of a unary request.
+type unaryStream struct {
+	resp interface{}
 	ctx  context.Context
 }
 
-func (gs *generateOTelScriptStream) Context() context.Context {
-	return gs.ctx
+func (us *unaryStream) Context() context.Context {
+	return us.ctx
 }
 
-func (gs *generateOTelScriptStream) SendMsg(data interface{}) error {
-	gs.resp = data.(*vizierpb.GenerateOTelScriptResponse)
+func (us *unaryStream) SendMsg(data interface{}) error {
+	us.resp = data
 	return nil
 }
 
-// GenerateOTelScript is the GRPC method to generate an OTel script from a DataFrame script.
-func (v *VizierPassThroughProxy) GenerateOTelScript(ctx context.Context, req *vizierpb.GenerateOTelScriptRequest) (*vizierpb.GenerateOTelScriptResponse, error) {
-	srv := &generateOTelScriptStream{ctx: ctx}
+// proxyUnaryRequest sends a unary request to the vizier and waits for its response. setMsg
+// should fill in the request message on the passthrough request.
+func (v *VizierPassThroughProxy) proxyUnaryRequest(ctx context.Context, req ClusterIDer, setMsg func(*cvmsgspb.C2VAPIStreamRequest)) (interface{}, error) {
+	srv := &unaryStream{ctx: ctx}
 	rp, err := newRequestProxyer(v.vc, v.nc, true, req, srv)
 	if err != nil {
 		return nil, err
 	}
 	defer rp.Finish()
 	vizReq := rp.prepareVizierRequest()
-	vizReq.Msg = &cvmsgspb.C2VAPIStreamRequest_GenerateOTelScriptReq{GenerateOTelScriptReq: req}
+	setMsg(vizReq)
 	if err := rp.sendMessageToVizier(vizReq); err != nil {
 		return nil, err
 	}
@@ -132,6 +134,54 @@ func (v *VizierPassThroughProxy) GenerateOTelScript(ctx context.Context, req *vi
 	return srv.resp, nil
 }
 
+// GenerateOTelScript is the GRPC method to generate an OTel script from a DataFrame script.
+func (v *VizierPassThroughProxy) GenerateOTelScript(ctx context.Context, req *vizierpb.GenerateOTelScriptRequest) (*vizierpb.GenerateOTelScriptResponse, error) {
+	resp, err := v.proxyUnaryRequest(ctx, req, func(vizReq *cvmsgspb.C2VAPIStreamRequest) {
+		vizReq.Msg = &cvmsgspb.C2VAPIStreamRequest_GenerateOTelScriptReq{GenerateOTelScriptReq: req}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, _ := resp.(*vizierpb.GenerateOTelScriptResponse)
+	return r, nil
+}
+
+// ListQueries is the GRPC method to list the queries running on a vizier.
+func (v *VizierPassThroughProxy) ListQueries(ctx context.Context, req *vizierpb.ListQueriesRequest) (*vizierpb.ListQueriesResponse, error) {
+	resp, err := v.proxyUnaryRequest(ctx, req, func(vizReq *cvmsgspb.C2VAPIStreamRequest) {
+		vizReq.Msg = &cvmsgspb.C2VAPIStreamRequest_ListQueriesReq{ListQueriesReq: req}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, _ := resp.(*vizierpb.ListQueriesResponse)
+	return r, nil
+}
+
+// GetQuery is the GRPC method to get the state of a query running on a vizier.
+func (v *VizierPassThroughProxy) GetQuery(ctx context.Context, req *vizierpb.GetQueryRequest) (*vizierpb.GetQueryResponse, error) {
+	resp, err := v.proxyUnaryRequest(ctx, req, func(vizReq *cvmsgspb.C2VAPIStreamRequest) {
+		vizReq.Msg = &cvmsgspb.C2VAPIStreamRequest_GetQueryReq{GetQueryReq: req}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, _ := resp.(*vizierpb.GetQueryResponse)
+	return r, nil
+}
+
+// CancelQuery is the GRPC method to cancel a query running on a vizier.
+func (v *VizierPassThroughProxy) CancelQuery(ctx context.Context, req *vizierpb.CancelQueryRequest) (*vizierpb.CancelQueryResponse, error) {
+	resp, err := v.proxyUnaryRequest(ctx, req, func(vizReq *cvmsgspb.C2VAPIStreamRequest) {
+		vizReq.Msg = &cvmsgspb.C2VAPIStreamRequest_CancelQueryReq{CancelQueryReq: req}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r, _ := resp.(*vizierpb.CancelQueryResponse)
+	return r, nil
+}
+
 // DebugPods is the GRPC method to fetch the list of Vizier pods (and statuses) from a cluster.
 func (v *VizierPassThroughProxy) DebugPods(req *vizierpb.DebugPodsRequest, srv vizierpb.VizierDebugService_DebugPodsServer) error {
 	rp, err := newRequestProxyer(v.vc, v.nc, true, req, srv)
@@ -719,6 +719,126 @@ func TestVizierPassThroughProxy_DebugPods(t *testing.T) {
 	}
 }
 
+func TestVizierPassThroughProxy_Queries(t *testing.T) {
+	viper.Set("jwt_signing_key", "the-key")
+
+	ts, cleanup := createTestState(t)
+	defer cleanup(t)
+
+	client := vizierpb.NewVizierServiceClient(ts.conn)
+	validTestToken := testingutils.GenerateTestJWTToken(t, viper.GetString("jwt_signing_key"))
+
+	queryInfo := &vizierpb.QueryInfo{
+		QueryID:       "11111111-1111-1111-1111-111111111111",
+		QueryName:     "px/cluster",
+		Principal:     "user:test@test.com",
+		ActiveAgents:  2,
+		PendingTables: []string{"output"},
+	}
+
+	testCases := []struct {
+		name string
+
+		clusterID      string
+		respFromVizier *cvmsgspb.V2CAPIStreamResponse
+		call           func(ctx context.Context, clusterID string) (interface{}, error)
+
+		expGRPCError    error
+		expGRPCResponse interface{}
+	}{
+		{
+			name:      "list queries",
+			clusterID: "00000000-1111-2222-2222-333333333333",
+			respFromVizier: &cvmsgspb.V2CAPIStreamResponse{
+				Msg: &cvmsgspb.V2CAPIStreamResponse_ListQueriesResp{
+					ListQueriesResp: &vizierpb.ListQueriesResponse{
+						Queries: []*vizierpb.QueryInfo{queryInfo},
+					},
+				},
+			},
+			call: func(ctx context.Context, clusterID string) (interface{}, error) {
+				return client.ListQueries(ctx, &vizierpb.ListQueriesRequest{ClusterID: clusterID})
+			},
+			expGRPCResponse: &vizierpb.ListQueriesResponse{
+				Queries: []*vizierpb.QueryInfo{queryInfo},
+			},
+		},
+		{
+			name:      "list queries on unhealthy cluster",
+			clusterID: "20000000-1111-2222-2222-333333333333",
+			respFromVizier: &cvmsgspb.V2CAPIStreamResponse{
+				Msg: &cvmsgspb.V2CAPIStreamResponse_ListQueriesResp{
+					ListQueriesResp: &vizierpb.ListQueriesResponse{},
+				},
+			},
+			call: func(ctx context.Context, clusterID string) (interface{}, error) {
+				return client.ListQueries(ctx, &vizierpb.ListQueriesRequest{ClusterID: clusterID})
+			},
+			expGRPCResponse: &vizierpb.ListQueriesResponse{},
+		},
+		{
+			name:      "list queries on disconnected cluster",
+			clusterID: "10000000-1111-2222-2222-333333333333",
+			call: func(ctx context.Context, clusterID string) (interface{}, error) {
+				return client.ListQueries(ctx, &vizierpb.ListQueriesRequest{ClusterID: clusterID})
+			},
+			expGRPCError: ptproxy.ErrNotAvailable,
+		},
+		{
+			name:      "get query",
+			clusterID: "00000000-1111-2222-2222-333333333333",
+			respFromVizier: &cvmsgspb.V2CAPIStreamResponse{
+				Msg: &cvmsgspb.V2CAPIStreamResponse_GetQueryResp{
+					GetQueryResp: &vizierpb.GetQueryResponse{Query: queryInfo},
+				},
+			},
+			call: func(ctx context.Context, clusterID string) (interface{}, error) {
+				return client.GetQuery(ctx, &vizierpb.GetQueryRequest{ClusterID: clusterID, QueryID: queryInfo.QueryID})
+			},
+			expGRPCResponse: &vizierpb.GetQueryResponse{Query: queryInfo},
+		},
+		{
+			name:      "cancel query",
+			clusterID: "00000000-1111-2222-2222-333333333333",
+			respFromVizier: &cvmsgspb.V2CAPIStreamResponse{
+				Msg: &cvmsgspb.V2CAPIStreamResponse_CancelQueryResp{
+					CancelQueryResp: &vizierpb.CancelQueryResponse{},
+				},
+			},
+			call: func(ctx context.Context, clusterID string) (interface{}, error) {
+				return client.CancelQuery(ctx, &vizierpb.CancelQueryRequest{ClusterID: clusterID, QueryID: queryInfo.QueryID})
+			},
+			expGRPCResponse: &vizierpb.CancelQueryResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization",
+				fmt.Sprintf("bearer %s", validTestToken))
+			ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+			defer cancel()
+
+			var respFromVizier []*cvmsgspb.V2CAPIStreamResponse
+			if tc.respFromVizier != nil {
+				respFromVizier = append(respFromVizier, tc.respFromVizier)
+			}
+			fv := newFakeVizier(t, uuid.FromStringOrNil(tc.clusterID), ts.nc)
+			fv.Run(t, respFromVizier)
+			defer fv.Stop()
+
+			resp, err := tc.call(ctx, tc.clusterID)
+			if tc.expGRPCError != nil {
+				require.Error(t, err)
+				assert.Equal(t, status.Code(tc.expGRPCError), status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expGRPCResponse, resp)
+		})
+	}
+}
+
 type fakeVzMgr struct{}
 
 func (v *fakeVzMgr) GetVizierInfo(ctx context.Context, in *uuidpb.UUID, opts ...grpc.CallOption) (*cvmsgspb.VizierInfo, error) {
@@ -26,7 +26,9 @@ import (
 	"github.com/gofrs/uuid"
 
 	"px.dev/pixie/src/api/proto/cloudpb"
+	"px.dev/pixie/src/api/proto/vispb"
 	"px.dev/pixie/src/cloud/autocomplete/ebnf"
+	"px.dev/pixie/src/utils/script"
 )
 
 // CursorMarker is the string that is used to denote the position of the cursor in the formatted output string.
@@ -46,6 +48,7 @@ type Suggestion struct {
 	Kind           cloudpb.AutocompleteEntityKind
 	ArgNames       []string // If the suggestion is a script, the args that the script takes.
 	ArgKinds       []cloudpb.AutocompleteEntityKind
+	ArgVariables   []*vispb.Vis_Variable // If the suggestion is a script, the vis variables for all of its args.
 	MatchedIndexes []int64
 	State          cloudpb.AutocompleteEntityState
 }
@@ -58,6 +61,11 @@ type TabStop struct {
 	Suggestions    []*Suggestion
 	ContainsCursor bool
 	ArgName        string
+	// invalidValue is set if the value doesn't match the type of the script arg.
+	invalidValue bool
+	// typed is set for args of non-entity script variables (ie. int64, duration), which are validated
+	// against the variable's type instead of entity suggestions.
+	typed bool
 }
 
 // Command represents an executable command.
@@ -132,10 +140,11 @@ func parseGoCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester) error
 	return errors.New("Not yet implemented")
 }
 
-func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID uuid.UUID, clusterUID string) (int, []string, []cloudpb.AutocompleteEntityKind, error) {
+func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID uuid.UUID, clusterUID string) (int, []string, []cloudpb.AutocompleteEntityKind, []*vispb.Vis_Variable, error) {
 	// The TabStop after the action should be the script. Check if there are any scripts defined.
 	argNames := make([]string, 0)
 	argTypes := make([]cloudpb.AutocompleteEntityKind, 0)
+	var argVars []*vispb.Vis_Variable
 	scriptTabIndex := -1
 	for i, a := range parsedCmd.Args {
 		if a.Type != nil && *a.Type == "script" {
@@ -152,7 +161,7 @@ func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 
 			res, err := s.GetSuggestions([]*SuggestionRequest{{orgID, clusterUID, searchTerm, []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT}, []cloudpb.AutocompleteEntityKind{}}})
 			if err != nil {
-				return -1, nil, nil, err
+				return -1, nil, nil, nil, err
 			}
 
 			suggestions := res[0].Suggestions
@@ -161,6 +170,7 @@ func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 			if exactMatch {
 				argNames = suggestions[0].ArgNames
 				argTypes = suggestions[0].ArgKinds
+				argVars = suggestions[0].ArgVariables
 				cmd.HasValidScript = true
 			}
 
@@ -176,11 +186,11 @@ func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 		}
 	}
 
-	return scriptTabIndex, argNames, argTypes, nil
+	return scriptTabIndex, argNames, argTypes, argVars, nil
 }
 
 // parseRunArgsWithScript parses the command args according to the expected args for the given script.
-func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, argNames []string, argTypes []cloudpb.AutocompleteEntityKind, scriptTabIndex int) ([]*TabStop, []cloudpb.AutocompleteEntityKind) {
+func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, argNames []string, argTypes []cloudpb.AutocompleteEntityKind, argVars []*vispb.Vis_Variable, scriptTabIndex int) ([]*TabStop, []cloudpb.AutocompleteEntityKind) {
 	// The tab stops for the command should be the args for the given script.
 	argToTabStop := make(map[string]*TabStop)
 	argNameToKind := make(map[string]cloudpb.AutocompleteEntityKind)
@@ -197,7 +207,14 @@ func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester
 		argNameToKind[n] = argType
 	}
 
+	argNameToVar := make(map[string]*vispb.Vis_Variable)
+	for _, v := range argVars {
+		argNameToVar[v.Name] = v
+	}
+
 	unusedArgs := make([]*ebnf.ParsedArg, 0)
+	typedArgs := make([]*TabStop, 0)
+	typedArgNames := make(map[string]bool)
 	// Look through args for any that are already labeled by an argName.
 	for i, a := range parsedCmd.Args {
 		if i == scriptTabIndex {
@@ -220,6 +237,22 @@ func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester
 					}
 					continue
 				}
+			} else if _, isVar := argNameToVar[*a.Type]; isVar && !typedArgNames[*a.Type] {
+				// The arg is labeled by a non-entity script variable, so it has no suggestions.
+				label := *a.Type
+				value := ""
+				if a.Name != nil {
+					value = *a.Name
+				}
+				typedArgs = append(typedArgs, &TabStop{
+					Value:          value,
+					Kind:           cloudpb.AEK_UNKNOWN,
+					ContainsCursor: strings.Contains(value, CursorMarker),
+					ArgName:        label,
+					typed:          true,
+				})
+				typedArgNames[label] = true
+				continue
 			}
 		}
 		unusedArgs = append(unusedArgs, a)
@@ -283,6 +316,8 @@ func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester
 		unassignedArgString = ""
 	}
 
+	args = append(args, typedArgs...)
+
 	if unassignedArgString != "" { // If all of the scriptArgs were assigned, but there is a cmdArg remaining, create an additional tab stop.
 		args = append(args, &TabStop{
 			Value:          unassignedArgString,
@@ -291,6 +326,17 @@ func parseRunArgsWithScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester
 		})
 	}
 
+	// Validate the values against the script's variables, so that bad input is never executable.
+	for _, a := range args {
+		v, ok := argNameToVar[a.ArgName]
+		if !ok {
+			continue
+		}
+		if _, err := script.ValidateArgValue(v, strings.Replace(a.Value, CursorMarker, "", 1)); err != nil {
+			a.invalidValue = true
+		}
+	}
+
 	return args, make([]cloudpb.AutocompleteEntityKind, 0)
 }
 
@@ -352,7 +398,7 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 		return nil
 	}
 
-	scriptTabIndex, argNames, argTypes, err := parseRunScript(parsedCmd, cmd, s, orgID, clusterUID)
+	scriptTabIndex, argNames, argTypes, argVars, err := parseRunScript(parsedCmd, cmd, s, orgID, clusterUID)
 	if err != nil {
 		return err
 	}
@@ -362,7 +408,7 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 
 	allowedKinds := []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE}
 	if cmd.HasValidScript {
-		args, specifiedEntities = parseRunArgsWithScript(parsedCmd, cmd, s, argNames, argTypes, scriptTabIndex)
+		args, specifiedEntities = parseRunArgsWithScript(parsedCmd, cmd, s, argNames, argTypes, argVars, scriptTabIndex)
 		allowedKinds = []cloudpb.AutocompleteEntityKind{}
 	} else {
 		args, specifiedEntities = parseRunArgs(parsedCmd, cmd, s, scriptTabIndex)
@@ -373,7 +419,14 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 
 	// Get suggestions for each argument.
 	reqs := make([]*SuggestionRequest, 0)
+	suggestedArgs := make([]*TabStop, 0)
 	for _, a := range args {
+		if a.typed {
+			// Typed args have no suggestions, they are valid if their value matches the variable's type.
+			a.Valid = strings.Replace(a.Value, CursorMarker, "", 1) != "" && !a.invalidValue
+			continue
+		}
+		suggestedArgs = append(suggestedArgs, a)
 		ak := allowedKinds
 		if a.Kind != cloudpb.AEK_UNKNOWN { // The kind is already specified in the input string.
 			ak = []cloudpb.AutocompleteEntityKind{a.Kind}
@@ -390,9 +443,9 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 		return err
 	}
 
-	for i, a := range args {
-		args[i].Suggestions = res[i].Suggestions
-		args[i].Valid = res[i].ExactMatch && a.Kind != cloudpb.AEK_UNKNOWN && a.ArgName != ""
+	for i, a := range suggestedArgs {
+		a.Suggestions = res[i].Suggestions
+		a.Valid = res[i].ExactMatch && a.Kind != cloudpb.AEK_UNKNOWN && a.ArgName != "" && !a.invalidValue
 	}
 
 	cmd.TabStops = append(cmd.TabStops, args...)
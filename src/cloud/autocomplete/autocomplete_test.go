@@ -19,6 +19,7 @@
 package autocomplete_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/gofrs/uuid"
@@ -27,6 +28,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"px.dev/pixie/src/api/proto/cloudpb"
+	"px.dev/pixie/src/api/proto/vispb"
 	"px.dev/pixie/src/cloud/autocomplete"
 	mock_autocomplete "px.dev/pixie/src/cloud/autocomplete/mock"
 )
@@ -107,6 +109,79 @@ func TestParseIntoCommand(t *testing.T) {
 				Executable: true,
 			},
 		},
+		{
+			name:  "invalid arg value",
+			input: "script:px/svc_info svc_name:pl/test",
+			requests: [][]*autocomplete.SuggestionRequest{
+				{
+					{
+						OrgID:        orgID,
+						ClusterUID:   "test",
+						Input:        "px/svc_info",
+						AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT},
+						AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+					},
+				},
+				{
+					{
+						OrgID:        orgID,
+						ClusterUID:   "test",
+						Input:        "pl/test",
+						AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+						AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+					},
+				},
+			},
+			responses: [][]*autocomplete.SuggestionResult{
+				{
+					{
+						Suggestions: []*autocomplete.Suggestion{
+							{
+								Name:     "px/svc_info",
+								Score:    1,
+								ArgNames: []string{"svc_name"},
+								ArgKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+								ArgVariables: []*vispb.Vis_Variable{
+									{
+										Name:        "svc_name",
+										Type:        vispb.PX_SERVICE,
+										ValidValues: []string{"pl/frontend"},
+									},
+								},
+							},
+						},
+						ExactMatch: true,
+					},
+				},
+				{
+					{
+						Suggestions: []*autocomplete.Suggestion{
+							{
+								Name:  "pl/test",
+								Score: 1,
+							},
+						},
+						ExactMatch: true,
+					},
+				},
+			},
+			expectedCmd: &autocomplete.Command{
+				TabStops: []*autocomplete.TabStop{
+					{
+						Value: "px/svc_info",
+						Kind:  cloudpb.AEK_SCRIPT,
+						Valid: true,
+					},
+					{
+						Value:   "pl/test",
+						Kind:    cloudpb.AEK_SVC,
+						Valid:   false,
+						ArgName: "svc_name",
+					},
+				},
+				Executable: false,
+			},
+		},
 		{
 			name:  "valid with run",
 			input: "run script:px/svc_info svc_name:pl/test",
@@ -819,6 +894,80 @@ func TestParseIntoCommand(t *testing.T) {
 	}
 }
 
+func TestParseIntoCommand_TypedArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable *vispb.Vis_Variable
+		value    string
+		valid    bool
+	}{
+		{"int64", &vispb.Vis_Variable{Name: "count", Type: vispb.PX_INT64}, "10", true},
+		{"bad int64", &vispb.Vis_Variable{Name: "count", Type: vispb.PX_INT64}, "ten", false},
+		{"float64", &vispb.Vis_Variable{Name: "ratio", Type: vispb.PX_FLOAT64}, "2", true},
+		{"bad float64", &vispb.Vis_Variable{Name: "ratio", Type: vispb.PX_FLOAT64}, "half", false},
+		{"duration", &vispb.Vis_Variable{Name: "start_time", Type: vispb.PX_DURATION}, "-5m", true},
+		{"bad duration", &vispb.Vis_Variable{Name: "start_time", Type: vispb.PX_DURATION}, "5", false},
+		{"bool", &vispb.Vis_Variable{Name: "verbose", Type: vispb.PX_BOOLEAN}, "yes", true},
+		{"bad bool", &vispb.Vis_Variable{Name: "verbose", Type: vispb.PX_BOOLEAN}, "maybe", false},
+		{"list", &vispb.Vis_Variable{Name: "names", Type: vispb.PX_STRING_LIST}, "a", true},
+		{"enum", &vispb.Vis_Variable{Name: "mode", Type: vispb.PX_STRING, ValidValues: []string{"fast", "slow"}}, "fast", true},
+		{"bad enum", &vispb.Vis_Variable{Name: "mode", Type: vispb.PX_STRING, ValidValues: []string{"fast", "slow"}}, "medium", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s := mock_autocomplete.NewMockSuggester(ctrl)
+
+			svcVar := &vispb.Vis_Variable{Name: "svc_name", Type: vispb.PX_SERVICE}
+			gomock.InOrder(
+				s.EXPECT().
+					GetSuggestions([]*autocomplete.SuggestionRequest{
+						{orgID, "test", "px/typed", []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT}, []cloudpb.AutocompleteEntityKind{}},
+					}).
+					Return([]*autocomplete.SuggestionResult{
+						{
+							Suggestions: []*autocomplete.Suggestion{
+								{
+									Name:         "px/typed",
+									Score:        1,
+									ArgNames:     []string{"svc_name"},
+									ArgKinds:     []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+									ArgVariables: []*vispb.Vis_Variable{svcVar, test.variable},
+								},
+							},
+							ExactMatch: true,
+						},
+					}, nil),
+				// Typed args don't have suggestions, so only the service is looked up.
+				s.EXPECT().
+					GetSuggestions([]*autocomplete.SuggestionRequest{
+						{orgID, "test", "pl/frontend", []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC}, []cloudpb.AutocompleteEntityKind{}},
+					}).
+					Return([]*autocomplete.SuggestionResult{
+						{
+							Suggestions: []*autocomplete.Suggestion{{Name: "pl/frontend", Score: 1}},
+							ExactMatch:  true,
+						},
+					}, nil),
+			)
+
+			input := fmt.Sprintf("script:px/typed svc_name:pl/frontend %s:%s", test.variable.Name, test.value)
+			cmd, err := autocomplete.ParseIntoCommand(input, s, orgID, "test")
+			require.NoError(t, err)
+
+			require.Len(t, cmd.TabStops, 3)
+			assert.True(t, cmd.TabStops[1].Valid)
+			typed := cmd.TabStops[2]
+			assert.Equal(t, test.variable.Name, typed.ArgName)
+			assert.Equal(t, test.value, typed.Value)
+			assert.Equal(t, test.valid, typed.Valid)
+			assert.Equal(t, test.valid, cmd.Executable)
+		})
+	}
+}
+
 func TestToFormatString(t *testing.T) {
 	tests := []struct {
 		name                  string
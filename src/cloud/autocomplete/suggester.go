@@ -167,6 +167,7 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 	scripts := []string{}
 	scriptArgMap := make(map[string][]cloudpb.AutocompleteEntityKind)
 	scriptArgNames := make(map[string][]string)
+	scriptArgVars := make(map[string][]*vispb.Vis_Variable)
 	if br != nil {
 		for _, s := range br.GetScripts() {
 			scripts = append(scripts, s.ScriptName)
@@ -179,6 +180,8 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 					aKind = cloudpb.AEK_SVC
 				}
 
+				// All variables are needed to validate typed args, but only entity args have tab stops.
+				scriptArgVars[s.ScriptName] = append(scriptArgVars[s.ScriptName], a)
 				if aKind != cloudpb.AEK_UNKNOWN {
 					scriptArgMap[s.ScriptName] = append(scriptArgMap[s.ScriptName], aKind)
 					scriptArgNames[s.ScriptName] = append(scriptArgNames[s.ScriptName], a.Name)
@@ -237,6 +240,7 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 								Desc:           script.LongDoc,
 								ArgNames:       scriptNames,
 								ArgKinds:       scriptArgs,
+								ArgVariables:   scriptArgVars[m.Str],
 								MatchedIndexes: matchedIdxs,
 							})
 						}
@@ -57,6 +57,14 @@ const (
 	EsMDTypeScript EsMDType = "script"
 	// EsMDTypeNode is for node entities.
 	EsMDTypeNode EsMDType = "node"
+	// EsMDTypeStatefulSet is for stateful set entities.
+	EsMDTypeStatefulSet EsMDType = "statefulset"
+	// EsMDTypeDaemonSet is for daemon set entities.
+	EsMDTypeDaemonSet EsMDType = "daemonset"
+	// EsMDTypeJob is for job entities.
+	EsMDTypeJob EsMDType = "job"
+	// EsMDTypeCronJob is for cron job entities.
+	EsMDTypeCronJob EsMDType = "cronjob"
 )
 
 // EsMDEntity is the struct that is stored in elastic.
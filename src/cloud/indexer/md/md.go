@@ -278,6 +278,48 @@ func nodeConditionToState(node *metadatapb.NodeUpdate) ESMDEntityState {
 	return ESMDEntityStatePending
 }
 
+// ownerNames returns the namespaced names of the given owners, so that workloads can be
+// related back to the objects that manage them.
+func ownerNames(namespace string, owners []*metadatapb.OwnerReference) []string {
+	names := make([]string, len(owners))
+	for i, o := range owners {
+		names[i] = namespacedName(namespace, o.Name)
+	}
+	return names
+}
+
+func (v *VizierIndexer) workloadUpdateToEMD(u *metadatapb.ResourceUpdate, kind EsMDType, uid string, namespace string, name string,
+	startNS int64, stopNS int64, owners []*metadatapb.OwnerReference, state ESMDEntityState) *EsMDEntity {
+	return &EsMDEntity{
+		OrgID:              v.orgID.String(),
+		VizierID:           v.vizierID.String(),
+		ClusterUID:         v.k8sUID,
+		UID:                uid,
+		Name:               namespacedName(namespace, name),
+		Kind:               string(kind),
+		TimeStartedNS:      startNS,
+		TimeStoppedNS:      stopNS,
+		RelatedEntityNames: ownerNames(namespace, owners),
+		UpdateVersion:      u.UpdateVersion,
+		State:              state,
+	}
+}
+
+func jobToState(job *metadatapb.JobUpdate) ESMDEntityState {
+	if job.StopTimestampNS > 0 || job.JobCompletionTimeNS > 0 {
+		return ESMDEntityStateTerminated
+	}
+	for _, c := range job.Conditions {
+		if c.Type == "Failed" && c.Status == metadatapb.CONDITION_STATUS_TRUE {
+			return ESMDEntityStateFailed
+		}
+	}
+	if job.Active == 0 {
+		return ESMDEntityStatePending
+	}
+	return ESMDEntityStateRunning
+}
+
 func (v *VizierIndexer) resourceUpdateToEMD(update *metadatapb.ResourceUpdate) *EsMDEntity {
 	switch update.Update.(type) {
 	case *metadatapb.ResourceUpdate_NamespaceUpdate:
@@ -288,6 +330,22 @@ func (v *VizierIndexer) resourceUpdateToEMD(update *metadatapb.ResourceUpdate) *
 		return v.serviceUpdateToEMD(update, update.GetServiceUpdate())
 	case *metadatapb.ResourceUpdate_NodeUpdate:
 		return v.nodeUpdateToEMD(update, update.GetNodeUpdate())
+	case *metadatapb.ResourceUpdate_StatefulSetUpdate:
+		ss := update.GetStatefulSetUpdate()
+		return v.workloadUpdateToEMD(update, EsMDTypeStatefulSet, ss.UID, ss.Namespace, ss.Name,
+			ss.StartTimestampNS, ss.StopTimestampNS, ss.OwnerReferences, getStateFromTimestamps(ss.StopTimestampNS))
+	case *metadatapb.ResourceUpdate_DaemonSetUpdate:
+		ds := update.GetDaemonSetUpdate()
+		return v.workloadUpdateToEMD(update, EsMDTypeDaemonSet, ds.UID, ds.Namespace, ds.Name,
+			ds.StartTimestampNS, ds.StopTimestampNS, ds.OwnerReferences, getStateFromTimestamps(ds.StopTimestampNS))
+	case *metadatapb.ResourceUpdate_JobUpdate:
+		job := update.GetJobUpdate()
+		return v.workloadUpdateToEMD(update, EsMDTypeJob, job.UID, job.Namespace, job.Name,
+			job.StartTimestampNS, job.StopTimestampNS, job.OwnerReferences, jobToState(job))
+	case *metadatapb.ResourceUpdate_CronJobUpdate:
+		cj := update.GetCronJobUpdate()
+		return v.workloadUpdateToEMD(update, EsMDTypeCronJob, cj.UID, cj.Namespace, cj.Name,
+			cj.StartTimestampNS, cj.StopTimestampNS, cj.OwnerReferences, getStateFromTimestamps(cj.StopTimestampNS))
 	default:
 		// We don't care about any other update types.
 		// Notably containerUpdates and nodeUpdates.
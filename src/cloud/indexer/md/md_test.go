@@ -224,6 +224,48 @@ func TestVizierIndexer_ResourceUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "job-update",
+			updates: []*metadatapb.ResourceUpdate{
+				{
+					Update: &metadatapb.ResourceUpdate_JobUpdate{
+						JobUpdate: &metadatapb.JobUpdate{
+							UID:              "500",
+							Name:             "backup-1234",
+							Namespace:        "pl",
+							StartTimestampNS: 1000,
+							StopTimestampNS:  0,
+							Active:           1,
+							OwnerReferences: []*metadatapb.OwnerReference{
+								{
+									Kind: "CronJob",
+									Name: "backup",
+									UID:  "501",
+								},
+							},
+						},
+					},
+					UpdateVersion:     1,
+					PrevUpdateVersion: 0,
+				},
+			},
+			expectedResults: []*md.EsMDEntity{
+				{
+					OrgID:              orgID.String(),
+					VizierID:           vzID.String(),
+					ClusterUID:         "job-update",
+					UID:                "500",
+					NS:                 "",
+					Name:               "pl/backup-1234",
+					Kind:               "job",
+					TimeStartedNS:      int64(1000),
+					TimeStoppedNS:      int64(0),
+					RelatedEntityNames: []string{"pl/backup"},
+					UpdateVersion:      1,
+					State:              md.ESMDEntityStateRunning,
+				},
+			},
+		},
 		{
 			name: "svc-update",
 			updates: []*metadatapb.ResourceUpdate{
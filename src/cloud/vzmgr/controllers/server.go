@@ -462,6 +462,11 @@ func (s *Server) GetVizierConnectionInfo(ctx context.Context, req *uuidpb.UUID)
 	// Generate a signed token for this cluster.
 	jwtKey := info.JWTSigningKey[SaltLength:]
 	claims := jwtutils.GenerateJWTForCluster("vizier_cluster", "vizier")
+	// Record the user that the token is issued to, so that Vizier can attribute the queries
+	// made with it to the user rather than to the cloud.
+	if sCtx, err := authcontext.FromContext(ctx); err == nil {
+		claims.GetClusterClaims().ForwardedUser = sCtx.Claims.GetUserClaims()
+	}
 	tokenString, err := jwtutils.SignJWTClaims(claims, jwtKey)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to sign token: %s", err.Error())
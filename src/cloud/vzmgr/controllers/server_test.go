@@ -311,6 +311,11 @@ func TestServer_GetVizierConnectionInfo(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, []string{"cluster"}, srvutils.GetScopes(token))
+	forwardedUser := srvutils.GetForwardedUser(token)
+	require.NotNil(t, forwardedUser)
+	assert.Equal(t, "abcdef", forwardedUser.UserID)
+	assert.Equal(t, testAuthOrgID, forwardedUser.OrgID)
+	assert.Equal(t, "test@test.com", forwardedUser.Email)
 }
 
 func TestServer_VizierConnectedHealthy(t *testing.T) {
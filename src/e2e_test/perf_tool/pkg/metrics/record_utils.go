@@ -23,83 +23,40 @@ import (
 	"time"
 
 	"px.dev/pixie/src/api/go/pxapi/types"
-	"px.dev/pixie/src/api/proto/vizierpb"
 )
 
 func getColumnAsTime(r *types.Record, colName string, specName string) (time.Time, error) {
-	d, err := getDatum(r, colName, specName)
+	val, err := r.ColumnAsTime(colName)
 	if err != nil {
-		return time.Time{}, err
-	}
-	var val time.Time
-	switch d.Type() {
-	case vizierpb.TIME64NS:
-		val = d.(*types.Time64NSValue).Value()
-	case vizierpb.INT64:
-		ns := d.(*types.Int64Value).Value()
-		val = time.Unix(0, ns)
-	default:
-		return time.Time{}, fmt.Errorf(
-			"'%s' specified a timestamp column named '%s', but that column is not a TIME64 or INT64 value",
-			specName,
-			colName,
-		)
+		return time.Time{}, specError(specName, err)
 	}
 	return val, nil
 }
 
 func getColumnAsFloat(r *types.Record, colName string, specName string) (float64, error) {
-	d, err := getDatum(r, colName, specName)
+	val, err := r.ColumnAsFloat(colName)
 	if err != nil {
-		return 0.0, err
-	}
-	var val float64
-	switch d.Type() {
-	case vizierpb.INT64:
-		val = float64(d.(*types.Int64Value).Value())
-	case vizierpb.FLOAT64:
-		val = d.(*types.Float64Value).Value()
-	default:
-		return 0.0, fmt.Errorf(
-			"'%s' specified a numeric column name '%s', but that column is not a INT64 or FLOAT64 value",
-			specName,
-			colName,
-		)
+		return 0.0, specError(specName, err)
 	}
 	return val, nil
 }
 
 func getColumnAsInt64(r *types.Record, colName string, specName string) (int64, error) {
-	d, err := getDatum(r, colName, specName)
+	val, err := r.ColumnAsInt64(colName)
 	if err != nil {
-		return 0.0, err
-	}
-	var val int64
-	switch d.Type() {
-	case vizierpb.INT64:
-		val = d.(*types.Int64Value).Value()
-	default:
-		return 0.0, fmt.Errorf(
-			"'%s' specified an int64 column named '%s', but that column is not an INT64 value",
-			specName,
-			colName,
-		)
+		return 0, specError(specName, err)
 	}
 	return val, nil
 }
 
 func getColumnAsString(r *types.Record, colName string, specName string) (string, error) {
-	d, err := getDatum(r, colName, specName)
+	val, err := r.ColumnAsString(colName)
 	if err != nil {
-		return "", err
+		return "", specError(specName, err)
 	}
-	return d.String(), nil
+	return val, nil
 }
 
-func getDatum(r *types.Record, colName string, specName string) (types.Datum, error) {
-	d := r.GetDatum(colName)
-	if d == nil {
-		return nil, fmt.Errorf("'%s' specified column '%s', but no such column in script output", specName, colName)
-	}
-	return d, nil
+func specError(specName string, err error) error {
+	return fmt.Errorf("invalid column specified by '%s': %w", specName, err)
 }
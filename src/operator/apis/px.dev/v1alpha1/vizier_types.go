@@ -71,6 +71,9 @@ type VizierSpec struct {
 	// DataAccess defines the level of data that may be accesssed when executing a script on the cluster. If none specified,
 	// assumes full data access.
 	DataAccess DataAccessLevel `json:"dataAccess,omitempty"`
+	// DataAccessPolicies choose the data access for queries based on who runs them. Policies are evaluated in order,
+	// and the first policy that matches the principal of a query applies. Queries that match no policy use DataAccess.
+	DataAccessPolicies []DataAccessPolicy `json:"dataAccessPolicies,omitempty"`
 	// DataCollectorParams specifies the set of params for configuring the dataCollector. If no params are specified, defaults are used.
 	DataCollectorParams *DataCollectorParams `json:"dataCollectorParams,omitempty"`
 	// LeadershipElectionParams specifies configurable values for the K8s leaderships elections which Vizier uses manage pod leadership.
@@ -100,6 +103,43 @@ const (
 	DataAccessPIIRestricted DataAccessLevel = "PIIRestricted"
 )
 
+// DataAccessPolicy defines the data that the matching principals may access when executing a script on the cluster.
+type DataAccessPolicy struct {
+	// Name identifies the policy in errors and audit logs.
+	Name string `json:"name"`
+	// Principals defines who the policy applies to.
+	Principals DataAccessPrincipals `json:"principals,omitempty"`
+	// DataAccess is the level of data that the principals may access. If none specified, assumes full data access.
+	DataAccess DataAccessLevel `json:"dataAccess,omitempty"`
+	// AllowedNamespaces restricts queries to the data of processes in these namespaces. Entries may be glob
+	// patterns, such as "team-a-*". Queries that read tables without a upid column are rejected. If none
+	// specified, all namespaces are allowed.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// AllowedTables restricts the tables that scripts may read from. If none specified, all tables are allowed.
+	AllowedTables []string `json:"allowedTables,omitempty"`
+}
+
+const (
+	// DataAccessPoliciesConfigMap is the name of the config map that the operator stores the DataAccessPolicies of a
+	// Vizier in, for the query broker to read.
+	DataAccessPoliciesConfigMap = "pl-data-access-policies"
+	// DataAccessPoliciesKey is the key of the JSON encoded DataAccessPolicies in the config map.
+	DataAccessPoliciesKey = "policies.json"
+)
+
+// DataAccessPrincipals matches the principals that a DataAccessPolicy applies to. A principal matches if it matches
+// any of the fields. If no fields are specified, every principal matches.
+type DataAccessPrincipals struct {
+	// Users are the emails or IDs of the users. Entries may be glob patterns, such as "*@example.com".
+	Users []string `json:"users,omitempty"`
+	// OrgIDs are the IDs of the orgs whose users match.
+	OrgIDs []string `json:"orgIDs,omitempty"`
+	// Services are the IDs of Pixie services, such as "query_broker" for cron scripts.
+	Services []string `json:"services,omitempty"`
+	// APIKeys matches all queries that are made with an API key.
+	APIKeys bool `json:"apiKeys,omitempty"`
+}
+
 // ClockConverterType defines which clock conversion routine to use for converting timestamps to a synced reference time.
 // +kubebuilder:validation:Enum=default;grpc
 type ClockConverterType string
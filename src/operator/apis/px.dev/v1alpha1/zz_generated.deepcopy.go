@@ -10,6 +10,62 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataAccessPolicy) DeepCopyInto(out *DataAccessPolicy) {
+	*out = *in
+	in.Principals.DeepCopyInto(&out.Principals)
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTables != nil {
+		in, out := &in.AllowedTables, &out.AllowedTables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataAccessPolicy.
+func (in *DataAccessPolicy) DeepCopy() *DataAccessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataAccessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataAccessPrincipals) DeepCopyInto(out *DataAccessPrincipals) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OrgIDs != nil {
+		in, out := &in.OrgIDs, &out.OrgIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataAccessPrincipals.
+func (in *DataAccessPrincipals) DeepCopy() *DataAccessPrincipals {
+	if in == nil {
+		return nil
+	}
+	out := new(DataAccessPrincipals)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataCollectorParams) DeepCopyInto(out *DataCollectorParams) {
 	*out = *in
@@ -185,6 +241,13 @@ func (in *VizierSpec) DeepCopyInto(out *VizierSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.DataAccessPolicies != nil {
+		in, out := &in.DataAccessPolicies, &out.DataAccessPolicies
+		*out = make([]DataAccessPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.DataCollectorParams != nil {
 		in, out := &in.DataCollectorParams, &out.DataCollectorParams
 		*out = new(DataCollectorParams)
@@ -533,6 +533,12 @@ func (r *VizierReconciler) deployVizier(ctx context.Context, req ctrl.Request, v
 		}
 	}
 
+	err = deployDataAccessPolicies(ctx, r.Clientset, req.Namespace, vz)
+	if err != nil {
+		log.WithError(err).Error("Failed to deploy data access policies")
+		return err
+	}
+
 	if vz.Spec.UseEtcdOperator {
 		err := r.Clientset.AppsV1().StatefulSets(req.Namespace).Delete(ctx, "vizier-metadata", metav1.DeleteOptions{})
 		if err != nil && k8serrors.IsNotFound(err) {
@@ -719,6 +725,43 @@ func (r *VizierReconciler) deployVizierConfigs(ctx context.Context, namespace st
 	return k8s.ApplyResources(r.Clientset, r.RestConfig, resources, namespace, nil, false)
 }
 
+// deployDataAccessPolicies stores the data access policies of the Vizier in a config map, which the query broker
+// watches for changes.
+func deployDataAccessPolicies(ctx context.Context, clientset kubernetes.Interface, namespace string, vz *v1alpha1.Vizier) error {
+	policies := vz.Spec.DataAccessPolicies
+	if policies == nil {
+		policies = []v1alpha1.DataAccessPolicy{}
+	}
+	b, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v1alpha1.DataAccessPoliciesConfigMap,
+			Namespace: namespace,
+			Labels:    vz.Spec.Pod.Labels,
+		},
+		Data: map[string]string{
+			v1alpha1.DataAccessPoliciesKey: string(b),
+		},
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps(namespace)
+	existing, err := configMaps.Get(ctx, cm.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing.Labels = cm.Labels
+	existing.Data = cm.Data
+	_, err = configMaps.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
 // deployNATSStatefulset deploys nats to the given namespace.
 func (r *VizierReconciler) deployNATSStatefulset(ctx context.Context, namespace string, vz *v1alpha1.Vizier, yamlMap map[string]string) error {
 	log.Info("Deploying NATS")
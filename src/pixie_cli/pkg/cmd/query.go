@@ -0,0 +1,157 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/pixie_cli/pkg/components"
+	"px.dev/pixie/src/pixie_cli/pkg/utils"
+	"px.dev/pixie/src/pixie_cli/pkg/vizier"
+)
+
+func init() {
+	QueryCmd.PersistentFlags().StringP("cluster", "c", "", "ID of the cluster to run on. "+
+		"Use 'px get viziers' to find the ID")
+	QueryCmd.PersistentFlags().StringP("output", "o", "", "Output format: one of: json|table")
+
+	QueryCmd.AddCommand(QueryListCmd)
+	QueryCmd.AddCommand(QueryDescribeCmd)
+	QueryCmd.AddCommand(QueryCancelCmd)
+}
+
+// QueryCmd is the "query" command, used to manage the queries running on a cluster.
+var QueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Inspect and cancel the queries running on a cluster",
+}
+
+// QueryListCmd is the "query list" command.
+var QueryListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the queries running on a cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		conn := mustConnectQueryVizier(cmd)
+		ctx, cleanup := utils.WithSignalCancellable(context.Background())
+		defer cleanup()
+
+		queries, err := conn.ListQueries(ctx)
+		if err != nil {
+			utils.WithError(err).Fatal("Failed to list queries")
+		}
+		writeQueries(cmd, queries, false)
+	},
+}
+
+// QueryDescribeCmd is the "query describe" command.
+var QueryDescribeCmd = &cobra.Command{
+	Use:   "describe <query ID>",
+	Short: "Show the state of a query running on a cluster",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		conn := mustConnectQueryVizier(cmd)
+		ctx, cleanup := utils.WithSignalCancellable(context.Background())
+		defer cleanup()
+
+		query, err := conn.GetQuery(ctx, args[0])
+		if err != nil {
+			utils.WithError(err).Fatal("Failed to get query")
+		}
+		writeQueries(cmd, []*vizierpb.QueryInfo{query}, true)
+	},
+}
+
+// QueryCancelCmd is the "query cancel" command.
+var QueryCancelCmd = &cobra.Command{
+	Use:   "cancel <query ID>",
+	Short: "Cancel a query running on a cluster",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		conn := mustConnectQueryVizier(cmd)
+		ctx, cleanup := utils.WithSignalCancellable(context.Background())
+		defer cleanup()
+
+		if err := conn.CancelQuery(ctx, args[0]); err != nil {
+			utils.WithError(err).Fatal("Failed to cancel query")
+		}
+		utils.Infof("Cancelled query %s", args[0])
+	},
+}
+
+// mustConnectQueryVizier connects to the selected cluster. Like the debug commands, this does not
+// require the cluster to be healthy, since runaway queries are often the reason it isn't.
+func mustConnectQueryVizier(cmd *cobra.Command) *vizier.Connector {
+	cloudAddr := viper.GetString("cloud_addr")
+	directVzAddr := viper.GetString("direct_vizier_addr")
+	directVzKey := viper.GetString("direct_vizier_key")
+	if directVzAddr != "" {
+		return vizier.MustConnectVizier(cloudAddr, false, uuid.Nil, directVzAddr, directVzKey)[0]
+	}
+
+	selectedCluster, _ := cmd.Flags().GetString("cluster")
+	clusterID := uuid.FromStringOrNil(selectedCluster)
+	if clusterID == uuid.Nil {
+		var err error
+		clusterID, err = getVizier(cloudAddr)
+		if err != nil {
+			utils.WithError(err).Fatal("Could not fetch vizier")
+		}
+	}
+
+	conn, err := vizier.ConnectionToVizierByID(cloudAddr, clusterID)
+	if err != nil {
+		utils.WithError(err).Fatal("Could not connect to vizier")
+	}
+	return conn
+}
+
+func writeQueries(cmd *cobra.Command, queries []*vizierpb.QueryInfo, showTables bool) {
+	format, _ := cmd.Flags().GetString("output")
+	format = strings.ToLower(format)
+	pretty := format == "" || format == "table"
+
+	w := components.CreateStreamWriter(format, os.Stdout)
+	defer w.Finish()
+	w.SetHeader("queries", []string{"ID", "Name", "Principal", "Started", "Active Agents", "Pending Tables", "Rows", "Bytes"})
+	for _, q := range queries {
+		var started, pendingTables, bytesStreamed interface{}
+		started, pendingTables, bytesStreamed = q.StartTimeNs, q.PendingTables, q.BytesStreamed
+		if pretty {
+			started = humanize.Time(time.Unix(0, q.StartTimeNs))
+			bytesStreamed = humanize.Bytes(uint64(q.BytesStreamed))
+			if showTables {
+				pendingTables = strings.Join(q.PendingTables, ", ")
+			} else {
+				pendingTables = len(q.PendingTables)
+			}
+		}
+		_ = w.Write([]interface{}{q.QueryID, q.QueryName, q.Principal, started, q.ActiveAgents,
+			pendingTables, q.RowsStreamed, bytesStreamed})
+	}
+}
@@ -33,13 +33,15 @@ import (
 	"github.com/spf13/viper"
 
 	"px.dev/pixie/src/cloud/api/ptproxy"
+	"px.dev/pixie/src/pixie_cli/pkg/components"
 	"px.dev/pixie/src/pixie_cli/pkg/utils"
 	"px.dev/pixie/src/pixie_cli/pkg/vizier"
 	"px.dev/pixie/src/utils/script"
 )
 
 func init() {
-	RunCmd.Flags().StringP("output", "o", "", "Output format: one of: json|table|csv")
+	RunCmd.Flags().StringP("output", "o", "", "Output format: one of: json|table|csv|ndjson|arrow|parquet")
+	RunCmd.Flags().String("output_dir", ".", "Directory to write one file per table to, used by the arrow and parquet output formats")
 	RunCmd.Flags().StringP("file", "f", "", "Script file, specify - for STDIN")
 	RunCmd.Flags().BoolP("list", "l", false, "List available scripts")
 	RunCmd.Flags().BoolP("e2e_encryption", "e", true, "Enable E2E encryption")
@@ -172,6 +174,9 @@ func createNewCobraCommand() *cobra.Command {
 						cmd.Help()
 						os.Exit(1)
 					}
+					if errors.Is(err, script.ErrInvalidArgument) {
+						utils.WithError(err).Fatal("Invalid script argument, run `px run <script_name> -- --help` to see the argument types")
+					}
 					utils.WithError(err).Fatal("Error parsing script flags")
 				}
 			}
@@ -197,7 +202,16 @@ func createNewCobraCommand() *cobra.Command {
 			// Support Ctrl+C to cancel a query.
 			ctx, cleanup := utils.WithSignalCancellable(context.Background())
 			defer cleanup()
-			err = vizier.RunScriptAndOutputResults(ctx, conns, execScript, format, useEncryption)
+			var adapterOpts []vizier.StreamOutputAdapterOption
+			if outputDir, _ := cmd.Flags().GetString("output_dir"); outputDir != "" {
+				adapterOpts = append(adapterOpts, vizier.WithStreamWriterOptions(components.WithOutputDir(outputDir)))
+			}
+			// Only tag rows with their cluster when results from several clusters are merged, so that
+			// single cluster output keeps the same format.
+			if len(conns) > 1 {
+				adapterOpts = append(adapterOpts, vizier.WithClusterIDColumn())
+			}
+			err = vizier.RunScriptAndOutputResults(ctx, conns, execScript, format, useEncryption, adapterOpts...)
 
 			if err != nil {
 				vzErr, ok := err.(*vizier.ScriptExecutionError)
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+	"github.com/gofrs/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"px.dev/pixie/src/api/go/pxapi/formatters"
+	"px.dev/pixie/src/api/go/pxapi/types"
+)
+
+// BatchOutputStreamWriter is implemented by writers that consume typed row batches instead of rows.
+// SetTable is called instead of SetHeader and WriteBatch is called instead of Write.
+type BatchOutputStreamWriter interface {
+	OutputStreamWriter
+	SetTable(md *types.TableMetadata) error
+	WriteBatch(b *types.RowBatch) error
+}
+
+var errRowWritesUnsupported = errors.New("writer only supports batch writes")
+
+// batchOnlyWriter implements the row based methods of OutputStreamWriter for batch writers.
+type batchOnlyWriter struct{}
+
+// SetHeader is not used by batch writers.
+func (batchOnlyWriter) SetHeader(id string, headerValues []string) {}
+
+// Write is not supported by batch writers.
+func (batchOnlyWriter) Write(data []interface{}) error {
+	return errRowWritesUnsupported
+}
+
+// NDJSONStreamWriter writes one json record per row, keeping the native type of each value.
+type NDJSONStreamWriter struct {
+	batchOnlyWriter
+	md      *types.TableMetadata
+	record  *types.Record
+	encoder *json.Encoder
+}
+
+// NewNDJSONStreamWriter creates a NDJSONStreamWriter.
+func NewNDJSONStreamWriter(w io.Writer) *NDJSONStreamWriter {
+	return &NDJSONStreamWriter{encoder: json.NewEncoder(w)}
+}
+
+// SetTable is called with the table metadata before any batches are written.
+func (n *NDJSONStreamWriter) SetTable(md *types.TableMetadata) error {
+	n.md = md
+	n.record = types.NewRecord(md)
+	return nil
+}
+
+func nativeValue(d types.Datum) interface{} {
+	switch v := d.(type) {
+	case *types.BooleanValue:
+		return v.Value()
+	case *types.Int64Value:
+		return v.Value()
+	case *types.Float64Value:
+		// JSON has no representation for these values.
+		if math.IsNaN(v.Value()) || math.IsInf(v.Value(), 0) {
+			return nil
+		}
+		return v.Value()
+	case *types.StringValue:
+		return v.Value()
+	case *types.Time64NSValue:
+		return v.Value().UTC().Format(time.RFC3339Nano)
+	case *types.UInt128Value:
+		return uuid.FromBytesOrNil(v.Value()).String()
+	default:
+		return d.String()
+	}
+}
+
+// WriteBatch is called for each batch of the table.
+func (n *NDJSONStreamWriter) WriteBatch(b *types.RowBatch) error {
+	val := make([]MapItem, len(n.md.ColInfo)+1) // +1 for the table name
+	val[0].Key = tableNameKey
+	val[0].Value = n.md.Name
+	for i, col := range n.md.ColInfo {
+		val[i+1].Key = col.Name
+	}
+
+	for rowIdx := int64(0); rowIdx < b.NumRows; rowIdx++ {
+		if err := b.ScanRow(rowIdx, n.record); err != nil {
+			return err
+		}
+		for i, d := range n.record.Data {
+			val[i+1].Value = nativeValue(d)
+		}
+		if err := n.encoder.Encode(MapSlice(val)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finish is called to flush all the data.
+func (n *NDJSONStreamWriter) Finish() {
+	// Since the NDJSON writer outputs records right away there is nothing to do here.
+}
+
+// ArrowStreamWriter writes each table as an Arrow IPC stream to "<dir>/<table name>.arrow".
+// Batches are written out as they arrive.
+type ArrowStreamWriter struct {
+	batchOnlyWriter
+	dir string
+	f   *os.File
+	w   *ipc.Writer
+}
+
+// NewArrowStreamWriter creates an ArrowStreamWriter.
+func NewArrowStreamWriter(dir string) *ArrowStreamWriter {
+	return &ArrowStreamWriter{dir: dir}
+}
+
+// SetTable is called with the table metadata before any batches are written.
+func (a *ArrowStreamWriter) SetTable(md *types.TableMetadata) error {
+	schema, err := types.ArrowSchema(md)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+	a.f, err = os.Create(filepath.Join(a.dir, formatters.FileNameForTable(md.Name)+".arrow"))
+	if err != nil {
+		return err
+	}
+	a.w = ipc.NewWriter(a.f, ipc.WithSchema(schema))
+	return nil
+}
+
+// WriteBatch is called for each batch of the table.
+func (a *ArrowStreamWriter) WriteBatch(b *types.RowBatch) error {
+	rec, err := b.ArrowRecord(memory.DefaultAllocator)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+	return a.w.Write(rec)
+}
+
+// Finish is called when all the data has been sent and closes the file.
+func (a *ArrowStreamWriter) Finish() {
+	if a.f == nil {
+		return
+	}
+	if err := a.w.Close(); err != nil {
+		log.WithError(err).Error("Failed to write arrow stream")
+	}
+	if err := a.f.Close(); err != nil {
+		log.WithError(err).Error("Failed to close arrow file")
+	}
+}
+
+// ParquetStreamWriter writes each table to "<dir>/<table name>.parquet".
+type ParquetStreamWriter struct {
+	batchOnlyWriter
+	dir string
+	p   *formatters.ParquetFormatter
+}
+
+// NewParquetStreamWriter creates a ParquetStreamWriter.
+func NewParquetStreamWriter(dir string) *ParquetStreamWriter {
+	return &ParquetStreamWriter{dir: dir}
+}
+
+// SetTable is called with the table metadata before any batches are written.
+func (p *ParquetStreamWriter) SetTable(md *types.TableMetadata) error {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(p.dir, formatters.FileNameForTable(md.Name)+".parquet"))
+	if err != nil {
+		return err
+	}
+	p.p, err = formatters.NewParquetFormatter(f)
+	if err != nil {
+		return err
+	}
+	return p.p.HandleInit(context.Background(), *md)
+}
+
+// WriteBatch is called for each batch of the table.
+func (p *ParquetStreamWriter) WriteBatch(b *types.RowBatch) error {
+	return p.p.HandleBatch(context.Background(), b)
+}
+
+// Finish is called when all the data has been sent and closes the file.
+func (p *ParquetStreamWriter) Finish() {
+	if p.p == nil {
+		return
+	}
+	if err := p.p.HandleDone(context.Background()); err != nil {
+		log.WithError(err).Error("Failed to write parquet file")
+	}
+}
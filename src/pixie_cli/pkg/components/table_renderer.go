@@ -44,11 +44,39 @@ type TableView interface {
 	Data() [][]interface{}
 }
 
+// streamWriterOptions contains the options used to create stream writers.
+type streamWriterOptions struct {
+	outputDir string
+}
+
+// StreamWriterOption configures the writers created by CreateStreamWriter.
+type StreamWriterOption func(*streamWriterOptions)
+
+// WithOutputDir sets the directory that file based writers, ie. arrow and parquet, write to.
+func WithOutputDir(dir string) StreamWriterOption {
+	return func(o *streamWriterOptions) {
+		o.outputDir = dir
+	}
+}
+
 // CreateStreamWriter creates a formatted writer with the default options.
-func CreateStreamWriter(format string, w io.Writer) OutputStreamWriter {
+func CreateStreamWriter(format string, w io.Writer, opts ...StreamWriterOption) OutputStreamWriter {
+	o := &streamWriterOptions{
+		outputDir: ".",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	switch format {
 	case "json":
 		return NewJSONStreamWriter(w)
+	case "ndjson":
+		return NewNDJSONStreamWriter(w)
+	case "arrow":
+		return NewArrowStreamWriter(o.outputDir)
+	case "parquet":
+		return NewParquetStreamWriter(o.outputDir)
 	case "table":
 		return NewTableStreamWriter(w)
 	case "csv":
@@ -21,7 +21,6 @@ package vizier
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"os"
 	"os/signal"
@@ -36,7 +35,6 @@ import (
 	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/proto/cloudpb"
-	"px.dev/pixie/src/api/proto/vispb"
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/pixie_cli/pkg/auth"
 	cliUtils "px.dev/pixie/src/pixie_cli/pkg/utils"
@@ -120,89 +118,9 @@ func (c *Connector) connect(addr string) error {
 	return nil
 }
 
-func lookupVariable(variable string, computedArgs []script.Arg) (string, error) {
-	for _, arg := range computedArgs {
-		if arg.Name == variable {
-			return arg.Value, nil
-		}
-	}
-	return "", fmt.Errorf("variable '%s' not found", variable)
-}
-
-func makeFuncToExecute(f *vispb.Widget_Func, computedArgs []script.Arg, name string) (*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
-	execFunc := &vizierpb.ExecuteScriptRequest_FuncToExecute{}
-	execFunc.FuncName = f.Name
-
-	execFunc.ArgValues = make([]*vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue, len(f.Args))
-	for idx, arg := range f.Args {
-		var value string
-		var err error
-		switch x := arg.Input.(type) {
-		case *vispb.Widget_Func_FuncArg_Value:
-			value = x.Value
-		case *vispb.Widget_Func_FuncArg_Variable:
-			// Lookup variable.
-			value, err = lookupVariable(x.Variable, computedArgs)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return nil, fmt.Errorf("Value not found")
-		}
-
-		execFunc.ArgValues[idx] = &vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue{
-			Name:  arg.Name,
-			Value: value,
-		}
-	}
-
-	execFunc.OutputTablePrefix = "widget"
-	if name != "" {
-		execFunc.OutputTablePrefix = name
-	}
-
-	return execFunc, nil
-}
-
 // GetFuncsToExecute extracts the funcs to execute from the script.
-func GetFuncsToExecute(script *script.ExecutableScript) ([]*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
-	if script.Vis == nil {
-		return []*vizierpb.ExecuteScriptRequest_FuncToExecute{}, nil
-	}
-	// Accumulate the global function definitions.
-	execFuncs := []*vizierpb.ExecuteScriptRequest_FuncToExecute{}
-	computedArgs, err := script.ComputedArgs()
-	if err != nil {
-		return nil, err
-	}
-
-	if script.Vis.GlobalFuncs != nil {
-		for _, f := range script.Vis.GlobalFuncs {
-			execFunc, err := makeFuncToExecute(f.Func, computedArgs, f.OutputName)
-			if err != nil {
-				return []*vizierpb.ExecuteScriptRequest_FuncToExecute{}, err
-			}
-			execFuncs = append(execFuncs, execFunc)
-		}
-	}
-	// Find function definitions within widgets.
-	for _, w := range script.Vis.Widgets {
-		var f *vispb.Widget_Func
-		switch x := w.FuncOrRef.(type) {
-		case *vispb.Widget_Func_:
-			f = x.Func
-		default:
-			// Skip if it's not a function definition.
-			continue
-		}
-
-		execFunc, err := makeFuncToExecute(f, computedArgs, w.Name)
-		if err != nil {
-			return []*vizierpb.ExecuteScriptRequest_FuncToExecute{}, err
-		}
-		execFuncs = append(execFuncs, execFunc)
-	}
-	return execFuncs, nil
+func GetFuncsToExecute(es *script.ExecutableScript) ([]*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
+	return script.GetFuncsToExecute(es)
 }
 
 func containsMutation(script *script.ExecutableScript) bool {
@@ -223,6 +141,15 @@ func (c *Connector) restartConnAndResumeExecute(ctx context.Context, queryID str
 	return c.vz.ExecuteScript(ctx, reqPB)
 }
 
+// ctxWithCreds attaches the credentials for the Vizier, either the direct Vizier key or the
+// user's cloud credentials, to the context.
+func (c *Connector) ctxWithCreds(ctx context.Context) context.Context {
+	if c.directVzAddr != "" {
+		return metadata.AppendToOutgoingContext(ctx, "X-DIRECT-VIZIER-KEY", c.directVzKey)
+	}
+	return auth.CtxWithCreds(ctx)
+}
+
 func checkForTransientGRPCFailure(s *status.Status) bool {
 	if s.Code() == codes.Unavailable {
 		return true
@@ -316,12 +243,7 @@ func (c *Connector) ExecuteScriptStream(ctx context.Context, script *script.Exec
 		QueryName:         scriptName,
 	}
 
-	if c.directVzAddr != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "X-DIRECT-VIZIER-KEY", c.directVzKey)
-	} else {
-		ctx = auth.CtxWithCreds(ctx)
-	}
-
+	ctx = c.ctxWithCreds(ctx)
 	resp, err := c.vz.ExecuteScript(ctx, reqPB)
 	if err != nil {
 		return nil, err
@@ -464,3 +386,35 @@ func (c *Connector) DebugPodsRequest(ctx context.Context) (chan *DebugPodsRespon
 	}()
 	return results, nil
 }
+
+// ListQueries lists the queries that are currently running on the Vizier.
+func (c *Connector) ListQueries(ctx context.Context) ([]*vizierpb.QueryInfo, error) {
+	resp, err := c.vz.ListQueries(c.ctxWithCreds(ctx), &vizierpb.ListQueriesRequest{
+		ClusterID: c.id.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Queries, nil
+}
+
+// GetQuery returns the state of a query that is running on the Vizier.
+func (c *Connector) GetQuery(ctx context.Context, queryID string) (*vizierpb.QueryInfo, error) {
+	resp, err := c.vz.GetQuery(c.ctxWithCreds(ctx), &vizierpb.GetQueryRequest{
+		ClusterID: c.id.String(),
+		QueryID:   queryID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Query, nil
+}
+
+// CancelQuery cancels a query that is running on the Vizier.
+func (c *Connector) CancelQuery(ctx context.Context, queryID string) error {
+	_, err := c.vz.CancelQuery(c.ctxWithCreds(ctx), &vizierpb.CancelQueryRequest{
+		ClusterID: c.id.String(),
+		QueryID:   queryID,
+	})
+	return err
+}
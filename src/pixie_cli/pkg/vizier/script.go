@@ -59,14 +59,15 @@ func (t *taskWrapper) Run() error {
 }
 
 // RunScriptAndOutputResults runs the specified script on vizier and outputs based on format string.
-func RunScriptAndOutputResults(ctx context.Context, conns []*Connector, execScript *script.ExecutableScript, format string, useEncryption bool) error {
+func RunScriptAndOutputResults(ctx context.Context, conns []*Connector, execScript *script.ExecutableScript, format string, useEncryption bool,
+	opts ...StreamOutputAdapterOption) error {
 	// Check for the presence of df.stream() in the query.
-	if strings.Contains(execScript.ScriptString, "stream()") && format != "json" {
+	if strings.Contains(execScript.ScriptString, "stream()") && format != "json" && format != "ndjson" {
 		return fmt.Errorf("Cannot execute a query containing df.stream() using px run with table output. " +
 			"Please try using `px live` instead or setting output format to json (`-o json`).")
 	}
 
-	tw, err := runScript(ctx, conns, execScript, format, useEncryption)
+	tw, err := runScript(ctx, conns, execScript, format, useEncryption, opts...)
 	if err == nil { // Script ran successfully.
 		err = tw.Finish()
 		if err != nil {
@@ -134,7 +135,7 @@ func RunScriptAndOutputResults(ctx context.Context, conns []*Connector, execScri
 
 		tries := 5
 		for tries > 0 {
-			tw, err = runScript(ctx, conns, execScript, format, useEncryption)
+			tw, err = runScript(ctx, conns, execScript, format, useEncryption, opts...)
 			if err == nil {
 				schemaCh <- true
 				break
@@ -175,7 +176,8 @@ func RunScriptAndOutputResults(ctx context.Context, conns []*Connector, execScri
 	return err
 }
 
-func runScript(ctx context.Context, conns []*Connector, execScript *script.ExecutableScript, format string, useEncryption bool) (*StreamOutputAdapter, error) {
+func runScript(ctx context.Context, conns []*Connector, execScript *script.ExecutableScript, format string, useEncryption bool,
+	opts ...StreamOutputAdapterOption) (*StreamOutputAdapter, error) {
 	var encOpts, decOpts *vizierpb.ExecuteScriptRequest_EncryptionOptions
 	var err error
 	if useEncryption {
@@ -190,7 +192,7 @@ func runScript(ctx context.Context, conns []*Connector, execScript *script.Execu
 		return nil, err
 	}
 
-	tw := NewStreamOutputAdapter(ctx, resp, format, decOpts)
+	tw := NewStreamOutputAdapter(ctx, resp, format, decOpts, opts...)
 	err = tw.WaitForCompletion()
 	return tw, err
 }
@@ -222,6 +224,11 @@ func RunScript(ctx context.Context, conns []*Connector, execScript *script.Execu
 
 		eg.Go(func() error {
 			for v := range resp {
+				// Only the end of the merged stream should be reported, which is signaled by
+				// closing the channel once all clusters are done.
+				if v.Err != nil && v.Err == io.EOF && len(conns) > 1 {
+					return nil
+				}
 				mergedResponses <- v
 				if v.Err != nil && v.Err == io.EOF {
 					return nil
@@ -35,6 +35,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/status"
 
+	"px.dev/pixie/src/api/go/pxapi/types"
 	apiutils "px.dev/pixie/src/api/go/pxapi/utils"
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/pixie_cli/pkg/components"
@@ -49,6 +50,7 @@ type TableInfo struct {
 	w          components.OutputStreamWriter
 	ID         string
 	relation   *vizierpb.Relation
+	md         *types.TableMetadata
 	timeColIdx int
 }
 
@@ -70,6 +72,7 @@ type StreamOutputAdapter struct {
 	formatters          map[string]DataFormatter
 	mutationInfo        *vizierpb.MutationInfo
 	decOpts             *vizierpb.ExecuteScriptRequest_EncryptionOptions
+	addClusterID        bool
 
 	// This is used to track table/ID -> names across multiple clusters.
 	tabledIDToName map[string]string
@@ -90,11 +93,46 @@ var (
 // FormatInMemory denotes the inmemory format.
 const FormatInMemory string = "inmemory"
 
+// ClusterIDColumnName is the name of the column that is added to each table when WithClusterIDColumn is used.
+const ClusterIDColumnName string = "cluster_id"
+
+type streamOutputAdapterOptions struct {
+	addClusterID bool
+	writerOpts   []components.StreamWriterOption
+}
+
+// StreamOutputAdapterOption configures a StreamOutputAdapter.
+type StreamOutputAdapterOption func(*streamOutputAdapterOptions)
+
+// WithClusterIDColumn adds a column with the ID of the cluster that produced the data to each table.
+func WithClusterIDColumn() StreamOutputAdapterOption {
+	return func(o *streamOutputAdapterOptions) {
+		o.addClusterID = true
+	}
+}
+
+// WithStreamWriterOptions sets the options used by NewStreamOutputAdapter to create the stream writers.
+func WithStreamWriterOptions(opts ...components.StreamWriterOption) StreamOutputAdapterOption {
+	return func(o *streamOutputAdapterOptions) {
+		o.writerOpts = append(o.writerOpts, opts...)
+	}
+}
+
+func newStreamOutputAdapterOptions(opts []StreamOutputAdapterOption) *streamOutputAdapterOptions {
+	o := &streamOutputAdapterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 // NewStreamOutputAdapterWithFactory creates a new vizier output adapter factory.
 func NewStreamOutputAdapterWithFactory(ctx context.Context, stream chan *ExecData, format string,
 	decOpts *vizierpb.ExecuteScriptRequest_EncryptionOptions,
-	factoryFunc func(*vizierpb.ExecuteScriptResponse_MetaData) components.OutputStreamWriter) *StreamOutputAdapter {
+	factoryFunc func(*vizierpb.ExecuteScriptResponse_MetaData) components.OutputStreamWriter,
+	opts ...StreamOutputAdapterOption) *StreamOutputAdapter {
 	enableFormat := format != "json" && format != FormatInMemory
+	o := newStreamOutputAdapterOptions(opts)
 
 	adapter := &StreamOutputAdapter{
 		tableNameToInfo:     make(map[string]*TableInfo),
@@ -104,6 +142,7 @@ func NewStreamOutputAdapterWithFactory(ctx context.Context, stream chan *ExecDat
 		formatters:          make(map[string]DataFormatter),
 		tabledIDToName:      make(map[string]string),
 		decOpts:             decOpts,
+		addClusterID:        o.addClusterID,
 	}
 
 	adapter.wg.Add(1)
@@ -113,11 +152,13 @@ func NewStreamOutputAdapterWithFactory(ctx context.Context, stream chan *ExecDat
 }
 
 // NewStreamOutputAdapter creates a new vizier output adapter.
-func NewStreamOutputAdapter(ctx context.Context, stream chan *ExecData, format string, decOpts *vizierpb.ExecuteScriptRequest_EncryptionOptions) *StreamOutputAdapter {
+func NewStreamOutputAdapter(ctx context.Context, stream chan *ExecData, format string, decOpts *vizierpb.ExecuteScriptRequest_EncryptionOptions,
+	opts ...StreamOutputAdapterOption) *StreamOutputAdapter {
+	writerOpts := newStreamOutputAdapterOptions(opts).writerOpts
 	factoryFunc := func(md *vizierpb.ExecuteScriptResponse_MetaData) components.OutputStreamWriter {
-		return components.CreateStreamWriter(format, os.Stdout)
+		return components.CreateStreamWriter(format, os.Stdout, writerOpts...)
 	}
-	return NewStreamOutputAdapterWithFactory(ctx, stream, format, decOpts, factoryFunc)
+	return NewStreamOutputAdapterWithFactory(ctx, stream, format, decOpts, factoryFunc, opts...)
 }
 
 // Finish must be called to wait for the output and flush all the data.
@@ -252,7 +293,7 @@ func (v *StreamOutputAdapter) handleStream(ctx context.Context, stream chan *Exe
 			case *vizierpb.ExecuteScriptResponse_MetaData:
 				err = v.handleMetadata(ctx, res)
 			case *vizierpb.ExecuteScriptResponse_Data:
-				err = v.handleData(ctx, res)
+				err = v.handleData(ctx, msg.ClusterID, res)
 			default:
 				err = fmt.Errorf("unhandled response type" + reflect.TypeOf(msg.Resp.Result).String())
 			}
@@ -358,7 +399,7 @@ func (v *StreamOutputAdapter) handleMutationInfo(ctx context.Context, mi *vizier
 	v.mutationInfo = mi
 }
 
-func (v *StreamOutputAdapter) handleData(ctx context.Context, d *vizierpb.ExecuteScriptResponse_Data) error {
+func (v *StreamOutputAdapter) handleData(ctx context.Context, clusterID uuid.UUID, d *vizierpb.ExecuteScriptResponse_Data) error {
 	if d.Data.ExecutionStats != nil {
 		err := v.handleExecutionStats(ctx, d.Data.ExecutionStats)
 		if err != nil {
@@ -401,10 +442,29 @@ func (v *StreamOutputAdapter) handleData(ctx context.Context, d *vizierpb.Execut
 	}
 
 	cols := d.Data.Batch.Cols
+	if bw, ok := tableInfo.w.(components.BatchOutputStreamWriter); ok {
+		if v.addClusterID {
+			cols = append([]*vizierpb.Column{clusterIDColumn(clusterID, numRows)}, cols...)
+		}
+		b, err := types.NewRowBatch(tableInfo.md, int64(numRows), cols)
+		if err != nil {
+			return err
+		}
+		return bw.WriteBatch(b)
+	}
+
+	colOffset := 0
+	if v.addClusterID {
+		colOffset = 1
+	}
 	for rowIdx := 0; rowIdx < numRows; rowIdx++ {
+		rec := make([]interface{}, len(cols)+colOffset)
 		// Add the cluster ID to the output colums.
-		rec := make([]interface{}, len(cols))
-		for colIdx, col := range cols {
+		if v.addClusterID {
+			rec[0] = clusterID.String()
+		}
+		for i, col := range cols {
+			colIdx := i + colOffset
 			val := v.getNativeTypedValue(tableInfo, rowIdx, colIdx, col.ColData)
 			if v.enableFormat {
 				rec[colIdx] = formatter.FormatValue(colIdx, val)
@@ -436,6 +496,9 @@ func (v *StreamOutputAdapter) handleMetadata(ctx context.Context, md *vizierpb.E
 		return nil
 	}
 	relation := md.MetaData.Relation
+	if v.addClusterID {
+		relation = withClusterIDColumn(relation)
+	}
 
 	timeColIdx := -1
 	for idx, col := range relation.Columns {
@@ -445,20 +508,54 @@ func (v *StreamOutputAdapter) handleMetadata(ctx context.Context, md *vizierpb.E
 		}
 	}
 
-	// Write out the header keys in the order specified by the relation.
-	headerKeys := make([]string, len(relation.Columns))
-	for i, col := range relation.Columns {
-		headerKeys[i] = col.ColumnName
+	tableMD := types.NewTableMetadata(md.MetaData.Name, relation)
+	if bw, ok := newWriter.(components.BatchOutputStreamWriter); ok {
+		if err := bw.SetTable(&tableMD); err != nil {
+			return err
+		}
+	} else {
+		// Write out the header keys in the order specified by the relation.
+		headerKeys := make([]string, len(relation.Columns))
+		for i, col := range relation.Columns {
+			headerKeys[i] = col.ColumnName
+		}
+		newWriter.SetHeader(md.MetaData.Name, headerKeys)
 	}
-	newWriter.SetHeader(md.MetaData.Name, headerKeys)
 
 	v.tableNameToInfo[tableName] = &TableInfo{
 		ID:         tableName,
 		w:          newWriter,
 		relation:   relation,
+		md:         &tableMD,
 		timeColIdx: timeColIdx,
 	}
 
 	v.formatters[tableName] = NewDataFormatterForTable(relation)
 	return nil
 }
+
+// withClusterIDColumn returns a copy of the relation with the cluster ID column prepended.
+func withClusterIDColumn(relation *vizierpb.Relation) *vizierpb.Relation {
+	cols := make([]*vizierpb.Relation_ColumnInfo, 0, len(relation.Columns)+1)
+	cols = append(cols, &vizierpb.Relation_ColumnInfo{
+		ColumnName:         ClusterIDColumnName,
+		ColumnType:         vizierpb.STRING,
+		ColumnSemanticType: vizierpb.ST_NONE,
+	})
+	cols = append(cols, relation.Columns...)
+	return &vizierpb.Relation{Columns: cols}
+}
+
+// clusterIDColumn creates a column with the cluster ID repeated for each row.
+func clusterIDColumn(clusterID uuid.UUID, numRows int) *vizierpb.Column {
+	id := []byte(clusterID.String())
+	data := make([][]byte, numRows)
+	for i := range data {
+		data[i] = id
+	}
+	return &vizierpb.Column{
+		ColData: &vizierpb.Column_StringData{
+			StringData: &vizierpb.StringColumn{Data: data},
+		},
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package vizier_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/pixie_cli/pkg/components"
+	"px.dev/pixie/src/pixie_cli/pkg/vizier"
+)
+
+func streamTestMessages(clusterIDs ...uuid.UUID) []*vizier.ExecData {
+	var msgs []*vizier.ExecData
+	for i, clusterID := range clusterIDs {
+		tableID := uuid.Must(uuid.NewV4()).String()
+		msgs = append(msgs, &vizier.ExecData{
+			ClusterID: clusterID,
+			Resp: &vizierpb.ExecuteScriptResponse{
+				Result: &vizierpb.ExecuteScriptResponse_MetaData{
+					MetaData: &vizierpb.QueryMetadata{
+						Name: "http",
+						ID:   tableID,
+						Relation: &vizierpb.Relation{
+							Columns: []*vizierpb.Relation_ColumnInfo{
+								{ColumnName: "req_path", ColumnType: vizierpb.STRING},
+								{ColumnName: "status", ColumnType: vizierpb.INT64},
+							},
+						},
+					},
+				},
+			},
+		}, &vizier.ExecData{
+			ClusterID: clusterID,
+			Resp: &vizierpb.ExecuteScriptResponse{
+				Result: &vizierpb.ExecuteScriptResponse_Data{
+					Data: &vizierpb.QueryData{
+						Batch: &vizierpb.RowBatchData{
+							TableID: tableID,
+							NumRows: 1,
+							Cols: []*vizierpb.Column{
+								// A numeric string should stay a string.
+								{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: [][]byte{[]byte("123")}}}},
+								{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: []int64{int64(200 + i)}}}},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	return msgs
+}
+
+func runStreamAdapter(t *testing.T, format string, msgs []*vizier.ExecData, opts ...vizier.StreamOutputAdapterOption) string {
+	return runStreamAdapterWithWriterOpts(t, format, msgs, nil, opts...)
+}
+
+func runStreamAdapterWithWriterOpts(t *testing.T, format string, msgs []*vizier.ExecData, writerOpts []components.StreamWriterOption,
+	opts ...vizier.StreamOutputAdapterOption) string {
+	buf := &bytes.Buffer{}
+	stream := make(chan *vizier.ExecData, len(msgs))
+	for _, msg := range msgs {
+		stream <- msg
+	}
+	close(stream)
+
+	factory := func(md *vizierpb.ExecuteScriptResponse_MetaData) components.OutputStreamWriter {
+		return components.CreateStreamWriter(format, buf, writerOpts...)
+	}
+	adapter := vizier.NewStreamOutputAdapterWithFactory(context.Background(), stream, format, nil, factory, opts...)
+	require.NoError(t, adapter.Finish())
+	return buf.String()
+}
+
+func TestStreamOutputAdapter_NDJSON(t *testing.T) {
+	clusterID := uuid.Must(uuid.NewV4())
+	out := runStreamAdapter(t, "ndjson", streamTestMessages(clusterID))
+	assert.Equal(t, "{\"_tableName_\":\"http\",\"req_path\":\"123\",\"status\":200}\n", out)
+}
+
+func TestStreamOutputAdapter_ClusterIDColumn(t *testing.T) {
+	c1 := uuid.Must(uuid.NewV4())
+	c2 := uuid.Must(uuid.NewV4())
+
+	out := runStreamAdapter(t, "ndjson", streamTestMessages(c1, c2), vizier.WithClusterIDColumn())
+	assert.Equal(t,
+		"{\"_tableName_\":\"http\",\"cluster_id\":\""+c1.String()+"\",\"req_path\":\"123\",\"status\":200}\n"+
+			"{\"_tableName_\":\"http\",\"cluster_id\":\""+c2.String()+"\",\"req_path\":\"123\",\"status\":201}\n",
+		out)
+
+	out = runStreamAdapter(t, "csv", streamTestMessages(c1, c2), vizier.WithClusterIDColumn())
+	assert.Equal(t,
+		"table_id,cluster_id,req_path,status\n"+
+			"http,"+c1.String()+",123,200\n"+
+			"http,"+c2.String()+",123,201\n",
+		out)
+}
+
+func TestStreamOutputAdapter_Arrow(t *testing.T) {
+	dir := t.TempDir()
+	out := runStreamAdapterWithWriterOpts(t, "arrow", streamTestMessages(uuid.Must(uuid.NewV4())),
+		[]components.StreamWriterOption{components.WithOutputDir(dir)})
+	assert.Empty(t, out)
+
+	f, err := os.Open(filepath.Join(dir, "http.arrow"))
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := ipc.NewReader(f)
+	require.NoError(t, err)
+	defer r.Release()
+
+	require.True(t, r.Next())
+	rec := r.Record()
+	assert.Equal(t, int64(1), rec.NumRows())
+	assert.Equal(t, "req_path", rec.ColumnName(0))
+	assert.Equal(t, "123", rec.Column(0).(*array.String).Value(0))
+	assert.Equal(t, []int64{200}, rec.Column(1).(*array.Int64).Int64Values())
+	assert.False(t, r.Next())
+}
+
+func TestStreamOutputAdapter_Parquet(t *testing.T) {
+	dir := t.TempDir()
+	out := runStreamAdapterWithWriterOpts(t, "parquet", streamTestMessages(uuid.Must(uuid.NewV4())),
+		[]components.StreamWriterOption{components.WithOutputDir(dir)})
+	assert.Empty(t, out)
+
+	f, err := os.Open(filepath.Join(dir, "http.parquet"))
+	require.NoError(t, err)
+	r, err := file.NewParquetReader(f)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, int64(1), r.NumRows())
+	assert.Equal(t, 2, r.MetaData().Schema.NumColumns())
+}
@@ -20,10 +20,16 @@ package k8s
 
 import (
 	"fmt"
+	"sort"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -815,3 +821,656 @@ func DeploymentToProto(d *apps.Deployment) *metadatapb.Deployment {
 		Status:   DeploymentStatusToProto(&d.Status),
 	}
 }
+
+// StatefulSetSpecToProto converts apps.StatefulSetSpec to proto
+func StatefulSetSpecToProto(s *apps.StatefulSetSpec) *metadatapb.StatefulSetSpec {
+	var replicas, revisionHistoryLimit int32
+	if s.Replicas == nil {
+		replicas = 1
+	} else {
+		replicas = *s.Replicas
+	}
+	if s.RevisionHistoryLimit != nil {
+		revisionHistoryLimit = *s.RevisionHistoryLimit
+	}
+
+	return &metadatapb.StatefulSetSpec{
+		Replicas:             replicas,
+		Selector:             LabelSelectorToProto(s.Selector),
+		Template:             PodTemplateSpecToProto(s.Template),
+		ServiceName:          s.ServiceName,
+		PodManagementPolicy:  string(s.PodManagementPolicy),
+		MinReadySeconds:      s.MinReadySeconds,
+		RevisionHistoryLimit: revisionHistoryLimit,
+	}
+}
+
+// StatefulSetStatusToProto converts apps.StatefulSetStatus to proto
+func StatefulSetStatusToProto(s *apps.StatefulSetStatus) *metadatapb.StatefulSetStatus {
+	return &metadatapb.StatefulSetStatus{
+		ObservedGeneration: s.ObservedGeneration,
+		Replicas:           s.Replicas,
+		ReadyReplicas:      s.ReadyReplicas,
+		CurrentReplicas:    s.CurrentReplicas,
+		UpdatedReplicas:    s.UpdatedReplicas,
+		AvailableReplicas:  s.AvailableReplicas,
+		CurrentRevision:    s.CurrentRevision,
+		UpdateRevision:     s.UpdateRevision,
+	}
+}
+
+// StatefulSetToProto converts apps.StatefulSet to proto
+func StatefulSetToProto(s *apps.StatefulSet) *metadatapb.StatefulSet {
+	return &metadatapb.StatefulSet{
+		Metadata: ObjectMetadataToProto(&s.ObjectMeta),
+		Spec:     StatefulSetSpecToProto(&s.Spec),
+		Status:   StatefulSetStatusToProto(&s.Status),
+	}
+}
+
+// DaemonSetSpecToProto converts apps.DaemonSetSpec to proto
+func DaemonSetSpecToProto(d *apps.DaemonSetSpec) *metadatapb.DaemonSetSpec {
+	var revisionHistoryLimit int32
+	if d.RevisionHistoryLimit != nil {
+		revisionHistoryLimit = *d.RevisionHistoryLimit
+	}
+
+	return &metadatapb.DaemonSetSpec{
+		Selector:             LabelSelectorToProto(d.Selector),
+		Template:             PodTemplateSpecToProto(d.Template),
+		MinReadySeconds:      d.MinReadySeconds,
+		RevisionHistoryLimit: revisionHistoryLimit,
+	}
+}
+
+// DaemonSetStatusToProto converts apps.DaemonSetStatus to proto
+func DaemonSetStatusToProto(d *apps.DaemonSetStatus) *metadatapb.DaemonSetStatus {
+	return &metadatapb.DaemonSetStatus{
+		CurrentNumberScheduled: d.CurrentNumberScheduled,
+		NumberMisscheduled:     d.NumberMisscheduled,
+		DesiredNumberScheduled: d.DesiredNumberScheduled,
+		NumberReady:            d.NumberReady,
+		ObservedGeneration:     d.ObservedGeneration,
+		UpdatedNumberScheduled: d.UpdatedNumberScheduled,
+		NumberAvailable:        d.NumberAvailable,
+		NumberUnavailable:      d.NumberUnavailable,
+	}
+}
+
+// DaemonSetToProto converts apps.DaemonSet to proto
+func DaemonSetToProto(d *apps.DaemonSet) *metadatapb.DaemonSet {
+	return &metadatapb.DaemonSet{
+		Metadata: ObjectMetadataToProto(&d.ObjectMeta),
+		Spec:     DaemonSetSpecToProto(&d.Spec),
+		Status:   DaemonSetStatusToProto(&d.Status),
+	}
+}
+
+// JobSpecToProto converts batch.JobSpec to proto
+func JobSpecToProto(j *batch.JobSpec) *metadatapb.JobSpec {
+	var parallelism, completions, backoffLimit int32
+	var activeDeadlineSeconds int64
+	if j.Parallelism != nil {
+		parallelism = *j.Parallelism
+	}
+	if j.Completions != nil {
+		completions = *j.Completions
+	}
+	if j.BackoffLimit != nil {
+		backoffLimit = *j.BackoffLimit
+	}
+	if j.ActiveDeadlineSeconds != nil {
+		activeDeadlineSeconds = *j.ActiveDeadlineSeconds
+	}
+
+	var selector *metadatapb.LabelSelector
+	if j.Selector != nil {
+		selector = LabelSelectorToProto(j.Selector)
+	}
+
+	var completionMode string
+	if j.CompletionMode != nil {
+		completionMode = string(*j.CompletionMode)
+	}
+
+	return &metadatapb.JobSpec{
+		Parallelism:           parallelism,
+		Completions:           completions,
+		ActiveDeadlineSeconds: activeDeadlineSeconds,
+		BackoffLimit:          backoffLimit,
+		Selector:              selector,
+		Template:              PodTemplateSpecToProto(j.Template),
+		CompletionMode:        completionMode,
+		Suspend:               j.Suspend != nil && *j.Suspend,
+	}
+}
+
+// JobConditionToProto converts batch.JobCondition to proto
+func JobConditionToProto(c *batch.JobCondition) *metadatapb.JobCondition {
+	return &metadatapb.JobCondition{
+		Type:                 string(c.Type),
+		Status:               conditionStatusObjToPbMap[c.Status],
+		LastProbeTimeNS:      c.LastProbeTime.UnixNano(),
+		LastTransitionTimeNS: c.LastTransitionTime.UnixNano(),
+		Reason:               c.Reason,
+		Message:              c.Message,
+	}
+}
+
+// JobStatusToProto converts batch.JobStatus to proto
+func JobStatusToProto(j *batch.JobStatus) *metadatapb.JobStatus {
+	var conditions []*metadatapb.JobCondition
+	for _, c := range j.Conditions {
+		conditions = append(conditions, JobConditionToProto(&c))
+	}
+
+	var startTime, completionTime int64
+	if j.StartTime != nil {
+		startTime = j.StartTime.UnixNano()
+	}
+	if j.CompletionTime != nil {
+		completionTime = j.CompletionTime.UnixNano()
+	}
+
+	return &metadatapb.JobStatus{
+		StartTimeNS:      startTime,
+		CompletionTimeNS: completionTime,
+		Active:           j.Active,
+		Succeeded:        j.Succeeded,
+		Failed:           j.Failed,
+		Conditions:       conditions,
+	}
+}
+
+// JobToProto converts batch.Job to proto
+func JobToProto(j *batch.Job) *metadatapb.Job {
+	return &metadatapb.Job{
+		Metadata: ObjectMetadataToProto(&j.ObjectMeta),
+		Spec:     JobSpecToProto(&j.Spec),
+		Status:   JobStatusToProto(&j.Status),
+	}
+}
+
+// CronJobSpecToProto converts batch.CronJobSpec to proto
+func CronJobSpecToProto(c *batch.CronJobSpec) *metadatapb.CronJobSpec {
+	var timeZone string
+	if c.TimeZone != nil {
+		timeZone = *c.TimeZone
+	}
+	var startingDeadlineSeconds int64
+	if c.StartingDeadlineSeconds != nil {
+		startingDeadlineSeconds = *c.StartingDeadlineSeconds
+	}
+	var successfulJobsHistoryLimit, failedJobsHistoryLimit int32
+	if c.SuccessfulJobsHistoryLimit != nil {
+		successfulJobsHistoryLimit = *c.SuccessfulJobsHistoryLimit
+	}
+	if c.FailedJobsHistoryLimit != nil {
+		failedJobsHistoryLimit = *c.FailedJobsHistoryLimit
+	}
+
+	return &metadatapb.CronJobSpec{
+		Schedule:                   c.Schedule,
+		TimeZone:                   timeZone,
+		ConcurrencyPolicy:          string(c.ConcurrencyPolicy),
+		Suspend:                    c.Suspend != nil && *c.Suspend,
+		StartingDeadlineSeconds:    startingDeadlineSeconds,
+		SuccessfulJobsHistoryLimit: successfulJobsHistoryLimit,
+		FailedJobsHistoryLimit:     failedJobsHistoryLimit,
+	}
+}
+
+// CronJobStatusToProto converts batch.CronJobStatus to proto
+func CronJobStatusToProto(c *batch.CronJobStatus) *metadatapb.CronJobStatus {
+	active := make([]*metadatapb.ObjectReference, len(c.Active))
+	for i, a := range c.Active {
+		active[i] = ObjectReferenceToProto(&a)
+	}
+
+	var lastScheduleTime, lastSuccessfulTime int64
+	if c.LastScheduleTime != nil {
+		lastScheduleTime = c.LastScheduleTime.UnixNano()
+	}
+	if c.LastSuccessfulTime != nil {
+		lastSuccessfulTime = c.LastSuccessfulTime.UnixNano()
+	}
+
+	return &metadatapb.CronJobStatus{
+		Active:               active,
+		LastScheduleTimeNS:   lastScheduleTime,
+		LastSuccessfulTimeNS: lastSuccessfulTime,
+	}
+}
+
+// CronJobToProto converts batch.CronJob to proto
+func CronJobToProto(c *batch.CronJob) *metadatapb.CronJob {
+	return &metadatapb.CronJob{
+		Metadata: ObjectMetadataToProto(&c.ObjectMeta),
+		Spec:     CronJobSpecToProto(&c.Spec),
+		Status:   CronJobStatusToProto(&c.Status),
+	}
+}
+
+// EndpointSlicesToProto merges the EndpointSlices that belong to a single service into an Endpoints
+// proto, so that slices can be processed the same way as core/v1 Endpoints. Each slice becomes one
+// subset. IPv4 slices are used when present, otherwise IPv6 slices are used, which mirrors the single
+// address family that core/v1 Endpoints exposes.
+func EndpointSlicesToProto(serviceName string, slices []*discovery.EndpointSlice) *metadatapb.Endpoints {
+	addressType := discovery.AddressTypeIPv6
+	for _, s := range slices {
+		if s.AddressType == discovery.AddressTypeIPv4 {
+			addressType = discovery.AddressTypeIPv4
+			break
+		}
+	}
+
+	sorted := make([]*discovery.EndpointSlice, 0, len(slices))
+	for _, s := range slices {
+		if s.AddressType == addressType {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	md := &metadatapb.ObjectMetadata{
+		Name:            serviceName,
+		OwnerReferences: []*metadatapb.OwnerReference{},
+	}
+	if len(slices) > 0 {
+		oldest := slices[0]
+		for _, s := range slices[1:] {
+			if s.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+				oldest = s
+			}
+		}
+		md.Namespace = oldest.Namespace
+		md.Labels = serviceLabelsFromSlice(oldest)
+		md.CreationTimestampNS = oldest.CreationTimestamp.UnixNano()
+		// Slices that aren't managed by the EndpointSlice controller may not reference their service,
+		// so fall back to the oldest slice to get a UID that is stable for the life of the service.
+		md.UID = string(oldest.UID)
+		for _, ref := range oldest.OwnerReferences {
+			if ref.Kind == "Service" {
+				md.UID = string(ref.UID)
+			}
+		}
+	}
+
+	var subsets []*metadatapb.EndpointSubset
+	for _, s := range sorted {
+		subset := EndpointSliceToSubsetProto(s)
+		if len(subset.Addresses) == 0 && len(subset.NotReadyAddresses) == 0 {
+			continue
+		}
+		subsets = append(subsets, subset)
+	}
+	if subsets == nil {
+		subsets = []*metadatapb.EndpointSubset{}
+	}
+
+	return &metadatapb.Endpoints{
+		Metadata: md,
+		Subsets:  subsets,
+	}
+}
+
+// serviceLabelsFromSlice returns the labels copied onto a slice from its service, dropping the
+// labels that the EndpointSlice controller adds for its own bookkeeping.
+func serviceLabelsFromSlice(s *discovery.EndpointSlice) map[string]string {
+	if s.Labels == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(s.Labels))
+	for k, v := range s.Labels {
+		if k == discovery.LabelServiceName || k == discovery.LabelManagedBy {
+			continue
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+// EndpointSliceToSubsetProto converts a single EndpointSlice into an EndpointSubset proto.
+func EndpointSliceToSubsetProto(s *discovery.EndpointSlice) *metadatapb.EndpointSubset {
+	addresses := make([]*metadatapb.EndpointAddress, 0)
+	notReadyAddrs := make([]*metadatapb.EndpointAddress, 0)
+	for _, e := range s.Endpoints {
+		// A nil ready condition is treated as ready, consistent with how the Endpoints mirror
+		// controller handles it.
+		ready := e.Conditions.Ready == nil || *e.Conditions.Ready
+		for _, ip := range e.Addresses {
+			addr := &metadatapb.EndpointAddress{
+				IP: ip,
+			}
+			if e.Hostname != nil {
+				addr.Hostname = *e.Hostname
+			}
+			if e.NodeName != nil {
+				addr.NodeName = *e.NodeName
+			}
+			if e.TargetRef != nil {
+				addr.TargetRef = ObjectReferenceToProto(e.TargetRef)
+			}
+			if ready {
+				addresses = append(addresses, addr)
+			} else {
+				notReadyAddrs = append(notReadyAddrs, addr)
+			}
+		}
+	}
+
+	ports := make([]*metadatapb.EndpointPort, len(s.Ports))
+	for i, p := range s.Ports {
+		port := &metadatapb.EndpointPort{}
+		if p.Name != nil {
+			port.Name = *p.Name
+		}
+		if p.Port != nil {
+			port.Port = *p.Port
+		}
+		if p.Protocol != nil {
+			port.Protocol = ipProtocolObjToPbMap[*p.Protocol]
+		}
+		ports[i] = port
+	}
+
+	return &metadatapb.EndpointSubset{
+		Addresses:         addresses,
+		NotReadyAddresses: notReadyAddrs,
+		Ports:             ports,
+	}
+}
+
+// IngressBackendToProto converts a networking.IngressBackend to proto. Resource backends aren't
+// services, so they are converted to an empty backend.
+func IngressBackendToProto(b *networking.IngressBackend) *metadatapb.IngressBackend {
+	pb := &metadatapb.IngressBackend{}
+	if b.Service != nil {
+		pb.ServiceName = b.Service.Name
+		pb.ServicePortNumber = b.Service.Port.Number
+		pb.ServicePortName = b.Service.Port.Name
+	}
+	return pb
+}
+
+// IngressSpecToProto converts a networking.IngressSpec to proto.
+func IngressSpecToProto(i *networking.IngressSpec) *metadatapb.IngressSpec {
+	tls := make([]*metadatapb.IngressTLS, len(i.TLS))
+	for idx, t := range i.TLS {
+		tls[idx] = &metadatapb.IngressTLS{
+			Hosts:      t.Hosts,
+			SecretName: t.SecretName,
+		}
+	}
+
+	rules := make([]*metadatapb.IngressRule, len(i.Rules))
+	for idx, r := range i.Rules {
+		paths := make([]*metadatapb.HTTPIngressPath, 0)
+		if r.HTTP != nil {
+			for _, p := range r.HTTP.Paths {
+				path := &metadatapb.HTTPIngressPath{
+					Path:    p.Path,
+					Backend: IngressBackendToProto(&p.Backend),
+				}
+				if p.PathType != nil {
+					path.PathType = string(*p.PathType)
+				}
+				paths = append(paths, path)
+			}
+		}
+		rules[idx] = &metadatapb.IngressRule{
+			Host:  r.Host,
+			Paths: paths,
+		}
+	}
+
+	pb := &metadatapb.IngressSpec{
+		TLS:   tls,
+		Rules: rules,
+	}
+	if i.IngressClassName != nil {
+		pb.IngressClassName = *i.IngressClassName
+	}
+	if i.DefaultBackend != nil {
+		pb.DefaultBackend = IngressBackendToProto(i.DefaultBackend)
+	}
+	return pb
+}
+
+// IngressStatusToProto converts a networking.IngressStatus to proto.
+func IngressStatusToProto(i *networking.IngressStatus) *metadatapb.IngressStatus {
+	ips := make([]string, 0)
+	hostnames := make([]string, 0)
+	for _, lb := range i.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			ips = append(ips, lb.IP)
+		}
+		if lb.Hostname != "" {
+			hostnames = append(hostnames, lb.Hostname)
+		}
+	}
+	return &metadatapb.IngressStatus{
+		LoadBalancerIPs:       ips,
+		LoadBalancerHostnames: hostnames,
+	}
+}
+
+// IngressToProto converts a networking.Ingress to proto.
+func IngressToProto(i *networking.Ingress) *metadatapb.Ingress {
+	return &metadatapb.Ingress{
+		Metadata: ObjectMetadataToProto(&i.ObjectMeta),
+		Spec:     IngressSpecToProto(&i.Spec),
+		Status:   IngressStatusToProto(&i.Status),
+	}
+}
+
+// NetworkPolicyPeerToProto converts a networking.NetworkPolicyPeer to proto.
+func NetworkPolicyPeerToProto(p *networking.NetworkPolicyPeer) *metadatapb.NetworkPolicyPeer {
+	pb := &metadatapb.NetworkPolicyPeer{}
+	if p.PodSelector != nil {
+		pb.PodSelector = LabelSelectorToProto(p.PodSelector)
+	}
+	if p.NamespaceSelector != nil {
+		pb.NamespaceSelector = LabelSelectorToProto(p.NamespaceSelector)
+	}
+	if p.IPBlock != nil {
+		pb.IPBlock = &metadatapb.IPBlock{
+			CIDR:   p.IPBlock.CIDR,
+			Except: p.IPBlock.Except,
+		}
+	}
+	return pb
+}
+
+// NetworkPolicyPortToProto converts a networking.NetworkPolicyPort to proto.
+func NetworkPolicyPortToProto(p *networking.NetworkPolicyPort) *metadatapb.NetworkPolicyPort {
+	// The protocol defaults to TCP when it isn't specified.
+	pb := &metadatapb.NetworkPolicyPort{
+		Protocol: metadatapb.TCP,
+	}
+	if p.Protocol != nil {
+		pb.Protocol = ipProtocolObjToPbMap[*p.Protocol]
+	}
+	if p.Port != nil {
+		pb.Port = IntOrStringToString(p.Port)
+	}
+	if p.EndPort != nil {
+		pb.EndPort = *p.EndPort
+	}
+	return pb
+}
+
+func networkPolicyPortsToProto(ports []networking.NetworkPolicyPort) []*metadatapb.NetworkPolicyPort {
+	pbs := make([]*metadatapb.NetworkPolicyPort, len(ports))
+	for i := range ports {
+		pbs[i] = NetworkPolicyPortToProto(&ports[i])
+	}
+	return pbs
+}
+
+func networkPolicyPeersToProto(peers []networking.NetworkPolicyPeer) []*metadatapb.NetworkPolicyPeer {
+	pbs := make([]*metadatapb.NetworkPolicyPeer, len(peers))
+	for i := range peers {
+		pbs[i] = NetworkPolicyPeerToProto(&peers[i])
+	}
+	return pbs
+}
+
+// NetworkPolicySpecToProto converts a networking.NetworkPolicySpec to proto.
+func NetworkPolicySpecToProto(n *networking.NetworkPolicySpec) *metadatapb.NetworkPolicySpec {
+	ingress := make([]*metadatapb.NetworkPolicyIngressRule, len(n.Ingress))
+	for i, r := range n.Ingress {
+		ingress[i] = &metadatapb.NetworkPolicyIngressRule{
+			Ports: networkPolicyPortsToProto(r.Ports),
+			From:  networkPolicyPeersToProto(r.From),
+		}
+	}
+
+	egress := make([]*metadatapb.NetworkPolicyEgressRule, len(n.Egress))
+	for i, r := range n.Egress {
+		egress[i] = &metadatapb.NetworkPolicyEgressRule{
+			Ports: networkPolicyPortsToProto(r.Ports),
+			To:    networkPolicyPeersToProto(r.To),
+		}
+	}
+
+	policyTypes := make([]string, len(n.PolicyTypes))
+	for i, t := range n.PolicyTypes {
+		policyTypes[i] = string(t)
+	}
+
+	return &metadatapb.NetworkPolicySpec{
+		PodSelector: LabelSelectorToProto(&n.PodSelector),
+		Ingress:     ingress,
+		Egress:      egress,
+		PolicyTypes: policyTypes,
+	}
+}
+
+// NetworkPolicyToProto converts a networking.NetworkPolicy to proto.
+func NetworkPolicyToProto(n *networking.NetworkPolicy) *metadatapb.NetworkPolicy {
+	return &metadatapb.NetworkPolicy{
+		Metadata: ObjectMetadataToProto(&n.ObjectMeta),
+		Spec:     NetworkPolicySpecToProto(&n.Spec),
+	}
+}
+
+// httpRoute mirrors the fields of a Gateway API HTTPRoute that we convert to proto. HTTPRoutes are
+// CRDs that are only read through the dynamic client, so the subset of the schema we need is
+// declared here rather than depending on the Gateway API module.
+type httpRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		ParentRefs []struct {
+			Group       *string `json:"group,omitempty"`
+			Kind        *string `json:"kind,omitempty"`
+			Namespace   *string `json:"namespace,omitempty"`
+			Name        string  `json:"name"`
+			SectionName *string `json:"sectionName,omitempty"`
+		} `json:"parentRefs,omitempty"`
+		Hostnames []string `json:"hostnames,omitempty"`
+		Rules     []struct {
+			Matches []struct {
+				Path *struct {
+					Type  *string `json:"type,omitempty"`
+					Value *string `json:"value,omitempty"`
+				} `json:"path,omitempty"`
+				Method *string `json:"method,omitempty"`
+			} `json:"matches,omitempty"`
+			BackendRefs []struct {
+				Group     *string `json:"group,omitempty"`
+				Kind      *string `json:"kind,omitempty"`
+				Namespace *string `json:"namespace,omitempty"`
+				Name      string  `json:"name"`
+				Port      *int32  `json:"port,omitempty"`
+				Weight    *int32  `json:"weight,omitempty"`
+			} `json:"backendRefs,omitempty"`
+		} `json:"rules,omitempty"`
+	} `json:"spec"`
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// HTTPRouteToProto converts an unstructured Gateway API HTTPRoute to proto.
+func HTTPRouteToProto(u *unstructured.Unstructured) (*metadatapb.HTTPRoute, error) {
+	var r httpRoute
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &r); err != nil {
+		return nil, err
+	}
+
+	parentRefs := make([]*metadatapb.GatewayParentReference, len(r.Spec.ParentRefs))
+	for i, p := range r.Spec.ParentRefs {
+		parentRefs[i] = &metadatapb.GatewayParentReference{
+			Group:       stringOrEmpty(p.Group),
+			Kind:        stringOrEmpty(p.Kind),
+			Namespace:   stringOrEmpty(p.Namespace),
+			Name:        p.Name,
+			SectionName: stringOrEmpty(p.SectionName),
+		}
+	}
+
+	rules := make([]*metadatapb.HTTPRouteRule, len(r.Spec.Rules))
+	for i, rule := range r.Spec.Rules {
+		matches := make([]*metadatapb.HTTPRouteMatch, len(rule.Matches))
+		for j, m := range rule.Matches {
+			matches[j] = &metadatapb.HTTPRouteMatch{
+				Method: stringOrEmpty(m.Method),
+			}
+			if m.Path != nil {
+				matches[j].PathType = stringOrEmpty(m.Path.Type)
+				matches[j].PathValue = stringOrEmpty(m.Path.Value)
+			}
+		}
+
+		backendRefs := make([]*metadatapb.HTTPBackendRef, len(rule.BackendRefs))
+		for j, b := range rule.BackendRefs {
+			// Unset fields are given the defaults from the Gateway API spec.
+			backendRefs[j] = &metadatapb.HTTPBackendRef{
+				Group:     stringOrEmpty(b.Group),
+				Kind:      "Service",
+				Namespace: r.Namespace,
+				Name:      b.Name,
+				Weight:    1,
+			}
+			if b.Kind != nil {
+				backendRefs[j].Kind = *b.Kind
+			}
+			if b.Namespace != nil {
+				backendRefs[j].Namespace = *b.Namespace
+			}
+			if b.Port != nil {
+				backendRefs[j].Port = *b.Port
+			}
+			if b.Weight != nil {
+				backendRefs[j].Weight = *b.Weight
+			}
+		}
+
+		rules[i] = &metadatapb.HTTPRouteRule{
+			Matches:     matches,
+			BackendRefs: backendRefs,
+		}
+	}
+
+	hostnames := r.Spec.Hostnames
+	if hostnames == nil {
+		hostnames = []string{}
+	}
+
+	return &metadatapb.HTTPRoute{
+		Metadata: ObjectMetadataToProto(&r.ObjectMeta),
+		Spec: &metadatapb.HTTPRouteSpec{
+			ParentRefs: parentRefs,
+			Hostnames:  hostnames,
+			Rules:      rules,
+		},
+	}, nil
+}
@@ -25,9 +25,14 @@ import (
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 
 	"px.dev/pixie/src/shared/k8s"
@@ -1855,3 +1860,518 @@ func TestDeploymentToProto(t *testing.T) {
 	t.Logf("%v\n", expectedPb)
 	assert.Equal(t, expectedPb, oPb)
 }
+
+func TestStatefulSetToProto(t *testing.T) {
+	var replicas int32 = 3
+	o := apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web",
+			Namespace:         "a_namespace",
+			UID:               "ss-uid",
+			CreationTimestamp: metav1.Unix(0, 4),
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "web"},
+			},
+			ServiceName:         "web-svc",
+			PodManagementPolicy: apps.ParallelPodManagement,
+		},
+		Status: apps.StatefulSetStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			ReadyReplicas:      2,
+			CurrentReplicas:    3,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  2,
+			CurrentRevision:    "web-1",
+			UpdateRevision:     "web-2",
+		},
+	}
+
+	oPb := k8s.StatefulSetToProto(&o)
+	assert.Equal(t, "web", oPb.Metadata.Name)
+	assert.Equal(t, "ss-uid", oPb.Metadata.UID)
+	assert.Equal(t, int64(4), oPb.Metadata.CreationTimestampNS)
+	assert.Equal(t, int32(3), oPb.Spec.Replicas)
+	assert.Equal(t, map[string]string{"app": "web"}, oPb.Spec.Selector.MatchLabels)
+	assert.Equal(t, "web-svc", oPb.Spec.ServiceName)
+	assert.Equal(t, "Parallel", oPb.Spec.PodManagementPolicy)
+	assert.Equal(t, &metadatapb.StatefulSetStatus{
+		ObservedGeneration: 2,
+		Replicas:           3,
+		ReadyReplicas:      2,
+		CurrentReplicas:    3,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  2,
+		CurrentRevision:    "web-1",
+		UpdateRevision:     "web-2",
+	}, oPb.Status)
+}
+
+func TestDaemonSetToProto(t *testing.T) {
+	o := apps.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "agent",
+			Namespace: "a_namespace",
+			UID:       "ds-uid",
+		},
+		Spec: apps.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "agent"},
+			},
+			MinReadySeconds: 5,
+		},
+		Status: apps.DaemonSetStatus{
+			CurrentNumberScheduled: 3,
+			NumberMisscheduled:     1,
+			DesiredNumberScheduled: 4,
+			NumberReady:            2,
+			ObservedGeneration:     7,
+			UpdatedNumberScheduled: 3,
+			NumberAvailable:        2,
+			NumberUnavailable:      2,
+		},
+	}
+
+	oPb := k8s.DaemonSetToProto(&o)
+	assert.Equal(t, "agent", oPb.Metadata.Name)
+	assert.Equal(t, "ds-uid", oPb.Metadata.UID)
+	assert.Equal(t, map[string]string{"app": "agent"}, oPb.Spec.Selector.MatchLabels)
+	assert.Equal(t, int32(5), oPb.Spec.MinReadySeconds)
+	assert.Equal(t, &metadatapb.DaemonSetStatus{
+		CurrentNumberScheduled: 3,
+		NumberMisscheduled:     1,
+		DesiredNumberScheduled: 4,
+		NumberReady:            2,
+		ObservedGeneration:     7,
+		UpdatedNumberScheduled: 3,
+		NumberAvailable:        2,
+		NumberUnavailable:      2,
+	}, oPb.Status)
+}
+
+func TestJobToProto(t *testing.T) {
+	var completions int32 = 5
+	var parallelism int32 = 2
+	startTime := metav1.Unix(0, 10)
+	completionTime := metav1.Unix(0, 20)
+	o := batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-1234",
+			Namespace: "a_namespace",
+			UID:       "job-uid",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind: "CronJob",
+					Name: "backup",
+					UID:  "cj-uid",
+				},
+			},
+		},
+		Spec: batch.JobSpec{
+			Completions: &completions,
+			Parallelism: &parallelism,
+		},
+		Status: batch.JobStatus{
+			StartTime:      &startTime,
+			CompletionTime: &completionTime,
+			Succeeded:      5,
+			Conditions: []batch.JobCondition{
+				{
+					Type:               batch.JobComplete,
+					Status:             v1.ConditionTrue,
+					LastProbeTime:      metav1.Unix(0, 20),
+					LastTransitionTime: metav1.Unix(0, 20),
+				},
+			},
+		},
+	}
+
+	oPb := k8s.JobToProto(&o)
+	assert.Equal(t, "backup-1234", oPb.Metadata.Name)
+	assert.Equal(t, []*metadatapb.OwnerReference{
+		{Kind: "CronJob", Name: "backup", UID: "cj-uid"},
+	}, oPb.Metadata.OwnerReferences)
+	assert.Nil(t, oPb.Spec.Selector)
+	assert.Equal(t, int32(5), oPb.Spec.Completions)
+	assert.Equal(t, int32(2), oPb.Spec.Parallelism)
+	assert.Equal(t, &metadatapb.JobStatus{
+		StartTimeNS:      10,
+		CompletionTimeNS: 20,
+		Succeeded:        5,
+		Conditions: []*metadatapb.JobCondition{
+			{
+				Type:                 "Complete",
+				Status:               metadatapb.CONDITION_STATUS_TRUE,
+				LastProbeTimeNS:      20,
+				LastTransitionTimeNS: 20,
+			},
+		},
+	}, oPb.Status)
+}
+
+func TestCronJobToProto(t *testing.T) {
+	suspend := true
+	lastSchedule := metav1.Unix(0, 30)
+	o := batch.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "a_namespace",
+			UID:       "cj-uid",
+		},
+		Spec: batch.CronJobSpec{
+			Schedule:          "*/5 * * * *",
+			ConcurrencyPolicy: batch.ForbidConcurrent,
+			Suspend:           &suspend,
+		},
+		Status: batch.CronJobStatus{
+			Active: []v1.ObjectReference{
+				{
+					Kind:      "Job",
+					Namespace: "a_namespace",
+					Name:      "backup-1234",
+					UID:       "job-uid",
+				},
+			},
+			LastScheduleTime: &lastSchedule,
+		},
+	}
+
+	oPb := k8s.CronJobToProto(&o)
+	assert.Equal(t, "cj-uid", oPb.Metadata.UID)
+	assert.Equal(t, &metadatapb.CronJobSpec{
+		Schedule:          "*/5 * * * *",
+		ConcurrencyPolicy: "Forbid",
+		Suspend:           true,
+	}, oPb.Spec)
+	assert.Equal(t, &metadatapb.CronJobStatus{
+		Active: []*metadatapb.ObjectReference{
+			{
+				Kind:      "Job",
+				Namespace: "a_namespace",
+				Name:      "backup-1234",
+				UID:       "job-uid",
+			},
+		},
+		LastScheduleTimeNS: 30,
+	}, oPb.Status)
+}
+
+func TestEndpointSlicesToProto(t *testing.T) {
+	ready := true
+	notReady := false
+	nodeA := "node-a"
+	nodeB := "node-b"
+	portName := "http"
+	port := int32(8080)
+	protocol := v1.ProtocolTCP
+	ports := []discovery.EndpointPort{{Name: &portName, Port: &port, Protocol: &protocol}}
+	serviceRef := []metav1.OwnerReference{{Kind: "Service", Name: "web", UID: "svc-uid"}}
+	sliceLabels := map[string]string{
+		discovery.LabelServiceName: "web",
+		discovery.LabelManagedBy:   "endpointslice-controller.k8s.io",
+		"app":                      "web",
+	}
+
+	slices := []*discovery.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web-b",
+				Namespace:         "ns",
+				UID:               "slice-b",
+				CreationTimestamp: metav1.Unix(0, 20),
+				Labels:            sliceLabels,
+				OwnerReferences:   serviceRef,
+			},
+			AddressType: discovery.AddressTypeIPv4,
+			Endpoints: []discovery.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.2"},
+					Conditions: discovery.EndpointConditions{Ready: &notReady},
+					NodeName:   &nodeB,
+					TargetRef:  &v1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "web-2", UID: "pod-2"},
+				},
+			},
+			Ports: ports,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web-a",
+				Namespace:         "ns",
+				UID:               "slice-a",
+				CreationTimestamp: metav1.Unix(0, 10),
+				Labels:            sliceLabels,
+				OwnerReferences:   serviceRef,
+			},
+			AddressType: discovery.AddressTypeIPv4,
+			Endpoints: []discovery.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discovery.EndpointConditions{Ready: &ready},
+					NodeName:   &nodeA,
+					TargetRef:  &v1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "web-1", UID: "pod-1"},
+				},
+			},
+			Ports: ports,
+		},
+		{
+			// IPv6 slices are ignored when IPv4 slices are present.
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web-v6",
+				Namespace:         "ns",
+				UID:               "slice-v6",
+				CreationTimestamp: metav1.Unix(0, 30),
+				Labels:            sliceLabels,
+				OwnerReferences:   serviceRef,
+			},
+			AddressType: discovery.AddressTypeIPv6,
+			Endpoints: []discovery.Endpoint{
+				{
+					Addresses: []string{"fd00::1"},
+					NodeName:  &nodeA,
+				},
+			},
+			Ports: ports,
+		},
+	}
+
+	expectedPorts := []*metadatapb.EndpointPort{{Name: "http", Port: 8080, Protocol: metadatapb.TCP}}
+	expected := &metadatapb.Endpoints{
+		Metadata: &metadatapb.ObjectMetadata{
+			Name:                "web",
+			Namespace:           "ns",
+			UID:                 "svc-uid",
+			CreationTimestampNS: 10,
+			Labels:              map[string]string{"app": "web"},
+			OwnerReferences:     []*metadatapb.OwnerReference{},
+		},
+		Subsets: []*metadatapb.EndpointSubset{
+			{
+				Addresses: []*metadatapb.EndpointAddress{
+					{
+						IP:        "10.0.0.1",
+						NodeName:  "node-a",
+						TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "web-1", UID: "pod-1"},
+					},
+				},
+				NotReadyAddresses: []*metadatapb.EndpointAddress{},
+				Ports:             expectedPorts,
+			},
+			{
+				Addresses: []*metadatapb.EndpointAddress{},
+				NotReadyAddresses: []*metadatapb.EndpointAddress{
+					{
+						IP:        "10.0.0.2",
+						NodeName:  "node-b",
+						TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "web-2", UID: "pod-2"},
+					},
+				},
+				Ports: expectedPorts,
+			},
+		},
+	}
+
+	assert.Equal(t, expected, k8s.EndpointSlicesToProto("web", slices))
+}
+
+func TestEndpointSlicesToProto_NoServiceOwner(t *testing.T) {
+	slices := []*discovery.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "custom-2",
+				Namespace:         "ns",
+				UID:               "slice-2",
+				CreationTimestamp: metav1.Unix(0, 20),
+			},
+			AddressType: discovery.AddressTypeIPv6,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "custom-1",
+				Namespace:         "ns",
+				UID:               "slice-1",
+				CreationTimestamp: metav1.Unix(0, 10),
+			},
+			AddressType: discovery.AddressTypeIPv6,
+		},
+	}
+
+	pb := k8s.EndpointSlicesToProto("custom", slices)
+	assert.Equal(t, "slice-1", pb.Metadata.UID)
+	assert.Equal(t, int64(10), pb.Metadata.CreationTimestampNS)
+	assert.Empty(t, pb.Subsets)
+}
+
+func TestIngressToProto(t *testing.T) {
+	className := "nginx"
+	prefix := networking.PathTypePrefix
+	o := networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "a_namespace",
+			UID:       "ing-uid",
+		},
+		Spec: networking.IngressSpec{
+			IngressClassName: &className,
+			TLS: []networking.IngressTLS{
+				{Hosts: []string{"example.com"}, SecretName: "tls-secret"},
+			},
+			Rules: []networking.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{
+							Paths: []networking.HTTPIngressPath{
+								{
+									Path:     "/api",
+									PathType: &prefix,
+									Backend: networking.IngressBackend{
+										Service: &networking.IngressServiceBackend{
+											Name: "api",
+											Port: networking.ServiceBackendPort{Number: 8080},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Status: networking.IngressStatus{
+			LoadBalancer: networking.IngressLoadBalancerStatus{
+				Ingress: []networking.IngressLoadBalancerIngress{
+					{IP: "1.2.3.4"},
+					{Hostname: "lb.example.com"},
+				},
+			},
+		},
+	}
+
+	oPb := k8s.IngressToProto(&o)
+	assert.Equal(t, "ing-uid", oPb.Metadata.UID)
+	assert.Equal(t, &metadatapb.IngressSpec{
+		IngressClassName: "nginx",
+		TLS: []*metadatapb.IngressTLS{
+			{Hosts: []string{"example.com"}, SecretName: "tls-secret"},
+		},
+		Rules: []*metadatapb.IngressRule{
+			{
+				Host: "example.com",
+				Paths: []*metadatapb.HTTPIngressPath{
+					{
+						Path:     "/api",
+						PathType: "Prefix",
+						Backend: &metadatapb.IngressBackend{
+							ServiceName:       "api",
+							ServicePortNumber: 8080,
+						},
+					},
+				},
+			},
+		},
+	}, oPb.Spec)
+	assert.Equal(t, &metadatapb.IngressStatus{
+		LoadBalancerIPs:       []string{"1.2.3.4"},
+		LoadBalancerHostnames: []string{"lb.example.com"},
+	}, oPb.Status)
+}
+
+func TestNetworkPolicyToProto(t *testing.T) {
+	udp := v1.ProtocolUDP
+	port := intstr.FromInt(53)
+	o := networking.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "allow-dns",
+			Namespace: "a_namespace",
+			UID:       "np-uid",
+		},
+		Spec: networking.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Egress: []networking.NetworkPolicyEgressRule{
+				{
+					Ports: []networking.NetworkPolicyPort{{Protocol: &udp, Port: &port}},
+					To: []networking.NetworkPolicyPeer{
+						{IPBlock: &networking.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+					},
+				},
+			},
+			PolicyTypes: []networking.PolicyType{networking.PolicyTypeEgress},
+		},
+	}
+
+	oPb := k8s.NetworkPolicyToProto(&o)
+	assert.Equal(t, "np-uid", oPb.Metadata.UID)
+	assert.Equal(t, &metadatapb.NetworkPolicySpec{
+		PodSelector: &metadatapb.LabelSelector{
+			MatchLabels:      map[string]string{"app": "web"},
+			MatchExpressions: []*metadatapb.LabelSelectorRequirement{},
+		},
+		Ingress: []*metadatapb.NetworkPolicyIngressRule{},
+		Egress: []*metadatapb.NetworkPolicyEgressRule{
+			{
+				Ports: []*metadatapb.NetworkPolicyPort{{Protocol: metadatapb.UDP, Port: "53"}},
+				To: []*metadatapb.NetworkPolicyPeer{
+					{IPBlock: &metadatapb.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+				},
+			},
+		},
+		PolicyTypes: []string{"Egress"},
+	}, oPb.Spec)
+}
+
+func TestHTTPRouteToProto(t *testing.T) {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":      "web",
+				"namespace": "a_namespace",
+				"uid":       "route-uid",
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{"name": "gateway"},
+				},
+				"hostnames": []interface{}{"example.com"},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"matches": []interface{}{
+							map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":  "PathPrefix",
+									"value": "/api",
+								},
+							},
+						},
+						"backendRefs": []interface{}{
+							map[string]interface{}{"name": "api", "port": int64(8080)},
+							map[string]interface{}{"name": "api-canary", "namespace": "canary", "port": int64(8080), "weight": int64(10)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	oPb, err := k8s.HTTPRouteToProto(u)
+	require.NoError(t, err)
+	assert.Equal(t, "route-uid", oPb.Metadata.UID)
+	assert.Equal(t, "a_namespace", oPb.Metadata.Namespace)
+	assert.Equal(t, &metadatapb.HTTPRouteSpec{
+		ParentRefs: []*metadatapb.GatewayParentReference{{Name: "gateway"}},
+		Hostnames:  []string{"example.com"},
+		Rules: []*metadatapb.HTTPRouteRule{
+			{
+				Matches: []*metadatapb.HTTPRouteMatch{{PathType: "PathPrefix", PathValue: "/api"}},
+				BackendRefs: []*metadatapb.HTTPBackendRef{
+					{Kind: "Service", Namespace: "a_namespace", Name: "api", Port: 8080, Weight: 1},
+					{Kind: "Service", Namespace: "canary", Name: "api-canary", Port: 8080, Weight: 10},
+				},
+			},
+		},
+	}, oPb.Spec)
+}
@@ -161,6 +161,13 @@ Status ApplyK8sUpdates(
         PX_RETURN_IF_ERROR(
             HandleDeploymentUpdate(update->deployment_update(), state, metadata_filter));
         break;
+      case ResourceUpdate::kStatefulSetUpdate:
+      case ResourceUpdate::kDaemonSetUpdate:
+      case ResourceUpdate::kJobUpdate:
+      case ResourceUpdate::kCronJobUpdate:
+        // Workload updates are not yet tracked in the agent metadata state.
+        VLOG(2) << "Skipping workload update type: " << update->update_case();
+        break;
       default:
         LOG(ERROR) << "Unhandled Update Type: " << update->update_case() << " (ignoring)";
     }
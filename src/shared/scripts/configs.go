@@ -18,9 +18,16 @@
 
 package scripts
 
+import (
+	"time"
+)
+
 // Config represents the configuration for a script. For example: which variables should be pulled in and how.
 type Config struct {
 	OtelEndpointConfig *OtelEndpointConfig `yaml:"otelEndpointConfig"`
+	Schedule           *ScheduleConfig     `yaml:"schedule"`
+	Execution          *ExecutionConfig    `yaml:"execution"`
+	Sinks              []*SinkConfig       `yaml:"sinks"`
 }
 
 // OtelEndpointConfig specifies values that should be filled in for all OTel endpoints in the script.
@@ -29,3 +36,117 @@ type OtelEndpointConfig struct {
 	Headers  map[string]string `yaml:"headers"`
 	Insecure bool              `yaml:"insecure"`
 }
+
+// ScheduleConfig specifies how the runs of a cron script are scheduled.
+type ScheduleConfig struct {
+	// Timezone is the IANA time zone that the cron expression is evaluated in. Defaults to UTC.
+	Timezone string `yaml:"timezone"`
+	// Jitter is the maximum random delay added before each scheduled run, to spread out the load of scripts that share a schedule.
+	Jitter time.Duration `yaml:"jitter"`
+	// CatchUp replays the windows that were missed while the script wasn't running (ie. while the query broker was restarting), in order. Runs are executed one at a time, regardless of MaxConcurrentRuns and OverlapPolicy. A failed window is retried after the MaxBackoff of the Retry config, up to MaxAttempts times, before moving on to the next window. Windows that fail with a compile error or an invalid argument are not retried.
+	CatchUp bool `yaml:"catchUp"`
+	// MaxCatchUpWindows limits the number of missed windows that are replayed. Older windows are skipped. Unlimited if 0.
+	MaxCatchUpWindows int `yaml:"maxCatchUpWindows"`
+}
+
+// OverlapPolicy specifies what happens to a run of a cron script that is due while its previous runs are still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapPolicySkip skips the run.
+	OverlapPolicySkip OverlapPolicy = "skip"
+	// OverlapPolicyQueue waits for a previous run to complete before starting the run.
+	OverlapPolicyQueue OverlapPolicy = "queue"
+)
+
+// ExecutionConfig specifies how the runs of a cron script are executed.
+type ExecutionConfig struct {
+	// Timeout is the deadline for each run of the script. Runs don't time out if 0.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxConcurrentRuns is the maximum number of runs of the script that execute at the same time. Defaults to 1.
+	MaxConcurrentRuns int `yaml:"maxConcurrentRuns"`
+	// OverlapPolicy specifies what happens to runs that are due while MaxConcurrentRuns runs are in progress. Defaults to skip.
+	OverlapPolicy OverlapPolicy `yaml:"overlapPolicy"`
+	// Retry specifies how runs that fail because vizier is unavailable are retried.
+	Retry *RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig specifies how a failed run is retried, with an exponential backoff between attempts.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts of a run, including the first one. Set to 1 to disable retries.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialBackoff is how long to wait before the first retry.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	// MaxBackoff is the maximum time to wait between attempts.
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+}
+
+// SinkType is the kind of destination that a sink sends result tables to.
+type SinkType string
+
+const (
+	// SinkTypeWebhook posts batches of rows as JSON to a URL.
+	SinkTypeWebhook SinkType = "webhook"
+	// SinkTypeKafka produces rows to a topic through a Kafka REST proxy.
+	SinkTypeKafka SinkType = "kafka"
+	// SinkTypeNATS publishes batches of rows as JSON to a NATS JetStream subject.
+	SinkTypeNATS SinkType = "nats"
+	// SinkTypeFile writes rows to files in a local directory.
+	SinkTypeFile SinkType = "file"
+)
+
+// SinkConfig specifies a destination that the result tables of a cron script are sent to, in addition to the OTel
+// export of the script. Delivery is at-least-once: a run that is retried after vizier becomes unavailable sends its
+// rows again, so consumers should deduplicate batches by script ID, table and start time. File sinks overwrite the
+// files of the failed attempt instead.
+type SinkConfig struct {
+	Type SinkType `yaml:"type"`
+	// Tables limits the sink to the result tables with these names. All tables are sent if empty.
+	Tables []string `yaml:"tables"`
+	// BatchSize is the maximum number of rows sent at once. Defaults to 1000.
+	BatchSize int `yaml:"batchSize"`
+	// Retry specifies how sending a batch is retried when it fails.
+	Retry *RetryConfig `yaml:"retry"`
+
+	Webhook *WebhookSinkConfig `yaml:"webhook"`
+	Kafka   *KafkaSinkConfig   `yaml:"kafka"`
+	NATS    *NATSSinkConfig    `yaml:"nats"`
+	File    *FileSinkConfig    `yaml:"file"`
+}
+
+// WebhookSinkConfig specifies the URL that a webhook sink posts batches to.
+type WebhookSinkConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// KafkaSinkConfig specifies the Kafka REST proxy (v2 API) and topic that a kafka sink produces rows to.
+type KafkaSinkConfig struct {
+	URL     string            `yaml:"url"`
+	Topic   string            `yaml:"topic"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// NATSSinkConfig specifies the NATS server and JetStream subject that a nats sink publishes batches to.
+type NATSSinkConfig struct {
+	URL     string `yaml:"url"`
+	Subject string `yaml:"subject"`
+}
+
+// FileFormat is the format of the files written by a file sink.
+type FileFormat string
+
+const (
+	// FileFormatNDJSON writes newline-delimited JSON.
+	FileFormatNDJSON FileFormat = "ndjson"
+	// FileFormatParquet writes parquet.
+	FileFormatParquet FileFormat = "parquet"
+)
+
+// FileSinkConfig specifies where a file sink writes result tables to. Each run of the script writes a file per table to
+// "<dir>/<script ID>/<run start time>/".
+type FileSinkConfig struct {
+	Dir string `yaml:"dir"`
+	// Format defaults to ndjson.
+	Format FileFormat `yaml:"format"`
+}
@@ -66,6 +66,14 @@ func ProtoToToken(pb *jwtpb.JWTClaims) (jwt.Token, error) {
 		builder.Claim("ServiceID", m.ServiceClaims.ServiceID)
 	case *jwtpb.JWTClaims_ClusterClaims:
 		builder.Claim("ClusterID", m.ClusterClaims.ClusterID)
+		// The forwarded user uses separate keys so that the token is not mistaken for user claims.
+		if u := m.ClusterClaims.ForwardedUser; u != nil {
+			builder.
+				Claim("ForwardedUserID", u.UserID).
+				Claim("ForwardedOrgID", u.OrgID).
+				Claim("ForwardedEmail", u.Email).
+				Claim("ForwardedIsAPIUser", u.IsAPIUser)
+		}
 	default:
 		log.WithField("type", m).Error("Could not find claims type")
 	}
@@ -110,7 +118,8 @@ func TokenToProto(token jwt.Token) (*jwtpb.JWTClaims, error) {
 	case HasClusterClaims(token):
 		p.CustomClaims = &jwtpb.JWTClaims_ClusterClaims{
 			ClusterClaims: &jwtpb.ClusterJWTClaims{
-				ClusterID: GetClusterID(token),
+				ClusterID:     GetClusterID(token),
+				ForwardedUser: GetForwardedUser(token),
 			},
 		}
 	}
@@ -224,6 +233,21 @@ func GetClusterID(t jwt.Token) string {
 	return clusterID.(string)
 }
 
+// GetForwardedUser fetches the user that a cluster token was issued on behalf of from the
+// custom claims, or nil if the token was not issued on behalf of a user.
+func GetForwardedUser(t jwt.Token) *jwtpb.UserJWTClaims {
+	claims := t.PrivateClaims()
+	userID, ok := claims["ForwardedUserID"].(string)
+	if !ok {
+		return nil
+	}
+	u := &jwtpb.UserJWTClaims{UserID: userID}
+	u.OrgID, _ = claims["ForwardedOrgID"].(string)
+	u.Email, _ = claims["ForwardedEmail"].(string)
+	u.IsAPIUser, _ = claims["ForwardedIsAPIUser"].(bool)
+	return u
+}
+
 // HasUserClaims checks if the custom claims include UserClaims.
 func HasUserClaims(t jwt.Token) bool {
 	claims := t.PrivateClaims()
@@ -126,6 +126,35 @@ func TestProtoToToken_Cluster(t *testing.T) {
 	assert.Equal(t, "cluster_id", utils.GetClusterID(token))
 }
 
+func TestProtoToToken_ClusterWithForwardedUser(t *testing.T) {
+	p := getStandardClaimsPb()
+	p.Scopes = []string{"cluster"}
+	p.CustomClaims = &jwtpb.JWTClaims_ClusterClaims{
+		ClusterClaims: &jwtpb.ClusterJWTClaims{
+			ClusterID: "cluster_id",
+			ForwardedUser: &jwtpb.UserJWTClaims{
+				UserID:    "user_id",
+				OrgID:     "org_id",
+				Email:     "user@email.com",
+				IsAPIUser: true,
+			},
+		},
+	}
+
+	token, err := utils.ProtoToToken(p)
+	require.NoError(t, err)
+
+	assert.True(t, utils.HasClusterClaims(token))
+	assert.False(t, utils.HasUserClaims(token))
+	assert.Equal(t, "cluster_id", utils.GetClusterID(token))
+	assert.Equal(t, &jwtpb.UserJWTClaims{
+		UserID:    "user_id",
+		OrgID:     "org_id",
+		Email:     "user@email.com",
+		IsAPIUser: true,
+	}, utils.GetForwardedUser(token))
+}
+
 func TestTokenToProto_Standard(t *testing.T) {
 	builder := getStandardClaimsBuilder()
 
@@ -192,6 +221,29 @@ func TestTokenToProto_Cluster(t *testing.T) {
 	assert.Equal(t, []string{"cluster"}, pb.Scopes)
 	customClaims := pb.GetClusterClaims()
 	assert.Equal(t, "cluster_id", customClaims.ClusterID)
+	assert.Nil(t, customClaims.ForwardedUser)
+}
+
+func TestTokenToProto_ClusterWithForwardedUser(t *testing.T) {
+	builder := getStandardClaimsBuilder().
+		Claim("Scopes", "cluster").
+		Claim("ClusterID", "cluster_id").
+		Claim("ForwardedUserID", "user_id").
+		Claim("ForwardedOrgID", "org_id").
+		Claim("ForwardedEmail", "user@email.com").
+		Claim("ForwardedIsAPIUser", false)
+
+	token, err := builder.Build()
+	require.NoError(t, err)
+
+	pb, err := utils.TokenToProto(token)
+	require.NoError(t, err)
+	customClaims := pb.GetClusterClaims()
+	assert.Equal(t, "cluster_id", customClaims.ClusterID)
+	assert.Equal(t, "user_id", customClaims.ForwardedUser.UserID)
+	assert.Equal(t, "org_id", customClaims.ForwardedUser.OrgID)
+	assert.Equal(t, "user@email.com", customClaims.ForwardedUser.Email)
+	assert.Equal(t, false, customClaims.ForwardedUser.IsAPIUser)
 }
 
 func TestTokenToProto_FailNoAudience(t *testing.T) {
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package script
+
+import (
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vispb"
+)
+
+// ErrInvalidArgument specifies that a script argument does not match the type of its variable.
+var ErrInvalidArgument = vis.ErrInvalidArgument
+
+// ArgValidator validates a raw argument value and returns the value in the form expected by the PxL compiler.
+type ArgValidator = vis.ArgValidator
+
+// ValidatorForVariable returns the validator for the type of the given vis variable.
+func ValidatorForVariable(v *vispb.Vis_Variable) ArgValidator {
+	return vis.ValidatorForVariable(v)
+}
+
+// ValidateArgValue checks that the value matches the type of the vis variable, and returns the value in the
+// form expected by the PxL compiler. Empty values are always accepted, since they denote an optional argument.
+func ValidateArgValue(v *vispb.Vis_Variable, value string) (string, error) {
+	return vis.ValidateArgValue(v, value)
+}
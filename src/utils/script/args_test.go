@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package script_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/utils/script"
+)
+
+func TestValidateArgValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		variable    *vispb.Vis_Variable
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{"int64", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_INT64}, "10", "10", false},
+		{"bad int64", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_INT64}, "ten", "", true},
+		{"bool", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_BOOLEAN}, "false", "False", false},
+		{"bool yes", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_BOOLEAN}, "Yes", "True", false},
+		{"bool n", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_BOOLEAN}, "n", "False", false},
+		{"bad bool", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_BOOLEAN}, "on", "", true},
+		{"duration", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_DURATION}, "-30s", "-30s", false},
+		{"bad duration", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_DURATION}, "30", "", true},
+		{"string list", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_STRING_LIST}, "x, y", `["x","y"]`, false},
+		{"enum", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_STRING, ValidValues: []string{"x", "y"}}, "y", "y", false},
+		{"bad enum", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_STRING, ValidValues: []string{"x", "y"}}, "z", "", true},
+		{"optional", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_INT64}, "", "", false},
+		{"pod", &vispb.Vis_Variable{Name: "a", Type: vispb.PX_POD}, "pl/pod-123", "pl/pod-123", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := script.ValidateArgValue(test.variable, test.value)
+			if test.expectError {
+				assert.True(t, errors.Is(err, script.ErrInvalidArgument))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, val)
+		})
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+
+	"px.dev/pixie/src/api/go/pxapi/vis"
 )
 
 // ErrMissingRequiredArgument specifies that a required script flag has not been provided.
@@ -36,6 +38,39 @@ type FlagSet struct {
 	// Keeps track of which args have values (whether it is a default value or a passed in value)
 	// Used to differentiate between an unset arg and an arg that has an empty default value.
 	argHasValue map[string]bool
+	// Typed args, which are validated when they are set.
+	typedArgs map[string]*argValue
+}
+
+// argValue is a flag.Value that validates values as they are set.
+type argValue struct {
+	value    string
+	validate ArgValidator
+	// err is the error from validating the default value. It is cleared once a valid value is set.
+	err error
+}
+
+func (a *argValue) String() string {
+	if a == nil {
+		return ""
+	}
+	return a.value
+}
+
+func (a *argValue) Set(s string) error {
+	// Empty values denote an optional argument that hasn't been specified.
+	if s == "" {
+		a.value = s
+		a.err = nil
+		return nil
+	}
+	v, err := a.validate(s)
+	if err != nil {
+		return err
+	}
+	a.value = v
+	a.err = nil
+	return nil
 }
 
 // NewFlagSet creates a new FlagSet.
@@ -43,6 +78,7 @@ func NewFlagSet(scriptName string) *FlagSet {
 	return &FlagSet{
 		baseFlagSet: flag.NewFlagSet(scriptName, flag.ContinueOnError),
 		argHasValue: make(map[string]bool),
+		typedArgs:   make(map[string]*argValue),
 	}
 }
 
@@ -58,6 +94,54 @@ func (f *FlagSet) String(name string, defaultValue *string, usage string) {
 	}
 }
 
+// Var declares an argument whose values are checked by the validator.
+// Like String, defaultValue is allowed to be nil. The default value is validated here, and an invalid
+// default value is reported when the flags are parsed or looked up.
+func (f *FlagSet) Var(name string, validate ArgValidator, defaultValue *string, usage string) {
+	f.argHasValue[name] = defaultValue != nil
+	a := &argValue{validate: validate}
+	if defaultValue != nil {
+		if err := a.Set(*defaultValue); err != nil {
+			a.value = *defaultValue
+			a.err = err
+		}
+	} else {
+		usage = fmt.Sprintf("(required) %s", usage)
+	}
+	f.typedArgs[name] = a
+	f.baseFlagSet.Var(a, name, usage)
+}
+
+// Int64 declares an argument that must be a valid int64.
+func (f *FlagSet) Int64(name string, defaultValue *string, usage string) {
+	f.Var(name, vis.ValidateInt64, defaultValue, usage)
+}
+
+// Float64 declares an argument that must be a valid float.
+func (f *FlagSet) Float64(name string, defaultValue *string, usage string) {
+	f.Var(name, vis.ValidateFloat64, defaultValue, usage)
+}
+
+// Bool declares an argument that must be a valid boolean. Unlike flag.FlagSet's Bool, a value is always required.
+func (f *FlagSet) Bool(name string, defaultValue *string, usage string) {
+	f.Var(name, vis.ValidateBool, defaultValue, usage)
+}
+
+// Duration declares an argument that must be a PxL duration (ie. "-5m").
+func (f *FlagSet) Duration(name string, defaultValue *string, usage string) {
+	f.Var(name, vis.ValidateDuration, defaultValue, usage)
+}
+
+// Enum declares an argument that must be one of the valid values.
+func (f *FlagSet) Enum(name string, validValues []string, defaultValue *string, usage string) {
+	f.Var(name, vis.EnumValidator(validValues), defaultValue, usage)
+}
+
+// List declares a list argument, which can be passed in as a comma separated list.
+func (f *FlagSet) List(name string, defaultValue *string, usage string) {
+	f.Var(name, vis.ValidateList, defaultValue, usage)
+}
+
 // Parse wraps flag.FlagSet's Parse function to parse args.
 func (f *FlagSet) Parse(arguments []string) error {
 	// Get the flag values defined, so we can mark which ones are actually set.
@@ -72,7 +156,20 @@ func (f *FlagSet) Parse(arguments []string) error {
 		}
 		f.argHasValue[splits[0]] = true
 	}
-	return f.baseFlagSet.Parse(arguments)
+	if err := f.baseFlagSet.Parse(arguments); err != nil {
+		return err
+	}
+	return f.validateDefaults()
+}
+
+// validateDefaults checks the default values of the typed args that weren't passed in.
+func (f *FlagSet) validateDefaults() error {
+	for name, a := range f.typedArgs {
+		if a.err != nil {
+			return fmt.Errorf("%w: invalid default value for '%s': %v", ErrInvalidArgument, name, a.err)
+		}
+	}
+	return nil
 }
 
 // Set wraps flag.FlagSet's Set function.
@@ -85,6 +182,9 @@ func (f *FlagSet) Set(name, value string) error {
 // look up a required arg (without a default value) that hasn't been set.
 func (f *FlagSet) Lookup(name string) (string, error) {
 	if f.argHasValue[name] {
+		if a, ok := f.typedArgs[name]; ok && a.err != nil {
+			return "", fmt.Errorf("%w: invalid default value for '%s': %v", ErrInvalidArgument, name, a.err)
+		}
 		return f.baseFlagSet.Lookup(name).Value.String(), nil
 	}
 	return "", fmt.Errorf("%w : '%s'", ErrMissingRequiredArgument, name)
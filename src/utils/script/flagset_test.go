@@ -20,6 +20,7 @@ package script_test
 
 import (
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -118,3 +119,95 @@ func TestMissingRequiredFlags(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, f4, "6")
 }
+
+func TestTypedFlags(t *testing.T) {
+	flags := script.NewFlagSet("px/typed")
+	defaultDuration := "-5m"
+	defaultBool := "true"
+	flags.Int64("limit", nil, "an int flag")
+	flags.Float64("ratio", nil, "a float flag")
+	flags.Bool("show_all", &defaultBool, "a bool flag")
+	flags.Duration("start_time", &defaultDuration, "a duration flag")
+	flags.Enum("kind", []string{"pod", "svc"}, nil, "an enum flag")
+	flags.List("names", nil, "a list flag")
+
+	flagVals := []string{
+		"--limit=100",
+		"--ratio", "0.5",
+		"--kind", "svc",
+		"--names", "a,b",
+	}
+	require.NoError(t, flags.Parse(flagVals))
+
+	expected := map[string]string{
+		"limit":      "100",
+		"ratio":      "0.5",
+		"show_all":   "True",
+		"start_time": "-5m",
+		"kind":       "svc",
+		"names":      `["a","b"]`,
+	}
+	for name, val := range expected {
+		actual, err := flags.Lookup(name)
+		require.NoError(t, err)
+		assert.Equal(t, val, actual, name)
+	}
+}
+
+func TestInvalidTypedFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"int", []string{"--limit=abc"}},
+		{"float", []string{"--ratio=1.2.3"}},
+		{"bool", []string{"--show_all=maybe"}},
+		{"duration", []string{"--start_time=5 minutes"}},
+		{"enum", []string{"--kind=node"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := script.NewFlagSet("px/typed")
+			flags.Int64("limit", nil, "an int flag")
+			flags.Float64("ratio", nil, "a float flag")
+			flags.Bool("show_all", nil, "a bool flag")
+			flags.Duration("start_time", nil, "a duration flag")
+			flags.Enum("kind", []string{"pod", "svc"}, nil, "an enum flag")
+			flags.SetOutput(io.Discard)
+
+			assert.Error(t, flags.Parse(test.flags))
+		})
+	}
+}
+
+func TestInvalidDefaultFlag(t *testing.T) {
+	flags := script.NewFlagSet("px/typed")
+	badDefault := "-5 minutes"
+	flags.Duration("start_time", &badDefault, "a duration flag")
+
+	_, err := flags.Lookup("start_time")
+	assert.True(t, errors.Is(err, script.ErrInvalidArgument))
+
+	err = flags.Parse([]string{})
+	assert.True(t, errors.Is(err, script.ErrInvalidArgument))
+
+	// Passing in a valid value overrides the bad default.
+	require.NoError(t, flags.Parse([]string{"--start_time=-1h"}))
+	val, err := flags.Lookup("start_time")
+	require.NoError(t, err)
+	assert.Equal(t, "-1h", val)
+}
+
+func TestDefaultFlagIsParsedOnce(t *testing.T) {
+	flags := script.NewFlagSet("px/typed")
+	defaultValue := "yes"
+	flags.Bool("show_all", &defaultValue, "a bool flag")
+
+	// The default value is converted when it is declared, so looking it up doesn't change it.
+	for i := 0; i < 2; i++ {
+		val, err := flags.Lookup("show_all")
+		require.NoError(t, err)
+		assert.Equal(t, "True", val)
+	}
+}
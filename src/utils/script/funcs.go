@@ -0,0 +1,40 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package script
+
+import (
+	"px.dev/pixie/src/api/go/pxapi/vis"
+	"px.dev/pixie/src/api/proto/vizierpb"
+)
+
+// GetFuncsToExecute extracts the funcs to execute from the script.
+func GetFuncsToExecute(script *ExecutableScript) ([]*vizierpb.ExecuteScriptRequest_FuncToExecute, error) {
+	if script.Vis == nil {
+		return []*vizierpb.ExecuteScriptRequest_FuncToExecute{}, nil
+	}
+	computedArgs, err := script.ComputedArgs()
+	if err != nil {
+		return nil, err
+	}
+	argValues := make(map[string]string, len(computedArgs))
+	for _, arg := range computedArgs {
+		argValues[arg.Name] = arg.Value
+	}
+	return vis.FuncsToExecute(script.Vis, argValues)
+}
@@ -20,20 +20,15 @@ package script
 
 import (
 	"fmt"
-	"io"
 	"net/url"
 	"strings"
 
-	"github.com/gogo/protobuf/jsonpb"
 	"github.com/spf13/viper"
 
+	"px.dev/pixie/src/api/go/pxapi/vis"
 	"px.dev/pixie/src/api/proto/vispb"
 )
 
-var jsonUnmashaler = &jsonpb.Unmarshaler{
-	AllowUnknownFields: true,
-}
-
 // Arg is a single script argument.
 type Arg struct {
 	Name  string
@@ -85,12 +80,7 @@ func (e ExecutableScript) LiveViewLink(clusterID *string) string {
 
 // ParseVisSpec parses the spec return nil on failure.
 func ParseVisSpec(specJSON string) (*vispb.Vis, error) {
-	var pb vispb.Vis
-	err := jsonUnmashaler.Unmarshal(strings.NewReader(specJSON), &pb)
-	if err != nil && err != io.EOF {
-		return nil, err
-	}
-	return &pb, nil
+	return vis.ParseSpec(specJSON)
 }
 
 // UpdateFlags updates the flags based on the passed in flag set.
@@ -142,7 +132,23 @@ func (e *ExecutableScript) GetFlagSet() *FlagSet {
 		if v.DefaultValue != nil {
 			defaultValue = &v.DefaultValue.Value
 		}
-		fs.String(v.Name, defaultValue, fmt.Sprintf("Type: %s", v.Type))
+		usage := fmt.Sprintf("Type: %s", v.Type)
+		switch {
+		case len(v.ValidValues) > 0:
+			fs.Enum(v.Name, v.ValidValues, defaultValue, fmt.Sprintf("%s, one of: %s", usage, strings.Join(v.ValidValues, ", ")))
+		case v.Type == vispb.PX_BOOLEAN:
+			fs.Bool(v.Name, defaultValue, usage)
+		case v.Type == vispb.PX_INT64:
+			fs.Int64(v.Name, defaultValue, usage)
+		case v.Type == vispb.PX_FLOAT64:
+			fs.Float64(v.Name, defaultValue, usage)
+		case v.Type == vispb.PX_DURATION:
+			fs.Duration(v.Name, defaultValue, usage)
+		case v.Type == vispb.PX_LIST, v.Type == vispb.PX_STRING_LIST:
+			fs.List(v.Name, defaultValue, usage)
+		default:
+			fs.String(v.Name, defaultValue, usage)
+		}
 	}
 	return fs
 }
@@ -132,6 +132,9 @@ type Manager interface {
 	GetComputedSchema() (*storepb.ComputedSchema, error)
 	// GetAgentIDForHostnamePair gets the agent for the given hostnamePair, if it exists.
 	GetAgentIDForHostnamePair(hnPair *HostnameIPPair) (string, error)
+	// GetProcesses gets the info for the processes with the given UPIDs. Terminated processes are only
+	// kept for a limited time.
+	GetProcesses(upids []*types.UInt128) ([]*metadatapb.ProcessInfo, error)
 
 	// GetServiceCIDR returns the service CIDR for the current cluster.
 	GetServiceCIDR() string
@@ -698,6 +701,12 @@ func (m *ManagerImpl) GetAgentIDForHostnamePair(hnPair *HostnameIPPair) (string,
 	return m.agtStore.GetAgentIDForHostnamePair(hnPair)
 }
 
+// GetProcesses gets the info for the processes with the given UPIDs. Terminated processes are only
+// kept for a limited time.
+func (m *ManagerImpl) GetProcesses(upids []*types.UInt128) ([]*metadatapb.ProcessInfo, error) {
+	return m.agtStore.GetProcesses(upids)
+}
+
 // GetServiceCIDR returns the service CIDR for the current cluster.
 func (m *ManagerImpl) GetServiceCIDR() string {
 	return m.cidr.GetServiceCIDR()
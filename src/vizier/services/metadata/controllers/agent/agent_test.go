@@ -420,6 +420,10 @@ func TestApplyUpdates(t *testing.T) {
 	assert.Equal(t, cProcessInfo[0], pInfos[0])
 	assert.Equal(t, cProcessInfo[1], pInfos[1])
 
+	pInfos, err = agtMgr.GetProcesses([]*types.UInt128{upid1})
+	require.NoError(t, err)
+	assert.Equal(t, []*k8s_metadatapb.ProcessInfo{cProcessInfo[0]}, pInfos)
+
 	dataInfos, err := ads.GetAgentsDataInfo()
 	require.NoError(t, err)
 	assert.NotNil(t, dataInfos)
@@ -69,6 +69,11 @@ func setup(t *testing.T, sendMsgFn controllers.SendMessageFn) (*controllers.Agen
 
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	agentInfo := new(agentpb.Agent)
 	if err := proto.UnmarshalText(testutils.UnhealthyKelvinAgentInfo, agentInfo); err != nil {
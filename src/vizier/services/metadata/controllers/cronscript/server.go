@@ -23,6 +23,9 @@ import (
 	"sync"
 
 	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
@@ -38,6 +41,8 @@ type Store interface {
 	SetCronScripts(scripts []*cvmsgspb.CronScript) error
 	RecordCronScriptResult(*storepb.CronScriptResult) error
 	GetAllCronScriptResults() ([]*storepb.CronScriptResult, error)
+	GetCronScriptCheckpoint(id uuid.UUID) (*types.Timestamp, error)
+	SetCronScriptCheckpoint(id uuid.UUID, checkpoint *types.Timestamp) error
 }
 
 // Server is an implementation of the cronscriptstore service.
@@ -164,3 +169,26 @@ func (s *Server) GetAllExecutionResults(ctx context.Context, req *metadatapb.Get
 	}
 	return resp, nil
 }
+
+// GetScriptCheckpoint returns the end of the last time window that a cron script was run for.
+func (s *Server) GetScriptCheckpoint(ctx context.Context, req *metadatapb.GetScriptCheckpointRequest) (*metadatapb.GetScriptCheckpointResponse, error) {
+	checkpoint, err := s.ds.GetCronScriptCheckpoint(utils.UUIDFromProtoOrNil(req.ScriptID))
+	if err != nil {
+		return nil, err
+	}
+	return &metadatapb.GetScriptCheckpointResponse{
+		Checkpoint: checkpoint,
+	}, nil
+}
+
+// SetScriptCheckpoint stores the end of the last time window that a cron script was run for.
+func (s *Server) SetScriptCheckpoint(ctx context.Context, req *metadatapb.SetScriptCheckpointRequest) (*metadatapb.SetScriptCheckpointResponse, error) {
+	if req.Checkpoint == nil {
+		return nil, status.Error(codes.InvalidArgument, "checkpoint must be set")
+	}
+	err := s.ds.SetCronScriptCheckpoint(utils.UUIDFromProtoOrNil(req.ScriptID), req.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &metadatapb.SetScriptCheckpointResponse{}, nil
+}
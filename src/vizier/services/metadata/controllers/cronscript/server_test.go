@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -137,3 +138,45 @@ func TestSetScripts(t *testing.T) {
 
 	assert.Equal(t, &metadatapb.SetScriptsResponse{}, resp)
 }
+
+func TestGetScriptCheckpoint(t *testing.T) {
+	// Set up mock.
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_cronscript.NewMockStore(ctrl)
+
+	ts := &types.Timestamp{Seconds: 1234}
+	mockStore.EXPECT().GetCronScriptCheckpoint(uuid.FromStringOrNil("223e4567-e89b-12d3-a456-426655440000")).Return(ts, nil)
+
+	s := cronscript.New(mockStore)
+
+	resp, err := s.GetScriptCheckpoint(context.Background(), &metadatapb.GetScriptCheckpointRequest{
+		ScriptID: utils.ProtoFromUUIDStrOrNil("223e4567-e89b-12d3-a456-426655440000"),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, &metadatapb.GetScriptCheckpointResponse{Checkpoint: ts}, resp)
+}
+
+func TestSetScriptCheckpoint(t *testing.T) {
+	// Set up mock.
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_cronscript.NewMockStore(ctrl)
+
+	ts := &types.Timestamp{Seconds: 1234}
+	mockStore.EXPECT().SetCronScriptCheckpoint(uuid.FromStringOrNil("223e4567-e89b-12d3-a456-426655440000"), ts).Return(nil)
+
+	s := cronscript.New(mockStore)
+
+	resp, err := s.SetScriptCheckpoint(context.Background(), &metadatapb.SetScriptCheckpointRequest{
+		ScriptID:   utils.ProtoFromUUIDStrOrNil("223e4567-e89b-12d3-a456-426655440000"),
+		Checkpoint: ts,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, &metadatapb.SetScriptCheckpointResponse{}, resp)
+
+	_, err = s.SetScriptCheckpoint(context.Background(), &metadatapb.SetScriptCheckpointRequest{
+		ScriptID: utils.ProtoFromUUIDStrOrNil("223e4567-e89b-12d3-a456-426655440000"),
+	})
+	require.Error(t, err)
+}
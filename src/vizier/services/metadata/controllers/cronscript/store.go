@@ -25,7 +25,7 @@ import (
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
-	log "github.com/sirupsen/logrus"
+	"github.com/gogo/protobuf/types"
 
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
@@ -36,9 +36,14 @@ import (
 const (
 	cronScriptPrefix    = "/cronScript/"
 	scriptResultsPrefix = "/cronScriptResults"
+	checkpointPrefix    = "/cronScriptCheckpoint/"
 	// The maximum results we store per CronScript. Note if you change this, you must ensure this value is than 10000
 	// otherwise the string formatter will fail and you'll run into issues related to the prefix.
 	maxResultsPerCronScript = 10
+	// The maximum number of operations SetCronScripts can apply. Its changes are written in a single transaction,
+	// and etcd limits a transaction to 128 operations. Each added or changed script takes one operation, and each
+	// removed script takes three.
+	maxSetCronScriptsOps = 128
 )
 
 // Datastore implements the CronScriptStore interface on a given Datastore.
@@ -78,6 +83,10 @@ func getCronScriptResultsIndexKey(scriptID uuid.UUID) string {
 	return path.Join(scriptResultsPrefix, scriptID.String(), "index")
 }
 
+func getCronScriptCheckpointKey(scriptID uuid.UUID) string {
+	return path.Join(checkpointPrefix, scriptID.String())
+}
+
 // GetCronScripts fetches all scripts in the cron script store.
 func (t *Datastore) GetCronScripts() ([]*cvmsgspb.CronScript, error) {
 	_, vals, err := t.ds.GetWithPrefix(cronScriptPrefix)
@@ -111,55 +120,64 @@ func (t *Datastore) UpsertCronScript(script *cvmsgspb.CronScript) error {
 
 // DeleteCronScript deletes a cron script from the store by ID.
 func (t *Datastore) DeleteCronScript(id uuid.UUID) error {
-	err := t.ds.DeleteWithPrefix(getCronScriptKey(id))
-	if err != nil {
-		return err
-	}
-	return t.ds.DeleteWithPrefix(getCronScriptResultsKey(id))
+	txn := t.ds.Txn()
+	deleteCronScript(txn, id)
+	return txn.Commit()
+}
+
+// deleteCronScript adds the deletes for the cron script, its checkpoint and its results to the transaction.
+func deleteCronScript(txn datastore.Txn, id uuid.UUID) {
+	txn.DeleteWithPrefix(getCronScriptKey(id))
+	txn.Delete(getCronScriptCheckpointKey(id))
+	txn.DeleteWithPrefix(getCronScriptResultsKey(id))
 }
 
-// SetCronScripts sets the list of all cron scripts to match the given set of scripts.
+// SetCronScripts sets the list of all cron scripts to match the given set of scripts. Only the scripts that
+// changed are written, and removed scripts are deleted along with their checkpoints and results, all in a single
+// transaction. It fails without writing anything if the changes take more than maxSetCronScriptsOps operations.
 func (t *Datastore) SetCronScripts(scripts []*cvmsgspb.CronScript) error {
 	existingScripts, err := t.GetCronScripts()
 	if err != nil {
 		return err
 	}
-	removedScriptIDs := make(map[uuid.UUID]struct{})
+	removedScripts := make(map[uuid.UUID]*cvmsgspb.CronScript)
 	for _, es := range existingScripts {
 		if es == nil {
 			continue
 		}
-		removedScriptIDs[utils.UUIDFromProtoOrNil(es.ID)] = struct{}{}
+		removedScripts[utils.UUIDFromProtoOrNil(es.ID)] = es
 	}
 
-	// Update with new scripts.
-	// We may want to make this a MultiSetter at some point.
-	var lastError error
+	var changedScripts []*cvmsgspb.CronScript
 	for _, s := range scripts {
-		err = t.UpsertCronScript(s)
-		if err != nil {
-			lastError = err
-			log.WithError(err).Error("Failed to add cron script")
-		}
-
+		sID := utils.UUIDFromProtoOrNil(s.ID)
+		es, ok := removedScripts[sID]
 		// A script that appears here was not removed.
 		// It is safe to delete elements that don't exist in the original map.
-		delete(removedScriptIDs, utils.UUIDFromProtoOrNil(s.ID))
+		delete(removedScripts, sID)
+		if ok && proto.Equal(es, s) {
+			continue
+		}
+		changedScripts = append(changedScripts, s)
 	}
 
-	if lastError != nil {
-		return lastError
+	numOps := len(changedScripts) + 3*len(removedScripts)
+	if numOps > maxSetCronScriptsOps {
+		return fmt.Errorf("setting cron scripts requires %d operations, more than the maximum of %d", numOps, maxSetCronScriptsOps)
 	}
 
-	// Delete all results for removed scripts
-	for k := range removedScriptIDs {
-		err := t.DeleteCronScript(k)
+	txn := t.ds.Txn()
+	for _, s := range changedScripts {
+		val, err := s.Marshal()
 		if err != nil {
-			lastError = err
+			return err
 		}
+		txn.Set(getCronScriptKey(utils.UUIDFromProtoOrNil(s.ID)), string(val))
 	}
-
-	return lastError
+	for id := range removedScripts {
+		deleteCronScript(txn, id)
+	}
+	return txn.Commit()
 }
 
 // GetCronScriptResults returns the results of past runs of a specific CronScript.
@@ -208,12 +226,11 @@ func (t *Datastore) RecordCronScriptResult(result *storepb.CronScriptResult) err
 	if err != nil {
 		return err
 	}
-	err = t.ds.Set(getCronScriptSpecificResultKey(scriptID, idx), string(val))
-	if err != nil {
-		return err
-	}
+	txn := t.ds.Txn()
+	txn.Set(getCronScriptSpecificResultKey(scriptID, idx), string(val))
 	// Increment the index.
-	return t.ds.Set(getCronScriptResultsIndexKey(scriptID), fmt.Sprint((idx+1)%maxResultsPerCronScript))
+	txn.Set(getCronScriptResultsIndexKey(scriptID), fmt.Sprint((idx+1)%maxResultsPerCronScript))
+	return txn.Commit()
 }
 
 // GetAllCronScriptResults returns all of the stored execution results for all scripts.
@@ -236,3 +253,29 @@ func (t *Datastore) GetAllCronScriptResults() ([]*storepb.CronScriptResult, erro
 	}
 	return results, nil
 }
+
+// GetCronScriptCheckpoint returns the end of the last window the script was run for, or nil if there is none.
+func (t *Datastore) GetCronScriptCheckpoint(id uuid.UUID) (*types.Timestamp, error) {
+	val, err := t.ds.Get(getCronScriptCheckpointKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(val) == 0 {
+		return nil, nil
+	}
+	ts := &types.Timestamp{}
+	err = proto.Unmarshal(val, ts)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// SetCronScriptCheckpoint stores the end of the last window the script was run for.
+func (t *Datastore) SetCronScriptCheckpoint(id uuid.UUID, checkpoint *types.Timestamp) error {
+	val, err := checkpoint.Marshal()
+	if err != nil {
+		return err
+	}
+	return t.ds.Set(getCronScriptCheckpointKey(id), string(val))
+}
@@ -19,6 +19,7 @@
 package cronscript
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -179,6 +180,43 @@ func TestStore_SetCronScripts(t *testing.T) {
 	assert.Contains(t, ids, utils.ProtoToUUIDStr(s4.ID))
 }
 
+func TestStore_SetCronScripts_OnlyWritesChangedScripts(t *testing.T) {
+	db, ds, cleanup := setupTest(t)
+	defer cleanup()
+
+	s1 := &cvmsgspb.CronScript{
+		ID:     utils.ProtoFromUUID(uuid.Must(uuid.NewV4())),
+		Script: "px.display()",
+	}
+	s2 := &cvmsgspb.CronScript{
+		ID:     utils.ProtoFromUUID(uuid.Must(uuid.NewV4())),
+		Script: "px.display()",
+	}
+	require.NoError(t, ds.SetCronScripts([]*cvmsgspb.CronScript{s1, s2}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := db.Watch(ctx, cronScriptPrefix)
+	require.NoError(t, err)
+
+	updatedS2 := &cvmsgspb.CronScript{
+		ID:     s2.ID,
+		Script: "px.display(px.DataFrame('http_events'))",
+	}
+	s3 := &cvmsgspb.CronScript{
+		ID: utils.ProtoFromUUID(uuid.Must(uuid.NewV4())),
+	}
+	require.NoError(t, ds.SetCronScripts([]*cvmsgspb.CronScript{s1, updatedS2, s3}))
+
+	require.Len(t, ch, 2)
+	assert.Equal(t, getCronScriptKey(utils.UUIDFromProtoOrNil(s2.ID)), (<-ch).Key)
+	assert.Equal(t, getCronScriptKey(utils.UUIDFromProtoOrNil(s3.ID)), (<-ch).Key)
+
+	scripts, err := ds.GetCronScripts()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*cvmsgspb.CronScript{s1, updatedS2, s3}, scripts)
+}
+
 func TestStore_SetCronScripts_RemovesResultsForRemovedScripts(t *testing.T) {
 	_, ds, cleanup := setupTest(t)
 	defer cleanup()
@@ -250,6 +288,29 @@ func TestStore_SetCronScripts_RemovesResultsForRemovedScripts(t *testing.T) {
 	assert.Equal(t, 1, len(allCronScriptResults))
 }
 
+func TestStore_SetCronScripts_TooManyChanges(t *testing.T) {
+	_, ds, cleanup := setupTest(t)
+	defer cleanup()
+
+	s1 := &cvmsgspb.CronScript{
+		ID: utils.ProtoFromUUID(uuid.Must(uuid.NewV4())),
+	}
+	require.NoError(t, ds.SetCronScripts([]*cvmsgspb.CronScript{s1}))
+
+	scripts := make([]*cvmsgspb.CronScript, maxSetCronScriptsOps+1)
+	for i := range scripts {
+		scripts[i] = &cvmsgspb.CronScript{
+			ID: utils.ProtoFromUUID(uuid.Must(uuid.NewV4())),
+		}
+	}
+	require.Error(t, ds.SetCronScripts(scripts))
+
+	// None of the changes are applied.
+	existing, err := ds.GetCronScripts()
+	require.NoError(t, err)
+	assert.Equal(t, []*cvmsgspb.CronScript{s1}, existing)
+}
+
 func TestStore_RecordCronScriptResult(t *testing.T) {
 	_, ds, cleanup := setupTest(t)
 	defer cleanup()
@@ -301,3 +362,34 @@ func TestStore_RecordCronScriptResult_HitsLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestStore_CronScriptCheckpoint(t *testing.T) {
+	_, ds, cleanup := setupTest(t)
+	defer cleanup()
+
+	scriptID := uuid.FromStringOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, ds.UpsertCronScript(&cvmsgspb.CronScript{ID: utils.ProtoFromUUID(scriptID)}))
+
+	checkpoint, err := ds.GetCronScriptCheckpoint(scriptID)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	ts, err := types.TimestampProto(time.Unix(1234, 5678))
+	require.NoError(t, err)
+	require.NoError(t, ds.SetCronScriptCheckpoint(scriptID, ts))
+
+	checkpoint, err = ds.GetCronScriptCheckpoint(scriptID)
+	require.NoError(t, err)
+	assert.Equal(t, ts, checkpoint)
+
+	// The checkpoint is not an execution result.
+	allCronScriptResults, err := ds.GetAllCronScriptResults()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(allCronScriptResults))
+
+	// Deleting the script removes its checkpoint.
+	require.NoError(t, ds.DeleteCronScript(scriptID))
+	checkpoint, err = ds.GetCronScriptCheckpoint(scriptID)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+}
@@ -22,6 +22,9 @@ import (
 	"sync"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -38,10 +41,32 @@ type Controller struct {
 	quitCh   chan struct{}
 	updateCh chan *K8sResourceMessage
 	once     sync.Once
+
+	useEndpointSlices bool
+	dynamicClient     dynamic.Interface
+}
+
+// ControllerOption configures optional behavior of the Controller.
+type ControllerOption func(*Controller)
+
+// WithEndpointSlices makes the controller derive service endpoints from discovery.k8s.io/v1
+// EndpointSlices instead of the deprecated core/v1 Endpoints, which are truncated for large services.
+func WithEndpointSlices(enabled bool) ControllerOption {
+	return func(mc *Controller) {
+		mc.useEndpointSlices = enabled
+	}
+}
+
+// WithDynamicClient sets the client used to watch resources that are defined by CRDs, such as
+// Gateway API HTTPRoutes. Those resources aren't watched if no dynamic client is set.
+func WithDynamicClient(client dynamic.Interface) ControllerOption {
+	return func(mc *Controller) {
+		mc.dynamicClient = client
+	}
 }
 
 // NewController creates a new Controller.
-func NewController(namespaces []string, updateCh chan *K8sResourceMessage) (*Controller, error) {
+func NewController(namespaces []string, updateCh chan *K8sResourceMessage, opts ...ControllerOption) (*Controller, error) {
 	// There is a specific config for services running in the cluster.
 	kubeConfig, err := rest.InClusterConfig()
 	if err != nil {
@@ -53,12 +78,21 @@ func NewController(namespaces []string, updateCh chan *K8sResourceMessage) (*Con
 	if err != nil {
 		return nil, err
 	}
-	return NewControllerWithClientSet(namespaces, updateCh, clientset)
+
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]ControllerOption{WithDynamicClient(dynamicClient)}, opts...)
+	return NewControllerWithClientSet(namespaces, updateCh, clientset, opts...)
 }
 
 // NewControllerWithClientSet creates a new Controller using the given Clientset.
-func NewControllerWithClientSet(namespaces []string, updateCh chan *K8sResourceMessage, clientset kubernetes.Interface) (*Controller, error) {
+func NewControllerWithClientSet(namespaces []string, updateCh chan *K8sResourceMessage, clientset kubernetes.Interface, opts ...ControllerOption) (*Controller, error) {
 	mc := &Controller{quitCh: make(chan struct{}), updateCh: updateCh}
+	for _, opt := range opts {
+		opt(mc)
+	}
 	go mc.startWithClientSet(namespaces, clientset)
 
 	return mc, nil
@@ -80,10 +114,37 @@ func (mc *Controller) startWithClientSet(namespaces []string, clientset kubernet
 	}
 
 	startPodWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
-	startEndpointsWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	if mc.useEndpointSlices {
+		startEndpointSliceWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	} else {
+		startEndpointsWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	}
 	startServiceWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
 	startReplicaSetWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
 	startDeploymentWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startStatefulSetWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startDaemonSetWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startJobWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startCronJobWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startIngressWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+	startNetworkPolicyWatcher(mc.updateCh, mc.quitCh, namespacedFactories)
+
+	if mc.dynamicClient == nil {
+		return
+	}
+	// The Gateway API CRDs are only looked up on startup, so HTTPRoutes won't be watched if the
+	// CRDs are installed after the metadata service starts.
+	gvr, ok := httpRouteResource(clientset.Discovery())
+	if !ok {
+		log.Info("Gateway API HTTPRoutes not found, skipping HTTPRoute watcher")
+		return
+	}
+	var dynamicFactories []dynamicinformer.DynamicSharedInformerFactory
+	for _, ns := range namespaces {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(mc.dynamicClient, 12*time.Hour, ns, nil)
+		dynamicFactories = append(dynamicFactories, factory)
+	}
+	startHTTPRouteWatcher(mc.updateCh, mc.quitCh, dynamicFactories, gvr)
 }
 
 // Stop stops all K8s watchers.
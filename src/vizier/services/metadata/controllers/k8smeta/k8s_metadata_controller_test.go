@@ -26,10 +26,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
@@ -811,6 +817,69 @@ func TestController(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "job owned by cronjob",
+			updates: []resourceUpdate{
+				&job{
+					j: &batchv1.Job{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "backup-1234",
+							OwnerReferences: []metav1.OwnerReference{
+								{
+									Kind: "CronJob",
+									Name: "backup",
+									UID:  "cj-uid",
+								},
+							},
+						},
+						Spec: batchv1.JobSpec{
+							Completions: int32ptr(1),
+							Template: v1.PodTemplateSpec{
+								ObjectMeta: metav1.ObjectMeta{
+									Name: "mypod",
+								},
+							},
+						},
+					},
+					ns: "test",
+					t:  create,
+				},
+			},
+			expectedUpdates: []*k8smeta.K8sResourceMessage{
+				{
+					ObjectType: "jobs",
+					Object: &storepb.K8SResource{
+						Resource: &storepb.K8SResource_Job{
+							Job: &metadatapb.Job{
+								Metadata: &metadatapb.ObjectMetadata{
+									Name:      "backup-1234",
+									Namespace: "test",
+									OwnerReferences: []*metadatapb.OwnerReference{
+										{
+											Kind: "CronJob",
+											Name: "backup",
+											UID:  "cj-uid",
+										},
+									},
+								},
+								Spec: &metadatapb.JobSpec{
+									Completions: 1,
+									Template: &metadatapb.PodTemplateSpec{
+										Metadata: &metadatapb.ObjectMetadata{
+											Name:            "mypod",
+											OwnerReferences: []*metadatapb.OwnerReference{},
+										},
+										Spec: &metadatapb.PodSpec{},
+									},
+								},
+								Status: &metadatapb.JobStatus{},
+							},
+						},
+					},
+					EventType: watch.Added,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -844,13 +913,19 @@ func TestController(t *testing.T) {
 
 			watchersStarted := map[string]bool{
 				// This list needs to be kept up-to-date with the list of watchers started in k8s_metadata_controller.go
-				"nodes":       false,
-				"namespaces":  false,
-				"pods":        false,
-				"endpoints":   false,
-				"services":    false,
-				"replicasets": false,
-				"deployments": false,
+				"nodes":           false,
+				"namespaces":      false,
+				"pods":            false,
+				"endpoints":       false,
+				"services":        false,
+				"replicasets":     false,
+				"deployments":     false,
+				"statefulsets":    false,
+				"daemonsets":      false,
+				"jobs":            false,
+				"cronjobs":        false,
+				"ingresses":       false,
+				"networkpolicies": false,
 			}
 			allStarted := func() bool {
 				for _, started := range watchersStarted {
@@ -889,6 +964,232 @@ func TestController(t *testing.T) {
 	}
 }
 
+func TestControllerWithEndpointSlices(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	watchStart := make(chan string)
+	defer close(watchStart)
+
+	client.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		gvr := action.GetResource()
+		ns := action.GetNamespace()
+		watch, err := client.Tracker().Watch(gvr, ns)
+		if err != nil {
+			return false, nil, err
+		}
+		watchStart <- gvr.Resource
+		return true, watch, nil
+	})
+
+	updateCh := make(chan *k8smeta.K8sResourceMessage, 10)
+	controller, err := k8smeta.NewControllerWithClientSet([]string{v1.NamespaceAll}, updateCh, client, k8smeta.WithEndpointSlices(true))
+	require.NoError(t, err)
+	defer controller.Stop()
+
+	watchersStarted := map[string]bool{
+		// This list needs to be kept up-to-date with the list of watchers started in k8s_metadata_controller.go
+		"nodes":           false,
+		"namespaces":      false,
+		"pods":            false,
+		"endpointslices":  false,
+		"services":        false,
+		"replicasets":     false,
+		"deployments":     false,
+		"statefulsets":    false,
+		"daemonsets":      false,
+		"jobs":            false,
+		"cronjobs":        false,
+		"ingresses":       false,
+		"networkpolicies": false,
+	}
+	allStarted := func() bool {
+		for _, started := range watchersStarted {
+			if !started {
+				return false
+			}
+		}
+		return true
+	}
+	for resource := range watchStart {
+		watchersStarted[resource] = true
+		if allStarted() {
+			break
+		}
+	}
+
+	ready := true
+	notReady := false
+	nodeName := "node-a"
+	newSlice := func(name string, podName string, isReady *bool) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					discoveryv1.LabelServiceName: "web",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Service", Name: "web", UID: "svc-uid"},
+				},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{Ready: isReady},
+					NodeName:   &nodeName,
+					TargetRef:  &v1.ObjectReference{Kind: "Pod", Namespace: "test", Name: podName},
+				},
+			},
+		}
+	}
+	sliceA := newSlice("web-a", "web-1", &ready)
+	sliceB := newSlice("web-b", "web-2", &notReady)
+
+	readySubset := &metadatapb.EndpointSubset{
+		Addresses: []*metadatapb.EndpointAddress{
+			{
+				IP:        "10.0.0.1",
+				NodeName:  "node-a",
+				TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "test", Name: "web-1"},
+			},
+		},
+		NotReadyAddresses: []*metadatapb.EndpointAddress{},
+		Ports:             []*metadatapb.EndpointPort{},
+	}
+	notReadySubset := &metadatapb.EndpointSubset{
+		Addresses: []*metadatapb.EndpointAddress{},
+		NotReadyAddresses: []*metadatapb.EndpointAddress{
+			{
+				IP:        "10.0.0.1",
+				NodeName:  "node-a",
+				TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "test", Name: "web-2"},
+			},
+		},
+		Ports: []*metadatapb.EndpointPort{},
+	}
+	expectedMsg := func(eventType watch.EventType, subsets ...*metadatapb.EndpointSubset) *k8smeta.K8sResourceMessage {
+		return &k8smeta.K8sResourceMessage{
+			ObjectType: "endpoints",
+			Object: &storepb.K8SResource{
+				Resource: &storepb.K8SResource_Endpoints{
+					Endpoints: &metadatapb.Endpoints{
+						Metadata: &metadatapb.ObjectMetadata{
+							Name:            "web",
+							Namespace:       "test",
+							UID:             "svc-uid",
+							Labels:          map[string]string{},
+							OwnerReferences: []*metadatapb.OwnerReference{},
+						},
+						Subsets: subsets,
+					},
+				},
+			},
+			EventType: eventType,
+		}
+	}
+
+	steps := []struct {
+		update   resourceUpdate
+		expected *k8smeta.K8sResourceMessage
+	}{
+		{
+			update:   &endpointSlice{s: sliceA, ns: "test", t: create},
+			expected: expectedMsg(watch.Added, readySubset),
+		},
+		{
+			update:   &endpointSlice{s: sliceB, ns: "test", t: create},
+			expected: expectedMsg(watch.Added, readySubset, notReadySubset),
+		},
+		{
+			update:   &endpointSlice{s: sliceA, ns: "test", t: del},
+			expected: expectedMsg(watch.Modified, notReadySubset),
+		},
+		{
+			update:   &endpointSlice{s: sliceB, ns: "test", t: del},
+			expected: expectedMsg(watch.Deleted, notReadySubset),
+		},
+	}
+
+	for _, step := range steps {
+		require.NoError(t, step.update.Apply(context.Background(), client))
+		u := <-updateCh
+		assert.Equal(t, zeroTimestamps([]*k8smeta.K8sResourceMessage{step.expected}), zeroTimestamps([]*k8smeta.K8sResourceMessage{u}))
+	}
+}
+
+func TestControllerWithHTTPRoutes(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// Make the Gateway API CRDs discoverable.
+	client.Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "gateway.networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "httproutes", Kind: "HTTPRoute", Namespaced: true},
+			},
+		},
+	}
+
+	gvr := schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":      "web",
+				"namespace": "test",
+				"uid":       "route-uid",
+			},
+			"spec": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{"name": "api", "port": int64(8080)},
+						},
+					},
+				},
+			},
+		},
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "HTTPRouteList"}, route)
+
+	updateCh := make(chan *k8smeta.K8sResourceMessage, 10)
+	controller, err := k8smeta.NewControllerWithClientSet([]string{v1.NamespaceAll}, updateCh, client, k8smeta.WithDynamicClient(dynamicClient))
+	require.NoError(t, err)
+	defer controller.Stop()
+
+	u := <-updateCh
+	expected := &k8smeta.K8sResourceMessage{
+		ObjectType: "httproutes",
+		EventType:  watch.Added,
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_HTTPRoute{
+				HTTPRoute: &metadatapb.HTTPRoute{
+					Metadata: &metadatapb.ObjectMetadata{
+						Name:            "web",
+						Namespace:       "test",
+						UID:             "route-uid",
+						OwnerReferences: []*metadatapb.OwnerReference{},
+					},
+					Spec: &metadatapb.HTTPRouteSpec{
+						ParentRefs: []*metadatapb.GatewayParentReference{},
+						Hostnames:  []string{},
+						Rules: []*metadatapb.HTTPRouteRule{
+							{
+								Matches: []*metadatapb.HTTPRouteMatch{},
+								BackendRefs: []*metadatapb.HTTPBackendRef{
+									{Kind: "Service", Namespace: "test", Name: "api", Port: 8080, Weight: 1},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, zeroTimestamps([]*k8smeta.K8sResourceMessage{expected}), zeroTimestamps([]*k8smeta.K8sResourceMessage{u}))
+}
+
 func TestControllerWithNotWatchedNameSpaces(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -1384,13 +1685,19 @@ func TestControllerWithNotWatchedNameSpaces(t *testing.T) {
 
 			watchersStarted := map[string]bool{
 				// This list needs to be kept up-to-date with the list of watchers started in k8s_metadata_controller.go
-				"nodes":       false,
-				"namespaces":  false,
-				"pods":        false,
-				"endpoints":   false,
-				"services":    false,
-				"replicasets": false,
-				"deployments": false,
+				"nodes":           false,
+				"namespaces":      false,
+				"pods":            false,
+				"endpoints":       false,
+				"services":        false,
+				"replicasets":     false,
+				"deployments":     false,
+				"statefulsets":    false,
+				"daemonsets":      false,
+				"jobs":            false,
+				"cronjobs":        false,
+				"ingresses":       false,
+				"networkpolicies": false,
 			}
 			allStarted := func() bool {
 				for _, started := range watchersStarted {
@@ -1498,6 +1805,18 @@ func zeroTimestamps(updates []*k8smeta.K8sResourceMessage) []*k8smeta.K8sResourc
 				cond.LastTransitionTimeNS = 0
 			}
 		}
+		if v.Object.GetJob() != nil {
+			v.Object.GetJob().GetMetadata().CreationTimestampNS = 0
+			v.Object.GetJob().GetMetadata().DeletionTimestampNS = 0
+			if v.Object.GetJob().GetSpec().GetTemplate() != nil {
+				v.Object.GetJob().GetSpec().GetTemplate().GetMetadata().CreationTimestampNS = 0
+				v.Object.GetJob().GetSpec().GetTemplate().GetMetadata().DeletionTimestampNS = 0
+			}
+		}
+		if v.Object.GetHTTPRoute() != nil {
+			v.Object.GetHTTPRoute().GetMetadata().CreationTimestampNS = 0
+			v.Object.GetHTTPRoute().GetMetadata().DeletionTimestampNS = 0
+		}
 		out[i] = &v
 	}
 	return out
@@ -1666,3 +1985,47 @@ func (d *deployment) Apply(ctx context.Context, c kubernetes.Interface) error {
 		return errors.New("invalid resourceUpdateType")
 	}
 }
+
+type job struct {
+	j  *batchv1.Job
+	ns string
+	t  resourceUpdateType
+}
+
+func (j *job) Apply(ctx context.Context, c kubernetes.Interface) error {
+	ei := c.BatchV1().Jobs(j.ns)
+	switch j.t {
+	case create:
+		_, err := ei.Create(ctx, j.j, metav1.CreateOptions{})
+		return err
+	case update:
+		_, err := ei.Update(ctx, j.j, metav1.UpdateOptions{})
+		return err
+	case del:
+		return ei.Delete(ctx, j.j.Name, metav1.DeleteOptions{})
+	default:
+		return errors.New("invalid resourceUpdateType")
+	}
+}
+
+type endpointSlice struct {
+	s  *discoveryv1.EndpointSlice
+	ns string
+	t  resourceUpdateType
+}
+
+func (e *endpointSlice) Apply(ctx context.Context, c kubernetes.Interface) error {
+	ei := c.DiscoveryV1().EndpointSlices(e.ns)
+	switch e.t {
+	case create:
+		_, err := ei.Create(ctx, e.s, metav1.CreateOptions{})
+		return err
+	case update:
+		_, err := ei.Update(ctx, e.s, metav1.UpdateOptions{})
+		return err
+	case del:
+		return ei.Delete(ctx, e.s.Name, metav1.DeleteOptions{})
+	default:
+		return errors.New("invalid resourceUpdateType")
+	}
+}
@@ -45,6 +45,10 @@ import (
 // KelvinUpdateTopic is the topic that all kelvins updates are sent on.
 const KelvinUpdateTopic = "all"
 
+// NetworkingUpdateTopic is the topic that ingress, HTTPRoute and network policy updates are sent on.
+// These updates aren't needed by the node agents, so they are kept off of the agent topics.
+const NetworkingUpdateTopic = "networking"
+
 // K8sMetadataUpdateChannel is the channel where metadata updates are sent.
 const K8sMetadataUpdateChannel = "K8sUpdates"
 
@@ -120,6 +124,41 @@ type PodLabelStore interface {
 	GetWithPrefix(prefix string) ([]string, [][]byte, error)
 }
 
+// The kinds of resources that are kept in the NetworkingStore.
+const (
+	NetworkingKindIngress       = "Ingress"
+	NetworkingKindHTTPRoute     = "HTTPRoute"
+	NetworkingKindNetworkPolicy = "NetworkPolicy"
+)
+
+// NetworkingStore handles storing and fetching the ingresses, routes and network policies in the cluster.
+type NetworkingStore interface {
+	// SetNetworkingResource stores the latest version of the resource of the given kind.
+	SetNetworkingResource(kind string, namespace string, name string, resource *storepb.K8SResource) error
+	// DeleteNetworkingResource deletes the resource of the given kind.
+	DeleteNetworkingResource(kind string, namespace string, name string) error
+	// FetchNetworkingResources gets all of the stored resources of the given kind.
+	FetchNetworkingResources(kind string) ([]*storepb.K8SResource, error)
+}
+
+// HistoryStore handles fetching the history of the K8s resources, as it is retained by the HistoryPolicy.
+type HistoryStore interface {
+	// HistoryEnabled returns whether the history policy enables recording the history of the K8s resources.
+	HistoryEnabled() bool
+	// FetchResourceWithUID gets the last known state of the resource with the given UID.
+	FetchResourceWithUID(uid string) (*storepb.K8SResource, error)
+	// FetchResourcesWithIP gets the pods and services that held the IP at the given time, most recently
+	// created first.
+	FetchResourcesWithIP(ip string, timestampNS int64) ([]*storepb.K8SResource, error)
+	// FetchPodUIDForContainer gets the UID of the pod that the container ran in.
+	FetchPodUIDForContainer(cid string) (string, error)
+	// FetchPodServices gets the services, in <namespace>/<name> format, that the pod was an endpoint of at the given time.
+	FetchPodServices(podUID string, timestampNS int64) ([]string, error)
+	// FetchNamespaceChanges gets the changes to the resources in the namespace from the `from` time,
+	// to the `to` time (exclusive). At most limit changes are returned, unless limit is 0.
+	FetchNamespaceChanges(namespace string, from int64, to int64, limit int) ([]*storepb.K8SResourceChange, error)
+}
+
 // An UpdateProcessor is responsible for processing an incoming update, such as determining what
 // updates should be persisted and sent to NATS.
 type UpdateProcessor interface {
@@ -160,6 +199,8 @@ type Handler struct {
 	mds Store
 	// The store where pod label information is stored.
 	pls PodLabelStore
+	// The store where ingresses, routes and network policies are stored.
+	nws NetworkingStore
 	// The NATS connection on which to send messages on.
 	conn *nats.Conn
 	// Done channel, to stop processing metadata updates.
@@ -173,12 +214,12 @@ type Handler struct {
 }
 
 // NewHandler creates a new Handler.
-func NewHandler(updateCh <-chan *K8sResourceMessage, mds Store, pls PodLabelStore, conn *nats.Conn) *Handler {
+func NewHandler(updateCh <-chan *K8sResourceMessage, mds Store, pls PodLabelStore, nws NetworkingStore, conn *nats.Conn) *Handler {
 	done := make(chan struct{})
 	leaderMsgs := make(map[string]*metadatapb.Endpoints)
 	handlerMap := make(map[string]UpdateProcessor)
 	state := ProcessorState{LeaderMsgs: leaderMsgs, PodCIDRs: make([]string, 0), NodeToIP: make(map[string]string), PodToIP: make(map[string]string)}
-	mh := &Handler{updateCh: updateCh, mds: mds, pls: pls, conn: conn, done: done, processHandlerMap: handlerMap, state: state}
+	mh := &Handler{updateCh: updateCh, mds: mds, pls: pls, nws: nws, conn: conn, done: done, processHandlerMap: handlerMap, state: state}
 
 	// Register update processors.
 	mh.processHandlerMap["endpoints"] = &EndpointsUpdateProcessor{}
@@ -188,6 +229,13 @@ func NewHandler(updateCh <-chan *K8sResourceMessage, mds Store, pls PodLabelStor
 	mh.processHandlerMap["namespaces"] = &NamespaceUpdateProcessor{}
 	mh.processHandlerMap["replicasets"] = &ReplicaSetUpdateProcessor{}
 	mh.processHandlerMap["deployments"] = &DeploymentUpdateProcessor{}
+	mh.processHandlerMap["statefulsets"] = &StatefulSetUpdateProcessor{}
+	mh.processHandlerMap["daemonsets"] = &DaemonSetUpdateProcessor{}
+	mh.processHandlerMap["jobs"] = &JobUpdateProcessor{}
+	mh.processHandlerMap["cronjobs"] = &CronJobUpdateProcessor{}
+	mh.processHandlerMap["ingresses"] = &IngressUpdateProcessor{}
+	mh.processHandlerMap["httproutes"] = &HTTPRouteUpdateProcessor{}
+	mh.processHandlerMap["networkpolicies"] = &NetworkPolicyUpdateProcessor{}
 
 	go mh.processUpdates()
 	return mh
@@ -248,6 +296,14 @@ func (m *Handler) processUpdates() {
 				}
 			}
 
+			switch msg.ObjectType {
+			case "ingresses", "httproutes", "networkpolicies":
+				err := UpdateNetworkingStore(update, m.nws)
+				if err != nil {
+					log.WithError(err).Error("Failed to update networking state")
+				}
+			}
+
 			// Persist the update in the data store.
 			updates := processor.GetStoredProtos(update)
 			if updates == nil {
@@ -771,6 +827,28 @@ func UpdatePodLabelStore(update *storepb.K8SResource, pls PodLabelStore) error {
 	return nil
 }
 
+// UpdateNetworkingStore reads the ingress, HTTPRoute or network policy resource update. If the resource
+// has been deleted, it is removed from the store. Otherwise the store is updated with the latest version.
+func UpdateNetworkingStore(update *storepb.K8SResource, nws NetworkingStore) error {
+	var kind string
+	var md *metadatapb.ObjectMetadata
+	switch r := update.Resource.(type) {
+	case *storepb.K8SResource_Ingress:
+		kind, md = NetworkingKindIngress, r.Ingress.Metadata
+	case *storepb.K8SResource_HTTPRoute:
+		kind, md = NetworkingKindHTTPRoute, r.HTTPRoute.Metadata
+	case *storepb.K8SResource_NetworkPolicy:
+		kind, md = NetworkingKindNetworkPolicy, r.NetworkPolicy.Metadata
+	default:
+		return nil
+	}
+
+	if md.DeletionTimestampNS != 0 {
+		return nws.DeleteNetworkingResource(kind, md.Namespace, md.Name)
+	}
+	return nws.SetNetworkingResource(kind, md.Namespace, md.Name, update)
+}
+
 // NodeUpdateProcessor is a processor for nodes.
 type NodeUpdateProcessor struct{}
 
@@ -1007,6 +1085,337 @@ func (p *DeploymentUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, st
 	}
 }
 
+// getWorkloadUpdatesToSend gets the updates to send for a stateful set, daemon set, job or cron job. The agents
+// don't track these workloads, so the updates are only sent on the Kelvin topic, which also forwards them to the cloud.
+func getWorkloadUpdatesToSend(updates []*StoredUpdate, toResourceUpdate func(*storepb.K8SResource, int64) *metadatapb.ResourceUpdate) []*OutgoingUpdate {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return []*OutgoingUpdate{
+		{
+			Update: toResourceUpdate(updates[0].Update, updates[0].UpdateVersion),
+			Topics: []string{KelvinUpdateTopic},
+		},
+	}
+}
+
+// StatefulSetUpdateProcessor is a processor for stateful set updates.
+type StatefulSetUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *StatefulSetUpdateProcessor) IsNodeScoped() bool {
+	return false
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *StatefulSetUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	ss := obj.GetStatefulSet()
+	if ss == nil {
+		return
+	}
+	setDeleted(ss.Metadata)
+}
+
+// ValidateUpdate checks that the provided stateful set object is valid, and casts it to the correct type.
+func (p *StatefulSetUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	ss := obj.GetStatefulSet()
+	if ss == nil {
+		log.WithField("object", obj).Trace("Received non-statefulset object when handling stateful set metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *StatefulSetUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *StatefulSetUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	return getWorkloadUpdatesToSend(updates, func(obj *storepb.K8SResource, rv int64) *metadatapb.ResourceUpdate {
+		return getResourceUpdateFromStatefulSet(obj.GetStatefulSet(), rv)
+	})
+}
+
+// DaemonSetUpdateProcessor is a processor for daemon set updates.
+type DaemonSetUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *DaemonSetUpdateProcessor) IsNodeScoped() bool {
+	return false
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *DaemonSetUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	ds := obj.GetDaemonSet()
+	if ds == nil {
+		return
+	}
+	setDeleted(ds.Metadata)
+}
+
+// ValidateUpdate checks that the provided daemon set object is valid, and casts it to the correct type.
+func (p *DaemonSetUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	ds := obj.GetDaemonSet()
+	if ds == nil {
+		log.WithField("object", obj).Trace("Received non-daemonset object when handling daemon set metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *DaemonSetUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *DaemonSetUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	return getWorkloadUpdatesToSend(updates, func(obj *storepb.K8SResource, rv int64) *metadatapb.ResourceUpdate {
+		return getResourceUpdateFromDaemonSet(obj.GetDaemonSet(), rv)
+	})
+}
+
+// JobUpdateProcessor is a processor for job updates.
+type JobUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *JobUpdateProcessor) IsNodeScoped() bool {
+	return false
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *JobUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	job := obj.GetJob()
+	if job == nil {
+		return
+	}
+	setDeleted(job.Metadata)
+}
+
+// ValidateUpdate checks that the provided job object is valid, and casts it to the correct type.
+func (p *JobUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	job := obj.GetJob()
+	if job == nil {
+		log.WithField("object", obj).Trace("Received non-job object when handling job metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *JobUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *JobUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	return getWorkloadUpdatesToSend(updates, func(obj *storepb.K8SResource, rv int64) *metadatapb.ResourceUpdate {
+		return getResourceUpdateFromJob(obj.GetJob(), rv)
+	})
+}
+
+// CronJobUpdateProcessor is a processor for cron job updates.
+type CronJobUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *CronJobUpdateProcessor) IsNodeScoped() bool {
+	return false
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *CronJobUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	cj := obj.GetCronJob()
+	if cj == nil {
+		return
+	}
+	setDeleted(cj.Metadata)
+}
+
+// ValidateUpdate checks that the provided cron job object is valid, and casts it to the correct type.
+func (p *CronJobUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	cj := obj.GetCronJob()
+	if cj == nil {
+		log.WithField("object", obj).Trace("Received non-cronjob object when handling cron job metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *CronJobUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *CronJobUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	return getWorkloadUpdatesToSend(updates, func(obj *storepb.K8SResource, rv int64) *metadatapb.ResourceUpdate {
+		return getResourceUpdateFromCronJob(obj.GetCronJob(), rv)
+	})
+}
+
+// IngressUpdateProcessor is a processor for ingresss.
+type IngressUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *IngressUpdateProcessor) IsNodeScoped() bool {
+	// The update is only stored for the networking topic, so that it isn't sent to agents that request
+	// their missing updates.
+	return true
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *IngressUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	ing := obj.GetIngress()
+	if ing == nil {
+		return
+	}
+	setDeleted(ing.Metadata)
+}
+
+// ValidateUpdate checks that the provided ingress object is valid, and casts it to the correct type.
+func (p *IngressUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	ing := obj.GetIngress()
+	if ing == nil {
+		log.WithField("object", obj).Trace("Received non-ingress object when handling ingress metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *IngressUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *IngressUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	rv := updates[0].UpdateVersion
+	ing := updates[0].Update.GetIngress()
+
+	return []*OutgoingUpdate{
+		{
+			Update: getResourceUpdateFromIngress(ing, rv),
+			Topics: []string{NetworkingUpdateTopic},
+		},
+	}
+}
+
+// HTTPRouteUpdateProcessor is a processor for HTTPRoutes.
+type HTTPRouteUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *HTTPRouteUpdateProcessor) IsNodeScoped() bool {
+	// The update is only stored for the networking topic, so that it isn't sent to agents that request
+	// their missing updates.
+	return true
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *HTTPRouteUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	route := obj.GetHTTPRoute()
+	if route == nil {
+		return
+	}
+	setDeleted(route.Metadata)
+}
+
+// ValidateUpdate checks that the provided HTTPRoute object is valid, and casts it to the correct type.
+func (p *HTTPRouteUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	route := obj.GetHTTPRoute()
+	if route == nil {
+		log.WithField("object", obj).Trace("Received non-httproute object when handling HTTPRoute metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *HTTPRouteUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *HTTPRouteUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	rv := updates[0].UpdateVersion
+	route := updates[0].Update.GetHTTPRoute()
+
+	return []*OutgoingUpdate{
+		{
+			Update: getResourceUpdateFromHTTPRoute(route, rv),
+			Topics: []string{NetworkingUpdateTopic},
+		},
+	}
+}
+
+// NetworkPolicyUpdateProcessor is a processor for network policys.
+type NetworkPolicyUpdateProcessor struct{}
+
+// IsNodeScoped returns whether this update is scoped to specific nodes, or should be sent to all nodes.
+func (p *NetworkPolicyUpdateProcessor) IsNodeScoped() bool {
+	// The update is only stored for the networking topic, so that it isn't sent to agents that request
+	// their missing updates.
+	return true
+}
+
+// SetDeleted sets the deletion timestamp for the object, if there is none already set.
+func (p *NetworkPolicyUpdateProcessor) SetDeleted(obj *storepb.K8SResource) {
+	np := obj.GetNetworkPolicy()
+	if np == nil {
+		return
+	}
+	setDeleted(np.Metadata)
+}
+
+// ValidateUpdate checks that the provided network policy object is valid, and casts it to the correct type.
+func (p *NetworkPolicyUpdateProcessor) ValidateUpdate(obj *storepb.K8SResource, state *ProcessorState) bool {
+	np := obj.GetNetworkPolicy()
+	if np == nil {
+		log.WithField("object", obj).Trace("Received non-networkpolicy object when handling network policy metadata.")
+		return false
+	}
+
+	return true
+}
+
+// GetStoredProtos gets the update protos that should be persisted.
+func (p *NetworkPolicyUpdateProcessor) GetStoredProtos(obj *storepb.K8SResource) []*storepb.K8SResource {
+	return []*storepb.K8SResource{obj}
+}
+
+// GetUpdatesToSend gets the resource updates that should be sent out to the agents, along with the agent IPs that the update should be sent to.
+func (p *NetworkPolicyUpdateProcessor) GetUpdatesToSend(updates []*StoredUpdate, state *ProcessorState) []*OutgoingUpdate {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	rv := updates[0].UpdateVersion
+	np := updates[0].Update.GetNetworkPolicy()
+
+	return []*OutgoingUpdate{
+		{
+			Update: getResourceUpdateFromNetworkPolicy(np, rv),
+			Topics: []string{NetworkingUpdateTopic},
+		},
+	}
+}
+
 func formatContainerID(cid string) (metadatapb.ContainerType, string) {
 	// Strip prefixes like docker:// or containerd://
 	tokens := strings.SplitN(cid, "://", 2)
@@ -1202,6 +1611,152 @@ func getResourceUpdateFromDeployment(dep *metadatapb.Deployment, uv int64) *meta
 	}
 }
 
+func getResourceUpdateFromStatefulSet(ss *metadatapb.StatefulSet, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_StatefulSetUpdate{
+			StatefulSetUpdate: &metadatapb.StatefulSetUpdate{
+				UID:                ss.Metadata.UID,
+				Name:               ss.Metadata.Name,
+				StartTimestampNS:   ss.Metadata.CreationTimestampNS,
+				StopTimestampNS:    ss.Metadata.DeletionTimestampNS,
+				Namespace:          ss.Metadata.Namespace,
+				ObservedGeneration: int32(ss.Status.ObservedGeneration),
+				Replicas:           ss.Status.Replicas,
+				ReadyReplicas:      ss.Status.ReadyReplicas,
+				CurrentReplicas:    ss.Status.CurrentReplicas,
+				UpdatedReplicas:    ss.Status.UpdatedReplicas,
+				AvailableReplicas:  ss.Status.AvailableReplicas,
+				RequestedReplicas:  ss.Spec.Replicas,
+				ServiceName:        ss.Spec.ServiceName,
+				OwnerReferences:    ss.Metadata.OwnerReferences,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromDaemonSet(ds *metadatapb.DaemonSet, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_DaemonSetUpdate{
+			DaemonSetUpdate: &metadatapb.DaemonSetUpdate{
+				UID:                    ds.Metadata.UID,
+				Name:                   ds.Metadata.Name,
+				StartTimestampNS:       ds.Metadata.CreationTimestampNS,
+				StopTimestampNS:        ds.Metadata.DeletionTimestampNS,
+				Namespace:              ds.Metadata.Namespace,
+				ObservedGeneration:     int32(ds.Status.ObservedGeneration),
+				CurrentNumberScheduled: ds.Status.CurrentNumberScheduled,
+				NumberMisscheduled:     ds.Status.NumberMisscheduled,
+				DesiredNumberScheduled: ds.Status.DesiredNumberScheduled,
+				NumberReady:            ds.Status.NumberReady,
+				UpdatedNumberScheduled: ds.Status.UpdatedNumberScheduled,
+				NumberAvailable:        ds.Status.NumberAvailable,
+				NumberUnavailable:      ds.Status.NumberUnavailable,
+				OwnerReferences:        ds.Metadata.OwnerReferences,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromJob(job *metadatapb.Job, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_JobUpdate{
+			JobUpdate: &metadatapb.JobUpdate{
+				UID:                  job.Metadata.UID,
+				Name:                 job.Metadata.Name,
+				StartTimestampNS:     job.Metadata.CreationTimestampNS,
+				StopTimestampNS:      job.Metadata.DeletionTimestampNS,
+				Namespace:            job.Metadata.Namespace,
+				JobStartTimeNS:       job.Status.StartTimeNS,
+				JobCompletionTimeNS:  job.Status.CompletionTimeNS,
+				Active:               job.Status.Active,
+				Succeeded:            job.Status.Succeeded,
+				Failed:               job.Status.Failed,
+				RequestedCompletions: job.Spec.Completions,
+				RequestedParallelism: job.Spec.Parallelism,
+				Conditions:           job.Status.Conditions,
+				OwnerReferences:      job.Metadata.OwnerReferences,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromCronJob(cj *metadatapb.CronJob, uv int64) *metadatapb.ResourceUpdate {
+	activeJobUIDs := make([]string, len(cj.Status.Active))
+	for i, j := range cj.Status.Active {
+		activeJobUIDs[i] = j.UID
+	}
+
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_CronJobUpdate{
+			CronJobUpdate: &metadatapb.CronJobUpdate{
+				UID:                  cj.Metadata.UID,
+				Name:                 cj.Metadata.Name,
+				StartTimestampNS:     cj.Metadata.CreationTimestampNS,
+				StopTimestampNS:      cj.Metadata.DeletionTimestampNS,
+				Namespace:            cj.Metadata.Namespace,
+				Schedule:             cj.Spec.Schedule,
+				Suspend:              cj.Spec.Suspend,
+				LastScheduleTimeNS:   cj.Status.LastScheduleTimeNS,
+				LastSuccessfulTimeNS: cj.Status.LastSuccessfulTimeNS,
+				ActiveJobUIDs:        activeJobUIDs,
+				OwnerReferences:      cj.Metadata.OwnerReferences,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromIngress(ing *metadatapb.Ingress, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_IngressUpdate{
+			IngressUpdate: &metadatapb.IngressUpdate{
+				UID:              ing.Metadata.UID,
+				Name:             ing.Metadata.Name,
+				StartTimestampNS: ing.Metadata.CreationTimestampNS,
+				StopTimestampNS:  ing.Metadata.DeletionTimestampNS,
+				Namespace:        ing.Metadata.Namespace,
+				Spec:             ing.Spec,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromHTTPRoute(route *metadatapb.HTTPRoute, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_HTTPRouteUpdate{
+			HTTPRouteUpdate: &metadatapb.HTTPRouteUpdate{
+				UID:              route.Metadata.UID,
+				Name:             route.Metadata.Name,
+				StartTimestampNS: route.Metadata.CreationTimestampNS,
+				StopTimestampNS:  route.Metadata.DeletionTimestampNS,
+				Namespace:        route.Metadata.Namespace,
+				Spec:             route.Spec,
+			},
+		},
+	}
+}
+
+func getResourceUpdateFromNetworkPolicy(np *metadatapb.NetworkPolicy, uv int64) *metadatapb.ResourceUpdate {
+	return &metadatapb.ResourceUpdate{
+		UpdateVersion: uv,
+		Update: &metadatapb.ResourceUpdate_NetworkPolicyUpdate{
+			NetworkPolicyUpdate: &metadatapb.NetworkPolicyUpdate{
+				UID:              np.Metadata.UID,
+				Name:             np.Metadata.Name,
+				StartTimestampNS: np.Metadata.CreationTimestampNS,
+				StopTimestampNS:  np.Metadata.DeletionTimestampNS,
+				Namespace:        np.Metadata.Namespace,
+				Spec:             np.Spec,
+			},
+		},
+	}
+}
+
 // Stop stops processing incoming k8s metadata updates.
 func (m *Handler) Stop() {
 	m.once.Do(func() {
@@ -176,6 +176,104 @@ func createDeploymentObject() *storepb.K8SResource {
 	}
 }
 
+func createStatefulSetObject() *storepb.K8SResource {
+	pb := &metadatapb.StatefulSet{}
+	err := proto.UnmarshalText(testutils.StatefulSetPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_StatefulSet{
+			StatefulSet: pb,
+		},
+	}
+}
+
+func createDaemonSetObject() *storepb.K8SResource {
+	pb := &metadatapb.DaemonSet{}
+	err := proto.UnmarshalText(testutils.DaemonSetPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_DaemonSet{
+			DaemonSet: pb,
+		},
+	}
+}
+
+func createJobObject() *storepb.K8SResource {
+	pb := &metadatapb.Job{}
+	err := proto.UnmarshalText(testutils.JobPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Job{
+			Job: pb,
+		},
+	}
+}
+
+func createCronJobObject() *storepb.K8SResource {
+	pb := &metadatapb.CronJob{}
+	err := proto.UnmarshalText(testutils.CronJobPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_CronJob{
+			CronJob: pb,
+		},
+	}
+}
+
+func createIngressObject() *storepb.K8SResource {
+	pb := &metadatapb.Ingress{}
+	err := proto.UnmarshalText(testutils.IngressPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Ingress{
+			Ingress: pb,
+		},
+	}
+}
+
+func createHTTPRouteObject() *storepb.K8SResource {
+	pb := &metadatapb.HTTPRoute{}
+	err := proto.UnmarshalText(testutils.HTTPRoutePb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_HTTPRoute{
+			HTTPRoute: pb,
+		},
+	}
+}
+
+func createNetworkPolicyObject() *storepb.K8SResource {
+	pb := &metadatapb.NetworkPolicy{}
+	err := proto.UnmarshalText(testutils.NetworkPolicyPb, pb)
+	if err != nil {
+		return &storepb.K8SResource{}
+	}
+
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_NetworkPolicy{
+			NetworkPolicy: pb,
+		},
+	}
+}
+
 type ResourceStore map[int64]*storepb.K8SResourceUpdate
 type InMemoryStore struct {
 	ResourceStoreByTopic map[string]ResourceStore
@@ -397,7 +495,7 @@ func TestHandler_GetUpdatesForIP(t *testing.T) {
 	require.NoError(t, err)
 
 	updateCh := make(chan *k8smeta.K8sResourceMessage)
-	mdh := k8smeta.NewHandler(updateCh, mds, lps, nil)
+	mdh := k8smeta.NewHandler(updateCh, mds, lps, nil, nil)
 	defer mdh.Stop()
 	updates, err := mdh.GetUpdatesForIP("", 0, 0)
 	require.NoError(t, err)
@@ -446,7 +544,7 @@ func TestHandler_ProcessUpdates(t *testing.T) {
 	nc, natsCleanup := testingutils.MustStartTestNATS(t)
 	defer natsCleanup()
 
-	mdh := k8smeta.NewHandler(updateCh, mds, lps, nc)
+	mdh := k8smeta.NewHandler(updateCh, mds, lps, nil, nc)
 	defer mdh.Stop()
 
 	expectedNSMsg := &messagespb.VizierMessage{
@@ -1594,3 +1692,410 @@ func TestDeploymentUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 	assert.Contains(t, updates[0].Topics, "127.0.0.1")
 	assert.Contains(t, updates[0].Topics, "127.0.0.2")
 }
+
+func TestStatefulSetUpdateProcessor(t *testing.T) {
+	// Construct stateful set object.
+	o := createStatefulSetObject()
+	p := k8smeta.StatefulSetUpdateProcessor{}
+
+	p.SetDeleted(o)
+	assert.Equal(t, int64(6), o.GetStatefulSet().Metadata.DeletionTimestampNS)
+
+	o.GetStatefulSet().Metadata.DeletionTimestampNS = 0
+	p.SetDeleted(o)
+	assert.NotEqual(t, 0, o.GetStatefulSet().Metadata.DeletionTimestampNS)
+}
+
+func TestStatefulSetUpdateProcessor_ValidateUpdate(t *testing.T) {
+	p := k8smeta.StatefulSetUpdateProcessor{}
+	state := &k8smeta.ProcessorState{}
+
+	assert.True(t, p.ValidateUpdate(createStatefulSetObject(), state))
+	assert.False(t, p.ValidateUpdate(createDeploymentObject(), state))
+}
+
+func TestStatefulSetUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        createStatefulSetObject(),
+			UpdateVersion: 2,
+		},
+	}
+
+	state := &k8smeta.ProcessorState{NodeToIP: map[string]string{
+		"node-1": "127.0.0.1",
+		"node-2": "127.0.0.2",
+	}}
+
+	p := k8smeta.StatefulSetUpdateProcessor{}
+	updates := p.GetUpdatesToSend(storedProtos, state)
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_StatefulSetUpdate{
+			StatefulSetUpdate: &metadatapb.StatefulSetUpdate{
+				UID:                "ss-uid",
+				Name:               "web",
+				StartTimestampNS:   4,
+				StopTimestampNS:    6,
+				Namespace:          "a_namespace",
+				ObservedGeneration: 2,
+				Replicas:           3,
+				ReadyReplicas:      2,
+				CurrentReplicas:    3,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  2,
+				RequestedReplicas:  3,
+				ServiceName:        "web-svc",
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.KelvinUpdateTopic}, updates[0].Topics)
+}
+
+func TestDaemonSetUpdateProcessor(t *testing.T) {
+	// Construct daemon set object.
+	o := createDaemonSetObject()
+	p := k8smeta.DaemonSetUpdateProcessor{}
+
+	p.SetDeleted(o)
+	assert.Equal(t, int64(6), o.GetDaemonSet().Metadata.DeletionTimestampNS)
+
+	o.GetDaemonSet().Metadata.DeletionTimestampNS = 0
+	p.SetDeleted(o)
+	assert.NotEqual(t, 0, o.GetDaemonSet().Metadata.DeletionTimestampNS)
+}
+
+func TestDaemonSetUpdateProcessor_ValidateUpdate(t *testing.T) {
+	p := k8smeta.DaemonSetUpdateProcessor{}
+	state := &k8smeta.ProcessorState{}
+
+	assert.True(t, p.ValidateUpdate(createDaemonSetObject(), state))
+	assert.False(t, p.ValidateUpdate(createDeploymentObject(), state))
+}
+
+func TestDaemonSetUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        createDaemonSetObject(),
+			UpdateVersion: 2,
+		},
+	}
+
+	state := &k8smeta.ProcessorState{NodeToIP: map[string]string{
+		"node-1": "127.0.0.1",
+		"node-2": "127.0.0.2",
+	}}
+
+	p := k8smeta.DaemonSetUpdateProcessor{}
+	updates := p.GetUpdatesToSend(storedProtos, state)
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_DaemonSetUpdate{
+			DaemonSetUpdate: &metadatapb.DaemonSetUpdate{
+				UID:                    "ds-uid",
+				Name:                   "agent",
+				StartTimestampNS:       4,
+				StopTimestampNS:        6,
+				Namespace:              "a_namespace",
+				ObservedGeneration:     7,
+				CurrentNumberScheduled: 3,
+				NumberMisscheduled:     1,
+				DesiredNumberScheduled: 4,
+				NumberReady:            2,
+				UpdatedNumberScheduled: 3,
+				NumberAvailable:        2,
+				NumberUnavailable:      2,
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.KelvinUpdateTopic}, updates[0].Topics)
+}
+
+func TestJobUpdateProcessor(t *testing.T) {
+	// Construct job object.
+	o := createJobObject()
+	p := k8smeta.JobUpdateProcessor{}
+
+	p.SetDeleted(o)
+	assert.Equal(t, int64(6), o.GetJob().Metadata.DeletionTimestampNS)
+
+	o.GetJob().Metadata.DeletionTimestampNS = 0
+	p.SetDeleted(o)
+	assert.NotEqual(t, 0, o.GetJob().Metadata.DeletionTimestampNS)
+}
+
+func TestJobUpdateProcessor_ValidateUpdate(t *testing.T) {
+	p := k8smeta.JobUpdateProcessor{}
+	state := &k8smeta.ProcessorState{}
+
+	assert.True(t, p.ValidateUpdate(createJobObject(), state))
+	assert.False(t, p.ValidateUpdate(createDeploymentObject(), state))
+}
+
+func TestJobUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        createJobObject(),
+			UpdateVersion: 2,
+		},
+	}
+
+	state := &k8smeta.ProcessorState{NodeToIP: map[string]string{
+		"node-1": "127.0.0.1",
+		"node-2": "127.0.0.2",
+	}}
+
+	p := k8smeta.JobUpdateProcessor{}
+	updates := p.GetUpdatesToSend(storedProtos, state)
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_JobUpdate{
+			JobUpdate: &metadatapb.JobUpdate{
+				UID:                  "job-uid",
+				Name:                 "backup-1234",
+				StartTimestampNS:     4,
+				StopTimestampNS:      6,
+				Namespace:            "a_namespace",
+				JobStartTimeNS:       10,
+				JobCompletionTimeNS:  20,
+				Succeeded:            5,
+				RequestedCompletions: 5,
+				RequestedParallelism: 2,
+				Conditions: []*metadatapb.JobCondition{
+					{
+						Type:                 "Complete",
+						Status:               metadatapb.CONDITION_STATUS_TRUE,
+						LastProbeTimeNS:      20,
+						LastTransitionTimeNS: 20,
+					},
+				},
+				OwnerReferences: []*metadatapb.OwnerReference{
+					{
+						Kind: "CronJob",
+						Name: "backup",
+						UID:  "cj-uid",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.KelvinUpdateTopic}, updates[0].Topics)
+}
+
+func TestCronJobUpdateProcessor(t *testing.T) {
+	// Construct cron job object.
+	o := createCronJobObject()
+	p := k8smeta.CronJobUpdateProcessor{}
+
+	p.SetDeleted(o)
+	assert.Equal(t, int64(6), o.GetCronJob().Metadata.DeletionTimestampNS)
+
+	o.GetCronJob().Metadata.DeletionTimestampNS = 0
+	p.SetDeleted(o)
+	assert.NotEqual(t, 0, o.GetCronJob().Metadata.DeletionTimestampNS)
+}
+
+func TestCronJobUpdateProcessor_ValidateUpdate(t *testing.T) {
+	p := k8smeta.CronJobUpdateProcessor{}
+	state := &k8smeta.ProcessorState{}
+
+	assert.True(t, p.ValidateUpdate(createCronJobObject(), state))
+	assert.False(t, p.ValidateUpdate(createDeploymentObject(), state))
+}
+
+func TestCronJobUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        createCronJobObject(),
+			UpdateVersion: 2,
+		},
+	}
+
+	state := &k8smeta.ProcessorState{NodeToIP: map[string]string{
+		"node-1": "127.0.0.1",
+		"node-2": "127.0.0.2",
+	}}
+
+	p := k8smeta.CronJobUpdateProcessor{}
+	updates := p.GetUpdatesToSend(storedProtos, state)
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_CronJobUpdate{
+			CronJobUpdate: &metadatapb.CronJobUpdate{
+				UID:                  "cj-uid",
+				Name:                 "backup",
+				StartTimestampNS:     4,
+				StopTimestampNS:      6,
+				Namespace:            "a_namespace",
+				Schedule:             "*/5 * * * *",
+				LastScheduleTimeNS:   30,
+				LastSuccessfulTimeNS: 25,
+				ActiveJobUIDs:        []string{"job-uid"},
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.KelvinUpdateTopic}, updates[0].Topics)
+}
+
+func TestIngressUpdateProcessor(t *testing.T) {
+	// Construct ingress object.
+	o := createIngressObject()
+	p := k8smeta.IngressUpdateProcessor{}
+
+	p.SetDeleted(o)
+	assert.Equal(t, int64(6), o.GetIngress().Metadata.DeletionTimestampNS)
+
+	o.GetIngress().Metadata.DeletionTimestampNS = 0
+	p.SetDeleted(o)
+	assert.NotEqual(t, 0, o.GetIngress().Metadata.DeletionTimestampNS)
+}
+
+func TestIngressUpdateProcessor_ValidateUpdate(t *testing.T) {
+	p := k8smeta.IngressUpdateProcessor{}
+	state := &k8smeta.ProcessorState{}
+
+	assert.True(t, p.ValidateUpdate(createIngressObject(), state))
+	assert.False(t, p.ValidateUpdate(createHTTPRouteObject(), state))
+}
+
+func TestIngressUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	o := createIngressObject()
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        o,
+			UpdateVersion: 2,
+		},
+	}
+
+	state := &k8smeta.ProcessorState{NodeToIP: map[string]string{
+		"node-1": "127.0.0.1",
+	}}
+
+	p := k8smeta.IngressUpdateProcessor{}
+	assert.True(t, p.IsNodeScoped())
+	updates := p.GetUpdatesToSend(storedProtos, state)
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_IngressUpdate{
+			IngressUpdate: &metadatapb.IngressUpdate{
+				UID:              "ing-uid",
+				Name:             "web",
+				StartTimestampNS: 4,
+				StopTimestampNS:  6,
+				Namespace:        "a_namespace",
+				Spec:             o.GetIngress().Spec,
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	// Networking updates should only go to the networking topic, and not to the agents.
+	assert.Equal(t, []string{k8smeta.NetworkingUpdateTopic}, updates[0].Topics)
+}
+
+func TestHTTPRouteUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	o := createHTTPRouteObject()
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        o,
+			UpdateVersion: 2,
+		},
+	}
+
+	p := k8smeta.HTTPRouteUpdateProcessor{}
+	assert.True(t, p.ValidateUpdate(o, &k8smeta.ProcessorState{}))
+	updates := p.GetUpdatesToSend(storedProtos, &k8smeta.ProcessorState{})
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_HTTPRouteUpdate{
+			HTTPRouteUpdate: &metadatapb.HTTPRouteUpdate{
+				UID:              "route-uid",
+				Name:             "web-route",
+				StartTimestampNS: 4,
+				StopTimestampNS:  6,
+				Namespace:        "a_namespace",
+				Spec:             o.GetHTTPRoute().Spec,
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.NetworkingUpdateTopic}, updates[0].Topics)
+}
+
+func TestNetworkPolicyUpdateProcessor_GetUpdatesToSend(t *testing.T) {
+	o := createNetworkPolicyObject()
+	storedProtos := []*k8smeta.StoredUpdate{
+		{
+			Update:        o,
+			UpdateVersion: 2,
+		},
+	}
+
+	p := k8smeta.NetworkPolicyUpdateProcessor{}
+	assert.True(t, p.ValidateUpdate(o, &k8smeta.ProcessorState{}))
+	updates := p.GetUpdatesToSend(storedProtos, &k8smeta.ProcessorState{})
+	assert.Equal(t, 1, len(updates))
+
+	expectedUpdate := &metadatapb.ResourceUpdate{
+		UpdateVersion: 2,
+		Update: &metadatapb.ResourceUpdate_NetworkPolicyUpdate{
+			NetworkPolicyUpdate: &metadatapb.NetworkPolicyUpdate{
+				UID:              "np-uid",
+				Name:             "allow-web",
+				StartTimestampNS: 4,
+				StopTimestampNS:  6,
+				Namespace:        "a_namespace",
+				Spec:             o.GetNetworkPolicy().Spec,
+			},
+		},
+	}
+
+	assert.Equal(t, expectedUpdate, updates[0].Update)
+	assert.Equal(t, []string{k8smeta.NetworkingUpdateTopic}, updates[0].Topics)
+}
+
+func TestUpdateNetworkingStore(t *testing.T) {
+	nws := &testutils.InMemoryNetworkingStore{
+		Store: make(map[string]*storepb.K8SResource),
+	}
+
+	ing := createIngressObject()
+	ing.GetIngress().Metadata.DeletionTimestampNS = 0
+	err := k8smeta.UpdateNetworkingStore(ing, nws)
+	require.NoError(t, err)
+	err = k8smeta.UpdateNetworkingStore(createPodObject(metadatapb.RUNNING), nws)
+	require.NoError(t, err)
+
+	resources, err := nws.FetchNetworkingResources(k8smeta.NetworkingKindIngress)
+	require.NoError(t, err)
+	assert.Equal(t, []*storepb.K8SResource{ing}, resources)
+	assert.Equal(t, 1, len(nws.Store))
+
+	// A deleted ingress should be removed from the store.
+	err = k8smeta.UpdateNetworkingStore(createIngressObject(), nws)
+	require.NoError(t, err)
+	resources, err = nws.FetchNetworkingResources(k8smeta.NetworkingKindIngress)
+	require.NoError(t, err)
+	assert.Empty(t, resources)
+}
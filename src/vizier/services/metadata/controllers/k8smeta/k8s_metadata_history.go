@@ -0,0 +1,426 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package k8smeta
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	"px.dev/pixie/src/shared/k8s/metadatapb"
+	"px.dev/pixie/src/vizier/services/metadata/storepb"
+)
+
+const (
+	historyObjectPrefix     = "/history/object"          // historyObjectPrefix/<uid> -> <K8sResource>
+	historyIPPrefix         = "/history/ip"              // historyIPPrefix/<ip>/<creationTimestampNS>/<uid> -> <uid>
+	historyPodServicePrefix = "/history/podService"      // historyPodServicePrefix/<podUID>/<namespace>/<name> -> <PodServiceMembership>
+	historyChangePrefix     = "/history/namespaceChange" // historyChangePrefix/<namespace>/<observedTimestampNS>/<updateVersion> -> <K8sResourceChange>
+)
+
+// HistoryPolicy configures how long the history of the K8s resources is retained.
+type HistoryPolicy struct {
+	// ResourceRetention is how long a resource, and the IPs and containers it held, can be looked up
+	// after it is deleted. History is not recorded if 0.
+	ResourceRetention time.Duration
+	// ChangeRetention is how long the changes to each namespace are kept. ResourceRetention is used if 0.
+	ChangeRetention time.Duration
+}
+
+func (p HistoryPolicy) enabled() bool {
+	return p.ResourceRetention > 0
+}
+
+// ProcessRetention gets how long terminated processes should be kept, so that their UPIDs can be attributed
+// to pods for as long as the history of the pods is retained. It is never less than minRetention.
+func (p HistoryPolicy) ProcessRetention(minRetention time.Duration) time.Duration {
+	if p.enabled() && p.ResourceRetention > minRetention {
+		return p.ResourceRetention
+	}
+	return minRetention
+}
+
+func (p HistoryPolicy) changeRetention() time.Duration {
+	if p.ChangeRetention > 0 {
+		return p.ChangeRetention
+	}
+	return p.ResourceRetention
+}
+
+// prefix/<uid>
+func getHistoryObjectKey(uid string) string {
+	return path.Join(historyObjectPrefix, uid)
+}
+
+// prefix/<ip>/<creationTimestampNS>/<uid>
+func getHistoryIPKey(ip string, creationTimestampNS int64, uid string) string {
+	return path.Join(historyIPPrefix, ip, fmt.Sprintf("%020d", creationTimestampNS), uid)
+}
+
+// prefix/<podUID>/<namespace>/<name>
+func getHistoryPodServiceKey(podUID string, namespace string, name string) string {
+	return path.Join(historyPodServicePrefix, podUID, namespace, name)
+}
+
+// prefix/<namespace>/<observedTimestampNS>/<updateVersion>
+func getHistoryChangeKey(namespace string, observedTimestampNS int64, updateVersion int64) string {
+	return path.Join(historyChangePrefix, namespace, fmt.Sprintf("%020d", observedTimestampNS), fmt.Sprintf("%020d", updateVersion))
+}
+
+// resourceMetadata gets the object metadata of the resource, or nil if it has none.
+func resourceMetadata(r *storepb.K8SResource) *metadatapb.ObjectMetadata {
+	switch res := r.Resource.(type) {
+	case *storepb.K8SResource_Pod:
+		return res.Pod.Metadata
+	case *storepb.K8SResource_Service:
+		return res.Service.Metadata
+	case *storepb.K8SResource_Endpoints:
+		return res.Endpoints.Metadata
+	case *storepb.K8SResource_Namespace:
+		return res.Namespace.Metadata
+	case *storepb.K8SResource_Node:
+		return res.Node.Metadata
+	case *storepb.K8SResource_ReplicaSet:
+		return res.ReplicaSet.Metadata
+	case *storepb.K8SResource_Deployment:
+		return res.Deployment.Metadata
+	case *storepb.K8SResource_StatefulSet:
+		return res.StatefulSet.Metadata
+	case *storepb.K8SResource_DaemonSet:
+		return res.DaemonSet.Metadata
+	case *storepb.K8SResource_Job:
+		return res.Job.Metadata
+	case *storepb.K8SResource_CronJob:
+		return res.CronJob.Metadata
+	case *storepb.K8SResource_Ingress:
+		return res.Ingress.Metadata
+	case *storepb.K8SResource_HTTPRoute:
+		return res.HTTPRoute.Metadata
+	case *storepb.K8SResource_NetworkPolicy:
+		return res.NetworkPolicy.Metadata
+	default:
+		return nil
+	}
+}
+
+// resourceHistoryID gets the ID that the resource's history is recorded under, the namespace that its
+// changes belong to, and whether it has been deleted. Containers are recorded under their CID.
+func resourceHistoryID(r *storepb.K8SResource) (string, string, bool) {
+	if c := r.GetContainer(); c != nil {
+		return c.CID, c.Namespace, c.StopTimestampNS != 0
+	}
+
+	md := resourceMetadata(r)
+	if md == nil {
+		return "", "", false
+	}
+	namespace := md.Namespace
+	if r.GetNamespace() != nil {
+		namespace = md.Name
+	}
+	return md.UID, namespace, md.DeletionTimestampNS != 0
+}
+
+// resourceIPs gets the IPs that are held by the resource. Pods on the host network are skipped, since
+// they share the IP of their node.
+func resourceIPs(r *storepb.K8SResource) []string {
+	if pod := r.GetPod(); pod != nil && pod.Status != nil {
+		if pod.Status.PodIP != "" && pod.Status.PodIP != pod.Status.HostIP {
+			return []string{pod.Status.PodIP}
+		}
+	}
+	if svc := r.GetService(); svc != nil && svc.Spec != nil {
+		if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != "None" {
+			return []string{svc.Spec.ClusterIP}
+		}
+	}
+	return nil
+}
+
+// aliveAt returns whether the resource existed at the given time.
+func aliveAt(md *metadatapb.ObjectMetadata, timestampNS int64) bool {
+	if md.CreationTimestampNS > timestampNS {
+		return false
+	}
+	return md.DeletionTimestampNS == 0 || md.DeletionTimestampNS > timestampNS
+}
+
+// addResourceHistory records the resource in the history, if the history policy enables it. Resources that
+// still exist are refreshed by the informer resync, so their history only expires once they are deleted.
+func (m *Datastore) addResourceHistory(updateVersion int64, resource *storepb.K8SResource, val []byte) error {
+	if !m.historyPolicy.enabled() {
+		return nil
+	}
+
+	uid, namespace, deleted := resourceHistoryID(resource)
+	if uid == "" {
+		return nil
+	}
+
+	ttl := resourceUpdateTTL + m.historyPolicy.ResourceRetention
+	if deleted {
+		ttl = m.historyPolicy.ResourceRetention
+	}
+
+	prev, err := m.ds.Get(getHistoryObjectKey(uid))
+	if err != nil {
+		return err
+	}
+	err = m.ds.SetWithTTL(getHistoryObjectKey(uid), string(val), ttl)
+	if err != nil {
+		return err
+	}
+
+	if md := resourceMetadata(resource); md != nil {
+		for _, ip := range resourceIPs(resource) {
+			err = m.ds.SetWithTTL(getHistoryIPKey(ip, md.CreationTimestampNS, uid), uid, ttl)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if ep := resource.GetEndpoints(); ep != nil {
+		err = m.updatePodServices(ep, prev, deleted)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The informer resync sends resources that haven't changed, which shouldn't be recorded as changes.
+	// Cluster-scoped resources, such as nodes, don't belong to a namespace.
+	if namespace == "" || bytes.Equal(prev, val) {
+		return nil
+	}
+
+	change := &storepb.K8SResourceChange{
+		ObservedTimestampNS: time.Now().UnixNano(),
+		UpdateVersion:       updateVersion,
+		Resource:            resource,
+	}
+	b, err := change.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.ds.SetWithTTL(getHistoryChangeKey(namespace, change.ObservedTimestampNS, updateVersion), string(b), m.historyPolicy.changeRetention())
+}
+
+// endpointPodUIDs gets the UIDs of the pods that are endpoints of the service, whether they are ready or not.
+func endpointPodUIDs(ep *metadatapb.Endpoints) map[string]bool {
+	uids := make(map[string]bool)
+	for _, subset := range ep.Subsets {
+		addrs := append(append([]*metadatapb.EndpointAddress{}, subset.Addresses...), subset.NotReadyAddresses...)
+		for _, addr := range addrs {
+			ref := addr.TargetRef
+			if ref == nil || ref.Kind != "Pod" || ref.UID == "" {
+				continue
+			}
+			uids[ref.UID] = true
+		}
+	}
+	return uids
+}
+
+// updatePodServices records when pods are added to and removed from the endpoints of the service. prev is the
+// previously recorded state of the endpoints, which is used to find the pods that were removed.
+func (m *Datastore) updatePodServices(ep *metadatapb.Endpoints, prev []byte, deleted bool) error {
+	if ep.Metadata == nil {
+		return nil
+	}
+	nowNS := time.Now().UnixNano()
+
+	current := endpointPodUIDs(ep)
+	removed := make(map[string]bool)
+	if deleted {
+		removed, current = current, nil
+	}
+	if prev != nil {
+		prevResource := &storepb.K8SResource{}
+		if err := proto.Unmarshal(prev, prevResource); err != nil {
+			return err
+		}
+		if prevEp := prevResource.GetEndpoints(); prevEp != nil {
+			for uid := range endpointPodUIDs(prevEp) {
+				if !current[uid] {
+					removed[uid] = true
+				}
+			}
+		}
+	}
+
+	for uid := range current {
+		if err := m.setPodServiceMembership(uid, ep.Metadata, nowNS, false); err != nil {
+			return err
+		}
+	}
+	for uid := range removed {
+		if err := m.setPodServiceMembership(uid, ep.Metadata, nowNS, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPodServiceMembership records that the pod is an endpoint of the service at nowNS, or that it was removed
+// from the endpoints at nowNS. A pod that rejoins the endpoints keeps its first seen time. Memberships that
+// have ended are kept for the retention period, and the others for as long as they keep being refreshed.
+func (m *Datastore) setPodServiceMembership(podUID string, md *metadatapb.ObjectMetadata, nowNS int64, removed bool) error {
+	key := getHistoryPodServiceKey(podUID, md.Namespace, md.Name)
+	val, err := m.ds.Get(key)
+	if err != nil {
+		return err
+	}
+	membership := &storepb.PodServiceMembership{
+		Service:     path.Join(md.Namespace, md.Name),
+		FirstSeenNS: nowNS,
+	}
+	if val != nil {
+		if err := proto.Unmarshal(val, membership); err != nil {
+			return err
+		}
+	}
+
+	ttl := resourceUpdateTTL + m.historyPolicy.ResourceRetention
+	if removed {
+		// Only pods that are known to have been endpoints can be removed, and only once.
+		if val == nil || membership.LastSeenNS != 0 {
+			return nil
+		}
+		membership.LastSeenNS = nowNS
+		ttl = m.historyPolicy.ResourceRetention
+	} else {
+		membership.LastSeenNS = 0
+	}
+
+	b, err := membership.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.ds.SetWithTTL(key, string(b), ttl)
+}
+
+// HistoryEnabled returns whether the history policy enables recording the history of the K8s resources.
+func (m *Datastore) HistoryEnabled() bool {
+	return m.historyPolicy.enabled()
+}
+
+// FetchResourceWithUID gets the last known state of the resource with the given UID.
+func (m *Datastore) FetchResourceWithUID(uid string) (*storepb.K8SResource, error) {
+	val, err := m.ds.Get(getHistoryObjectKey(uid))
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	pb := &storepb.K8SResource{}
+	err = proto.Unmarshal(val, pb)
+	if err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+// FetchResourcesWithIP gets the pods and services that held the IP at the given time, most recently
+// created first.
+func (m *Datastore) FetchResourcesWithIP(ip string, timestampNS int64) ([]*storepb.K8SResource, error) {
+	// Only resources that were created by the given time can have held the IP.
+	_, vals, err := m.ds.GetWithRange(getHistoryIPKey(ip, 0, ""), getHistoryIPKey(ip, timestampNS+1, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*storepb.K8SResource, 0)
+	for i := len(vals) - 1; i >= 0; i-- {
+		r, err := m.FetchResourceWithUID(string(vals[i]))
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			continue
+		}
+		if md := resourceMetadata(r); md != nil && aliveAt(md, timestampNS) {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// FetchPodUIDForContainer gets the UID of the pod that the container ran in.
+func (m *Datastore) FetchPodUIDForContainer(cid string) (string, error) {
+	r, err := m.FetchResourceWithUID(cid)
+	if err != nil || r == nil {
+		return "", err
+	}
+	return r.GetContainer().GetPodID(), nil
+}
+
+// FetchPodServices gets the services, in <namespace>/<name> format, that the pod was an endpoint of at the
+// given time.
+func (m *Datastore) FetchPodServices(podUID string, timestampNS int64) ([]string, error) {
+	// Add a trailing slash so that UIDs which share a prefix aren't matched.
+	_, vals, err := m.ds.GetWithPrefix(path.Join(historyPodServicePrefix, podUID) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0)
+	for _, val := range vals {
+		membership := &storepb.PodServiceMembership{}
+		if err := proto.Unmarshal(val, membership); err != nil {
+			return nil, err
+		}
+		if membership.FirstSeenNS > timestampNS {
+			continue
+		}
+		if membership.LastSeenNS != 0 && membership.LastSeenNS < timestampNS {
+			continue
+		}
+		services = append(services, membership.Service)
+	}
+	return services, nil
+}
+
+// FetchNamespaceChanges gets the changes to the resources in the namespace from the `from` time,
+// to the `to` time (exclusive). At most limit changes are returned, unless limit is 0.
+func (m *Datastore) FetchNamespaceChanges(namespace string, from int64, to int64, limit int) ([]*storepb.K8SResourceChange, error) {
+	_, vals, err := m.ds.GetWithRange(path.Join(historyChangePrefix, namespace, fmt.Sprintf("%020d", from)),
+		path.Join(historyChangePrefix, namespace, fmt.Sprintf("%020d", to)))
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(vals) > limit {
+		vals = vals[:limit]
+	}
+
+	changes := make([]*storepb.K8SResourceChange, 0, len(vals))
+	for _, val := range vals {
+		pb := &storepb.K8SResourceChange{}
+		err = proto.Unmarshal(val, pb)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, pb)
+	}
+	return changes, nil
+}
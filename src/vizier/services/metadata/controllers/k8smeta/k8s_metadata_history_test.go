@@ -0,0 +1,346 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package k8smeta
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/shared/k8s/metadatapb"
+	"px.dev/pixie/src/vizier/services/metadata/storepb"
+	"px.dev/pixie/src/vizier/utils/datastore/pebbledb"
+)
+
+func setupHistoryTest(t *testing.T) (*Datastore, func()) {
+	memFS := vfs.NewMem()
+	c, err := pebble.Open("test", &pebble.Options{
+		FS: memFS,
+	})
+	if err != nil {
+		t.Fatal("failed to initialize a pebbledb")
+		os.Exit(1)
+	}
+
+	db := pebbledb.New(c, 3*time.Second)
+	mds := NewDatastore(db, WithHistoryPolicy(HistoryPolicy{ResourceRetention: time.Hour}))
+	cleanup := func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatal("failed to close db")
+		}
+	}
+
+	return mds, cleanup
+}
+
+func historyPod(uid string, ip string, creationNS int64, deletionNS int64) *storepb.K8SResource {
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Pod{
+			Pod: &metadatapb.Pod{
+				Metadata: &metadatapb.ObjectMetadata{
+					Name:                uid,
+					Namespace:           "ns",
+					UID:                 uid,
+					CreationTimestampNS: creationNS,
+					DeletionTimestampNS: deletionNS,
+					OwnerReferences: []*metadatapb.OwnerReference{
+						{
+							Kind: "ReplicaSet",
+							Name: "rs",
+							UID:  "rs_uid",
+						},
+					},
+				},
+				Status: &metadatapb.PodStatus{
+					PodIP:  ip,
+					HostIP: "10.0.0.1",
+				},
+			},
+		},
+	}
+}
+
+func TestDatastore_ResourceHistory(t *testing.T) {
+	mds, cleanup := setupHistoryTest(t)
+	defer cleanup()
+
+	updates := []*storepb.K8SResource{
+		historyPod("pod1", "1.2.3.4", 10, 0),
+		historyPod("pod1", "1.2.3.4", 10, 50),
+		historyPod("pod2", "1.2.3.4", 60, 0),
+		// Pods on the host network share the IP of their node.
+		historyPod("pod3", "10.0.0.1", 60, 0),
+		{
+			Resource: &storepb.K8SResource_Service{
+				Service: &metadatapb.Service{
+					Metadata: &metadatapb.ObjectMetadata{
+						Name:                "svc",
+						Namespace:           "ns",
+						UID:                 "svc_uid",
+						CreationTimestampNS: 5,
+					},
+					Spec: &metadatapb.ServiceSpec{
+						ClusterIP: "10.96.0.10",
+					},
+				},
+			},
+		},
+		{
+			Resource: &storepb.K8SResource_Endpoints{
+				Endpoints: &metadatapb.Endpoints{
+					Metadata: &metadatapb.ObjectMetadata{
+						Name:      "svc",
+						Namespace: "ns",
+						UID:       "ep_uid",
+					},
+					Subsets: []*metadatapb.EndpointSubset{
+						{
+							Addresses: []*metadatapb.EndpointAddress{
+								{
+									IP:        "1.2.3.4",
+									TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "pod2", UID: "pod2"},
+								},
+							},
+							NotReadyAddresses: []*metadatapb.EndpointAddress{
+								{
+									IP:        "1.2.3.5",
+									TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "pod4", UID: "pod4"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Resource: &storepb.K8SResource_Container{
+				Container: &metadatapb.ContainerUpdate{
+					CID:       "container1",
+					Namespace: "ns",
+					PodID:     "pod2",
+				},
+			},
+		},
+	}
+	for i, u := range updates {
+		require.NoError(t, mds.AddFullResourceUpdate(int64(i+1), u))
+	}
+
+	pod, err := mds.FetchResourceWithUID("pod1")
+	require.NoError(t, err)
+	assert.Equal(t, updates[1], pod)
+
+	missing, err := mds.FetchResourceWithUID("abcd")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	tests := []struct {
+		name         string
+		ip           string
+		timestampNS  int64
+		expectedUIDs []string
+	}{
+		{"before creation", "1.2.3.4", 5, []string{}},
+		{"first pod", "1.2.3.4", 20, []string{"pod1"}},
+		{"between pods", "1.2.3.4", 55, []string{}},
+		{"second pod", "1.2.3.4", 70, []string{"pod2"}},
+		{"service", "10.96.0.10", 70, []string{"svc_uid"}},
+		{"host network", "10.0.0.1", 70, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources, err := mds.FetchResourcesWithIP(test.ip, test.timestampNS)
+			require.NoError(t, err)
+			uids := make([]string, len(resources))
+			for i, r := range resources {
+				uids[i] = resourceMetadata(r).UID
+			}
+			assert.Equal(t, test.expectedUIDs, uids)
+		})
+	}
+
+	podUID, err := mds.FetchPodUIDForContainer("container1")
+	require.NoError(t, err)
+	assert.Equal(t, "pod2", podUID)
+
+	podUID, err = mds.FetchPodUIDForContainer("container2")
+	require.NoError(t, err)
+	assert.Equal(t, "", podUID)
+
+	services, err := mds.FetchPodServices("pod2", time.Now().UnixNano())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/svc"}, services)
+
+	services, err = mds.FetchPodServices("pod4", time.Now().UnixNano())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/svc"}, services)
+
+	services, err = mds.FetchPodServices("pod1", time.Now().UnixNano())
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, services)
+}
+
+func historyEndpoints(podUIDs ...string) *storepb.K8SResource {
+	addrs := make([]*metadatapb.EndpointAddress, len(podUIDs))
+	for i, uid := range podUIDs {
+		addrs[i] = &metadatapb.EndpointAddress{
+			TargetRef: &metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: uid, UID: uid},
+		}
+	}
+	return &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Endpoints{
+			Endpoints: &metadatapb.Endpoints{
+				Metadata: &metadatapb.ObjectMetadata{Name: "svc", Namespace: "ns", UID: "ep_uid"},
+				Subsets:  []*metadatapb.EndpointSubset{{Addresses: addrs}},
+			},
+		},
+	}
+}
+
+func TestDatastore_PodServicesAtTime(t *testing.T) {
+	mds, cleanup := setupHistoryTest(t)
+	defer cleanup()
+
+	fetch := func(podUID string, timestampNS int64) []string {
+		services, err := mds.FetchPodServices(podUID, timestampNS)
+		require.NoError(t, err)
+		return services
+	}
+
+	beforeAdd := time.Now().UnixNano()
+	require.NoError(t, mds.AddFullResourceUpdate(1, historyEndpoints("pod1", "pod2")))
+	afterAdd := time.Now().UnixNano()
+	// A resync doesn't move the time that the pods were first seen.
+	require.NoError(t, mds.AddFullResourceUpdate(2, historyEndpoints("pod1", "pod2")))
+	require.NoError(t, mds.AddFullResourceUpdate(3, historyEndpoints("pod2")))
+	afterRemove := time.Now().UnixNano()
+
+	assert.Equal(t, []string{}, fetch("pod1", beforeAdd))
+	assert.Equal(t, []string{"ns/svc"}, fetch("pod1", afterAdd))
+	assert.Equal(t, []string{}, fetch("pod1", afterRemove))
+	assert.Equal(t, []string{"ns/svc"}, fetch("pod2", afterAdd))
+	assert.Equal(t, []string{"ns/svc"}, fetch("pod2", afterRemove))
+
+	// Deleting the endpoints removes the remaining pods.
+	deleted := historyEndpoints("pod2")
+	deleted.GetEndpoints().Metadata.DeletionTimestampNS = afterRemove
+	require.NoError(t, mds.AddFullResourceUpdate(4, deleted))
+	afterDelete := time.Now().UnixNano()
+	assert.Equal(t, []string{"ns/svc"}, fetch("pod2", afterRemove))
+	assert.Equal(t, []string{}, fetch("pod2", afterDelete))
+}
+
+func TestDatastore_NamespaceChanges(t *testing.T) {
+	mds, cleanup := setupHistoryTest(t)
+	defer cleanup()
+
+	start := time.Now().UnixNano()
+	require.NoError(t, mds.AddFullResourceUpdate(1, historyPod("pod1", "1.2.3.4", 10, 0)))
+	// Resyncs of unchanged resources aren't recorded as changes.
+	require.NoError(t, mds.AddFullResourceUpdate(2, historyPod("pod1", "1.2.3.4", 10, 0)))
+	require.NoError(t, mds.AddFullResourceUpdate(3, historyPod("pod2", "1.2.3.5", 20, 0)))
+	require.NoError(t, mds.AddFullResourceUpdate(4, historyPod("pod1", "1.2.3.4", 10, 50)))
+	require.NoError(t, mds.AddFullResourceUpdate(5, &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Namespace{
+			Namespace: &metadatapb.Namespace{
+				Metadata: &metadatapb.ObjectMetadata{
+					Name: "other_ns",
+					UID:  "ns_uid",
+				},
+			},
+		},
+	}))
+	end := time.Now().UnixNano() + 1
+
+	changes, err := mds.FetchNamespaceChanges("ns", start, end, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	versions := make([]int64, len(changes))
+	for i, c := range changes {
+		versions[i] = c.UpdateVersion
+		assert.GreaterOrEqual(t, c.ObservedTimestampNS, start)
+	}
+	assert.Equal(t, []int64{1, 3, 4}, versions)
+	assert.Equal(t, historyPod("pod1", "1.2.3.4", 10, 50), changes[2].Resource)
+
+	changes, err = mds.FetchNamespaceChanges("ns", start, end, 2)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	changes, err = mds.FetchNamespaceChanges("ns", end, end+int64(time.Hour), 0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 0)
+
+	changes, err = mds.FetchNamespaceChanges("other_ns", start, end, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, int64(5), changes[0].UpdateVersion)
+
+	require.NoError(t, mds.ds.Set(getHistoryChangeKey("ns", end-1, 6), "not a change"))
+	_, err = mds.FetchNamespaceChanges("ns", start, end, 0)
+	assert.Error(t, err)
+}
+
+func TestDatastore_HistoryDisabled(t *testing.T) {
+	db, mds, cleanup := setupMDSTest(t)
+	defer cleanup()
+
+	assert.False(t, mds.HistoryEnabled())
+	require.NoError(t, mds.AddFullResourceUpdate(1, historyPod("pod1", "1.2.3.4", 10, 0)))
+
+	keys, _, err := db.GetWithPrefix("/history")
+	require.NoError(t, err)
+	assert.Len(t, keys, 0)
+}
+
+func TestHistoryPolicy_ProcessRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   HistoryPolicy
+		expected time.Duration
+	}{
+		{
+			name:     "disabled",
+			policy:   HistoryPolicy{},
+			expected: 24 * time.Hour,
+		},
+		{
+			name:     "shorter retention",
+			policy:   HistoryPolicy{ResourceRetention: time.Hour},
+			expected: 24 * time.Hour,
+		},
+		{
+			name:     "longer retention",
+			policy:   HistoryPolicy{ResourceRetention: 72 * time.Hour},
+			expected: 72 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.ProcessRetention(24*time.Hour))
+		})
+	}
+}
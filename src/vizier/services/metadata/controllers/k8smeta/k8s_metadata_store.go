@@ -38,18 +38,35 @@ const (
 	topicVersionPrefix        = "/topicVersion"
 	labelPodUpdatePrefix      = "/labelPodUpdate" // labelPodUpdatePrefix/<namespace>/<labelKey>/<podName> -> <labelValue>
 	podLabelUpdatePrefix      = "/podLabelUpdate" // podLabelUpdatePrefix/<namespace>/<podName> -> [<labelKeys>]
+	networkingPrefix          = "/networking"     // networkingPrefix/<kind>/<namespace>/<name> -> <K8sResource>
 	// The topic for partial resource updates, which are not specific to a particular node.
 	unscopedTopic = "unscoped"
 )
 
 // Datastore implements the Store interface on a given Datastore.
 type Datastore struct {
-	ds datastore.MultiGetterSetterDeleterCloser
+	ds            datastore.MultiGetterSetterDeleterCloser
+	historyPolicy HistoryPolicy
+}
+
+// DatastoreOption configures optional behavior of the Datastore.
+type DatastoreOption func(*Datastore)
+
+// WithHistoryPolicy makes the datastore record the history of the K8s resources, and retain it as
+// specified by the policy.
+func WithHistoryPolicy(p HistoryPolicy) DatastoreOption {
+	return func(m *Datastore) {
+		m.historyPolicy = p
+	}
 }
 
 // NewDatastore wraps the datastore in a metadata store.
-func NewDatastore(ds datastore.MultiGetterSetterDeleterCloser) *Datastore {
-	return &Datastore{ds}
+func NewDatastore(ds datastore.MultiGetterSetterDeleterCloser, opts ...DatastoreOption) *Datastore {
+	m := &Datastore{ds: ds}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func getFullResourceUpdateKey(version int64) string {
@@ -79,6 +96,11 @@ func getNSPodUpdateKey(namespace string, podName string) string {
 	return path.Join(podLabelUpdatePrefix, namespace, podName)
 }
 
+// prefix/<kind>/<namespace>/<name>
+func getNetworkingKey(kind string, namespace string, name string) string {
+	return path.Join(networkingPrefix, kind, namespace, name)
+}
+
 func labelPodUpdateKeyToPodName(updateKey string) string {
 	keys := strings.Split(updateKey, "/")
 	return keys[len(keys)-1]
@@ -114,7 +136,12 @@ func (m *Datastore) AddFullResourceUpdate(updateVersion int64, resource *storepb
 		return err
 	}
 
-	return m.ds.SetWithTTL(getFullResourceUpdateKey(updateVersion), string(val), resourceUpdateTTL)
+	err = m.ds.SetWithTTL(getFullResourceUpdateKey(updateVersion), string(val), resourceUpdateTTL)
+	if err != nil {
+		return err
+	}
+
+	return m.addResourceHistory(updateVersion, resource, val)
 }
 
 // FetchResourceUpdates gets the resource updates from the `from` update version, to the `to`
@@ -362,6 +389,41 @@ func (m *Datastore) FetchPodsWithLabels(namespace string, labels map[string]stri
 	return result, nil
 }
 
+// SetNetworkingResource stores the latest version of the resource of the given kind. The informers resync
+// every 12 hours, so resources that don't change are refreshed before they expire.
+func (m *Datastore) SetNetworkingResource(kind string, namespace string, name string, resource *storepb.K8SResource) error {
+	val, err := resource.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.ds.SetWithTTL(getNetworkingKey(kind, namespace, name), string(val), resourceUpdateTTL)
+}
+
+// DeleteNetworkingResource deletes the resource of the given kind.
+func (m *Datastore) DeleteNetworkingResource(kind string, namespace string, name string) error {
+	return m.ds.Delete(getNetworkingKey(kind, namespace, name))
+}
+
+// FetchNetworkingResources gets all of the stored resources of the given kind.
+func (m *Datastore) FetchNetworkingResources(kind string) ([]*storepb.K8SResource, error) {
+	// Add a trailing slash so that kinds which share a prefix aren't matched.
+	_, vals, err := m.ds.GetWithPrefix(path.Join(networkingPrefix, kind) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*storepb.K8SResource, len(vals))
+	for i, val := range vals {
+		pb := &storepb.K8SResource{}
+		err := proto.Unmarshal(val, pb)
+		if err != nil {
+			return nil, err
+		}
+		resources[i] = pb
+	}
+	return resources, nil
+}
+
 // GetWithPrefix gets all keys and values with the given prefix, for debugging purposes.
 func (m *Datastore) GetWithPrefix(prefix string) ([]string, [][]byte, error) {
 	return m.ds.GetWithPrefix(prefix)
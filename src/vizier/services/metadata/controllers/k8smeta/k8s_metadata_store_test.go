@@ -443,3 +443,48 @@ func TestDataStore_FetchPodsWithLabels(t *testing.T) {
 	require.NoError(t, err)
 	assert.ElementsMatch(t, []string{"pod1", "pod2", "pod3"}, pods)
 }
+
+func TestDatastore_NetworkingResources(t *testing.T) {
+	db, mds, cleanup := setupMDSTest(t)
+	defer cleanup()
+
+	ingress := &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Ingress{
+			Ingress: &metadatapb.Ingress{
+				Metadata: &metadatapb.ObjectMetadata{Name: "web", Namespace: "ns1"},
+			},
+		},
+	}
+	policy := &storepb.K8SResource{
+		Resource: &storepb.K8SResource_NetworkPolicy{
+			NetworkPolicy: &metadatapb.NetworkPolicy{
+				Metadata: &metadatapb.ObjectMetadata{Name: "web", Namespace: "ns1"},
+			},
+		},
+	}
+
+	err := mds.SetNetworkingResource(NetworkingKindIngress, "ns1", "web", ingress)
+	require.NoError(t, err)
+	err = mds.SetNetworkingResource(NetworkingKindNetworkPolicy, "ns1", "web", policy)
+	require.NoError(t, err)
+
+	val, err := db.Get(getNetworkingKey(NetworkingKindIngress, "ns1", "web"))
+	require.NoError(t, err)
+	assert.NotNil(t, val)
+
+	// Only resources of the requested kind should be returned.
+	resources, err := mds.FetchNetworkingResources(NetworkingKindIngress)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resources))
+	assert.Equal(t, ingress, resources[0])
+
+	err = mds.DeleteNetworkingResource(NetworkingKindIngress, "ns1", "web")
+	require.NoError(t, err)
+	resources, err = mds.FetchNetworkingResources(NetworkingKindIngress)
+	require.NoError(t, err)
+	assert.Empty(t, resources)
+
+	resources, err = mds.FetchNetworkingResources(NetworkingKindNetworkPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, []*storepb.K8SResource{policy}, resources)
+}
@@ -21,10 +21,18 @@ package k8smeta
 import (
 	log "github.com/sirupsen/logrus"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	k8sdiscovery "k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"px.dev/pixie/src/shared/k8s"
@@ -133,6 +141,50 @@ func startEndpointsWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{},
 	}
 }
 
+// startEndpointSliceWatcher watches discovery.k8s.io/v1 EndpointSlices and sends them out as
+// merged Endpoints, so that downstream processing doesn't depend on which API is used.
+func startEndpointSliceWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		slices := factory.Discovery().V1().EndpointSlices()
+		inf := slices.Informer()
+		_, _ = inf.AddEventHandler(createEndpointSliceHandlers(slices.Lister(), ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func createEndpointSliceHandlers(lister discoverylisters.EndpointSliceLister, ch chan *K8sResourceMessage) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			msg, _ := endpointSliceConverter(lister, obj)
+			if msg != nil {
+				msg.EventType = watch.Added
+				ch <- msg
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			msg, _ := endpointSliceConverter(lister, newObj)
+			if msg != nil {
+				msg.EventType = watch.Modified
+				ch <- msg
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			msg, remaining := endpointSliceConverter(lister, obj)
+			if msg != nil {
+				// The service's endpoints are only deleted once its last slice is gone.
+				msg.EventType = watch.Modified
+				if remaining == 0 {
+					msg.EventType = watch.Deleted
+				}
+				ch <- msg
+			}
+		},
+	}
+}
+
 func startReplicaSetWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
 	for _, factory := range factories {
 		inf := factory.Apps().V1().ReplicaSets().Informer()
@@ -149,6 +201,85 @@ func startDeploymentWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{},
 	}
 }
 
+func startStatefulSetWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Apps().V1().StatefulSets().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(statefulSetConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func startDaemonSetWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Apps().V1().DaemonSets().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(daemonSetConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func startJobWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Batch().V1().Jobs().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(jobConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func startCronJobWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Batch().V1().CronJobs().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(cronJobConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func startIngressWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Networking().V1().Ingresses().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(ingressConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+func startNetworkPolicyWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []informers.SharedInformerFactory) {
+	for _, factory := range factories {
+		inf := factory.Networking().V1().NetworkPolicies().Informer()
+		_, _ = inf.AddEventHandler(createHandlers(networkPolicyConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
+// httpRouteGroupVersions are the Gateway API versions that HTTPRoutes are watched at, in order of preference.
+var httpRouteGroupVersions = []schema.GroupVersion{
+	{Group: "gateway.networking.k8s.io", Version: "v1"},
+	{Group: "gateway.networking.k8s.io", Version: "v1beta1"},
+}
+
+// httpRouteResource finds the HTTPRoute resource served by the API server. HTTPRoutes are CRDs, so
+// false is returned if the Gateway API isn't installed in the cluster.
+func httpRouteResource(dc k8sdiscovery.DiscoveryInterface) (schema.GroupVersionResource, bool) {
+	for _, gv := range httpRouteGroupVersions {
+		resources, err := dc.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == "httproutes" {
+				return gv.WithResource(r.Name), true
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+func startHTTPRouteWatcher(ch chan *K8sResourceMessage, quitCh <-chan struct{}, factories []dynamicinformer.DynamicSharedInformerFactory, gvr schema.GroupVersionResource) {
+	for _, factory := range factories {
+		inf := factory.ForResource(gvr).Informer()
+		_, _ = inf.AddEventHandler(createHandlers(httpRouteConverter, ch))
+		go inf.Run(quitCh)
+	}
+}
+
 func podConverter(obj interface{}) *K8sResourceMessage {
 	return &K8sResourceMessage{
 		ObjectType: "pods",
@@ -193,6 +324,40 @@ func endpointsConverter(obj interface{}) *K8sResourceMessage {
 	}
 }
 
+// endpointSliceConverter merges all of the slices that belong to the same service as the given
+// slice into a single Endpoints message. It also returns how many slices the service currently has.
+func endpointSliceConverter(lister discoverylisters.EndpointSliceLister, obj interface{}) (*K8sResourceMessage, int) {
+	slice, ok := obj.(*discovery.EndpointSlice)
+	if !ok {
+		return nil, 0
+	}
+	serviceName := slice.Labels[discovery.LabelServiceName]
+	if serviceName == "" {
+		// Slices that don't belong to a service can't be attributed to one.
+		return nil, 0
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{discovery.LabelServiceName: serviceName})
+	slices, err := lister.EndpointSlices(slice.Namespace).List(selector)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to list endpoint slices for service %s/%s", slice.Namespace, serviceName)
+		return nil, 0
+	}
+	remaining := len(slices)
+	if remaining == 0 {
+		slices = []*discovery.EndpointSlice{slice}
+	}
+
+	return &K8sResourceMessage{
+		ObjectType: "endpoints",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_Endpoints{
+				Endpoints: k8s.EndpointSlicesToProto(serviceName, slices),
+			},
+		},
+	}, remaining
+}
+
 func nodeConverter(obj interface{}) *K8sResourceMessage {
 	return &K8sResourceMessage{
 		ObjectType: "nodes",
@@ -225,3 +390,85 @@ func deploymentConverter(obj interface{}) *K8sResourceMessage {
 		},
 	}
 }
+
+func statefulSetConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "statefulsets",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_StatefulSet{
+				StatefulSet: k8s.StatefulSetToProto(obj.(*apps.StatefulSet)),
+			},
+		},
+	}
+}
+
+func daemonSetConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "daemonsets",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_DaemonSet{
+				DaemonSet: k8s.DaemonSetToProto(obj.(*apps.DaemonSet)),
+			},
+		},
+	}
+}
+
+func jobConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "jobs",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_Job{
+				Job: k8s.JobToProto(obj.(*batch.Job)),
+			},
+		},
+	}
+}
+
+func cronJobConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "cronjobs",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_CronJob{
+				CronJob: k8s.CronJobToProto(obj.(*batch.CronJob)),
+			},
+		},
+	}
+}
+
+func ingressConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "ingresses",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_Ingress{
+				Ingress: k8s.IngressToProto(obj.(*networking.Ingress)),
+			},
+		},
+	}
+}
+
+func networkPolicyConverter(obj interface{}) *K8sResourceMessage {
+	return &K8sResourceMessage{
+		ObjectType: "networkpolicies",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_NetworkPolicy{
+				NetworkPolicy: k8s.NetworkPolicyToProto(obj.(*networking.NetworkPolicy)),
+			},
+		},
+	}
+}
+
+func httpRouteConverter(obj interface{}) *K8sResourceMessage {
+	route, err := k8s.HTTPRouteToProto(obj.(*unstructured.Unstructured))
+	if err != nil {
+		log.WithError(err).Error("Failed to convert HTTPRoute")
+		return nil
+	}
+	return &K8sResourceMessage{
+		ObjectType: "httproutes",
+		Object: &storepb.K8SResource{
+			Resource: &storepb.K8SResource_HTTPRoute{
+				HTTPRoute: route,
+			},
+		},
+	}
+}
@@ -38,6 +38,8 @@ import (
 	"px.dev/pixie/src/carnot/planner/distributedpb"
 	"px.dev/pixie/src/carnot/planner/dynamic_tracing/ir/logicalpb"
 	"px.dev/pixie/src/common/base/statuspb"
+	k8s_metadatapb "px.dev/pixie/src/shared/k8s/metadatapb"
+	"px.dev/pixie/src/shared/types/gotypes"
 	"px.dev/pixie/src/table_store/schemapb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/vizier/services/metadata/controllers/agent"
@@ -54,11 +56,17 @@ import (
 // its last heartbeat is greater than this value.
 const UnhealthyAgentThreshold = 30 * time.Second
 
+// maxOwnerDepth is the maximum number of owners that are followed from a pod, in case the owner
+// references form a cycle.
+const maxOwnerDepth = 10
+
 // Server defines an gRPC server type.
 type Server struct {
 	env    metadataenv.MetadataEnv
 	ds     datastore.MultiGetterSetterDeleterCloser
 	pls    k8smeta.PodLabelStore
+	nws    k8smeta.NetworkingStore
+	hs     k8smeta.HistoryStore
 	agtMgr agent.Manager
 	tpMgr  *tracepoint.Manager
 	// The current cursor that is actively running the GetAgentsUpdate stream. Only one GetAgentsUpdate
@@ -68,11 +76,13 @@ type Server struct {
 }
 
 // NewServer creates GRPC handlers.
-func NewServer(env metadataenv.MetadataEnv, ds datastore.MultiGetterSetterDeleterCloser, pls k8smeta.PodLabelStore, agtMgr agent.Manager, tpMgr *tracepoint.Manager) *Server {
+func NewServer(env metadataenv.MetadataEnv, ds datastore.MultiGetterSetterDeleterCloser, pls k8smeta.PodLabelStore, nws k8smeta.NetworkingStore, hs k8smeta.HistoryStore, agtMgr agent.Manager, tpMgr *tracepoint.Manager) *Server {
 	return &Server{
 		env:    env,
 		ds:     ds,
 		pls:    pls,
+		nws:    nws,
+		hs:     hs,
 		agtMgr: agtMgr,
 		tpMgr:  tpMgr,
 	}
@@ -350,6 +360,257 @@ func (s *Server) GetWithPrefixKey(ctx context.Context, req *metadatapb.WithPrefi
 	return resp, nil
 }
 
+// GetServiceRoutes returns the host and path mappings from the Ingresses and HTTPRoutes in the cluster to
+// the services they route to.
+func (s *Server) GetServiceRoutes(ctx context.Context, req *metadatapb.ServiceRoutesRequest) (*metadatapb.ServiceRoutesResponse, error) {
+	ingresses, err := s.nws.FetchNetworkingResources(k8smeta.NetworkingKindIngress)
+	if err != nil {
+		return nil, err
+	}
+	httpRoutes, err := s.nws.FetchNetworkingResources(k8smeta.NetworkingKindHTTPRoute)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*metadatapb.ServiceRoute
+	for _, r := range ingresses {
+		if ing := r.GetIngress(); ing != nil {
+			routes = append(routes, ingressServiceRoutes(ing)...)
+		}
+	}
+	for _, r := range httpRoutes {
+		if route := r.GetHTTPRoute(); route != nil {
+			routes = append(routes, httpRouteServiceRoutes(route)...)
+		}
+	}
+
+	resp := &metadatapb.ServiceRoutesResponse{}
+	for _, r := range routes {
+		if req.Namespace != "" && r.ServiceNamespace != req.Namespace {
+			continue
+		}
+		if req.ServiceName != "" && r.ServiceName != req.ServiceName {
+			continue
+		}
+		resp.Routes = append(resp.Routes, r)
+	}
+	return resp, nil
+}
+
+// ingressServiceRoutes gets the routes to services defined by an ingress. The default backend is
+// returned as a route that matches all hosts and paths.
+func ingressServiceRoutes(ing *k8s_metadatapb.Ingress) []*metadatapb.ServiceRoute {
+	md := ing.GetMetadata()
+	newRoute := func(host string, path *k8s_metadatapb.HTTPIngressPath, backend *k8s_metadatapb.IngressBackend) *metadatapb.ServiceRoute {
+		route := &metadatapb.ServiceRoute{
+			SourceKind:       k8smeta.NetworkingKindIngress,
+			SourceNamespace:  md.GetNamespace(),
+			SourceName:       md.GetName(),
+			Host:             host,
+			Path:             path.GetPath(),
+			PathType:         path.GetPathType(),
+			ServiceNamespace: md.GetNamespace(),
+			ServiceName:      backend.ServiceName,
+			ServicePort:      backend.ServicePortName,
+		}
+		if backend.ServicePortNumber != 0 {
+			route.ServicePort = fmt.Sprint(backend.ServicePortNumber)
+		}
+		return route
+	}
+
+	var routes []*metadatapb.ServiceRoute
+	for _, rule := range ing.GetSpec().GetRules() {
+		for _, path := range rule.Paths {
+			if path.GetBackend().GetServiceName() == "" {
+				continue
+			}
+			routes = append(routes, newRoute(rule.Host, path, path.Backend))
+		}
+	}
+	if backend := ing.GetSpec().GetDefaultBackend(); backend.GetServiceName() != "" {
+		routes = append(routes, newRoute("", nil, backend))
+	}
+	return routes
+}
+
+// httpRouteServiceRoutes gets the routes to services defined by a Gateway API HTTPRoute. A route is
+// returned for every combination of hostname, match and service backend in a rule.
+func httpRouteServiceRoutes(route *k8s_metadatapb.HTTPRoute) []*metadatapb.ServiceRoute {
+	md := route.GetMetadata()
+	hostnames := route.GetSpec().GetHostnames()
+	if len(hostnames) == 0 {
+		// An HTTPRoute without hostnames matches all hosts.
+		hostnames = []string{""}
+	}
+
+	var routes []*metadatapb.ServiceRoute
+	for _, rule := range route.GetSpec().GetRules() {
+		matches := rule.Matches
+		if len(matches) == 0 {
+			// A rule without matches defaults to matching all paths.
+			matches = []*k8s_metadatapb.HTTPRouteMatch{{PathType: "PathPrefix", PathValue: "/"}}
+		}
+		for _, backend := range rule.BackendRefs {
+			if backend.Group != "" || backend.Kind != "Service" {
+				continue
+			}
+			port := ""
+			if backend.Port != 0 {
+				port = fmt.Sprint(backend.Port)
+			}
+			for _, host := range hostnames {
+				for _, match := range matches {
+					routes = append(routes, &metadatapb.ServiceRoute{
+						SourceKind:       k8smeta.NetworkingKindHTTPRoute,
+						SourceNamespace:  md.GetNamespace(),
+						SourceName:       md.GetName(),
+						Host:             host,
+						Path:             match.PathValue,
+						PathType:         match.PathType,
+						ServiceNamespace: backend.Namespace,
+						ServiceName:      backend.Name,
+						ServicePort:      port,
+					})
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// GetMetadataAtTime returns the pod, its owners and services, or the service, that held an IP or ran a
+// process at a point in time.
+func (s *Server) GetMetadataAtTime(ctx context.Context, req *metadatapb.MetadataAtTimeRequest) (*metadatapb.MetadataAtTimeResponse, error) {
+	if s.hs == nil || !s.hs.HistoryEnabled() {
+		return nil, status.Error(codes.Unimplemented, "Metadata history is not enabled")
+	}
+
+	timestampNS := req.TimestampNS
+	if timestampNS == 0 {
+		timestampNS = time.Now().UnixNano()
+	}
+
+	resp := &metadatapb.MetadataAtTimeResponse{}
+	switch sel := req.Selector.(type) {
+	case *metadatapb.MetadataAtTimeRequest_IP:
+		resources, err := s.hs.FetchResourcesWithIP(sel.IP, timestampNS)
+		if err != nil {
+			return nil, err
+		}
+		// The resources are ordered by creation time. Take the most recent ones, in case a deletion
+		// was missed.
+		for _, r := range resources {
+			if pod := r.GetPod(); pod != nil && resp.Pod == nil {
+				resp.Pod = pod
+			}
+			if svc := r.GetService(); svc != nil && resp.Service == nil {
+				resp.Service = svc
+			}
+		}
+	case *metadatapb.MetadataAtTimeRequest_UPID:
+		processes, err := s.agtMgr.GetProcesses([]*gotypes.UInt128{gotypes.UInt128FromProto(sel.UPID)})
+		if err != nil {
+			return nil, err
+		}
+		if len(processes) == 0 || processes[0] == nil {
+			return nil, status.Error(codes.NotFound, "Could not find process with the given UPID")
+		}
+		if p := processes[0]; p.StartTimestampNS > timestampNS || (p.StopTimestampNS != 0 && p.StopTimestampNS < timestampNS) {
+			return nil, status.Error(codes.NotFound, "The process with the given UPID was not running at the given time")
+		}
+		podUID, err := s.hs.FetchPodUIDForContainer(processes[0].CID)
+		if err != nil {
+			return nil, err
+		}
+		if podUID == "" {
+			return resp, nil
+		}
+		r, err := s.hs.FetchResourceWithUID(podUID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Pod = r.GetPod()
+	default:
+		return nil, status.Error(codes.InvalidArgument, "An IP or UPID should be specified in MetadataAtTimeRequest")
+	}
+
+	if resp.Pod == nil {
+		return resp, nil
+	}
+
+	owners, err := s.fetchOwners(resp.Pod.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	resp.PodOwners = owners
+
+	services, err := s.hs.FetchPodServices(resp.Pod.GetMetadata().GetUID(), timestampNS)
+	if err != nil {
+		return nil, err
+	}
+	resp.PodServices = services
+	return resp, nil
+}
+
+// fetchOwners gets the owners of a resource, starting with its direct owner. Only the first owner at
+// each level is followed, since K8s controllers set a single controller reference.
+func (s *Server) fetchOwners(md *k8s_metadatapb.ObjectMetadata) ([]*storepb.K8SResource, error) {
+	var owners []*storepb.K8SResource
+	for i := 0; i < maxOwnerDepth && len(md.GetOwnerReferences()) > 0; i++ {
+		owner, err := s.hs.FetchResourceWithUID(md.OwnerReferences[0].UID)
+		if err != nil {
+			return nil, err
+		}
+		if owner == nil {
+			break
+		}
+		owners = append(owners, owner)
+		md = ownerMetadata(owner)
+	}
+	return owners, nil
+}
+
+// ownerMetadata gets the object metadata of a resource that can own pods.
+func ownerMetadata(r *storepb.K8SResource) *k8s_metadatapb.ObjectMetadata {
+	switch {
+	case r.GetReplicaSet() != nil:
+		return r.GetReplicaSet().Metadata
+	case r.GetDeployment() != nil:
+		return r.GetDeployment().Metadata
+	case r.GetStatefulSet() != nil:
+		return r.GetStatefulSet().Metadata
+	case r.GetDaemonSet() != nil:
+		return r.GetDaemonSet().Metadata
+	case r.GetJob() != nil:
+		return r.GetJob().Metadata
+	case r.GetCronJob() != nil:
+		return r.GetCronJob().Metadata
+	default:
+		return nil
+	}
+}
+
+// GetNamespaceChanges returns the changes to the resources in a namespace during a time range.
+func (s *Server) GetNamespaceChanges(ctx context.Context, req *metadatapb.NamespaceChangesRequest) (*metadatapb.NamespaceChangesResponse, error) {
+	if s.hs == nil || !s.hs.HistoryEnabled() {
+		return nil, status.Error(codes.Unimplemented, "Metadata history is not enabled")
+	}
+	if req.Namespace == "" {
+		return nil, status.Error(codes.InvalidArgument, "Namespace should be specified in NamespaceChangesRequest")
+	}
+
+	endTimestampNS := req.EndTimestampNS
+	if endTimestampNS == 0 {
+		endTimestampNS = time.Now().UnixNano()
+	}
+	changes, err := s.hs.FetchNamespaceChanges(req.Namespace, req.StartTimestampNS, endTimestampNS, int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return &metadatapb.NamespaceChangesResponse{Changes: changes}, nil
+}
+
 // ConvertLabelsToPods fetches all the pods in the PodLabelStore that match the labels described in the input tp,
 // and then convert the LabelSelector to a PodProcess.
 func (s *Server) ConvertLabelsToPods(tp *logicalpb.TracepointDeployment) error {
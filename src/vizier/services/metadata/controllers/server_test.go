@@ -28,6 +28,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
@@ -37,8 +39,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	grpc_metadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	"px.dev/pixie/src/api/proto/uuidpb"
@@ -46,16 +50,19 @@ import (
 	"px.dev/pixie/src/carnot/planner/dynamic_tracing/ir/logicalpb"
 	"px.dev/pixie/src/common/base/statuspb"
 	"px.dev/pixie/src/shared/bloomfilterpb"
+	k8s_metadatapb "px.dev/pixie/src/shared/k8s/metadatapb"
 
 	sharedmetadatapb "px.dev/pixie/src/shared/metadatapb"
 	"px.dev/pixie/src/shared/services/env"
 	"px.dev/pixie/src/shared/services/server"
+	"px.dev/pixie/src/shared/types/gotypes"
 	"px.dev/pixie/src/shared/types/typespb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/utils/testingutils"
 	"px.dev/pixie/src/vizier/messages/messagespb"
 	"px.dev/pixie/src/vizier/services/metadata/controllers"
 	mock_agent "px.dev/pixie/src/vizier/services/metadata/controllers/agent/mock"
+	"px.dev/pixie/src/vizier/services/metadata/controllers/k8smeta"
 	"px.dev/pixie/src/vizier/services/metadata/controllers/testutils"
 	"px.dev/pixie/src/vizier/services/metadata/controllers/tracepoint"
 	mock_tracepoint "px.dev/pixie/src/vizier/services/metadata/controllers/tracepoint/mock"
@@ -63,6 +70,7 @@ import (
 	"px.dev/pixie/src/vizier/services/metadata/metadatapb"
 	"px.dev/pixie/src/vizier/services/metadata/storepb"
 	"px.dev/pixie/src/vizier/services/shared/agentpb"
+	"px.dev/pixie/src/vizier/utils/datastore/pebbledb"
 )
 
 func testTableInfos() []*storepb.TableInfo {
@@ -166,7 +174,7 @@ func TestGetAgentInfo(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, nil)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, nil)
 
 	req := metadatapb.AgentInfoRequest{}
 
@@ -212,7 +220,7 @@ func TestGetAgentInfoGetActiveAgentsFailed(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, nil)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, nil)
 
 	req := metadatapb.AgentInfoRequest{}
 
@@ -241,7 +249,7 @@ func TestGetSchemas(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, nil)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, nil)
 
 	req := metadatapb.SchemaRequest{}
 
@@ -274,6 +282,11 @@ func Test_Server_RegisterTracepoint(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 
@@ -349,7 +362,7 @@ func Test_Server_RegisterTracepoint(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, tracepointMgr)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, tracepointMgr)
 
 	reqs := []*metadatapb.RegisterTracepointRequest_TracepointRequest{
 		{
@@ -381,6 +394,11 @@ func Test_Server_RegisterTracepoint_Exists(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 
@@ -474,7 +492,7 @@ func Test_Server_RegisterTracepoint_Exists(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, tracepointMgr)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, tracepointMgr)
 
 	reqs := []*metadatapb.RegisterTracepointRequest_TracepointRequest{
 		{
@@ -614,6 +632,11 @@ func Test_Server_GetTracepointInfo(t *testing.T) {
 			defer ctrl.Finish()
 			mockAgtMgr := mock_agent.NewMockManager(ctrl)
 			mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+			mockTracepointStore.
+				EXPECT().
+				WatchTracepointTTLs(gomock.Any()).
+				Return(make(chan uuid.UUID), nil).
+				AnyTimes()
 
 			tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 
@@ -659,7 +682,7 @@ func Test_Server_GetTracepointInfo(t *testing.T) {
 				t.Fatal("Failed to create api environment.")
 			}
 
-			s := controllers.NewServer(env, nil, nil, mockAgtMgr, tracepointMgr)
+			s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, tracepointMgr)
 			req := metadatapb.GetTracepointInfoRequest{
 				IDs: []*uuidpb.UUID{utils.ProtoFromUUID(tID)},
 			}
@@ -693,6 +716,11 @@ func Test_Server_RemoveTracepoint(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 
@@ -717,7 +745,7 @@ func Test_Server_RemoveTracepoint(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, tracepointMgr)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, tracepointMgr)
 
 	req := metadatapb.RemoveTracepointRequest{
 		Names: []string{"test1", "test2"},
@@ -903,7 +931,7 @@ func TestGetAgentUpdates(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	srv := controllers.NewServer(mdEnv, nil, nil, mockAgtMgr, nil)
+	srv := controllers.NewServer(mdEnv, nil, nil, nil, nil, mockAgtMgr, nil)
 
 	env := env.New("withpixie.ai")
 	s := server.CreateGRPCServer(env, &server.GRPCServerOptions{})
@@ -1051,6 +1079,11 @@ func Test_Server_UpdateConfig(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 
 	mockAgtMgr.
@@ -1064,7 +1097,7 @@ func Test_Server_UpdateConfig(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, nil, mockAgtMgr, tracepointMgr)
+	s := controllers.NewServer(env, nil, nil, nil, nil, mockAgtMgr, tracepointMgr)
 
 	req := metadatapb.UpdateConfigRequest{
 		AgentPodName: "pl/pem-1234",
@@ -1105,7 +1138,7 @@ func Test_Server_ConvertLabelsToPods(t *testing.T) {
 		t.Fatal("Failed to create api environment.")
 	}
 
-	s := controllers.NewServer(env, nil, pls, nil, nil)
+	s := controllers.NewServer(env, nil, pls, nil, nil, nil, nil)
 
 	program := &logicalpb.TracepointDeployment{}
 	err = proto.UnmarshalText(testutils.TDLabelSelectorPb, program)
@@ -1121,3 +1154,353 @@ func Test_Server_ConvertLabelsToPods(t *testing.T) {
 
 	assert.True(t, proto.Equal(program, expected), fmt.Sprintf("expect: %s\nactual: %s", expected, program))
 }
+
+func Test_Server_GetServiceRoutes(t *testing.T) {
+	nws := &testutils.InMemoryNetworkingStore{
+		Store: make(map[string]*storepb.K8SResource),
+	}
+	ingress := &k8s_metadatapb.Ingress{}
+	err := proto.UnmarshalText(testutils.IngressPb, ingress)
+	require.NoError(t, err)
+	err = nws.SetNetworkingResource(k8smeta.NetworkingKindIngress, "a_namespace", "web", &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Ingress{Ingress: ingress},
+	})
+	require.NoError(t, err)
+	route := &k8s_metadatapb.HTTPRoute{}
+	err = proto.UnmarshalText(testutils.HTTPRoutePb, route)
+	require.NoError(t, err)
+	err = nws.SetNetworkingResource(k8smeta.NetworkingKindHTTPRoute, "a_namespace", "web-route", &storepb.K8SResource{
+		Resource: &storepb.K8SResource_HTTPRoute{HTTPRoute: route},
+	})
+	require.NoError(t, err)
+
+	env, err := metadataenv.New("vizier")
+	require.NoError(t, err)
+	s := controllers.NewServer(env, nil, nil, nws, nil, nil, nil)
+
+	ingressAPIRoute := &metadatapb.ServiceRoute{
+		SourceKind:       "Ingress",
+		SourceNamespace:  "a_namespace",
+		SourceName:       "web",
+		Host:             "example.com",
+		Path:             "/api",
+		PathType:         "Prefix",
+		ServiceNamespace: "a_namespace",
+		ServiceName:      "api",
+		ServicePort:      "8080",
+	}
+	ingressDefaultRoute := &metadatapb.ServiceRoute{
+		SourceKind:       "Ingress",
+		SourceNamespace:  "a_namespace",
+		SourceName:       "web",
+		ServiceNamespace: "a_namespace",
+		ServiceName:      "default-http",
+		ServicePort:      "http",
+	}
+	httpRouteAPIRoute := &metadatapb.ServiceRoute{
+		SourceKind:       "HTTPRoute",
+		SourceNamespace:  "a_namespace",
+		SourceName:       "web-route",
+		Host:             "example.com",
+		Path:             "/v2",
+		PathType:         "PathPrefix",
+		ServiceNamespace: "a_namespace",
+		ServiceName:      "api",
+		ServicePort:      "8080",
+	}
+	httpRouteCanaryRoute := &metadatapb.ServiceRoute{
+		SourceKind:       "HTTPRoute",
+		SourceNamespace:  "a_namespace",
+		SourceName:       "web-route",
+		Host:             "example.com",
+		Path:             "/v2",
+		PathType:         "PathPrefix",
+		ServiceNamespace: "b_namespace",
+		ServiceName:      "api-canary",
+		ServicePort:      "8080",
+	}
+
+	tests := []struct {
+		name           string
+		req            *metadatapb.ServiceRoutesRequest
+		expectedRoutes []*metadatapb.ServiceRoute
+	}{
+		{
+			name:           "all routes",
+			req:            &metadatapb.ServiceRoutesRequest{},
+			expectedRoutes: []*metadatapb.ServiceRoute{ingressAPIRoute, ingressDefaultRoute, httpRouteAPIRoute, httpRouteCanaryRoute},
+		},
+		{
+			name:           "by service",
+			req:            &metadatapb.ServiceRoutesRequest{Namespace: "a_namespace", ServiceName: "api"},
+			expectedRoutes: []*metadatapb.ServiceRoute{ingressAPIRoute, httpRouteAPIRoute},
+		},
+		{
+			name:           "by namespace",
+			req:            &metadatapb.ServiceRoutesRequest{Namespace: "b_namespace"},
+			expectedRoutes: []*metadatapb.ServiceRoute{httpRouteCanaryRoute},
+		},
+		{
+			name:           "no matches",
+			req:            &metadatapb.ServiceRoutesRequest{ServiceName: "unknown"},
+			expectedRoutes: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := s.GetServiceRoutes(context.Background(), test.req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRoutes, resp.Routes)
+		})
+	}
+}
+
+func setupHistoryStore(t *testing.T) (*k8smeta.Datastore, func()) {
+	c, err := pebble.Open("test", &pebble.Options{
+		FS: vfs.NewMem(),
+	})
+	require.NoError(t, err)
+	db := pebbledb.New(c, 3*time.Second)
+	mds := k8smeta.NewDatastore(db, k8smeta.WithHistoryPolicy(k8smeta.HistoryPolicy{ResourceRetention: time.Hour}))
+	return mds, func() {
+		require.NoError(t, db.Close())
+	}
+}
+
+func Test_Server_GetMetadataAtTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockAgtMgr := mock_agent.NewMockManager(ctrl)
+
+	hs, cleanup := setupHistoryStore(t)
+	defer cleanup()
+
+	deployment := &storepb.K8SResource{
+		Resource: &storepb.K8SResource_Deployment{
+			Deployment: &k8s_metadatapb.Deployment{
+				Metadata: &k8s_metadatapb.ObjectMetadata{Name: "web", Namespace: "ns", UID: "dep_uid", CreationTimestampNS: 1},
+			},
+		},
+	}
+	replicaSet := &storepb.K8SResource{
+		Resource: &storepb.K8SResource_ReplicaSet{
+			ReplicaSet: &k8s_metadatapb.ReplicaSet{
+				Metadata: &k8s_metadatapb.ObjectMetadata{
+					Name:                "web-abc",
+					Namespace:           "ns",
+					UID:                 "rs_uid",
+					CreationTimestampNS: 1,
+					OwnerReferences:     []*k8s_metadatapb.OwnerReference{{Kind: "Deployment", Name: "web", UID: "dep_uid"}},
+				},
+			},
+		},
+	}
+	pod := &k8s_metadatapb.Pod{
+		Metadata: &k8s_metadatapb.ObjectMetadata{
+			Name:                "web-abc-1",
+			Namespace:           "ns",
+			UID:                 "pod_uid",
+			CreationTimestampNS: 10,
+			OwnerReferences:     []*k8s_metadatapb.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc", UID: "rs_uid"}},
+		},
+		Status: &k8s_metadatapb.PodStatus{PodIP: "1.2.3.4", HostIP: "10.0.0.1"},
+	}
+	svc := &k8s_metadatapb.Service{
+		Metadata: &k8s_metadatapb.ObjectMetadata{Name: "web", Namespace: "ns", UID: "svc_uid", CreationTimestampNS: 5},
+		Spec:     &k8s_metadatapb.ServiceSpec{ClusterIP: "10.96.0.10"},
+	}
+	updates := []*storepb.K8SResource{
+		deployment,
+		replicaSet,
+		{Resource: &storepb.K8SResource_Pod{Pod: pod}},
+		{Resource: &storepb.K8SResource_Service{Service: svc}},
+		{
+			Resource: &storepb.K8SResource_Endpoints{
+				Endpoints: &k8s_metadatapb.Endpoints{
+					Metadata: &k8s_metadatapb.ObjectMetadata{Name: "web", Namespace: "ns", UID: "ep_uid"},
+					Subsets: []*k8s_metadatapb.EndpointSubset{
+						{
+							Addresses: []*k8s_metadatapb.EndpointAddress{
+								{IP: "1.2.3.4", TargetRef: &k8s_metadatapb.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "web-abc-1", UID: "pod_uid"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Resource: &storepb.K8SResource_Container{
+				Container: &k8s_metadatapb.ContainerUpdate{CID: "container1", Namespace: "ns", PodID: "pod_uid"},
+			},
+		},
+	}
+	for i, u := range updates {
+		require.NoError(t, hs.AddFullResourceUpdate(int64(i+1), u))
+	}
+
+	upid := &typespb.UInt128{Low: 89101, High: 528280977975}
+	mockAgtMgr.
+		EXPECT().
+		GetProcesses(gomock.Any()).
+		DoAndReturn(func(upids []*gotypes.UInt128) ([]*k8s_metadatapb.ProcessInfo, error) {
+			if upids[0].Low == upid.Low {
+				return []*k8s_metadatapb.ProcessInfo{{CID: "container1", StartTimestampNS: 10}}, nil
+			}
+			return []*k8s_metadatapb.ProcessInfo{nil}, nil
+		}).
+		AnyTimes()
+
+	env, err := metadataenv.New("vizier")
+	require.NoError(t, err)
+	s := controllers.NewServer(env, nil, nil, nil, hs, mockAgtMgr, nil)
+
+	podResp := &metadatapb.MetadataAtTimeResponse{
+		Pod:         pod,
+		PodOwners:   []*storepb.K8SResource{replicaSet, deployment},
+		PodServices: []string{"ns/web"},
+	}
+
+	tests := []struct {
+		name         string
+		req          *metadatapb.MetadataAtTimeRequest
+		expectedResp *metadatapb.MetadataAtTimeResponse
+		expectedCode codes.Code
+	}{
+		{
+			name: "pod IP",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector: &metadatapb.MetadataAtTimeRequest_IP{IP: "1.2.3.4"},
+			},
+			expectedResp: podResp,
+		},
+		{
+			name: "pod IP before it was an endpoint",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector:    &metadatapb.MetadataAtTimeRequest_IP{IP: "1.2.3.4"},
+				TimestampNS: 20,
+			},
+			expectedResp: &metadatapb.MetadataAtTimeResponse{
+				Pod:         pod,
+				PodOwners:   []*storepb.K8SResource{replicaSet, deployment},
+				PodServices: []string{},
+			},
+		},
+		{
+			name: "pod IP before creation",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector:    &metadatapb.MetadataAtTimeRequest_IP{IP: "1.2.3.4"},
+				TimestampNS: 5,
+			},
+			expectedResp: &metadatapb.MetadataAtTimeResponse{},
+		},
+		{
+			name: "cluster IP",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector: &metadatapb.MetadataAtTimeRequest_IP{IP: "10.96.0.10"},
+			},
+			expectedResp: &metadatapb.MetadataAtTimeResponse{Service: svc},
+		},
+		{
+			name: "UPID",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector: &metadatapb.MetadataAtTimeRequest_UPID{UPID: upid},
+			},
+			expectedResp: podResp,
+		},
+		{
+			name: "UPID before the process started",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector:    &metadatapb.MetadataAtTimeRequest_UPID{UPID: upid},
+				TimestampNS: 5,
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "unknown UPID",
+			req: &metadatapb.MetadataAtTimeRequest{
+				Selector: &metadatapb.MetadataAtTimeRequest_UPID{UPID: &typespb.UInt128{Low: 1, High: 2}},
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "no selector",
+			req:          &metadatapb.MetadataAtTimeRequest{},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := s.GetMetadataAtTime(context.Background(), test.req)
+			if test.expectedCode != codes.OK {
+				assert.Equal(t, test.expectedCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func Test_Server_GetNamespaceChanges(t *testing.T) {
+	hs, cleanup := setupHistoryStore(t)
+	defer cleanup()
+
+	start := time.Now().UnixNano()
+	for i, name := range []string{"a", "b", "c"} {
+		err := hs.AddFullResourceUpdate(int64(i+1), &storepb.K8SResource{
+			Resource: &storepb.K8SResource_Service{
+				Service: &k8s_metadatapb.Service{
+					Metadata: &k8s_metadatapb.ObjectMetadata{Name: name, Namespace: "ns", UID: name},
+				},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	env, err := metadataenv.New("vizier")
+	require.NoError(t, err)
+	s := controllers.NewServer(env, nil, nil, nil, hs, nil, nil)
+
+	resp, err := s.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{
+		Namespace:        "ns",
+		StartTimestampNS: start,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 3)
+	for i, c := range resp.Changes {
+		assert.Equal(t, int64(i+1), c.UpdateVersion)
+	}
+
+	resp, err = s.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{
+		Namespace:        "ns",
+		StartTimestampNS: start,
+		Limit:            1,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 1)
+	assert.Equal(t, "a", resp.Changes[0].Resource.GetService().Metadata.Name)
+
+	resp, err = s.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{
+		Namespace:        "other",
+		StartTimestampNS: start,
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Changes, 0)
+
+	_, err = s.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	noHistory := controllers.NewServer(env, nil, nil, nil, nil, nil, nil)
+	_, err = noHistory.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{Namespace: "ns"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	disabled := controllers.NewServer(env, nil, nil, nil, k8smeta.NewDatastore(nil), nil, nil)
+	_, err = disabled.GetNamespaceChanges(context.Background(), &metadatapb.NamespaceChangesRequest{Namespace: "ns"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = disabled.GetMetadataAtTime(context.Background(), &metadatapb.MetadataAtTimeRequest{
+		Selector: &metadatapb.MetadataAtTimeRequest_IP{IP: "1.2.3.4"},
+	})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -897,6 +897,231 @@ status {
 }
 `
 
+// StatefulSetPb is a protobuf for a StatefulSet object
+const StatefulSetPb = `
+metadata {
+	name: "web"
+	namespace: "a_namespace"
+	uid: "ss-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+	labels {
+		key: "app"
+		value: "web"
+	}
+}
+spec {
+	selector {
+		match_labels {
+			key: "app"
+			value: "web"
+		}
+	}
+	replicas: 3
+	service_name: "web-svc"
+	pod_management_policy: "OrderedReady"
+}
+status {
+	observed_generation: 2
+	replicas: 3
+	ready_replicas: 2
+	current_replicas: 3
+	updated_replicas: 1
+	available_replicas: 2
+	current_revision: "web-1"
+	update_revision: "web-2"
+}
+`
+
+// DaemonSetPb is a protobuf for a DaemonSet object
+const DaemonSetPb = `
+metadata {
+	name: "agent"
+	namespace: "a_namespace"
+	uid: "ds-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+}
+spec {
+	selector {
+		match_labels {
+			key: "app"
+			value: "agent"
+		}
+	}
+	min_ready_seconds: 5
+}
+status {
+	current_number_scheduled: 3
+	number_misscheduled: 1
+	desired_number_scheduled: 4
+	number_ready: 2
+	observed_generation: 7
+	updated_number_scheduled: 3
+	number_available: 2
+	number_unavailable: 2
+}
+`
+
+// JobPb is a protobuf for a Job object
+const JobPb = `
+metadata {
+	name: "backup-1234"
+	namespace: "a_namespace"
+	uid: "job-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+	owner_references {
+		kind: "CronJob"
+		name: "backup"
+		uid: "cj-uid"
+	}
+}
+spec {
+	parallelism: 2
+	completions: 5
+	backoff_limit: 6
+}
+status {
+	start_time_ns: 10
+	completion_time_ns: 20
+	succeeded: 5
+	conditions: {
+		type: "Complete"
+		status: 1
+		last_probe_time_ns: 20
+		last_transition_time_ns: 20
+	}
+}
+`
+
+// CronJobPb is a protobuf for a CronJob object
+const CronJobPb = `
+metadata {
+	name: "backup"
+	namespace: "a_namespace"
+	uid: "cj-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+}
+spec {
+	schedule: "*/5 * * * *"
+	concurrency_policy: "Forbid"
+}
+status {
+	active {
+		kind: "Job"
+		namespace: "a_namespace"
+		name: "backup-1234"
+		uid: "job-uid"
+	}
+	last_schedule_time_ns: 30
+	last_successful_time_ns: 25
+}
+`
+
+// IngressPb is a protobuf for an Ingress object
+const IngressPb = `
+metadata {
+	name: "web"
+	namespace: "a_namespace"
+	uid: "ing-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+}
+spec {
+	ingress_class_name: "nginx"
+	default_backend {
+		service_name: "default-http"
+		service_port_name: "http"
+	}
+	rules {
+		host: "example.com"
+		paths {
+			path: "/api"
+			path_type: "Prefix"
+			backend {
+				service_name: "api"
+				service_port_number: 8080
+			}
+		}
+	}
+}
+status {
+	load_balancer_ips: "1.2.3.4"
+}
+`
+
+// HTTPRoutePb is a protobuf for a Gateway API HTTPRoute object
+const HTTPRoutePb = `
+metadata {
+	name: "web-route"
+	namespace: "a_namespace"
+	uid: "route-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+}
+spec {
+	parent_refs {
+		name: "gateway"
+	}
+	hostnames: "example.com"
+	rules {
+		matches {
+			path_type: "PathPrefix"
+			path_value: "/v2"
+		}
+		backend_refs {
+			kind: "Service"
+			namespace: "a_namespace"
+			name: "api"
+			port: 8080
+			weight: 1
+		}
+		backend_refs {
+			kind: "Service"
+			namespace: "b_namespace"
+			name: "api-canary"
+			port: 8080
+			weight: 1
+		}
+	}
+}
+`
+
+// NetworkPolicyPb is a protobuf for a NetworkPolicy object
+const NetworkPolicyPb = `
+metadata {
+	name: "allow-web"
+	namespace: "a_namespace"
+	uid: "np-uid"
+	resource_version: "1"
+	creation_timestamp_ns: 4
+	deletion_timestamp_ns: 6
+}
+spec {
+	pod_selector {
+		match_labels {
+			key: "app"
+			value: "web"
+		}
+	}
+	ingress {
+		ports {
+			protocol: TCP
+			port: "8080"
+		}
+	}
+	policy_types: "Ingress"
+}
+`
+
 // TDLabelSelectorPb is a protobuf for a TracepointDeployment object with a LabelSelector.
 const TDLabelSelectorPb = `
 name: "test_probe"
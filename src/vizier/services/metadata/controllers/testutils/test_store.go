@@ -20,7 +20,10 @@ package testutils
 
 import (
 	"path"
+	"sort"
 	"strings"
+
+	"px.dev/pixie/src/vizier/services/metadata/storepb"
 )
 
 // InMemoryPodLabelStore implements the PodLabelStore interface for testing.
@@ -97,3 +100,37 @@ func (s *InMemoryPodLabelStore) FetchPodsWithLabels(namespace string, labels map
 func (s *InMemoryPodLabelStore) GetWithPrefix(prefix string) ([]string, [][]byte, error) {
 	return nil, nil, nil
 }
+
+// InMemoryNetworkingStore implements the NetworkingStore interface for testing.
+type InMemoryNetworkingStore struct {
+	Store map[string]*storepb.K8SResource
+}
+
+// SetNetworkingResource stores the latest version of the resource of the given kind.
+func (s *InMemoryNetworkingStore) SetNetworkingResource(kind string, namespace string, name string, resource *storepb.K8SResource) error {
+	s.Store[path.Join(kind, namespace, name)] = resource
+	return nil
+}
+
+// DeleteNetworkingResource deletes the resource of the given kind.
+func (s *InMemoryNetworkingStore) DeleteNetworkingResource(kind string, namespace string, name string) error {
+	delete(s.Store, path.Join(kind, namespace, name))
+	return nil
+}
+
+// FetchNetworkingResources gets all of the stored resources of the given kind, sorted by key.
+func (s *InMemoryNetworkingStore) FetchNetworkingResources(kind string) ([]*storepb.K8SResource, error) {
+	keys := make([]string, 0)
+	for k := range s.Store {
+		if strings.HasPrefix(k, kind+"/") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]*storepb.K8SResource, len(keys))
+	for i, k := range keys {
+		result[i] = s.Store[k]
+	}
+	return result, nil
+}
@@ -19,6 +19,7 @@
 package tracepoint
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -65,6 +66,7 @@ type Store interface {
 	DeleteTracepoint(uuid.UUID) error
 	DeleteTracepointsForAgent(uuid.UUID) error
 	GetTracepointTTLs() ([]uuid.UUID, []time.Time, error)
+	WatchTracepointTTLs(context.Context) (<-chan uuid.UUID, error)
 }
 
 // Manager manages the tracepoints deployed in the cluster.
@@ -84,11 +86,16 @@ func NewManager(ts Store, agtMgr agentMessenger, ttlReaperDuration time.Duration
 		done:   make(chan struct{}),
 	}
 
-	go tm.watchForTracepointExpiry(ttlReaperDuration)
+	ctx, cancel := context.WithCancel(context.Background())
+	expired := tm.watchTracepointTTLs(ctx)
+	go tm.watchForTracepointExpiry(ctx, cancel, expired, ttlReaperDuration)
 	return tm
 }
 
-func (m *Manager) watchForTracepointExpiry(ttlReaperDuration time.Duration) {
+// watchForTracepointExpiry terminates tracepoints as soon as their TTLs expire or are deleted. The TTLs
+// are also checked every ttlReaperDuration, in case the watch fails and misses some of the changes.
+func (m *Manager) watchForTracepointExpiry(ctx context.Context, cancel context.CancelFunc, expired <-chan uuid.UUID, ttlReaperDuration time.Duration) {
+	defer cancel()
 	ticker := time.NewTicker(ttlReaperDuration)
 	defer ticker.Stop()
 	for {
@@ -96,11 +103,30 @@ func (m *Manager) watchForTracepointExpiry(ttlReaperDuration time.Duration) {
 		case <-m.done:
 			return
 		case <-ticker.C:
+			if expired == nil {
+				expired = m.watchTracepointTTLs(ctx)
+			}
+			m.terminateExpiredTracepoints()
+		case _, ok := <-expired:
+			if !ok {
+				// The watch failed, so it is restarted on the next tick.
+				expired = nil
+				continue
+			}
 			m.terminateExpiredTracepoints()
 		}
 	}
 }
 
+func (m *Manager) watchTracepointTTLs(ctx context.Context) <-chan uuid.UUID {
+	expired, err := m.ts.WatchTracepointTTLs(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to watch tracepoint TTLs")
+		return nil
+	}
+	return expired
+}
+
 func (m *Manager) terminateExpiredTracepoints() {
 	tps, err := m.ts.GetTracepoints()
 	if err != nil {
@@ -19,6 +19,7 @@
 package tracepoint
 
 import (
+	"context"
 	"path"
 	"strings"
 	"time"
@@ -123,14 +124,12 @@ func (t *Datastore) UpsertTracepoint(tracepointID uuid.UUID, tracepointInfo *sto
 	return t.ds.Set(getTracepointKey(tracepointID), string(val))
 }
 
-// DeleteTracepoint deletes the tracepoint from the store.
+// DeleteTracepoint deletes the tracepoint and its states from the store.
 func (t *Datastore) DeleteTracepoint(tracepointID uuid.UUID) error {
-	err := t.ds.DeleteAll([]string{getTracepointKey(tracepointID)})
-	if err != nil {
-		return err
-	}
-
-	return t.ds.DeleteWithPrefix(getTracepointStatesKey(tracepointID))
+	txn := t.ds.Txn()
+	txn.Delete(getTracepointKey(tracepointID))
+	txn.DeleteWithPrefix(getTracepointStatesKey(tracepointID))
+	return txn.Commit()
 }
 
 // GetTracepoint gets the tracepoint info from the store, if it exists.
@@ -307,3 +306,36 @@ func (t *Datastore) GetTracepointTTLs() ([]uuid.UUID, []time.Time, error) {
 
 	return ids, expirations, nil
 }
+
+// WatchTracepointTTLs streams the IDs of the tracepoints whose TTLs are deleted or expire. The channel is
+// closed once the context is cancelled, or if the watch fails.
+func (t *Datastore) WatchTracepointTTLs(ctx context.Context) (<-chan uuid.UUID, error) {
+	events, err := t.ds.Watch(ctx, tracepointTTLsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(chan uuid.UUID)
+	go func() {
+		defer close(ids)
+		for e := range events {
+			if e.Type != datastore.EventTypeDelete {
+				continue
+			}
+			keyParts := strings.Split(e.Key, "/")
+			if len(keyParts) != 3 {
+				continue
+			}
+			id, err := uuid.FromString(keyParts[2])
+			if err != nil {
+				continue
+			}
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ids, nil
+}
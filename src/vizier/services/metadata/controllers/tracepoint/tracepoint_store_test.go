@@ -19,6 +19,7 @@
 package tracepoint
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -362,3 +363,27 @@ func TestTracepointStore_GetTracepointTTLs(t *testing.T) {
 	assert.Contains(t, tracepoints, s1ID)
 	assert.Contains(t, tracepoints, s2ID)
 }
+
+func TestTracepointStore_WatchTracepointTTLs(t *testing.T) {
+	_, ts, cleanup := setupTest(t)
+	defer cleanup()
+
+	tpID := uuid.Must(uuid.NewV4())
+	require.NoError(t, ts.SetTracepointTTL(tpID, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	expired, err := ts.WatchTracepointTTLs(ctx)
+	require.NoError(t, err)
+
+	// Setting a TTL doesn't expire the tracepoint, but deleting it does.
+	require.NoError(t, ts.SetTracepointTTL(tpID, 2*time.Hour))
+	require.NoError(t, ts.DeleteTracepointTTLs([]uuid.UUID{tpID}))
+
+	select {
+	case id := <-expired:
+		assert.Equal(t, tpID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the tracepoint TTL to be deleted")
+	}
+}
@@ -224,6 +224,11 @@ func TestCreateTracepoint(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+			mockTracepointStore.
+				EXPECT().
+				WatchTracepointTTLs(gomock.Any()).
+				Return(make(chan uuid.UUID), nil).
+				AnyTimes()
 
 			origID := uuid.Must(uuid.NewV4())
 
@@ -314,6 +319,11 @@ func TestGetTracepoints(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -345,6 +355,11 @@ func TestGetTracepointInfo(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -370,6 +385,11 @@ func TestGetTracepointStates(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -407,6 +427,11 @@ func TestRegisterTracepoint(t *testing.T) {
 
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -612,6 +637,11 @@ func TestUpdateAgentTracepointStatus(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -639,6 +669,11 @@ func TestUpdateAgentTracepointStatus_Terminated(t *testing.T) {
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -669,6 +704,11 @@ func TestTTLExpiration(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 
 	tpID1 := uuid.Must(uuid.NewV4())
@@ -762,12 +802,76 @@ func TestTTLExpiration(t *testing.T) {
 	assert.Contains(t, seenDeletions, tpID3.String())
 }
 
+func TestTTLExpiration_Watch(t *testing.T) {
+	// Set up mock.
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockAgtMgr := mock_agent.NewMockManager(ctrl)
+
+	tpID := uuid.Must(uuid.NewV4())
+
+	expired := make(chan uuid.UUID)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(expired, nil)
+
+	mockTracepointStore.
+		EXPECT().
+		GetTracepoints().
+		Return([]*storepb.TracepointInfo{
+			{
+				ID: utils.ProtoFromUUID(tpID),
+			},
+		}, nil)
+
+	mockTracepointStore.
+		EXPECT().
+		GetTracepointTTLs().
+		Return(nil, nil, nil)
+
+	mockTracepointStore.
+		EXPECT().
+		GetTracepoint(tpID).
+		Return(&storepb.TracepointInfo{
+			ID: utils.ProtoFromUUID(tpID),
+		}, nil)
+
+	mockTracepointStore.
+		EXPECT().
+		UpsertTracepoint(tpID, &storepb.TracepointInfo{ID: utils.ProtoFromUUID(tpID), ExpectedState: statuspb.TERMINATED_STATE}).
+		Return(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	mockAgtMgr.
+		EXPECT().
+		MessageActiveAgents(gomock.Any()).
+		DoAndReturn(func(msg []byte) error {
+			wg.Done()
+			return nil
+		})
+
+	// The reaper doesn't run during the test, so the tracepoint is only terminated because of the watch.
+	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, time.Hour)
+	defer tracepointMgr.Close()
+
+	expired <- tpID
+	wg.Wait()
+}
+
 func TestUpdateAgentTracepointStatus_RemoveTracepoints(t *testing.T) {
 	// Set up mock.
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockAgtMgr := mock_agent.NewMockManager(ctrl)
 	mockTracepointStore := mock_tracepoint.NewMockStore(ctrl)
+	mockTracepointStore.
+		EXPECT().
+		WatchTracepointTTLs(gomock.Any()).
+		Return(make(chan uuid.UUID), nil).
+		AnyTimes()
 
 	tracepointMgr := tracepoint.NewManager(mockTracepointStore, mockAgtMgr, 5*time.Second)
 	defer tracepointMgr.Close()
@@ -75,6 +75,9 @@ func init() {
 	pflag.String("nats_url", "pl-nats", "The URL of NATS")
 	pflag.Bool("use_etcd_operator", false, "Whether the etcd operator should be used instead of the persistent version.")
 	pflag.StringSlice("metadata_namespaces", []string{v1.NamespaceAll}, "The list of namespaces to watch for metadata.")
+	pflag.Bool("use_endpoint_slices", false, "Whether to watch discovery.k8s.io/v1 EndpointSlices instead of core/v1 Endpoints for service to pod mappings.")
+	pflag.Duration("k8s_history_retention", 24*time.Hour, "How long deleted K8s resources, and the IPs, containers and processes they held, can be looked up. The history isn't recorded if 0.")
+	pflag.Duration("k8s_change_history_retention", 0, "How long the changes to each namespace are kept. Defaults to k8s_history_retention if 0.")
 
 	// Metadata flags are set using the env vars in pl-cluster-config.
 	// We historically set PL_ETCD_OPERATOR_ENABLED but not PL_USE_ETCD_OPERATOR in the configmap.
@@ -232,19 +235,24 @@ func main() {
 	}
 	defer dataStore.Close()
 
-	k8sMds := k8smeta.NewDatastore(dataStore)
+	historyPolicy := k8smeta.HistoryPolicy{
+		ResourceRetention: viper.GetDuration("k8s_history_retention"),
+		ChangeRetention:   viper.GetDuration("k8s_change_history_retention"),
+	}
+	k8sMds := k8smeta.NewDatastore(dataStore, k8smeta.WithHistoryPolicy(historyPolicy))
 	// Listen for K8s metadata updates.
 	updateCh := make(chan *k8smeta.K8sResourceMessage)
-	mdh := k8smeta.NewHandler(updateCh, k8sMds, k8sMds, nc)
+	mdh := k8smeta.NewHandler(updateCh, k8sMds, k8sMds, k8sMds, nc)
 
 	namespaces := viper.GetStringSlice("metadata_namespaces")
 	if len(namespaces) == 0 {
 		namespaces = []string{v1.NamespaceAll}
 	}
-	k8sMc, err := k8smeta.NewController(namespaces, updateCh)
+	k8sMc, err := k8smeta.NewController(namespaces, updateCh, k8smeta.WithEndpointSlices(viper.GetBool("use_endpoint_slices")))
 	defer k8sMc.Stop()
 
-	ads := agent.NewDatastore(dataStore, 24*time.Hour)
+	// Terminated processes are kept as long as the K8s history, so that their UPIDs can still be attributed to pods.
+	ads := agent.NewDatastore(dataStore, historyPolicy.ProcessRetention(24*time.Hour))
 	agtMgr := agent.NewManager(ads, mdh, nc)
 
 	schemaQuitCh := make(chan struct{})
@@ -287,7 +295,7 @@ func main() {
 	healthz.RegisterDefaultChecks(mux)
 	metrics.MustRegisterMetricsHandlerNoDefaultMetrics(mux)
 
-	svr := controllers.NewServer(env, dataStore, k8sMds, agtMgr, tracepointMgr)
+	svr := controllers.NewServer(env, dataStore, k8sMds, k8sMds, k8sMds, agtMgr, tracepointMgr)
 
 	csDs := cronscript.NewDatastore(dataStore)
 	cronScriptSvr := cronscript.New(csDs)
@@ -0,0 +1,275 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var queryAdmissionRejectedCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "query_admission_rejected",
+		Help: "The number of queries rejected by admission control, by reason.",
+	},
+	[]string{"reason"},
+)
+
+const (
+	// DefaultMaxQueuedQueries is the default number of queries that may wait for admission.
+	DefaultMaxQueuedQueries = 64
+	// DefaultAdmissionQueueTimeout is the default time that a query may wait for admission.
+	DefaultAdmissionQueueTimeout = 30 * time.Second
+	// DefaultPriorityQueryHeadroom is the default number of priority queries that may run beyond the global limit.
+	DefaultPriorityQueryHeadroom = 2
+)
+
+type admissionWaiter struct {
+	principal string
+	priority  bool
+	// Closed once the waiter has been admitted. Only written while holding the controller's mutex.
+	admittedCh chan struct{}
+	admitted   bool
+}
+
+// AdmissionController limits the number of queries that run on the query broker at the same time.
+// Queries that can't run right away wait in a bounded queue. Priority queries, such as the
+// healthcheck and internal cron scripts, are not subject to the per-principal limit, may use
+// a few slots beyond the global limit, and are admitted ahead of any queued queries.
+type AdmissionController struct {
+	// A limit of zero means there is no limit.
+	maxInFlight      int
+	maxPerPrincipal  int
+	maxQueued        int
+	queueTimeout     time.Duration
+	priorityHeadroom int
+
+	mu           sync.Mutex
+	inFlight     int
+	perPrincipal map[string]int
+	// Waiters in the order they arrived. Priority waiters are admitted before all others.
+	queue     []*admissionWaiter
+	numQueued int
+}
+
+// AdmissionControllerOption allows specifying options for new AdmissionControllers.
+type AdmissionControllerOption func(*AdmissionController)
+
+// WithMaxInFlightQueries sets the number of queries that may run at the same time.
+func WithMaxInFlightQueries(n int) AdmissionControllerOption {
+	return func(ac *AdmissionController) {
+		ac.maxInFlight = n
+	}
+}
+
+// WithMaxQueriesPerPrincipal sets the number of queries that a single user, API key or service may run at the same time.
+func WithMaxQueriesPerPrincipal(n int) AdmissionControllerOption {
+	return func(ac *AdmissionController) {
+		ac.maxPerPrincipal = n
+	}
+}
+
+// WithMaxQueuedQueries sets the number of queries that may wait for a slot before new queries are rejected.
+func WithMaxQueuedQueries(n int) AdmissionControllerOption {
+	return func(ac *AdmissionController) {
+		ac.maxQueued = n
+	}
+}
+
+// WithAdmissionQueueTimeout sets how long a query may wait for a slot before it is rejected.
+func WithAdmissionQueueTimeout(timeout time.Duration) AdmissionControllerOption {
+	return func(ac *AdmissionController) {
+		ac.queueTimeout = timeout
+	}
+}
+
+// WithPriorityQueryHeadroom sets how many priority queries may run beyond the global limit.
+func WithPriorityQueryHeadroom(n int) AdmissionControllerOption {
+	return func(ac *AdmissionController) {
+		ac.priorityHeadroom = n
+	}
+}
+
+// NewAdmissionController creates a new AdmissionController. Without any options, it admits every query.
+func NewAdmissionController(opts ...AdmissionControllerOption) *AdmissionController {
+	ac := &AdmissionController{
+		maxQueued:        DefaultMaxQueuedQueries,
+		queueTimeout:     DefaultAdmissionQueueTimeout,
+		priorityHeadroom: DefaultPriorityQueryHeadroom,
+		perPrincipal:     make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(ac)
+	}
+	return ac
+}
+
+// canAdmit must be called while holding the mutex.
+func (ac *AdmissionController) canAdmit(principal string, priority bool) bool {
+	if priority {
+		return ac.maxInFlight == 0 || ac.inFlight < ac.maxInFlight+ac.priorityHeadroom
+	}
+	if ac.maxInFlight > 0 && ac.inFlight >= ac.maxInFlight {
+		return false
+	}
+	return ac.maxPerPrincipal == 0 || ac.perPrincipal[principal] < ac.maxPerPrincipal
+}
+
+// admit must be called while holding the mutex.
+func (ac *AdmissionController) admit(principal string) {
+	ac.inFlight++
+	ac.perPrincipal[principal]++
+}
+
+// hasQueuedAhead returns whether a waiter that should run before a new query is queued. Waiters
+// that are held back by their per-principal limit can't take the slot, so they don't count.
+// It must be called while holding the mutex.
+func (ac *AdmissionController) hasQueuedAhead(priority bool) bool {
+	for _, w := range ac.queue {
+		if (w.priority || !priority) && ac.canAdmit(w.principal, w.priority) {
+			return true
+		}
+	}
+	return false
+}
+
+// Admit blocks until the query may run, and returns a func that must be called once the query
+// finishes. Queries that can't be admitted return a ResourceExhausted error.
+func (ac *AdmissionController) Admit(ctx context.Context, principal string, priority bool) (func(), error) {
+	if ac == nil {
+		return func() {}, nil
+	}
+
+	ac.mu.Lock()
+	if !ac.hasQueuedAhead(priority) && ac.canAdmit(principal, priority) {
+		ac.admit(principal)
+		ac.mu.Unlock()
+		return ac.releaseFunc(principal), nil
+	}
+	if !priority && ac.numQueued >= ac.maxQueued {
+		msg := ac.busyMessage(principal)
+		ac.mu.Unlock()
+		queryAdmissionRejectedCounter.With(prometheus.Labels{"reason": "queue_full"}).Inc()
+		return nil, status.Error(codes.ResourceExhausted, msg)
+	}
+	w := &admissionWaiter{
+		principal:  principal,
+		priority:   priority,
+		admittedCh: make(chan struct{}),
+	}
+	ac.queue = append(ac.queue, w)
+	if !priority {
+		ac.numQueued++
+	}
+	ac.mu.Unlock()
+
+	t := time.NewTimer(ac.queueTimeout)
+	defer t.Stop()
+	timedOut := false
+	select {
+	case <-w.admittedCh:
+		return ac.releaseFunc(principal), nil
+	case <-t.C:
+		timedOut = true
+	case <-ctx.Done():
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	err := status.FromContextError(ctx.Err()).Err()
+	if timedOut {
+		queryAdmissionRejectedCounter.With(prometheus.Labels{"reason": "timeout"}).Inc()
+		err = status.Errorf(codes.ResourceExhausted, "%s, timed out after %s waiting to run", ac.busyMessage(principal), ac.queueTimeout)
+	}
+	if w.admitted {
+		// The waiter was admitted while timing out, so give the slot back.
+		ac.release(principal)
+		return nil, err
+	}
+	ac.removeWaiter(w)
+	return nil, err
+}
+
+// busyMessage must be called while holding the mutex.
+func (ac *AdmissionController) busyMessage(principal string) string {
+	if ac.maxPerPrincipal > 0 && ac.perPrincipal[principal] >= ac.maxPerPrincipal {
+		return fmt.Sprintf("too many queries running for %s (limit %d)", principal, ac.maxPerPrincipal)
+	}
+	return fmt.Sprintf("too many queries running (limit %d)", ac.maxInFlight)
+}
+
+func (ac *AdmissionController) releaseFunc(principal string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ac.mu.Lock()
+			defer ac.mu.Unlock()
+			ac.release(principal)
+		})
+	}
+}
+
+// release must be called while holding the mutex.
+func (ac *AdmissionController) release(principal string) {
+	ac.inFlight--
+	ac.perPrincipal[principal]--
+	if ac.perPrincipal[principal] <= 0 {
+		delete(ac.perPrincipal, principal)
+	}
+	ac.dispatch()
+}
+
+// removeWaiter must be called while holding the mutex.
+func (ac *AdmissionController) removeWaiter(w *admissionWaiter) {
+	for i, queued := range ac.queue {
+		if queued == w {
+			ac.queue = append(ac.queue[:i], ac.queue[i+1:]...)
+			break
+		}
+	}
+	if !w.priority {
+		ac.numQueued--
+	}
+}
+
+// dispatch admits as many queued waiters as fit, priority waiters first. A waiter that is held
+// back by its per-principal limit does not block the waiters behind it.
+// It must be called while holding the mutex.
+func (ac *AdmissionController) dispatch() {
+	for _, priority := range []bool{true, false} {
+		for i := 0; i < len(ac.queue); {
+			w := ac.queue[i]
+			if w.priority != priority || !ac.canAdmit(w.principal, w.priority) {
+				i++
+				continue
+			}
+			ac.admit(w.principal)
+			ac.removeWaiter(w)
+			w.admitted = true
+			close(w.admittedCh)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/vizier/services/query_broker/controllers"
+)
+
+func admitAsync(ac *controllers.AdmissionController, principal string, priority bool) chan func() {
+	ch := make(chan func(), 1)
+	go func() {
+		release, err := ac.Admit(context.Background(), principal, priority)
+		if err != nil {
+			close(ch)
+			return
+		}
+		ch <- release
+	}()
+	return ch
+}
+
+func requireAdmitted(t *testing.T, ch chan func()) func() {
+	select {
+	case release, ok := <-ch:
+		require.True(t, ok, "query was rejected")
+		return release
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for admission")
+	}
+	return nil
+}
+
+func requireWaiting(t *testing.T, ch chan func()) {
+	select {
+	case <-ch:
+		t.Fatal("query should still be waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAdmissionController_Unlimited(t *testing.T) {
+	ac := controllers.NewAdmissionController()
+	for i := 0; i < 100; i++ {
+		_, err := ac.Admit(context.Background(), "user:a", false)
+		require.NoError(t, err)
+	}
+
+	var nilAC *controllers.AdmissionController
+	release, err := nilAC.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+	release()
+}
+
+func TestAdmissionController_GlobalLimitQueues(t *testing.T) {
+	ac := controllers.NewAdmissionController(controllers.WithMaxInFlightQueries(1))
+
+	release, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+
+	waiting := admitAsync(ac, "user:b", false)
+	requireWaiting(t, waiting)
+
+	release()
+	// Releasing twice must not free up another slot.
+	release()
+	release = requireAdmitted(t, waiting)
+
+	waiting = admitAsync(ac, "user:c", false)
+	requireWaiting(t, waiting)
+	release()
+	requireAdmitted(t, waiting)()
+}
+
+func TestAdmissionController_QueueFull(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(1),
+		controllers.WithMaxQueuedQueries(0),
+	)
+
+	release, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = ac.Admit(context.Background(), "user:b", false)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "too many queries running (limit 1)")
+}
+
+func TestAdmissionController_QueueTimeout(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(1),
+		controllers.WithAdmissionQueueTimeout(10*time.Millisecond),
+	)
+
+	release, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+
+	_, err = ac.Admit(context.Background(), "user:b", false)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "timed out")
+
+	// The timed out query must not hold on to a slot.
+	release()
+	release, err = ac.Admit(context.Background(), "user:b", false)
+	require.NoError(t, err)
+	release()
+}
+
+func TestAdmissionController_ContextCancelled(t *testing.T) {
+	ac := controllers.NewAdmissionController(controllers.WithMaxInFlightQueries(1))
+
+	release, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ac.Admit(ctx, "user:b", false)
+	require.Error(t, err)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestAdmissionController_PerPrincipalLimit(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(3),
+		controllers.WithMaxQueriesPerPrincipal(1),
+		controllers.WithAdmissionQueueTimeout(10*time.Millisecond),
+	)
+
+	releaseA, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+
+	_, err = ac.Admit(context.Background(), "user:a", false)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "too many queries running for user:a (limit 1)")
+
+	// Other principals are not affected.
+	releaseB, err := ac.Admit(context.Background(), "user:b", false)
+	require.NoError(t, err)
+	releaseB()
+
+	releaseA()
+	releaseA, err = ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+	releaseA()
+}
+
+func TestAdmissionController_BlockedPrincipalDoesNotBlockQueue(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(2),
+		controllers.WithMaxQueriesPerPrincipal(1),
+	)
+
+	releaseA, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+	releaseB, err := ac.Admit(context.Background(), "user:b", false)
+	require.NoError(t, err)
+
+	waitingA := admitAsync(ac, "user:a", false)
+	requireWaiting(t, waitingA)
+	waitingC := admitAsync(ac, "user:c", false)
+	requireWaiting(t, waitingC)
+
+	// user:a is still at its limit, so the slot goes to user:c.
+	releaseB()
+	releaseC := requireAdmitted(t, waitingC)
+	requireWaiting(t, waitingA)
+
+	releaseA()
+	requireAdmitted(t, waitingA)()
+	releaseC()
+}
+
+func TestAdmissionController_BlockedPrincipalAtHeadDoesNotDelayNewQueries(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(3),
+		controllers.WithMaxQueriesPerPrincipal(1),
+	)
+
+	releaseA, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+
+	// user:a is at its limit, so it waits at the head of the queue while the global limit has free slots.
+	waitingA := admitAsync(ac, "user:a", false)
+	requireWaiting(t, waitingA)
+
+	// Other principals are admitted right away instead of queueing behind user:a.
+	releaseB, err := ac.Admit(context.Background(), "user:b", false)
+	require.NoError(t, err)
+	requireWaiting(t, waitingA)
+
+	releaseA()
+	requireAdmitted(t, waitingA)()
+	releaseB()
+}
+
+func TestAdmissionController_Priority(t *testing.T) {
+	ac := controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(1),
+		controllers.WithMaxQueriesPerPrincipal(1),
+		controllers.WithPriorityQueryHeadroom(1),
+	)
+
+	release, err := ac.Admit(context.Background(), "user:a", false)
+	require.NoError(t, err)
+
+	// Priority queries use the headroom beyond the global limit, and ignore the per-principal limit.
+	releasePriority, err := ac.Admit(context.Background(), "user:a", true)
+	require.NoError(t, err)
+
+	waiting := admitAsync(ac, "user:b", false)
+	requireWaiting(t, waiting)
+	waitingPriority := admitAsync(ac, "service:query_broker", true)
+	requireWaiting(t, waitingPriority)
+
+	// The priority query was queued last but is admitted first.
+	release()
+	releaseQueuedPriority := requireAdmitted(t, waitingPriority)
+	requireWaiting(t, waiting)
+
+	releasePriority()
+	requireWaiting(t, waiting)
+	releaseQueuedPriority()
+	requireAdmitted(t, waiting)()
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"px.dev/pixie/src/carnot/planner/distributedpb"
+	"px.dev/pixie/src/utils"
+)
+
+// The node label that holds the hostname of the node, which agents report as their hostname.
+const hostnameLabel = "kubernetes.io/hostname"
+
+// NodeLabeler looks up the labels of the nodes in the cluster.
+type NodeLabeler interface {
+	// NodeLabels returns the labels of the node with the given name or hostname.
+	NodeLabels(node string) (labels.Set, bool)
+}
+
+// agentSelector restricts the agents that a query runs on.
+type agentSelector struct {
+	// A glob pattern for the name of the node that the agent runs on.
+	nodePattern string
+	// A selector for the labels of the node that the agent runs on.
+	nodeLabels labels.Selector
+}
+
+// parseAgentSelector parses the value of the agent_selector query flag, which is either
+// "node:<glob>" or "label:<selector>". It returns nil if the value is empty.
+func parseAgentSelector(s string) (*agentSelector, error) {
+	if s == "" {
+		return nil, nil
+	}
+	kind, value, _ := strings.Cut(s, ":")
+	switch kind {
+	case "node":
+		if _, err := path.Match(value, ""); err != nil || value == "" {
+			return nil, fmt.Errorf("invalid node name pattern '%s'", value)
+		}
+		return &agentSelector{nodePattern: value}, nil
+	case "label":
+		sel, err := labels.Parse(value)
+		if err != nil || sel.Empty() {
+			return nil, fmt.Errorf("invalid label selector '%s'", value)
+		}
+		return &agentSelector{nodeLabels: sel}, nil
+	default:
+		return nil, fmt.Errorf("expected node:<name> or label:<selector>")
+	}
+}
+
+func (s *agentSelector) matches(hostname string, nodeLabeler NodeLabeler) (bool, error) {
+	if s.nodeLabels == nil {
+		ok, _ := path.Match(s.nodePattern, hostname)
+		return ok, nil
+	}
+	if nodeLabeler == nil {
+		return false, status.Error(codes.FailedPrecondition, "node labels are not available to select agents by")
+	}
+	nodeLabels, ok := nodeLabeler.NodeLabels(hostname)
+	return ok && s.nodeLabels.Matches(nodeLabels), nil
+}
+
+// selectAgents removes the PEMs that the query may not run on from the distributed state.
+// Kelvins are always kept, since they don't hold any data.
+func selectAgents(ds *distributedpb.DistributedState, hostnames map[uuid.UUID]string, flags *QueryFlags, nodeLabeler NodeLabeler) error {
+	maxAgents := flags.GetInt64("max_target_agents")
+	selector, err := parseAgentSelector(flags.GetString("agent_selector"))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if selector == nil && maxAgents == 0 {
+		return nil
+	}
+
+	var selected []*distributedpb.CarnotInfo
+	numPEMs := int64(0)
+	for _, carnotInfo := range ds.CarnotInfo {
+		if !carnotInfo.HasDataStore {
+			selected = append(selected, carnotInfo)
+			continue
+		}
+		if selector != nil {
+			ok, err := selector.matches(hostnames[utils.UUIDFromProtoOrNil(carnotInfo.AgentID)], nodeLabeler)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		selected = append(selected, carnotInfo)
+		numPEMs++
+	}
+
+	if selector != nil && numPEMs == 0 {
+		return status.Errorf(codes.InvalidArgument, "agent_selector '%s' does not match any agents", flags.GetString("agent_selector"))
+	}
+	if maxAgents > 0 && numPEMs > maxAgents {
+		return status.Errorf(codes.InvalidArgument,
+			"query would run on %d agents, more than max_target_agents=%d; use agent_selector to run it on fewer agents", numPEMs, maxAgents)
+	}
+	ds.CarnotInfo = selected
+	return nil
+}
+
+// NodeLabelWatcher implements NodeLabeler by watching the nodes in the cluster.
+type NodeLabelWatcher struct {
+	indexer cache.Indexer
+}
+
+// NewNodeLabelWatcher starts watching the nodes in the cluster until stopCh is closed.
+func NewNodeLabelWatcher(client kubernetes.Interface, stopCh <-chan struct{}) (*NodeLabelWatcher, error) {
+	informer := informers.NewSharedInformerFactory(client, 12*time.Hour).Core().V1().Nodes().Informer()
+	err := informer.AddIndexers(cache.Indexers{
+		hostnameLabel: func(obj interface{}) ([]string, error) {
+			node, ok := obj.(*corev1.Node)
+			if !ok || node.Labels[hostnameLabel] == "" {
+				return nil, nil
+			}
+			return []string{node.Labels[hostnameLabel]}, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	go informer.Run(stopCh)
+	return &NodeLabelWatcher{indexer: informer.GetIndexer()}, nil
+}
+
+// NodeLabels returns the labels of the node with the given name or hostname.
+func (w *NodeLabelWatcher) NodeLabels(node string) (labels.Set, bool) {
+	if obj, exists, err := w.indexer.GetByKey(node); err == nil && exists {
+		return labels.Set(obj.(*corev1.Node).Labels), true
+	}
+	objs, err := w.indexer.ByIndex(hostnameLabel, node)
+	if err != nil || len(objs) == 0 {
+		return nil, false
+	}
+	return labels.Set(objs[0].(*corev1.Node).Labels), true
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/vizier/utils/messagebus"
+)
+
+// QueryAuditTopic is the topic that audit events are published on. The cloud connector
+// forwards every v2c topic to Pixie Cloud.
+var QueryAuditTopic = messagebus.V2CTopic("QueryAudit")
+
+// AuditSink stores query audit events.
+type AuditSink interface {
+	Write(event *cvmsgspb.QueryAuditEvent) error
+	Close() error
+}
+
+// QueryAuditor sends query audit events to all of its sinks.
+type QueryAuditor struct {
+	sinks []AuditSink
+}
+
+// NewQueryAuditor creates a new QueryAuditor.
+func NewQueryAuditor(sinks ...AuditSink) *QueryAuditor {
+	return &QueryAuditor{
+		sinks: sinks,
+	}
+}
+
+// Record sends the event to every sink. Failing to store an audit event does not fail the query.
+func (a *QueryAuditor) Record(event *cvmsgspb.QueryAuditEvent) {
+	if a == nil {
+		return
+	}
+	for _, sink := range a.sinks {
+		if err := sink.Write(event); err != nil {
+			log.WithError(err).WithField("query_id", event.QueryID).Error("Failed to write query audit event")
+		}
+	}
+}
+
+// Close closes all of the sinks.
+func (a *QueryAuditor) Close() {
+	if a == nil {
+		return
+	}
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			log.WithError(err).Error("Failed to close query audit sink")
+		}
+	}
+}
+
+func redactionLevel(ctx context.Context, dataPrivacy DataPrivacy) string {
+	opts, err := dataPrivacy.RedactionOptions(ctx)
+	switch {
+	case err != nil:
+		return "unknown"
+	case opts.GetUseFullRedaction():
+		return "full"
+	case opts.GetUsePxRedactPiiBestEffort():
+		return "pii_best_effort"
+	default:
+		return "none"
+	}
+}
+
+// newQueryAuditEvent creates the audit event for a query that is about to run. The caller sets the
+// query ID once it is known, and the outcome is filled in by finishQueryAuditEvent.
+func newQueryAuditEvent(ctx context.Context, queryName string, principal string,
+	req *vizierpb.ExecuteScriptRequest, dataPrivacy DataPrivacy) *cvmsgspb.QueryAuditEvent {
+	hash := sha256.Sum256([]byte(req.QueryStr))
+	return &cvmsgspb.QueryAuditEvent{
+		Principal:      principal,
+		QueryName:      queryName,
+		ScriptHash:     hex.EncodeToString(hash[:]),
+		Script:         req.QueryStr,
+		ExecFuncs:      req.ExecFuncs,
+		Mutation:       req.Mutation,
+		RedactionLevel: redactionLevel(ctx, dataPrivacy),
+		StartTimeNs:    time.Now().UnixNano(),
+	}
+}
+
+func finishQueryAuditEvent(event *cvmsgspb.QueryAuditEvent, err error) {
+	event.DurationNs = time.Now().UnixNano() - event.StartTimeNs
+	if err == nil {
+		event.Status = codes.OK.String()
+		return
+	}
+	code := status.Code(err)
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	event.Status = code.String()
+	event.ErrorMessage = err.Error()
+}
+
+// FileAuditSink writes audit events as JSON lines to a local file. Once the file reaches its
+// maximum size it is rotated to <path>.1, and older files are shifted up to maxBackups.
+type FileAuditSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu sync.Mutex
+	// f is nil after a failed rotation, in which case the file is reopened by the next write.
+	f      *os.File
+	size   int64
+	closed bool
+}
+
+// NewFileAuditSink creates a FileAuditSink that appends to the file at path.
+func NewFileAuditSink(path string, maxBytes int64, maxBackups int) (*FileAuditSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	s := &FileAuditSink{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileAuditSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileAuditSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+// rotate must be called while holding the mutex.
+func (s *FileAuditSink) rotate() error {
+	err := s.f.Close()
+	s.f = nil
+	if err != nil {
+		return err
+	}
+	if s.maxBackups > 0 {
+		for i := s.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(s.backupPath(i), s.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.backupPath(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Write appends the event to the file, rotating it first if the event doesn't fit.
+func (s *FileAuditSink) Write(event *cvmsgspb.QueryAuditEvent) error {
+	var b bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&b, event); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	if s.f == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(b.Len()) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(b.Bytes())
+	s.size += int64(n)
+	return err
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// NATSAuditSink publishes audit events on the QueryAuditTopic.
+type NATSAuditSink struct {
+	nc *nats.Conn
+}
+
+// NewNATSAuditSink creates a new NATSAuditSink.
+func NewNATSAuditSink(nc *nats.Conn) *NATSAuditSink {
+	return &NATSAuditSink{
+		nc: nc,
+	}
+}
+
+// Write publishes the event.
+func (s *NATSAuditSink) Write(event *cvmsgspb.QueryAuditEvent) error {
+	anyMsg, err := types.MarshalAny(event)
+	if err != nil {
+		return err
+	}
+	v2cMsg := cvmsgspb.V2CMessage{
+		Msg: anyMsg,
+	}
+	b, err := v2cMsg.Marshal()
+	if err != nil {
+		return err
+	}
+	return s.nc.Publish(QueryAuditTopic, b)
+}
+
+// Close is a no-op, since the NATS connection is owned by the caller.
+func (s *NATSAuditSink) Close() error {
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers_test
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/utils/testingutils"
+	"px.dev/pixie/src/vizier/services/query_broker/controllers"
+)
+
+func readAuditLog(t *testing.T, path string) []*cvmsgspb.QueryAuditEvent {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []*cvmsgspb.QueryAuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		event := &cvmsgspb.QueryAuditEvent{}
+		require.NoError(t, jsonpb.UnmarshalString(scanner.Text(), event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestFileAuditSink_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "queries.log")
+	event := &cvmsgspb.QueryAuditEvent{
+		Principal: "user:test@example.com",
+		QueryName: "a",
+		Script:    "import px",
+		Status:    "OK",
+	}
+	line, err := (&jsonpb.Marshaler{}).MarshalToString(event)
+	require.NoError(t, err)
+
+	// Each file fits two events.
+	sink, err := controllers.NewFileAuditSink(path, int64(2*(len(line)+1)), 2)
+	require.NoError(t, err)
+
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		event.QueryName = name
+		require.NoError(t, sink.Write(event))
+	}
+	require.NoError(t, sink.Close())
+
+	names := func(events []*cvmsgspb.QueryAuditEvent) []string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.QueryName)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"g"}, names(readAuditLog(t, path)))
+	assert.Equal(t, []string{"e", "f"}, names(readAuditLog(t, path+".1")))
+	assert.Equal(t, []string{"c", "d"}, names(readAuditLog(t, path+".2")))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// Reopening the sink appends to the existing file.
+	sink, err = controllers.NewFileAuditSink(path, int64(2*(len(line)+1)), 2)
+	require.NoError(t, err)
+	event.QueryName = "h"
+	require.NoError(t, sink.Write(event))
+	require.NoError(t, sink.Close())
+	assert.Equal(t, []string{"g", "h"}, names(readAuditLog(t, path)))
+}
+
+func TestFileAuditSink_RecoversFromFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	event := &cvmsgspb.QueryAuditEvent{QueryName: "a"}
+	line, err := (&jsonpb.Marshaler{}).MarshalToString(event)
+	require.NoError(t, err)
+
+	sink, err := controllers.NewFileAuditSink(path, int64(len(line)+1), 1)
+	require.NoError(t, err)
+	defer sink.Close()
+	require.NoError(t, sink.Write(event))
+
+	// The file can't be rotated while a non-empty directory is in the way of the backup.
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "dir"), 0o750))
+	event.QueryName = "b"
+	require.Error(t, sink.Write(event))
+
+	require.NoError(t, os.RemoveAll(path+".1"))
+	event.QueryName = "c"
+	require.NoError(t, sink.Write(event))
+
+	names := func(events []*cvmsgspb.QueryAuditEvent) []string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.QueryName)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"c"}, names(readAuditLog(t, path)))
+	assert.Equal(t, []string{"a"}, names(readAuditLog(t, path+".1")))
+}
+
+func TestNATSAuditSink(t *testing.T) {
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+
+	sub, err := nc.SubscribeSync(controllers.QueryAuditTopic)
+	require.NoError(t, err)
+
+	event := &cvmsgspb.QueryAuditEvent{
+		Principal: "service:query_broker",
+		Status:    "InvalidArgument",
+	}
+	sink := controllers.NewNATSAuditSink(nc)
+	require.NoError(t, sink.Write(event))
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	v2cMsg := &cvmsgspb.V2CMessage{}
+	require.NoError(t, v2cMsg.Unmarshal(msg.Data))
+	received := &cvmsgspb.QueryAuditEvent{}
+	require.NoError(t, types.UnmarshalAny(v2cMsg.Msg, received))
+	assert.Equal(t, event, received)
+}
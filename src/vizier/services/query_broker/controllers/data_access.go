@@ -0,0 +1,295 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/carnot/planner/distributedpb"
+	"px.dev/pixie/src/carnot/planpb"
+	"px.dev/pixie/src/shared/types/typespb"
+	"px.dev/pixie/src/table_store/schemapb"
+)
+
+// checkTableAccess returns a PermissionDenied error if the plan reads from a table that the restrictions don't allow.
+func checkTableAccess(planMap map[uuid.UUID]*planpb.Plan, restrictions *DataAccessRestrictions) error {
+	if restrictions == nil || len(restrictions.AllowedTables) == 0 {
+		return nil
+	}
+	for _, plan := range planMap {
+		for _, fragment := range plan.Nodes {
+			for _, node := range fragment.Nodes {
+				memSource := node.Op.GetMemSourceOp()
+				if memSource == nil || matchesAny(restrictions.AllowedTables, memSource.Name) {
+					continue
+				}
+				return status.Errorf(codes.PermissionDenied, "table %q is not allowed by data access policy %q", memSource.Name, restrictions.Policy)
+			}
+		}
+	}
+	return nil
+}
+
+// namespaceGlobToRegexp converts a path.Match pattern into an equivalent RE2 expression. Invalid patterns
+// return false, because path.Match never matches them.
+func namespaceGlobToRegexp(pattern string) (string, bool) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			if inClass && pattern[i] == '-' {
+				sb.WriteString(`\-`)
+			} else {
+				sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+			sb.WriteByte(c)
+		case c == '[':
+			inClass = true
+			sb.WriteByte(c)
+		case c == '*':
+			sb.WriteString(`[^/]*`)
+		case c == '?':
+			sb.WriteString(`[^/]`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return sb.String(), true
+}
+
+// namespacesRegexp returns an RE2 expression that fully matches the namespaces that the patterns allow.
+func namespacesRegexp(patterns []string) string {
+	var alternatives []string
+	for _, pattern := range patterns {
+		if re, ok := namespaceGlobToRegexp(pattern); ok {
+			alternatives = append(alternatives, re)
+		}
+	}
+	if len(alternatives) == 0 {
+		// Matches nothing.
+		return `[^\x00-\x{10FFFF}]`
+	}
+	return "(?:" + strings.Join(alternatives, "|") + ")"
+}
+
+func maxScalarFuncID(expr *planpb.ScalarExpression) int64 {
+	fn := expr.GetFunc()
+	if fn == nil {
+		return 0
+	}
+	maxID := fn.Id
+	for _, arg := range fn.Args {
+		if id := maxScalarFuncID(arg); id > maxID {
+			maxID = id
+		}
+	}
+	return maxID
+}
+
+// namespaceScoper rewrites a plan so that its sources only read the rows of processes in the allowed namespaces.
+type namespaceScoper struct {
+	restrictions *DataAccessRestrictions
+	regexp       string
+	schemas      map[string]*schemapb.Relation
+
+	nextNodeID uint64
+	nextFuncID int64
+}
+
+func (s *namespaceScoper) scopePlan(plan *planpb.Plan) error {
+	// New nodes and functions are given IDs that aren't used anywhere in the plan.
+	s.nextNodeID, s.nextFuncID = 0, 0
+	for _, fragment := range plan.Nodes {
+		for _, node := range fragment.Nodes {
+			if node.Id >= s.nextNodeID {
+				s.nextNodeID = node.Id + 1
+			}
+			var exprs []*planpb.ScalarExpression
+			exprs = append(exprs, node.Op.GetMapOp().GetExpressions()...)
+			if filter := node.Op.GetFilterOp(); filter != nil {
+				exprs = append(exprs, filter.Expression)
+			}
+			for _, expr := range exprs {
+				if id := maxScalarFuncID(expr); id >= s.nextFuncID {
+					s.nextFuncID = id + 1
+				}
+			}
+		}
+	}
+
+	for _, fragment := range plan.Nodes {
+		// Scoping a source inserts a node, so iterate over a copy of the nodes.
+		nodes := append([]*planpb.PlanNode(nil), fragment.Nodes...)
+		for _, node := range nodes {
+			if udtf := node.Op.GetUdtfSourceOp(); udtf != nil {
+				return status.Errorf(codes.PermissionDenied,
+					"%s can't be restricted to the namespaces allowed by data access policy %q", udtf.Name, s.restrictions.Policy)
+			}
+			if node.Op.GetMemSourceOp() == nil {
+				continue
+			}
+			if err := s.scopeSource(fragment, node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scopeSource inserts a filter on the namespace of each row's UPID directly after the memory source. The filter
+// takes over the ID of the source, so that the operators which read from the source read from the filter instead.
+func (s *namespaceScoper) scopeSource(fragment *planpb.PlanFragment, node *planpb.PlanNode) error {
+	src := node.Op.GetMemSourceOp()
+	numCols := len(src.ColumnNames)
+	upidIdx := -1
+	for i, name := range src.ColumnNames {
+		if name == "upid" {
+			upidIdx = i
+			break
+		}
+	}
+	if upidIdx < 0 {
+		// Read the upid column too. The filter drops it again, so the output of the source doesn't change.
+		tableIdx := -1
+		for i, col := range s.schemas[src.Name].GetColumns() {
+			if col.ColumnName == "upid" && col.ColumnType == typespb.UINT128 {
+				tableIdx = i
+				break
+			}
+		}
+		if tableIdx < 0 {
+			return status.Errorf(codes.PermissionDenied,
+				"table %q has no upid column, so it can't be restricted to the namespaces allowed by data access policy %q",
+				src.Name, s.restrictions.Policy)
+		}
+		src.ColumnIdxs = append(src.ColumnIdxs, int64(tableIdx))
+		src.ColumnNames = append(src.ColumnNames, "upid")
+		src.ColumnTypes = append(src.ColumnTypes, typespb.UINT128)
+		upidIdx = numCols
+	}
+
+	filterID, srcID := node.Id, s.nextNodeID
+	s.nextNodeID++
+	namespaceFuncID, matchFuncID := s.nextFuncID, s.nextFuncID+1
+	s.nextFuncID += 2
+
+	columns := make([]*planpb.Column, numCols)
+	for i := range columns {
+		columns[i] = &planpb.Column{Node: srcID, Index: uint64(i)}
+	}
+	namespace := &planpb.ScalarExpression{
+		Value: &planpb.ScalarExpression_Func{
+			Func: &planpb.ScalarFunc{
+				Name: "upid_to_namespace",
+				Id:   namespaceFuncID,
+				Args: []*planpb.ScalarExpression{
+					{Value: &planpb.ScalarExpression_Column{Column: &planpb.Column{Node: srcID, Index: uint64(upidIdx)}}},
+				},
+				ArgsDataTypes: []typespb.DataType{typespb.UINT128},
+			},
+		},
+	}
+	filter := &planpb.PlanNode{
+		Id: filterID,
+		Op: &planpb.Operator{
+			OpType: planpb.FILTER_OPERATOR,
+			Op: &planpb.Operator_FilterOp{
+				FilterOp: &planpb.FilterOperator{
+					Expression: &planpb.ScalarExpression{
+						Value: &planpb.ScalarExpression_Func{
+							Func: &planpb.ScalarFunc{
+								Name: "regex_match",
+								Id:   matchFuncID,
+								InitArgs: []*planpb.ScalarValue{
+									{DataType: typespb.STRING, Value: &planpb.ScalarValue_StringValue{StringValue: s.regexp}},
+								},
+								Args:          []*planpb.ScalarExpression{namespace},
+								ArgsDataTypes: []typespb.DataType{typespb.STRING, typespb.STRING},
+							},
+						},
+					},
+					Columns: columns,
+				},
+			},
+		},
+	}
+	node.Id = srcID
+
+	for i, n := range fragment.Nodes {
+		if n == node {
+			fragment.Nodes = append(fragment.Nodes[:i+1], append([]*planpb.PlanNode{filter}, fragment.Nodes[i+1:]...)...)
+			break
+		}
+	}
+	for i, dagNode := range fragment.Dag.GetNodes() {
+		if dagNode.Id != filterID {
+			continue
+		}
+		filterDAGNode := &planpb.DAG_DAGNode{
+			Id:             filterID,
+			SortedParents:  []uint64{srcID},
+			SortedChildren: dagNode.SortedChildren,
+		}
+		dagNode.Id = srcID
+		dagNode.SortedChildren = []uint64{filterID}
+		fragment.Dag.Nodes = append(fragment.Dag.Nodes[:i+1], append([]*planpb.DAG_DAGNode{filterDAGNode}, fragment.Dag.Nodes[i+1:]...)...)
+		break
+	}
+	return nil
+}
+
+// scopePlanToNamespaces restricts every source in the plan to the rows of processes in the namespaces that the
+// restrictions allow, by filtering on the namespace of each row's UPID right after the source. Rows whose UPID
+// can't be attributed to a namespace are dropped. Tables without a upid column and UDTF sources can't be
+// restricted this way, so plans that read from them are rejected with a PermissionDenied error.
+func scopePlanToNamespaces(planMap map[uuid.UUID]*planpb.Plan, schemas []*distributedpb.SchemaInfo, restrictions *DataAccessRestrictions) error {
+	if restrictions == nil || len(restrictions.AllowedNamespaces) == 0 {
+		return nil
+	}
+	s := &namespaceScoper{
+		restrictions: restrictions,
+		regexp:       namespacesRegexp(restrictions.AllowedNamespaces),
+		schemas:      make(map[string]*schemapb.Relation),
+	}
+	for _, schema := range schemas {
+		s.schemas[schema.Name] = schema.Relation
+	}
+	for _, plan := range planMap {
+		if err := s.scopePlan(plan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
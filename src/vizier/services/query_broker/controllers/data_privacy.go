@@ -20,12 +20,28 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
+	"sync"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 
 	"px.dev/pixie/src/carnot/planner/distributedpb"
+	"px.dev/pixie/src/shared/services/authcontext"
+	"px.dev/pixie/src/shared/services/jwtpb"
+	serviceUtils "px.dev/pixie/src/shared/services/utils"
+	"px.dev/pixie/src/utils/shared/k8s"
 
 	pixie "px.dev/pixie/src/operator/apis/px.dev/v1alpha1"
 )
@@ -34,29 +50,199 @@ func init() {
 	pflag.String("data_access", "Full", "The data access level for queries. Options are 'Full' or 'Restricted' or 'PIIRestricted")
 }
 
+// DataAccessRestrictions limits the data that a query may read, on top of any redaction.
+type DataAccessRestrictions struct {
+	// Policy is the name of the data access policy that the restrictions come from.
+	Policy string
+	// AllowedNamespaces are glob patterns of the namespaces whose processes the query may read data about.
+	// If empty, all namespaces are allowed.
+	AllowedNamespaces []string
+	// AllowedTables are the tables that the query may read from. If empty, all tables are allowed.
+	AllowedTables []string
+}
+
 type vizierCachedDataPrivacy struct {
 	dataAccess pixie.DataAccessLevel
+
+	mu       sync.RWMutex
+	policies []pixie.DataAccessPolicy
+}
+
+// NewDataPrivacy creates a DataPrivacy that applies the first of the policies that matches the principal
+// of a query, and the given data access level to queries that match none of them.
+func NewDataPrivacy(dataAccess pixie.DataAccessLevel, policies []pixie.DataAccessPolicy) DataPrivacy {
+	return &vizierCachedDataPrivacy{
+		dataAccess: dataAccess,
+		policies:   policies,
+	}
+}
+
+func (dp *vizierCachedDataPrivacy) setPolicies(policies []pixie.DataAccessPolicy) {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	dp.policies = policies
+}
+
+func matchesAny(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			if ok, err := path.Match(pattern, value); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func principalsMatch(principals *pixie.DataAccessPrincipals, claims *jwtpb.JWTClaims) bool {
+	if len(principals.Users) == 0 && len(principals.OrgIDs) == 0 && len(principals.Services) == 0 && !principals.APIKeys {
+		return true
+	}
+	if claims == nil {
+		return false
+	}
+	// Requests proxied through the cloud match the policies of the user that the cluster token was
+	// issued for. Cluster tokens that don't name a user only match policies without principals.
+	if user := effectiveUserClaims(claims); user != nil {
+		return matchesAny(principals.Users, user.Email, user.UserID) ||
+			matchesAny(principals.OrgIDs, user.OrgID) ||
+			(principals.APIKeys && user.IsAPIUser)
+	}
+	if serviceUtils.GetClaimsType(claims) == serviceUtils.ServiceClaimType {
+		return matchesAny(principals.Services, claims.GetServiceClaims().ServiceID)
+	}
+	return false
+}
+
+// policy returns the first policy that matches the principal of the request, or nil if none match.
+func (dp *vizierCachedDataPrivacy) policy(ctx context.Context) *pixie.DataAccessPolicy {
+	dp.mu.RLock()
+	defer dp.mu.RUnlock()
+	if len(dp.policies) == 0 {
+		return nil
+	}
+
+	var claims *jwtpb.JWTClaims
+	if aCtx, err := authcontext.FromContext(ctx); err == nil {
+		claims = aCtx.Claims
+	}
+	for i := range dp.policies {
+		if principalsMatch(&dp.policies[i].Principals, claims) {
+			return &dp.policies[i]
+		}
+	}
+	return nil
 }
 
 // RedactionOptions returns the proto message containing options for redaction based on the cached data privacy level.
 func (dp *vizierCachedDataPrivacy) RedactionOptions(ctx context.Context) (*distributedpb.RedactionOptions, error) {
-	if dp.dataAccess == pixie.DataAccessFull {
+	dataAccess := dp.dataAccess
+	if p := dp.policy(ctx); p != nil {
+		dataAccess = p.DataAccess
+	}
+	if dataAccess == pixie.DataAccessFull || dataAccess == pixie.DataAccessUnknown {
 		return nil, nil
 	}
 	return &distributedpb.RedactionOptions{
-		UseFullRedaction:         dp.dataAccess == pixie.DataAccessRestricted,
-		UsePxRedactPiiBestEffort: dp.dataAccess == pixie.DataAccessPIIRestricted,
+		UseFullRedaction:         dataAccess == pixie.DataAccessRestricted,
+		UsePxRedactPiiBestEffort: dataAccess == pixie.DataAccessPIIRestricted,
 	}, nil
 }
 
+// AccessRestrictions returns the namespaces and tables that the principal of the request may access.
+func (dp *vizierCachedDataPrivacy) AccessRestrictions(ctx context.Context) (*DataAccessRestrictions, error) {
+	p := dp.policy(ctx)
+	if p == nil || (len(p.AllowedNamespaces) == 0 && len(p.AllowedTables) == 0) {
+		return nil, nil
+	}
+	return &DataAccessRestrictions{
+		Policy:            p.Name,
+		AllowedNamespaces: p.AllowedNamespaces,
+		AllowedTables:     p.AllowedTables,
+	}, nil
+}
+
+func parseDataAccessPolicies(cm *corev1.ConfigMap) ([]pixie.DataAccessPolicy, error) {
+	var policies []pixie.DataAccessPolicy
+	data, ok := cm.Data[pixie.DataAccessPoliciesKey]
+	if !ok {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(data), &policies); err != nil {
+		return nil, fmt.Errorf("invalid data access policies in config map %s: %w", cm.Name, err)
+	}
+	return policies, nil
+}
+
+// watchPolicies keeps the policies up to date with the config map that the operator writes them to.
+func (dp *vizierCachedDataPrivacy) watchPolicies(client kubernetes.Interface, ns string) error {
+	informer := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		12*time.Hour,
+		informers.WithNamespace(ns),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", pixie.DataAccessPoliciesConfigMap).String()
+		}),
+	).Core().V1().ConfigMaps().Informer()
+
+	update := func(obj interface{}) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return
+		}
+		policies, err := parseDataAccessPolicies(cm)
+		if err != nil {
+			// Keep the last valid policies rather than falling back to the less restrictive default.
+			log.WithError(err).Error("Failed to update data access policies")
+			return
+		}
+		dp.setPolicies(policies)
+		log.WithField("num_policies", len(policies)).Info("Updated data access policies")
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    update,
+		UpdateFunc: func(_, newObj interface{}) { update(newObj) },
+		// Like invalid policies, a deleted config map keeps the last policies rather than falling back
+		// to the less restrictive default.
+		DeleteFunc: func(interface{}) {
+			log.Warn("Data access policies config map was deleted, keeping the last data access policies")
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	go informer.Run(stopCh)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		close(stopCh)
+		return fmt.Errorf("timed out waiting for the %s config map", pixie.DataAccessPoliciesConfigMap)
+	}
+	return nil
+}
+
 // CreateDataPrivacyManager creates a privacy manager for the namespace.
 func CreateDataPrivacyManager(ns string) (DataPrivacy, error) {
 	dataAccessStr := viper.GetString("data_access")
 	dataAccess := pixie.DataAccessLevel(dataAccessStr)
 	switch dataAccess {
 	case pixie.DataAccessFull, pixie.DataAccessRestricted, pixie.DataAccessPIIRestricted:
-		return &vizierCachedDataPrivacy{dataAccess}, nil
 	default:
 		return nil, fmt.Errorf("Invalid DataAccess: '%s'", dataAccessStr)
 	}
+
+	dp := &vizierCachedDataPrivacy{dataAccess: dataAccess}
+	kubeConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := dp.watchPolicies(k8s.GetClientset(kubeConfig), ns); err != nil {
+		return nil, err
+	}
+	return dp, nil
 }
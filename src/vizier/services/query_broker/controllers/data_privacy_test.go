@@ -0,0 +1,138 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pixie "px.dev/pixie/src/operator/apis/px.dev/v1alpha1"
+	"px.dev/pixie/src/shared/services/authcontext"
+	"px.dev/pixie/src/shared/services/jwtpb"
+	"px.dev/pixie/src/shared/services/utils"
+	"px.dev/pixie/src/utils/testingutils"
+	"px.dev/pixie/src/vizier/services/query_broker/controllers"
+)
+
+func contextWithClaims(claims *jwtpb.JWTClaims) context.Context {
+	aCtx := authcontext.New()
+	aCtx.Claims = claims
+	return authcontext.NewContext(context.Background(), aCtx)
+}
+
+func TestDataPrivacy_Policies(t *testing.T) {
+	policies := []pixie.DataAccessPolicy{
+		{
+			Name: "team-a",
+			Principals: pixie.DataAccessPrincipals{
+				Users: []string{"*@team-a.com"},
+			},
+			DataAccess:        pixie.DataAccessPIIRestricted,
+			AllowedNamespaces: []string{"team-a"},
+		},
+		{
+			Name: "api-keys",
+			Principals: pixie.DataAccessPrincipals{
+				APIKeys: true,
+			},
+			DataAccess:    pixie.DataAccessRestricted,
+			AllowedTables: []string{"process_stats"},
+		},
+		{
+			Name: "services",
+			Principals: pixie.DataAccessPrincipals{
+				Services: []string{"plugin"},
+			},
+			DataAccess: pixie.DataAccessFull,
+		},
+	}
+	dp := controllers.NewDataPrivacy(pixie.DataAccessRestricted, policies)
+
+	tests := []struct {
+		name                 string
+		ctx                  context.Context
+		expectedFull         bool
+		expectedPII          bool
+		expectedRestrictions *controllers.DataAccessRestrictions
+	}{
+		{
+			name:        "matching user",
+			ctx:         contextWithClaims(testingutils.GenerateTestClaimsWithEmail(t, "dev@team-a.com")),
+			expectedPII: true,
+			expectedRestrictions: &controllers.DataAccessRestrictions{
+				Policy:            "team-a",
+				AllowedNamespaces: []string{"team-a"},
+			},
+		},
+		{
+			name:         "api key",
+			ctx:          contextWithClaims(utils.GenerateJWTForAPIUser("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "6ba7b810-9dad-11d1-80b4-00c04fd430c9", time.Now().Add(time.Hour), "withpixie.ai")),
+			expectedFull: true,
+			expectedRestrictions: &controllers.DataAccessRestrictions{
+				Policy:        "api-keys",
+				AllowedTables: []string{"process_stats"},
+			},
+		},
+		{
+			name: "matching service",
+			ctx:  contextWithClaims(testingutils.GenerateTestServiceClaims(t, "plugin")),
+		},
+		{
+			name:        "cluster token for matching user",
+			ctx:         contextWithClaims(clusterClaimsForUser("dev@team-a.com")),
+			expectedPII: true,
+			expectedRestrictions: &controllers.DataAccessRestrictions{
+				Policy:            "team-a",
+				AllowedNamespaces: []string{"team-a"},
+			},
+		},
+		{
+			name:         "cluster token without user",
+			ctx:          contextWithClaims(clusterClaimsForUser("")),
+			expectedFull: true,
+		},
+		{
+			name:         "no matching policy",
+			ctx:          contextWithClaims(testingutils.GenerateTestClaimsWithEmail(t, "dev@team-b.com")),
+			expectedFull: true,
+		},
+		{
+			name:         "no auth context",
+			ctx:          context.Background(),
+			expectedFull: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := dp.RedactionOptions(test.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedFull, opts.GetUseFullRedaction())
+			assert.Equal(t, test.expectedPII, opts.GetUsePxRedactPiiBestEffort())
+
+			restrictions, err := dp.AccessRestrictions(test.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRestrictions, restrictions)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"px.dev/pixie/src/shared/services/authcontext"
+	"px.dev/pixie/src/shared/services/jwtpb"
+	serviceUtils "px.dev/pixie/src/shared/services/utils"
+)
+
+// effectiveUserClaims returns the claims of the user that made the request, either directly or through a
+// cluster token that the cloud issued on the user's behalf. It returns nil if the request was not made on
+// behalf of a user.
+func effectiveUserClaims(claims *jwtpb.JWTClaims) *jwtpb.UserJWTClaims {
+	switch serviceUtils.GetClaimsType(claims) {
+	case serviceUtils.UserClaimType:
+		return claims.GetUserClaims()
+	case serviceUtils.ClusterClaimType:
+		return claims.GetClusterClaims().ForwardedUser
+	default:
+		return nil
+	}
+}
+
+// userClaimsFromContext returns the effective user claims of the request in the context.
+func userClaimsFromContext(ctx context.Context) *jwtpb.UserJWTClaims {
+	aCtx, err := authcontext.FromContext(ctx)
+	if err != nil || aCtx.Claims == nil {
+		return nil
+	}
+	return effectiveUserClaims(aCtx.Claims)
+}
+
+// principalFromContext returns a printable identifier for the user, service or cluster that
+// made the request, or an empty string if the request has no claims attached. Requests proxied
+// through the cloud are attributed to the user that the cluster token was issued for. Cluster
+// tokens that don't name a user, such as those issued by older clouds, all map to the same
+// principal and so share its admission limits.
+func principalFromContext(ctx context.Context) string {
+	if user := userClaimsFromContext(ctx); user != nil {
+		if user.Email != "" {
+			return "user:" + user.Email
+		}
+		return "user:" + user.UserID
+	}
+	aCtx, err := authcontext.FromContext(ctx)
+	if err != nil || aCtx.Claims == nil {
+		return ""
+	}
+	claims := aCtx.Claims
+	switch serviceUtils.GetClaimsType(claims) {
+	case serviceUtils.ServiceClaimType:
+		return "service:" + claims.GetServiceClaims().ServiceID
+	case serviceUtils.ClusterClaimType:
+		return "cluster:" + claims.GetClusterClaims().ClusterID
+	default:
+		return claims.Subject
+	}
+}
+
+// isInternalPrincipal returns whether the request was made by a Pixie service, such as the cron
+// script runner, rather than by a user.
+func isInternalPrincipal(ctx context.Context) bool {
+	aCtx, err := authcontext.FromContext(ctx)
+	if err != nil || aCtx.Claims == nil {
+		return false
+	}
+	return serviceUtils.GetClaimsType(aCtx.Claims) == serviceUtils.ServiceClaimType
+}
@@ -524,6 +524,27 @@ func OutputSchemaFromPlan(planMap map[uuid.UUID]*planpb.Plan) map[string]*schema
 	return outputRelations
 }
 
+// ResultTablesByAgent takes in a plan map and returns the names of the final output tables that each agent streams
+// to the query broker. Agents that only send data to other agents map to no tables.
+func ResultTablesByAgent(planMap map[uuid.UUID]*planpb.Plan) map[uuid.UUID][]string {
+	agentTables := make(map[uuid.UUID][]string, len(planMap))
+	for agentID, plan := range planMap {
+		tables := []string{}
+		for _, fragment := range plan.Nodes {
+			for _, node := range fragment.Nodes {
+				if node.Op.OpType != planpb.GRPC_SINK_OPERATOR {
+					continue
+				}
+				if output := node.Op.GetGRPCSinkOp().GetOutputTable(); output != nil {
+					tables = append(tables, output.TableName)
+				}
+			}
+		}
+		agentTables[agentID] = tables
+	}
+	return agentTables
+}
+
 // AgentRelationToVizierRelation converts the agent relation format to the Vizier relation format.
 func AgentRelationToVizierRelation(relation *schemapb.Relation) *vizierpb.Relation {
 	var cols []*vizierpb.Relation_ColumnInfo
@@ -776,3 +776,25 @@ func TestOutputSchemaFromPlan(t *testing.T) {
 		ColumnSemanticType: typespb.ST_NONE,
 	}, output["agent2_table"].Columns[0])
 }
+
+func TestResultTablesByAgent(t *testing.T) {
+	plannerResultPB := &distributedpb.LogicalPlannerResult{}
+	if err := proto.UnmarshalText(expectedPlannerResult, plannerResultPB); err != nil {
+		t.Fatal("Could not unmarshal protobuf text for planner result.")
+	}
+
+	agent1 := uuid.FromStringOrNil(agent1ID)
+	agent2 := uuid.FromStringOrNil(agent2ID)
+	agent3 := uuid.Must(uuid.NewV4())
+	planMap := map[uuid.UUID]*planpb.Plan{
+		agent1: plannerResultPB.Plan.QbAddressToPlan[agent1ID],
+		agent2: plannerResultPB.Plan.QbAddressToPlan[agent2ID],
+		agent3: {},
+	}
+
+	assert.Equal(t, map[uuid.UUID][]string{
+		agent1: {"agent1_table"},
+		agent2: {"agent2_table"},
+		agent3: {},
+	}, controllers.ResultTablesByAgent(planMap))
+}
@@ -41,6 +41,8 @@ import (
 	"px.dev/pixie/src/carnot/planner/plannerpb"
 	"px.dev/pixie/src/carnot/planpb"
 	"px.dev/pixie/src/common/base/statuspb"
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/vizier/services/metadata/metadatapb"
 )
 
@@ -83,6 +85,8 @@ type QueryExecutor interface {
 type DataPrivacy interface {
 	// RedactionOptions returns the proto message containing options for redaction based on the cached data privacy level.
 	RedactionOptions(ctx context.Context) (*distributedpb.RedactionOptions, error)
+	// AccessRestrictions returns the namespaces and tables that the request may access, or nil if it may access all of them.
+	AccessRestrictions(ctx context.Context) (*DataAccessRestrictions, error)
 }
 
 // MutationExecFactory is a function that creates a new MutationExecutorImpl.
@@ -113,8 +117,38 @@ type QueryExecutorImpl struct {
 
 	// queryName is used for labeling execution time metrics.
 	queryName string
+	// principal identifies who started the query.
+	principal string
 	// numPEMsQueried is stored so that the prometheus metric is only updated if the query succeeded.
 	numPEMsQueried int
+
+	// restrictions limit the namespaces and tables that the query may access. If nil, it may access all of them.
+	restrictions *DataAccessRestrictions
+
+	// Used to select agents by the labels of their nodes. If nil, agents can only be selected by node name.
+	nodeLabeler NodeLabeler
+
+	// If nil, queries are not audited.
+	auditor *QueryAuditor
+	// auditEvent is recorded once the query finishes.
+	auditEvent *cvmsgspb.QueryAuditEvent
+}
+
+// QueryExecutorOption allows specifying options for new QueryExecutors.
+type QueryExecutorOption func(*QueryExecutorImpl)
+
+// WithQueryAuditor records an audit event for every query that the executor runs.
+func WithQueryAuditor(auditor *QueryAuditor) QueryExecutorOption {
+	return func(q *QueryExecutorImpl) {
+		q.auditor = auditor
+	}
+}
+
+// WithNodeLabeler lets queries select the agents that they run on by the labels of their nodes.
+func WithNodeLabeler(nodeLabeler NodeLabeler) QueryExecutorOption {
+	return func(q *QueryExecutorImpl) {
+		q.nodeLabeler = nodeLabeler
+	}
 }
 
 // NewQueryExecutorFromServer creates a new QueryExecutor using the properties of a query broker server.
@@ -130,6 +164,8 @@ func NewQueryExecutorFromServer(s *Server, mutExecFactory MutationExecFactory) Q
 		s.resultForwarder,
 		s.planner,
 		mutExecFactory,
+		WithQueryAuditor(s.auditor),
+		WithNodeLabeler(s.nodeLabeler),
 	)
 }
 
@@ -145,8 +181,9 @@ func NewQueryExecutor(
 	resultForwarder QueryResultForwarder,
 	planner Planner,
 	mutExecFactory MutationExecFactory,
+	opts ...QueryExecutorOption,
 ) QueryExecutor {
-	return &QueryExecutorImpl{
+	q := &QueryExecutorImpl{
 		resultAddress:       resultAddress,
 		resultSSLTargetName: resultSSLTargetName,
 		agentsTracker:       agentsTracker,
@@ -160,12 +197,32 @@ func NewQueryExecutor(
 		queryName:           "",
 		numPEMsQueried:      0,
 	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
 }
 
 // Run launches a query with the given QueryResultConsumer consuming results, and does not wait for the query to error or finish.
-func (q *QueryExecutorImpl) Run(ctx context.Context, req *vizierpb.ExecuteScriptRequest, consumer QueryResultConsumer) error {
+func (q *QueryExecutorImpl) Run(ctx context.Context, req *vizierpb.ExecuteScriptRequest, consumer QueryResultConsumer) (err error) {
 	q.eg, ctx = errgroup.WithContext(ctx)
 
+	q.queryName = req.QueryName
+	if q.queryName == "" {
+		q.queryName = "unnamed"
+	}
+	q.principal = principalFromContext(ctx)
+	// Resumed queries were audited when they first started. Queries that fail to launch are
+	// audited here, because Wait is never called for them.
+	if q.auditor != nil && req.QueryID == "" {
+		q.auditEvent = newQueryAuditEvent(ctx, q.queryName, q.principal, req, q.dataPrivacy)
+		defer func() {
+			if err != nil {
+				q.recordAuditEvent(err)
+			}
+		}()
+	}
+
 	if req.QueryID != "" {
 		queryID, err := uuid.FromString(req.QueryID)
 		if err != nil {
@@ -180,11 +237,16 @@ func (q *QueryExecutorImpl) Run(ctx context.Context, req *vizierpb.ExecuteScript
 		q.queryID = queryID
 	}
 
-	q.queryName = req.QueryName
-	if q.queryName == "" {
-		q.queryName = "unnamed"
+	if q.auditEvent != nil {
+		q.auditEvent.QueryID = utils.ProtoFromUUID(q.queryID)
 	}
 
+	restrictions, err := q.dataPrivacy.AccessRestrictions(ctx)
+	if err != nil {
+		return err
+	}
+	q.restrictions = restrictions
+
 	resultCh := make(chan *vizierpb.ExecuteScriptResponse)
 
 	q.eg.Go(func() error { return q.runConsumer(ctx, resultCh, consumer) })
@@ -195,9 +257,21 @@ func (q *QueryExecutorImpl) Run(ctx context.Context, req *vizierpb.ExecuteScript
 	return nil
 }
 
+// recordAuditEvent records the query's audit event with the outcome of the query. The event is
+// only recorded once.
+func (q *QueryExecutorImpl) recordAuditEvent(err error) {
+	if q.auditEvent == nil {
+		return
+	}
+	finishQueryAuditEvent(q.auditEvent, err)
+	q.auditor.Record(q.auditEvent)
+	q.auditEvent = nil
+}
+
 // Wait waits for the query to finish or error.
 func (q *QueryExecutorImpl) Wait() error {
 	err := q.eg.Wait()
+	q.recordAuditEvent(err)
 	if err == nil {
 		d := time.Since(q.startTime)
 		queryExecTimeSummary.With(prometheus.Labels{"script_name": q.queryName}).Observe(float64(d.Milliseconds()))
@@ -264,6 +338,10 @@ func (q *QueryExecutorImpl) runConsumer(ctx context.Context, resultCh <-chan *vi
 			if !ok {
 				return nil
 			}
+			if stats := result.GetData().GetExecutionStats(); stats != nil && q.auditEvent != nil {
+				q.auditEvent.BytesProcessed = stats.BytesProcessed
+				q.auditEvent.RecordsProcessed = stats.RecordsProcessed
+			}
 			if err := consumer.Consume(result); err != nil {
 				return err
 			}
@@ -280,14 +358,22 @@ func (q *QueryExecutorImpl) sendResponse(ctx context.Context, resultCh chan<- *v
 	}
 }
 
-func (q *QueryExecutorImpl) getPlanOpts(queryStr string) (*planpb.PlanOptions, error) {
+// parseQueryFlags parses the flags set in the script. Invalid flags are sent on the resultCh
+// as compiler errors, and also returned as an error.
+func (q *QueryExecutorImpl) parseQueryFlags(ctx context.Context, resultCh chan<- *vizierpb.ExecuteScriptResponse, queryStr string) (*QueryFlags, error) {
 	flags, err := ParseQueryFlags(queryStr)
+	var flagErrs QueryFlagErrors
+	if !errors.As(err, &flagErrs) {
+		return flags, err
+	}
+	s, err := flagErrs.Status()
 	if err != nil {
 		return nil, err
 	}
-
-	planOpts := flags.GetPlanOptions()
-	return planOpts, nil
+	if err := q.sendResponse(ctx, resultCh, StatusToVizierResponse(q.queryID, s)); err != nil {
+		return nil, err
+	}
+	return nil, StatusToError(s)
 }
 
 func (q *QueryExecutorImpl) runMutation(ctx context.Context, resultCh chan<- *vizierpb.ExecuteScriptResponse, req *vizierpb.ExecuteScriptRequest, planOpts *planpb.PlanOptions, distributedState *distributedpb.DistributedState) error {
@@ -466,12 +552,17 @@ func (q *QueryExecutorImpl) buildQueryPlanOpts(ctx context.Context, resultCh cha
 }
 
 func (q *QueryExecutorImpl) prepareScript(ctx context.Context, resultCh chan<- *vizierpb.ExecuteScriptResponse, req *vizierpb.ExecuteScriptRequest) error {
-	planOpts, err := q.getPlanOpts(req.QueryStr)
+	flags, err := q.parseQueryFlags(ctx, resultCh, req.QueryStr)
 	if err != nil {
 		return err
 	}
+	planOpts := flags.GetPlanOptions()
 
-	distributedState := q.agentsTracker.GetAgentInfo().DistributedState()
+	agentsInfo := q.agentsTracker.GetAgentInfo()
+	distributedState := agentsInfo.DistributedState()
+	if err := selectAgents(&distributedState, agentsInfo.AgentHostnames(), flags, q.nodeLabeler); err != nil {
+		return err
+	}
 
 	if req.Mutation {
 		if err := q.runMutation(ctx, resultCh, req, planOpts, &distributedState); err != nil {
@@ -496,6 +587,12 @@ func (q *QueryExecutorImpl) prepareScript(ctx context.Context, resultCh chan<- *
 	if err != nil {
 		return err
 	}
+	if err := checkTableAccess(planMap, q.restrictions); err != nil {
+		return err
+	}
+	if err := scopePlanToNamespaces(planMap, distributedState.SchemaInfo, q.restrictions); err != nil {
+		return err
+	}
 	tableNameToIDMap, err := q.buildTableMap(planMap)
 	if err != nil {
 		return err
@@ -510,7 +607,8 @@ func (q *QueryExecutorImpl) prepareScript(ctx context.Context, resultCh chan<- *
 		return err
 	}
 
-	err = q.resultForwarder.RegisterQuery(q.queryID, tableNameToIDMap, q.compilationTimeNs, queryPlanOpts, q.queryName)
+	err = q.resultForwarder.RegisterQuery(q.queryID, tableNameToIDMap, ResultTablesByAgent(planMap), q.compilationTimeNs, queryPlanOpts, q.queryName, q.principal,
+		flags.GetQueryLimits())
 	if err != nil {
 		return err
 	}
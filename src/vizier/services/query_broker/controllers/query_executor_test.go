@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -33,12 +34,21 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/carnot/carnotpb"
 	"px.dev/pixie/src/carnot/planner/distributedpb"
+	"px.dev/pixie/src/carnot/planner/plannerpb"
 	"px.dev/pixie/src/carnot/planpb"
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/shared/types/typespb"
+	"px.dev/pixie/src/table_store/schemapb"
+	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/utils/testingutils"
+	"px.dev/pixie/src/vizier/messages/messagespb"
 	"px.dev/pixie/src/vizier/services/query_broker/controllers"
 	mock_controllers "px.dev/pixie/src/vizier/services/query_broker/controllers/mock"
 	"px.dev/pixie/src/vizier/services/query_broker/tracker"
@@ -282,6 +292,7 @@ type fakeResultForwarder struct {
 	QueryDeleted          uuid.UUID
 	QueryStreamed         uuid.UUID
 	StreamedQueryPlanOpts *controllers.QueryPlanOpts
+	Limits                controllers.QueryLimits
 
 	// Variables to set/use for TransferResultChunk testing.
 	ClientStreamClosed   bool
@@ -290,12 +301,13 @@ type fakeResultForwarder struct {
 }
 
 // RegisterQuery registers a query.
-func (f *fakeResultForwarder) RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string,
+func (f *fakeResultForwarder) RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string, agentTables map[uuid.UUID][]string,
 	compilationTimeNs int64,
-	queryPlanOpts *controllers.QueryPlanOpts, queryName string) error {
+	queryPlanOpts *controllers.QueryPlanOpts, queryName string, principal string, limits controllers.QueryLimits) error {
 	f.QueryRegistered = queryID
 	f.TableIDMap = tableIDMap
 	f.StreamedQueryPlanOpts = queryPlanOpts
+	f.Limits = limits
 	return nil
 }
 
@@ -305,6 +317,12 @@ func (f *fakeResultForwarder) StreamResults(ctx context.Context, queryID uuid.UU
 	f.QueryStreamed = queryID
 
 	for _, expectedResult := range f.ClientResultsToSend {
+		// Table IDs are generated while the query runs, so batches may name their table instead.
+		if batch := expectedResult.GetData().GetBatch(); batch != nil {
+			if id, ok := f.TableIDMap[batch.TableID]; ok {
+				batch.TableID = id
+			}
+		}
 		select {
 		case <-ctx.Done():
 			return nil
@@ -334,6 +352,21 @@ func (f *fakeResultForwarder) ProducerCancelStream(queryID uuid.UUID, err error)
 	f.ClientStreamClosed = true
 }
 
+// ListQueries lists the registered queries.
+func (f *fakeResultForwarder) ListQueries() []*vizierpb.QueryInfo {
+	return nil
+}
+
+// GetQuery returns the state of a registered query.
+func (f *fakeResultForwarder) GetQuery(queryID uuid.UUID) (*vizierpb.QueryInfo, error) {
+	return nil, controllers.ErrQueryNotFound
+}
+
+// CancelQuery cancels a registered query.
+func (f *fakeResultForwarder) CancelQuery(queryID uuid.UUID, err error) error {
+	return controllers.ErrQueryNotFound
+}
+
 type queryExecTestCase struct {
 	Name                       string
 	Req                        *vizierpb.ExecuteScriptRequest
@@ -374,7 +407,9 @@ func (c *testConsumer) Consume(result *vizierpb.ExecuteScriptResponse) error {
 }
 
 type fakeDataPrivacy struct {
-	Options *distributedpb.RedactionOptions
+	Options         *distributedpb.RedactionOptions
+	Restrictions    *controllers.DataAccessRestrictions
+	RestrictionsErr error
 }
 
 // RedactionOptions returns RedactionOptions proto message that was set on the fakeDataPrivacy struct.
@@ -382,6 +417,24 @@ func (fdp *fakeDataPrivacy) RedactionOptions(_ context.Context) (*distributedpb.
 	return fdp.Options, nil
 }
 
+// AccessRestrictions returns the DataAccessRestrictions that were set on the fakeDataPrivacy struct.
+func (fdp *fakeDataPrivacy) AccessRestrictions(_ context.Context) (*controllers.DataAccessRestrictions, error) {
+	return fdp.Restrictions, fdp.RestrictionsErr
+}
+
+type fakeAuditSink struct {
+	events []*cvmsgspb.QueryAuditEvent
+}
+
+func (s *fakeAuditSink) Write(event *cvmsgspb.QueryAuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *fakeAuditSink) Close() error {
+	return nil
+}
+
 func runTestCase(t *testing.T, test *queryExecTestCase) {
 	// Start NATS.
 	nc, cleanup := testingutils.MustStartTestNATS(t)
@@ -529,6 +582,102 @@ func buildSimpleSuccessTestCase(t *testing.T) queryExecTestCase {
 	}
 }
 
+// runAuditedTestCase runs the test case with a query auditor, and returns the audit events that
+// were recorded along with the executor. Like the server, it only waits for queries that launched.
+func runAuditedTestCase(t *testing.T, test *queryExecTestCase, dp *fakeDataPrivacy) ([]*cvmsgspb.QueryAuditEvent, controllers.QueryExecutor) {
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	at := &fakeAgentsTracker{
+		agentsInfo: tracker.NewTestAgentsInfo(test.PlannerState.DistributedState),
+	}
+	rf := &fakeResultForwarder{
+		ClientResultsToSend: test.ResultForwarderResps,
+		Error:               test.StreamResultsErr,
+	}
+	planner := mock_controllers.NewMockPlanner(ctrl)
+	planner.EXPECT().Plan(gomock.Any()).Return(test.ExpectedPlannerResult, nil).AnyTimes()
+
+	auditSink := &fakeAuditSink{}
+	queryExec := controllers.NewQueryExecutor("qb_address", "qb_hostname", at, dp, nc, nil, nil, rf, planner, test.MutExecFactory,
+		controllers.WithQueryAuditor(controllers.NewQueryAuditor(auditSink)))
+	err := queryExec.Run(context.Background(), test.Req, newTestConsumer(test.ConsumeErrs))
+	assert.Equal(t, test.QueryExecExpectedRunError, err)
+	if err == nil {
+		assert.Equal(t, test.QueryExecExpectedWaitError, queryExec.Wait())
+	}
+	return auditSink.events, queryExec
+}
+
+func TestQueryExecutor_Audit(t *testing.T) {
+	tests := []struct {
+		name     string
+		testCase queryExecTestCase
+	}{
+		{"success", buildSimpleSuccessTestCase(t)},
+		{"planner error", buildPlannerErrorTestCase(t)},
+		{"stream results error", buildStreamResultErrorTestCase(t)},
+		{"consume error", buildConsumeErrorTestCase(t)},
+		{"resumed query", buildResumeQueryTestCase(t)},
+		{"resumed query with bad ID", buildResumeQueryBadQueryIDTestCase(t)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			test := tc.testCase
+			events, queryExec := runAuditedTestCase(t, &test, &fakeDataPrivacy{})
+			if test.Req.QueryID != "" {
+				assert.Empty(t, events, "resumed queries should not be audited")
+				return
+			}
+			require.Len(t, events, 1)
+			event := events[0]
+			assert.Equal(t, utils.ProtoFromUUID(queryExec.QueryID()), event.QueryID)
+			assert.Equal(t, test.Req.QueryStr, event.Script)
+			assert.Len(t, event.ScriptHash, 64)
+			assert.Equal(t, test.Req.Mutation, event.Mutation)
+			assert.Equal(t, "none", event.RedactionLevel)
+			if test.QueryExecExpectedWaitError == nil {
+				assert.Equal(t, "OK", event.Status)
+			} else {
+				assert.NotEqual(t, "OK", event.Status)
+				assert.Equal(t, test.QueryExecExpectedWaitError.Error(), event.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestQueryExecutor_AuditsRunFailure(t *testing.T) {
+	test := buildSimpleSuccessTestCase(t)
+	runErr := status.Error(codes.Unavailable, "data access policies are not loaded")
+	test.QueryExecExpectedRunError = runErr
+
+	events, queryExec := runAuditedTestCase(t, &test, &fakeDataPrivacy{RestrictionsErr: runErr})
+	require.Len(t, events, 1)
+	event := events[0]
+	assert.Equal(t, utils.ProtoFromUUID(queryExec.QueryID()), event.QueryID)
+	assert.Equal(t, test.Req.QueryStr, event.Script)
+	assert.Equal(t, "Unavailable", event.Status)
+	assert.Equal(t, runErr.Error(), event.ErrorMessage)
+}
+
+func TestQueryExecutor_AuditsProcessedStats(t *testing.T) {
+	test := buildSimpleSuccessTestCase(t)
+	test.Req.QueryName = "px/http_data"
+	dp := &fakeDataPrivacy{Options: &distributedpb.RedactionOptions{UseFullRedaction: true}}
+	events, _ := runAuditedTestCase(t, &test, dp)
+
+	require.Len(t, events, 1)
+	event := events[0]
+	assert.Equal(t, "px/http_data", event.QueryName)
+	assert.Equal(t, "full", event.RedactionLevel)
+	assert.Equal(t, int64(4521), event.BytesProcessed)
+	assert.Equal(t, int64(4), event.RecordsProcessed)
+	assert.Greater(t, event.StartTimeNs, int64(0))
+	assert.GreaterOrEqual(t, event.DurationNs, int64(0))
+}
+
 func buildPlannerErrorTestCase(t *testing.T) queryExecTestCase {
 	errResp := &vizierpb.ExecuteScriptResponse{
 		Status: &vizierpb.Status{
@@ -816,3 +965,354 @@ func buildMutationFailedQueryTestCase(t *testing.T) queryExecTestCase {
 		},
 	}
 }
+
+const namespacedPlannerResult = `
+status: {}
+plan: {
+	qb_address_to_plan: {
+		key: "21285cdd-1de9-4ab1-ae6a-0ba08c8c676c"
+		value: {
+			dag: {
+				nodes: {
+					id: 1
+				}
+			}
+			nodes: {
+				id: 1
+				dag: {
+					nodes: {
+						id: 3
+						sorted_children: 0
+					}
+					nodes: {
+						sorted_parents: 3
+					}
+				}
+				nodes: {
+					id: 3
+					op: {
+						op_type: MEMORY_SOURCE_OPERATOR
+						mem_source_op: {
+							name: "http_events"
+							column_idxs: 0
+							column_idxs: 1
+							column_names: "pod"
+							column_names: "latency"
+							column_types: STRING
+							column_types: INT64
+							tablet: "1"
+						}
+					}
+				}
+				nodes: {
+					op: {
+						op_type: GRPC_SINK_OPERATOR
+						grpc_sink_op: {
+							address: "foo"
+							output_table {
+								table_name: "output"
+								column_types: STRING
+								column_types: INT64
+								column_names: "pod"
+								column_names: "latency"
+								column_semantic_types: ST_POD_NAME
+								column_semantic_types: ST_NONE
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	qb_address_to_dag_id: {
+		key: "21285cdd-1de9-4ab1-ae6a-0ba08c8c676c"
+		value: 0
+	}
+	dag: {
+		nodes: {
+			id: 0
+		}
+	}
+}
+`
+
+// runRestrictedQuery runs the planner result with the given restrictions, and returns the plan that was
+// launched on the agent, if any.
+func runRestrictedQuery(t *testing.T, plannerResult *distributedpb.LogicalPlannerResult, state *distributedpb.DistributedState,
+	restrictions *controllers.DataAccessRestrictions) (*planpb.Plan, error) {
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+	sub, err := nc.SubscribeSync("Agent/21285cdd-1de9-4ab1-ae6a-0ba08c8c676c")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	at := &fakeAgentsTracker{
+		agentsInfo: tracker.NewTestAgentsInfo(state),
+	}
+	rf := &fakeResultForwarder{}
+	planner := mock_controllers.NewMockPlanner(ctrl)
+	planner.EXPECT().Plan(gomock.Any()).Return(plannerResult, nil)
+
+	dp := &fakeDataPrivacy{Restrictions: restrictions}
+	queryExec := controllers.NewQueryExecutor("qb_address", "qb_hostname", at, dp, nc, nil, nil, rf, planner, nil)
+	require.NoError(t, queryExec.Run(context.Background(), &vizierpb.ExecuteScriptRequest{QueryStr: testQuery}, newTestConsumer(nil)))
+	if err := queryExec.Wait(); err != nil {
+		return nil, err
+	}
+
+	msg, err := sub.NextMsg(time.Second)
+	require.NoError(t, err)
+	pb := &messagespb.VizierMessage{}
+	require.NoError(t, proto.Unmarshal(msg.Data, pb))
+	return pb.GetExecuteQueryRequest().Plan, nil
+}
+
+func TestQueryExecutor_DisallowedTable(t *testing.T) {
+	restrictions := &controllers.DataAccessRestrictions{
+		Policy:        "team-a",
+		AllowedTables: []string{"process_stats"},
+	}
+	state := buildPlannerState(t, singleAgentDistributedState).DistributedState
+	_, err := runRestrictedQuery(t, buildPlannerResult(t, expectedPlannerResult), state, restrictions)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), `table "table1" is not allowed by data access policy "team-a"`)
+}
+
+func TestQueryExecutor_NamespaceRestrictionWithoutUPID(t *testing.T) {
+	restrictions := &controllers.DataAccessRestrictions{
+		Policy:            "team-a",
+		AllowedNamespaces: []string{"team-a"},
+	}
+	// The schema of table1 is unknown, so the source can't be made to read the upid column.
+	state := buildPlannerState(t, singleAgentDistributedState).DistributedState
+	_, err := runRestrictedQuery(t, buildPlannerResult(t, expectedPlannerResult), state, restrictions)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), `table "table1" has no upid column`)
+}
+
+func TestQueryExecutor_ScopesSourcesToNamespaces(t *testing.T) {
+	restrictions := &controllers.DataAccessRestrictions{
+		Policy:            "team-a",
+		AllowedNamespaces: []string{"team-a*", "ops"},
+		AllowedTables:     []string{"http_events"},
+	}
+	state := buildPlannerState(t, singleAgentDistributedState).DistributedState
+	state.SchemaInfo = append(state.SchemaInfo, &distributedpb.SchemaInfo{
+		Name: "http_events",
+		Relation: &schemapb.Relation{
+			Columns: []*schemapb.Relation_ColumnInfo{
+				{ColumnName: "pod", ColumnType: typespb.STRING},
+				{ColumnName: "latency", ColumnType: typespb.INT64},
+				{ColumnName: "upid", ColumnType: typespb.UINT128},
+			},
+		},
+	})
+
+	plan, err := runRestrictedQuery(t, buildPlannerResult(t, namespacedPlannerResult), state, restrictions)
+	require.NoError(t, err)
+	require.Len(t, plan.Nodes, 1)
+	fragment := plan.Nodes[0]
+	require.Len(t, fragment.Nodes, 3)
+
+	// The source reads the upid column too, under a new ID.
+	src := fragment.Nodes[0]
+	assert.Equal(t, uint64(4), src.Id)
+	assert.Equal(t, []int64{0, 1, 2}, src.Op.GetMemSourceOp().ColumnIdxs)
+	assert.Equal(t, []string{"pod", "latency", "upid"}, src.Op.GetMemSourceOp().ColumnNames)
+
+	// The filter takes over the ID of the source, and drops the upid column again.
+	filter := fragment.Nodes[1]
+	assert.Equal(t, uint64(3), filter.Id)
+	expectedFilter := &planpb.FilterOperator{
+		Expression: &planpb.ScalarExpression{
+			Value: &planpb.ScalarExpression_Func{
+				Func: &planpb.ScalarFunc{
+					Name: "regex_match",
+					Id:   1,
+					InitArgs: []*planpb.ScalarValue{
+						{DataType: typespb.STRING, Value: &planpb.ScalarValue_StringValue{StringValue: `(?:team-a[^/]*|ops)`}},
+					},
+					Args: []*planpb.ScalarExpression{
+						{
+							Value: &planpb.ScalarExpression_Func{
+								Func: &planpb.ScalarFunc{
+									Name: "upid_to_namespace",
+									Id:   0,
+									Args: []*planpb.ScalarExpression{
+										{Value: &planpb.ScalarExpression_Column{Column: &planpb.Column{Node: 4, Index: 2}}},
+									},
+									ArgsDataTypes: []typespb.DataType{typespb.UINT128},
+								},
+							},
+						},
+					},
+					ArgsDataTypes: []typespb.DataType{typespb.STRING, typespb.STRING},
+				},
+			},
+		},
+		Columns: []*planpb.Column{{Node: 4, Index: 0}, {Node: 4, Index: 1}},
+	}
+	assert.Equal(t, expectedFilter, filter.Op.GetFilterOp())
+
+	expectedDAG := &planpb.DAG{
+		Nodes: []*planpb.DAG_DAGNode{
+			{Id: 4, SortedChildren: []uint64{3}},
+			{Id: 3, SortedParents: []uint64{4}, SortedChildren: []uint64{0}},
+			{SortedParents: []uint64{3}},
+		},
+	}
+	assert.Equal(t, expectedDAG, fragment.Dag)
+}
+
+func TestQueryExecutor_NamespaceRestrictionRejectsUDTFs(t *testing.T) {
+	restrictions := &controllers.DataAccessRestrictions{
+		Policy:            "team-a",
+		AllowedNamespaces: []string{"team-a"},
+	}
+	plannerResult := buildPlannerResult(t, namespacedPlannerResult)
+	for _, plan := range plannerResult.Plan.QbAddressToPlan {
+		plan.Nodes[0].Nodes[0].Op = &planpb.Operator{
+			OpType: planpb.UDTF_SOURCE_OPERATOR,
+			Op: &planpb.Operator_UdtfSourceOp{
+				UdtfSourceOp: &planpb.UDTFSourceOperator{Name: "GetAgentStatus"},
+			},
+		}
+	}
+	state := buildPlannerState(t, singleAgentDistributedState).DistributedState
+	_, err := runRestrictedQuery(t, plannerResult, state, restrictions)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), "GetAgentStatus can't be restricted")
+}
+
+func TestQueryExecutor_InvalidFlags(t *testing.T) {
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	at := &fakeAgentsTracker{
+		agentsInfo: tracker.NewTestAgentsInfo(buildPlannerState(t, singleAgentDistributedState).DistributedState),
+	}
+	planner := mock_controllers.NewMockPlanner(ctrl)
+
+	queryExec := controllers.NewQueryExecutor("qb_address", "qb_hostname", at, &fakeDataPrivacy{}, nc, nil, nil, &fakeResultForwarder{}, planner, nil)
+	consumer := newTestConsumer(nil)
+	req := &vizierpb.ExecuteScriptRequest{QueryStr: "#px:set query_timeout=-5s\n" + testQuery}
+	require.NoError(t, queryExec.Run(context.Background(), req, consumer))
+	err := queryExec.Wait()
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	require.Equal(t, 1, len(consumer.results))
+	details := consumer.results[0].Status.ErrorDetails
+	require.Equal(t, 1, len(details))
+	assert.Equal(t, uint64(1), details[0].GetCompilerError().Line)
+	assert.Equal(t, uint64(23), details[0].GetCompilerError().Column)
+}
+
+type fakeNodeLabeler map[string]labels.Set
+
+func (f fakeNodeLabeler) NodeLabels(node string) (labels.Set, bool) {
+	l, ok := f[node]
+	return l, ok
+}
+
+func TestQueryExecutor_AgentSelection(t *testing.T) {
+	pem1 := uuid.Must(uuid.FromString("21285cdd-1de9-4ab1-ae6a-0ba08c8c676c"))
+	pem2 := uuid.Must(uuid.FromString("31285cdd-1de9-4ab1-ae6a-0ba08c8c676c"))
+	kelvin := uuid.Must(uuid.FromString("41285cdd-1de9-4ab1-ae6a-0ba08c8c676c"))
+
+	ds := buildPlannerState(t, singleAgentDistributedState).DistributedState
+	ds.CarnotInfo = append(ds.CarnotInfo,
+		&distributedpb.CarnotInfo{AgentID: utils.ProtoFromUUID(pem2), HasDataStore: true, ProcessesData: true},
+		&distributedpb.CarnotInfo{AgentID: utils.ProtoFromUUID(kelvin), HasGRPCServer: true, ProcessesData: true, AcceptsRemoteSources: true},
+	)
+	hostnames := map[uuid.UUID]string{
+		pem1:   "node-a-1",
+		pem2:   "node-b-1",
+		kelvin: "kelvin-0",
+	}
+	nodeLabeler := fakeNodeLabeler{
+		"node-a-1": labels.Set{"pool": "default"},
+		"node-b-1": labels.Set{"pool": "batch"},
+	}
+
+	tests := []struct {
+		name           string
+		flags          string
+		expectedAgents []uuid.UUID
+		expectedErr    codes.Code
+	}{
+		{
+			name:           "no selection",
+			flags:          "",
+			expectedAgents: []uuid.UUID{pem1, pem2, kelvin},
+		},
+		{
+			name:           "node name",
+			flags:          "#px:set agent_selector=node:node-a-*\n",
+			expectedAgents: []uuid.UUID{pem1, kelvin},
+		},
+		{
+			name:           "node labels",
+			flags:          "#px:set agent_selector=label:pool=batch\n",
+			expectedAgents: []uuid.UUID{pem2, kelvin},
+		},
+		{
+			name:        "no matching agents",
+			flags:       "#px:set agent_selector=node:node-c-*\n",
+			expectedErr: codes.InvalidArgument,
+		},
+		{
+			name:        "too many agents",
+			flags:       "#px:set max_target_agents=1\n",
+			expectedErr: codes.InvalidArgument,
+		},
+		{
+			name:           "selection within max agents",
+			flags:          "#px:set max_target_agents=1\n#px:set agent_selector=label:pool!=batch\n",
+			expectedAgents: []uuid.UUID{pem1, kelvin},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nc, cleanup := testingutils.MustStartTestNATS(t)
+			defer cleanup()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			at := &fakeAgentsTracker{
+				agentsInfo: tracker.NewTestAgentsInfoWithHostnames(ds, hostnames),
+			}
+
+			var plannedAgents []uuid.UUID
+			planner := mock_controllers.NewMockPlanner(ctrl)
+			planner.EXPECT().Plan(gomock.Any()).DoAndReturn(func(req *plannerpb.QueryRequest) (*distributedpb.LogicalPlannerResult, error) {
+				for _, carnotInfo := range req.LogicalPlannerState.DistributedState.CarnotInfo {
+					plannedAgents = append(plannedAgents, utils.UUIDFromProtoOrNil(carnotInfo.AgentID))
+				}
+				return buildPlannerResult(t, expectedPlannerResult), nil
+			}).AnyTimes()
+
+			queryExec := controllers.NewQueryExecutor("qb_address", "qb_hostname", at, &fakeDataPrivacy{}, nc, nil, nil, &fakeResultForwarder{}, planner, nil,
+				controllers.WithNodeLabeler(nodeLabeler))
+			req := &vizierpb.ExecuteScriptRequest{QueryStr: test.flags + testQuery}
+			require.NoError(t, queryExec.Run(context.Background(), req, newTestConsumer(nil)))
+			err := queryExec.Wait()
+
+			if test.expectedErr != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, status.Code(err))
+				assert.Nil(t, plannedAgents)
+				return
+			}
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedAgents, plannedAgents)
+		})
+	}
+}
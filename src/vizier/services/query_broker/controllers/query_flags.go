@@ -19,16 +19,26 @@
 package controllers
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 
+	"px.dev/pixie/src/carnot/planner/compilerpb"
 	"px.dev/pixie/src/carnot/planpb"
+	"px.dev/pixie/src/common/base/statuspb"
 )
 
+func init() {
+	pflag.Int64("max_output_rows_per_table", 10000, "The default maximum number of rows that each output table of a query returns. "+
+		"Scripts can override it with '#px:set max_output_rows_per_table=<rows>'")
+}
+
 // The prefix which a PL Config line should begin with.
 const plConfigPrefix = "#px:set "
 
@@ -38,6 +48,91 @@ var defaultQueryFlags = map[string]interface{}{
 	"explain":                   false,
 	"analyze":                   false,
 	"max_output_rows_per_table": 10000,
+	// Set to false to always run the query instead of replaying recent results from the result cache.
+	"result_cache": true,
+	// The wall-clock time after which the query is cancelled. 0 disables the timeout.
+	"query_timeout": time.Duration(0),
+	// The maximum number of result bytes returned to the client. 0 disables the budget.
+	"max_bytes_to_return": 0,
+	// The maximum number of agents that the query may run on. 0 disables the limit.
+	"max_target_agents": 0,
+	// Restricts the agents that the query runs on, by node name ("node:<glob>") or node labels ("label:<selector>").
+	"agent_selector": "",
+}
+
+// Flags whose defaults are configured for the whole cluster, rather than in defaultQueryFlags.
+var clusterQueryFlags = map[string]bool{
+	"max_output_rows_per_table": true,
+}
+
+// Checks on flag values beyond their type.
+var queryFlagValidators = map[string]func(interface{}) error{
+	"max_output_rows_per_table": validateNonNegative,
+	"query_timeout":             validateNonNegative,
+	"max_bytes_to_return":       validateNonNegative,
+	"max_target_agents":         validateNonNegative,
+	"agent_selector": func(val interface{}) error {
+		_, err := parseAgentSelector(val.(string))
+		return err
+	},
+}
+
+func validateNonNegative(val interface{}) error {
+	var negative bool
+	switch v := val.(type) {
+	case int64:
+		negative = v < 0
+	case time.Duration:
+		negative = v < 0
+	}
+	if negative {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// QueryFlagError is an invalid flag setting in a script.
+type QueryFlagError struct {
+	// The 1-indexed line and column of the error in the script.
+	Line    uint64
+	Column  uint64
+	Message string
+}
+
+// QueryFlagErrors are the invalid flag settings in a script. They are reported to clients the same way as compiler errors.
+type QueryFlagErrors []*QueryFlagError
+
+func (e QueryFlagErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, flagErr := range e {
+		msgs[i] = fmt.Sprintf("%d:%d %s", flagErr.Line, flagErr.Column, flagErr.Message)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Status converts the errors into an invalid argument status with a compiler error context.
+func (e QueryFlagErrors) Status() (*statuspb.Status, error) {
+	errs := make([]*compilerpb.CompilerError, len(e))
+	for i, flagErr := range e {
+		errs[i] = &compilerpb.CompilerError{
+			Error: &compilerpb.CompilerError_LineColError{
+				LineColError: &compilerpb.LineColError{
+					Line:    flagErr.Line,
+					Column:  flagErr.Column,
+					Message: flagErr.Message,
+				},
+			},
+		}
+	}
+	errCtx, err := types.MarshalAny(&compilerpb.CompilerErrorGroup{Errors: errs})
+	if err != nil {
+		return nil, err
+	}
+	return &statuspb.Status{
+		ErrCode: statuspb.INVALID_ARGUMENT,
+		Msg:     e.Error(),
+		Context: errCtx,
+	}, nil
 }
 
 // QueryFlags represents a set of Pixie configuration flags.
@@ -58,6 +153,12 @@ func (f *QueryFlags) get(key string) interface{} {
 	if val, ok := f.flags[key]; ok {
 		return val
 	}
+	// Check if the cluster overrides the default.
+	if clusterQueryFlags[key] {
+		if val := viper.Get(key); val != nil {
+			return val
+		}
+	}
 	// Check if the key is defined in the default configs.
 	if val, ok := defaultQueryFlags[key]; ok {
 		return val
@@ -89,31 +190,54 @@ func (f *QueryFlags) GetFloat64(key string) float64 {
 	return cast.ToFloat64(val)
 }
 
+// GetDuration gets the value of the given flag as a duration.
+func (f *QueryFlags) GetDuration(key string) time.Duration {
+	val := f.get(key)
+	return cast.ToDuration(val)
+}
+
 func (f *QueryFlags) set(key string, value string) error {
 	// Ensure that the key is a valid flag that can be set, by checking it is
 	// defined in the defaults.
-	if defVal, ok := defaultQueryFlags[key]; ok {
-		var typedVal interface{}
-		var err error
-		switch defVal.(type) {
-		case int:
-			typedVal, err = strconv.ParseInt(value, 10, 64)
-		case float64:
-			typedVal, err = strconv.ParseFloat(value, 64)
-		case string:
-			typedVal = value
-		case bool:
-			typedVal, err = strconv.ParseBool(value)
-		}
+	defVal, ok := defaultQueryFlags[key]
+	if !ok {
+		return fmt.Errorf("%s is not a valid flag", key)
+	}
 
+	var typedVal interface{}
+	var err error
+	switch defVal.(type) {
+	case int:
+		typedVal, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for flag %s: expected an integer", value, key)
+		}
+	case float64:
+		typedVal, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for flag %s: expected a number", value, key)
+		}
+	case string:
+		typedVal = value
+	case bool:
+		typedVal, err = strconv.ParseBool(value)
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid value '%s' for flag %s: expected true or false", value, key)
+		}
+	case time.Duration:
+		typedVal, err = time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for flag %s: expected a duration such as 30s", value, key)
 		}
-		f.flags[key] = typedVal
-		return nil
 	}
 
-	return fmt.Errorf("%s is not a valid flag", key)
+	if validate, ok := queryFlagValidators[key]; ok {
+		if err := validate(typedVal); err != nil {
+			return fmt.Errorf("invalid value '%s' for flag %s: %v", value, key, err)
+		}
+	}
+	f.flags[key] = typedVal
+	return nil
 }
 
 // GetPlanOptions creates the plan option proto from the specified query flags.
@@ -125,29 +249,52 @@ func (f *QueryFlags) GetPlanOptions() *planpb.PlanOptions {
 	}
 }
 
+// GetQueryLimits returns the limits that the result forwarder enforces on the query.
+func (f *QueryFlags) GetQueryLimits() QueryLimits {
+	return QueryLimits{
+		Timeout:       f.GetDuration("query_timeout"),
+		MaxResultSize: f.GetInt64("max_bytes_to_return"),
+	}
+}
+
 // ParseQueryFlags takes a query string containing some config options and generates
 // a QueryFlags object that can be used to retrieve those options.
+// If any of the options are invalid, it returns QueryFlagErrors.
 func ParseQueryFlags(queryStr string) (*QueryFlags, error) {
 	qf := newQueryFlags()
+	var flagErrs QueryFlagErrors
 
-	for _, line := range strings.Split(strings.TrimSuffix(queryStr, "\n"), "\n") {
+	for i, line := range strings.Split(strings.TrimSuffix(queryStr, "\n"), "\n") {
 		// If the line begins with the PL config prefix, attempt to parse the line.
-		if strings.HasPrefix(line, plConfigPrefix) {
-			queryComponents := strings.Split(line, " ")
-
-			if len(queryComponents) != 2 {
-				return nil, errors.New("Config setting is malformed")
-			}
-			keyVal := strings.Split(queryComponents[1], "=")
-			if len(keyVal) != 2 {
-				return nil, errors.New("Config setting is malformed")
-			}
-			err := qf.set(keyVal[0], keyVal[1])
-			if err != nil {
-				return nil, err
+		if !strings.HasPrefix(line, plConfigPrefix) {
+			continue
+		}
+		setting := strings.TrimPrefix(line, plConfigPrefix)
+		keyVal := strings.SplitN(setting, "=", 2)
+		if strings.Contains(setting, " ") || len(keyVal) != 2 {
+			flagErrs = append(flagErrs, &QueryFlagError{
+				Line:    uint64(i + 1),
+				Column:  uint64(len(plConfigPrefix) + 1),
+				Message: "Config setting is malformed, expected '#px:set <flag>=<value>'",
+			})
+			continue
+		}
+		if err := qf.set(keyVal[0], keyVal[1]); err != nil {
+			column := len(plConfigPrefix) + 1
+			if _, ok := defaultQueryFlags[keyVal[0]]; ok {
+				// Point at the value if the flag itself is valid.
+				column += len(keyVal[0]) + 1
 			}
+			flagErrs = append(flagErrs, &QueryFlagError{
+				Line:    uint64(i + 1),
+				Column:  uint64(column),
+				Message: err.Error(),
+			})
 		}
 	}
 
+	if len(flagErrs) > 0 {
+		return nil, flagErrs
+	}
 	return qf, nil
 }
@@ -19,10 +19,14 @@
 package controllers_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
 
 	"px.dev/pixie/src/vizier/services/query_broker/controllers"
 )
@@ -110,3 +114,104 @@ func TestParseQueryFlags_PlanOptions(t *testing.T) {
 	assert.Equal(t, options.Explain, false)
 	assert.Equal(t, options.Analyze, true)
 }
+
+const queryWithLimitFlags = `import px
+
+#px:set query_timeout=30s
+#px:set max_bytes_to_return=1048576
+#px:set max_target_agents=5
+#px:set agent_selector=label:pool=batch,zone!=us-east1-b
+
+df = px.DataFrame(table='process_stats', start_time='-5s')
+`
+
+func TestParseQueryFlags_Limits(t *testing.T) {
+	qf, err := controllers.ParseQueryFlags(queryWithLimitFlags)
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Second, qf.GetDuration("query_timeout"))
+	assert.Equal(t, int64(5), qf.GetInt64("max_target_agents"))
+	assert.Equal(t, "label:pool=batch,zone!=us-east1-b", qf.GetString("agent_selector"))
+	assert.Equal(t, controllers.QueryLimits{
+		Timeout:       30 * time.Second,
+		MaxResultSize: 1048576,
+	}, qf.GetQueryLimits())
+
+	qf, err = controllers.ParseQueryFlags(validQueryWithoutFlag)
+	require.NoError(t, err)
+	assert.Equal(t, controllers.QueryLimits{}, qf.GetQueryLimits())
+}
+
+func TestParseQueryFlags_ClusterDefault(t *testing.T) {
+	viper.Set("max_output_rows_per_table", 500)
+	defer viper.Set("max_output_rows_per_table", nil)
+
+	qf, err := controllers.ParseQueryFlags(validQueryWithoutFlag)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), qf.GetPlanOptions().MaxOutputRowsPerTable)
+
+	// Scripts override the cluster default.
+	qf, err = controllers.ParseQueryFlags(validQueryWithFlag)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9999), qf.GetPlanOptions().MaxOutputRowsPerTable)
+}
+
+func TestParseQueryFlags_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedErrors controllers.QueryFlagErrors
+	}{
+		{
+			name:  "invalid duration",
+			query: "import px\n#px:set query_timeout=soon\n",
+			expectedErrors: controllers.QueryFlagErrors{
+				{Line: 2, Column: 23, Message: "invalid value 'soon' for flag query_timeout: expected a duration such as 30s"},
+			},
+		},
+		{
+			name:  "negative value",
+			query: "#px:set max_bytes_to_return=-1",
+			expectedErrors: controllers.QueryFlagErrors{
+				{Line: 1, Column: 29, Message: "invalid value '-1' for flag max_bytes_to_return: must not be negative"},
+			},
+		},
+		{
+			name:  "invalid selector",
+			query: "#px:set agent_selector=pod:foo",
+			expectedErrors: controllers.QueryFlagErrors{
+				{Line: 1, Column: 24, Message: "invalid value 'pod:foo' for flag agent_selector: expected node:<name> or label:<selector>"},
+			},
+		},
+		{
+			name:  "multiple errors",
+			query: "#px:set ABCD=efgh\nimport px\n#px:set analyze,true\n",
+			expectedErrors: controllers.QueryFlagErrors{
+				{Line: 1, Column: 9, Message: "ABCD is not a valid flag"},
+				{Line: 3, Column: 9, Message: "Config setting is malformed, expected '#px:set <flag>=<value>'"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			qf, err := controllers.ParseQueryFlags(test.query)
+			assert.Nil(t, qf)
+			var flagErrs controllers.QueryFlagErrors
+			require.True(t, errors.As(err, &flagErrs))
+			assert.Equal(t, test.expectedErrors, flagErrs)
+
+			s, err := flagErrs.Status()
+			require.NoError(t, err)
+			vzStatus := controllers.StatusToVizierStatus(s)
+			assert.Equal(t, int32(codes.InvalidArgument), vzStatus.Code)
+			require.Equal(t, len(test.expectedErrors), len(vzStatus.ErrorDetails))
+			for i, flagErr := range test.expectedErrors {
+				compilerErr := vzStatus.ErrorDetails[i].GetCompilerError()
+				assert.Equal(t, flagErr.Line, compilerErr.Line)
+				assert.Equal(t, flagErr.Column, compilerErr.Column)
+				assert.Equal(t, flagErr.Message, compilerErr.Message)
+			}
+		})
+	}
+}
@@ -20,15 +20,20 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/carnot/carnotpb"
@@ -80,6 +85,9 @@ const defaultProducerTimeout = 180 * time.Second
 // since the consumer can't consume data if the producers aren't sending any data.
 const defaultConsumerTimeout = 180 * time.Second
 
+// ErrQueryNotFound is returned when a query is not registered in the result forwarder.
+var ErrQueryNotFound = errors.New("query not found")
+
 var void = struct{}{}
 
 type concurrentSet struct {
@@ -130,6 +138,9 @@ func (s *concurrentSet) size() int {
 type activeQuery struct {
 	queryResultCh chan *carnotpb.TransferResultChunkRequest
 	tableIDMap    map[string]string
+	// The result tables that each agent in the plan streams to the query broker. Agents that only send
+	// data to other agents have no tables. This is not modified after the query is registered.
+	agentTables map[uuid.UUID][]string
 
 	// The set of result tables that still need to open a connection to this
 	// result forwarder via TransferResultChunk. If they take too long to open the
@@ -165,14 +176,27 @@ type activeQuery struct {
 
 	// Name used for labeling metrics recorded for this query.
 	queryName string
+	// The user, service or cluster that started the query.
+	principal string
+	startTime time.Time
+
+	// Limits set by the query's flags.
+	limits QueryLimits
+
+	// Progress counters reported by the query listing API. These are updated by the
+	// producer and consumer goroutines and read concurrently, so they are accessed atomically.
+	rowsStreamed  int64
+	bytesStreamed int64
 }
 
-func newActiveQuery(producerCtx context.Context, tableIDMap map[string]string,
+func newActiveQuery(producerCtx context.Context, tableIDMap map[string]string, agentTables map[uuid.UUID][]string,
 	compilationTimeNs int64,
-	queryPlanOpts *QueryPlanOpts, watchdogCancel context.CancelFunc, queryName string) *activeQuery {
+	queryPlanOpts *QueryPlanOpts, watchdogCancel context.CancelFunc, queryName string, principal string,
+	limits QueryLimits) *activeQuery {
 	aq := &activeQuery{
 		queryResultCh: make(chan *carnotpb.TransferResultChunkRequest, activeQueryBufferSize),
 		tableIDMap:    tableIDMap,
+		agentTables:   agentTables,
 
 		uninitializedTables: &concurrentSet{unsafeMap: make(map[string]struct{})},
 		remainingTableEos:   &concurrentSet{unsafeMap: make(map[string]struct{})},
@@ -192,6 +216,9 @@ func newActiveQuery(producerCtx context.Context, tableIDMap map[string]string,
 		producerCtx:     producerCtx,
 
 		queryName: queryName,
+		principal: principal,
+		startTime: time.Now(),
+		limits:    limits,
 	}
 
 	for tableName := range tableIDMap {
@@ -228,6 +255,13 @@ func (a *activeQuery) updateQueryState(msg *carnotpb.TransferResultChunkRequest)
 		tableName := queryResult.GetTableName()
 
 		if rb := queryResult.GetRowBatch(); rb != nil {
+			atomic.AddInt64(&a.rowsStreamed, rb.NumRows)
+			bytesStreamed := atomic.AddInt64(&a.bytesStreamed, int64(rb.Size()))
+			if a.limits.MaxResultSize > 0 && bytesStreamed > a.limits.MaxResultSize {
+				return status.Errorf(codes.ResourceExhausted, "query %s returned more than max_bytes_to_return=%d bytes",
+					queryIDStr, a.limits.MaxResultSize)
+			}
+
 			if a.uninitializedTables.exists(tableName) {
 				a.uninitializedTables.remove(tableName)
 				if a.uninitializedTables.size() == 0 {
@@ -278,6 +312,13 @@ func (a *activeQuery) watchdog(ctx context.Context, queryID uuid.UUID, consumerT
 		}
 		t.Reset(timeout)
 	}
+	// A nil channel never fires, so queries without a timeout only end on the other cases.
+	var deadline <-chan time.Time
+	if a.limits.Timeout > 0 {
+		deadlineTimer := time.NewTimer(a.limits.Timeout)
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C
+	}
 	cancelConsumer := func() {}
 forLoop:
 	for {
@@ -299,6 +340,9 @@ forLoop:
 		case <-producerTimer.C:
 			a.cancelQueryError = fmt.Errorf("Query %s timedout waiting for producers", queryID.String())
 			break forLoop
+		case <-deadline:
+			a.cancelQueryError = status.Errorf(codes.DeadlineExceeded, "Query %s exceeded query_timeout=%s", queryID.String(), a.limits.Timeout)
+			break forLoop
 
 		case <-ctx.Done():
 			break forLoop
@@ -390,12 +434,58 @@ func (a *activeQuery) cancelQuery(err error) {
 	a.cancelQueryFunc()
 }
 
+// activeAgents returns the number of agents in the plan that are still producing results. An agent is done once
+// all of the result tables that it streams to the query broker have sent EOS. Agents that only send data to other
+// agents are done once every result table of the query has sent EOS, since their data is consumed before then.
+func (a *activeQuery) activeAgents() int64 {
+	queryDone := a.remainingTableEos.size() == 0
+	var active int64
+	for _, tables := range a.agentTables {
+		if len(tables) == 0 {
+			if !queryDone {
+				active++
+			}
+			continue
+		}
+		for _, table := range tables {
+			if a.remainingTableEos.exists(table) {
+				active++
+				break
+			}
+		}
+	}
+	return active
+}
+
+func (a *activeQuery) info(queryID uuid.UUID) *vizierpb.QueryInfo {
+	pendingTables := a.remainingTableEos.values()
+	sort.Strings(pendingTables)
+	return &vizierpb.QueryInfo{
+		QueryID:       queryID.String(),
+		QueryName:     a.queryName,
+		Principal:     a.principal,
+		StartTimeNs:   a.startTime.UnixNano(),
+		ActiveAgents:  a.activeAgents(),
+		PendingTables: pendingTables,
+		RowsStreamed:  atomic.LoadInt64(&a.rowsStreamed),
+		BytesStreamed: atomic.LoadInt64(&a.bytesStreamed),
+	}
+}
+
+// QueryLimits are the limits that the result forwarder enforces on a query. Zero values disable a limit.
+type QueryLimits struct {
+	// The wall-clock time after which the query is cancelled.
+	Timeout time.Duration
+	// The maximum number of bytes of row batches that the query may return.
+	MaxResultSize int64
+}
+
 // QueryResultForwarder is responsible for receiving query results from the agent streams and forwarding
 // that data to the client stream.
 type QueryResultForwarder interface {
-	RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string,
+	RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string, agentTables map[uuid.UUID][]string,
 		compilationTimeNs int64,
-		queryPlanOpts *QueryPlanOpts, queryName string) error
+		queryPlanOpts *QueryPlanOpts, queryName string, principal string, limits QueryLimits) error
 
 	// Streams results from the agent stream to the client stream.
 	// Blocks until the stream (& the agent stream) has completed, been cancelled, or experienced an error.
@@ -410,6 +500,13 @@ type QueryResultForwarder interface {
 	// If the producer of data (i.e. Kelvin) errors then this function can be used to shutdown the stream.
 	// The producer should not call this function if there's a retry is possible.
 	ProducerCancelStream(queryID uuid.UUID, err error)
+
+	// Returns the state of all of the registered queries, ordered by start time.
+	ListQueries() []*vizierpb.QueryInfo
+	// Returns the state of the given query, or ErrQueryNotFound if it isn't registered.
+	GetQuery(queryID uuid.UUID) (*vizierpb.QueryInfo, error)
+	// Cancels the given query, or returns ErrQueryNotFound if it isn't registered.
+	CancelQuery(queryID uuid.UUID, err error) error
 }
 
 // QueryResultForwarderImpl implements the QueryResultForwarder interface.
@@ -469,10 +566,12 @@ func NewQueryResultForwarderWithOptions(opts ...QueryResultForwarderOption) Quer
 }
 
 // RegisterQuery registers a query ID in the result forwarder.
-func (f *QueryResultForwarderImpl) RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string,
+func (f *QueryResultForwarderImpl) RegisterQuery(queryID uuid.UUID, tableIDMap map[string]string, agentTables map[uuid.UUID][]string,
 	compilationTimeNs int64,
 	queryPlanOpts *QueryPlanOpts,
-	queryName string) error {
+	queryName string,
+	principal string,
+	limits QueryLimits) error {
 	f.activeQueriesMutex.Lock()
 	defer f.activeQueriesMutex.Unlock()
 
@@ -481,7 +580,7 @@ func (f *QueryResultForwarderImpl) RegisterQuery(queryID uuid.UUID, tableIDMap m
 	}
 	watchdogCtx, watchdogCancel := context.WithCancel(context.Background())
 	producerCtx, producerCancel := context.WithCancel(context.Background())
-	aq := newActiveQuery(producerCtx, tableIDMap, compilationTimeNs, queryPlanOpts, watchdogCancel, queryName)
+	aq := newActiveQuery(producerCtx, tableIDMap, agentTables, compilationTimeNs, queryPlanOpts, watchdogCancel, queryName, principal, limits)
 	f.activeQueries[queryID] = aq
 
 	deleteQuery := func() {
@@ -607,3 +706,47 @@ func (f *QueryResultForwarderImpl) ProducerCancelStream(queryID uuid.UUID, err e
 	// Cancel the query if it hasn't already been cancelled.
 	activeQuery.cancelQuery(err)
 }
+
+// ListQueries returns the state of all of the queries registered in the result forwarder.
+func (f *QueryResultForwarderImpl) ListQueries() []*vizierpb.QueryInfo {
+	f.activeQueriesMutex.Lock()
+	queries := make([]*vizierpb.QueryInfo, 0, len(f.activeQueries))
+	for queryID, activeQuery := range f.activeQueries {
+		queries = append(queries, activeQuery.info(queryID))
+	}
+	f.activeQueriesMutex.Unlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].StartTimeNs != queries[j].StartTimeNs {
+			return queries[i].StartTimeNs < queries[j].StartTimeNs
+		}
+		return queries[i].QueryID < queries[j].QueryID
+	})
+	return queries
+}
+
+// GetQuery returns the state of a query registered in the result forwarder.
+func (f *QueryResultForwarderImpl) GetQuery(queryID uuid.UUID) (*vizierpb.QueryInfo, error) {
+	f.activeQueriesMutex.Lock()
+	activeQuery, present := f.activeQueries[queryID]
+	f.activeQueriesMutex.Unlock()
+
+	if !present {
+		return nil, fmt.Errorf("%w: %s", ErrQueryNotFound, queryID.String())
+	}
+	return activeQuery.info(queryID), nil
+}
+
+// CancelQuery cancels a query registered in the result forwarder. The consumer of the query
+// receives the given error.
+func (f *QueryResultForwarderImpl) CancelQuery(queryID uuid.UUID, err error) error {
+	f.activeQueriesMutex.Lock()
+	activeQuery, present := f.activeQueries[queryID]
+	f.activeQueriesMutex.Unlock()
+
+	if !present {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, queryID.String())
+	}
+	activeQuery.cancelQuery(err)
+	return nil
+}
@@ -20,6 +20,7 @@ package controllers_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -29,6 +30,8 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/carnot/carnotpb"
@@ -162,7 +165,7 @@ func TestStreamResultsSimple(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -229,7 +232,7 @@ func TestStreamResultsAgentCancel(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -296,7 +299,7 @@ func TestStreamResultsClientContextCancel(t *testing.T) {
 	}()
 	errCh := make(chan error)
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err := f.StreamResults(consumerCtx, queryID, resultCh)
@@ -364,7 +367,7 @@ func TestStreamResultsQueryPlan(t *testing.T) {
 		Plan:    plan,
 		PlanMap: planMap,
 	}
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, queryPlanOpts, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, queryPlanOpts, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -446,7 +449,7 @@ func TestStreamResultsWrongQueryID(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -509,7 +512,7 @@ func TestStreamResultsResultsBeforeInitialization(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -558,7 +561,7 @@ func TestStreamResultsNeverInitializedTable(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -622,7 +625,7 @@ func TestStreamResultsProducerTimeout(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -681,7 +684,7 @@ func TestStreamResultsNewConsumer(t *testing.T) {
 	}()
 	var consumer1Err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		consumer1Err = f.StreamResults(consumer1Ctx, queryID, resultCh1)
@@ -785,7 +788,7 @@ func TestStreamResultsMultipleProducers(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -971,7 +974,7 @@ func TestStreamResultsReceiveExecutionError(t *testing.T) {
 			}()
 			var err error
 
-			assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+			assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 			go func() {
 				err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -1022,7 +1025,7 @@ func TestStreamErrorWithMultipleProducers(t *testing.T) {
 	}()
 	var err error
 
-	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, 350, nil, ""))
+	assert.Nil(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", controllers.QueryLimits{}))
 
 	go func() {
 		err = f.StreamResults(consumerCtx, queryID, resultCh)
@@ -1069,3 +1072,132 @@ func TestStreamErrorWithMultipleProducers(t *testing.T) {
 	assert.Equal(t, expected0, results[0].GetData().Batch)
 	assert.Equal(t, controllers.StatusToVizierStatus(errorStatus), results[1].GetStatus())
 }
+
+func TestListGetAndCancelQueries(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	otherQueryID := uuid.Must(uuid.NewV4())
+
+	f := controllers.NewQueryResultForwarderWithOptions(controllers.WithResultSinkTimeout(1 * time.Second))
+
+	expectedTables := make(map[string]string)
+	expectedTables["foo"] = "123"
+	expectedTables["bar"] = "456"
+
+	resultCh := make(chan *vizierpb.ExecuteScriptResponse)
+	consumerCtx, cancelConsumer := context.WithCancel(context.Background())
+	defer cancelConsumer()
+	producerCtx, cancelProducer := context.WithCancel(context.Background())
+	defer cancelProducer()
+
+	// Each result table is streamed back by a different agent, and a third agent only sends data to the others.
+	agentTables := map[uuid.UUID][]string{
+		uuid.Must(uuid.NewV4()): {"foo"},
+		uuid.Must(uuid.NewV4()): {"bar"},
+		uuid.Must(uuid.NewV4()): {},
+	}
+	require.NoError(t, f.RegisterQuery(queryID, expectedTables, agentTables, 350, nil, "px/http_data", "user:test@test.com", controllers.QueryLimits{}))
+	require.NoError(t, f.RegisterQuery(otherQueryID, expectedTables, nil, 350, nil, "px/cluster", "service:cron_script_runner", controllers.QueryLimits{}))
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- f.StreamResults(consumerCtx, queryID, resultCh)
+	}()
+
+	info, err := f.GetQuery(queryID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.ActiveAgents)
+
+	_, in0 := makeRowBatchResult(t, queryID, "foo", "123" /*eos*/, true)
+	require.NoError(t, f.ForwardQueryResult(producerCtx, makeInitiateConnectionRequest(queryID)))
+	require.NoError(t, f.ForwardQueryResult(producerCtx, in0))
+	select {
+	case <-resultCh:
+	case <-time.After(time.Second):
+		require.Fail(t, "Test timedout waiting for consumer to receive a result.")
+	}
+
+	queries := f.ListQueries()
+	require.Len(t, queries, 2)
+	principals := make(map[string]string)
+	for _, q := range queries {
+		principals[q.QueryID] = q.Principal
+	}
+	assert.Equal(t, map[string]string{
+		queryID.String():      "user:test@test.com",
+		otherQueryID.String(): "service:cron_script_runner",
+	}, principals)
+
+	info, err = f.GetQuery(queryID)
+	require.NoError(t, err)
+	assert.Equal(t, "px/http_data", info.QueryName)
+	assert.Equal(t, "user:test@test.com", info.Principal)
+	// The agent that streamed "foo" is done.
+	assert.Equal(t, int64(2), info.ActiveAgents)
+	assert.Equal(t, []string{"bar"}, info.PendingTables)
+	assert.Equal(t, in0.GetQueryResult().GetRowBatch().NumRows, info.RowsStreamed)
+	assert.Equal(t, int64(in0.GetQueryResult().GetRowBatch().Size()), info.BytesStreamed)
+	assert.NotZero(t, info.StartTimeNs)
+
+	cancelErr := fmt.Errorf("query cancelled")
+	require.NoError(t, f.CancelQuery(queryID, cancelErr))
+	select {
+	case err := <-errCh:
+		assert.Equal(t, cancelErr, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "Test timedout waiting for the query to be cancelled.")
+	}
+
+	require.Eventually(t, func() bool {
+		_, err := f.GetQuery(queryID)
+		return errors.Is(err, controllers.ErrQueryNotFound)
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, errors.Is(f.CancelQuery(queryID, cancelErr), controllers.ErrQueryNotFound))
+
+	queries = f.ListQueries()
+	require.Len(t, queries, 1)
+	assert.Equal(t, otherQueryID.String(), queries[0].QueryID)
+}
+
+func TestStreamResults_QueryTimeout(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	f := controllers.NewQueryResultForwarder()
+
+	expectedTables := map[string]string{"foo": "123"}
+	require.NoError(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "",
+		controllers.QueryLimits{Timeout: 100 * time.Millisecond}))
+
+	resultCh := make(chan *vizierpb.ExecuteScriptResponse, 10)
+	err := f.StreamResults(context.Background(), queryID, resultCh)
+	require.Error(t, err)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Contains(t, err.Error(), "query_timeout=100ms")
+}
+
+func TestStreamResults_MaxResultSize(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	f := controllers.NewQueryResultForwarder()
+
+	expectedTables := map[string]string{"foo": "123"}
+	_, in0 := makeRowBatchResult(t, queryID, "foo", "123" /*eos*/, false)
+	_, in1 := makeRowBatchResult(t, queryID, "foo", "123" /*eos*/, true)
+	// Allow the first batch, but not the second.
+	limits := controllers.QueryLimits{MaxResultSize: int64(in0.GetQueryResult().GetRowBatch().Size()) + 1}
+	require.NoError(t, f.RegisterQuery(queryID, expectedTables, nil, 350, nil, "", "", limits))
+
+	resultCh := make(chan *vizierpb.ExecuteScriptResponse, 10)
+	errCh := make(chan error)
+	go func() {
+		errCh <- f.StreamResults(context.Background(), queryID, resultCh)
+	}()
+
+	producerCtx := context.Background()
+	require.NoError(t, f.ForwardQueryResult(producerCtx, makeInitiateConnectionRequest(queryID)))
+	require.NoError(t, f.ForwardQueryResult(producerCtx, in0))
+	// The query may already be cancelled by the time the second batch is forwarded.
+	_ = f.ForwardQueryResult(producerCtx, in1)
+
+	err := <-errCh
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 1, len(resultCh))
+}
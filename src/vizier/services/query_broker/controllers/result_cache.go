@@ -0,0 +1,305 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/carnot/planner/distributedpb"
+)
+
+var queryResultCacheCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "query_result_cache_lookups",
+		Help: "The number of query result cache lookups, by result.",
+	},
+	[]string{"result"},
+)
+
+const (
+	// DefaultResultCacheMaxEntries is the default number of query results that the cache holds.
+	DefaultResultCacheMaxEntries = 128
+	// DefaultResultCacheMaxBytes is the default total size of the query results that the cache holds.
+	DefaultResultCacheMaxBytes = 64 * 1024 * 1024
+	// DefaultResultCacheTTL is the default time that a cached query result may be replayed for.
+	DefaultResultCacheTTL = 10 * time.Second
+	// DefaultResultCacheWindow is the default size of the time windows that cache keys are bucketed into.
+	DefaultResultCacheWindow = 10 * time.Second
+)
+
+type resultCacheEntry struct {
+	key       string
+	responses []*vizierpb.ExecuteScriptResponse
+	size      int
+	expiresAt time.Time
+}
+
+// ResultCache holds the responses of recently executed scripts, so that running the same script with
+// the same arguments again within the same time window replays the responses instead of querying
+// every agent again. Entries are evicted in least recently used order once the cache is full.
+type ResultCache struct {
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+	window     time.Duration
+	now        func() time.Time
+
+	mu sync.Mutex
+	// The front of the list holds the most recently used entry.
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int
+}
+
+// ResultCacheOption allows specifying options for new ResultCaches.
+type ResultCacheOption func(*ResultCache)
+
+// WithResultCacheMaxEntries sets the number of query results that the cache holds.
+func WithResultCacheMaxEntries(n int) ResultCacheOption {
+	return func(c *ResultCache) {
+		c.maxEntries = n
+	}
+}
+
+// WithResultCacheMaxBytes sets the total size of the query results that the cache holds.
+func WithResultCacheMaxBytes(n int) ResultCacheOption {
+	return func(c *ResultCache) {
+		c.maxBytes = n
+	}
+}
+
+// WithResultCacheTTL sets how long a cached query result may be replayed for.
+func WithResultCacheTTL(ttl time.Duration) ResultCacheOption {
+	return func(c *ResultCache) {
+		c.ttl = ttl
+	}
+}
+
+// WithResultCacheWindow sets the size of the time windows that cache keys are bucketed into.
+func WithResultCacheWindow(window time.Duration) ResultCacheOption {
+	return func(c *ResultCache) {
+		c.window = window
+	}
+}
+
+// WithResultCacheClock sets the func used to get the current time. Used for testing.
+func WithResultCacheClock(now func() time.Time) ResultCacheOption {
+	return func(c *ResultCache) {
+		c.now = now
+	}
+}
+
+// NewResultCache creates a new ResultCache.
+func NewResultCache(opts ...ResultCacheOption) *ResultCache {
+	c := &ResultCache{
+		maxEntries: DefaultResultCacheMaxEntries,
+		maxBytes:   DefaultResultCacheMaxBytes,
+		ttl:        DefaultResultCacheTTL,
+		window:     DefaultResultCacheWindow,
+		now:        time.Now,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// normalizeScript drops the parts of a script that don't change its results: blank lines,
+// trailing whitespace and comments other than query flags.
+func normalizeScript(queryStr string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(queryStr, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(line, plConfigPrefix)) {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Key returns the cache key for the given request, or false if the request's results should not be cached.
+// Requests only share results if the same redaction and access restrictions apply to them.
+func (c *ResultCache) Key(req *vizierpb.ExecuteScriptRequest, redactOptions *distributedpb.RedactionOptions,
+	restrictions *DataAccessRestrictions) (string, bool) {
+	// Mutations change the state of the cluster, and resumed queries are already running.
+	if req.Mutation || req.QueryID != "" {
+		return "", false
+	}
+	flags, err := ParseQueryFlags(req.QueryStr)
+	if err != nil || !flags.GetBool("result_cache") {
+		return "", false
+	}
+
+	h := sha256.New()
+	writeField := func(b []byte) {
+		h.Write([]byte(strconv.Itoa(len(b))))
+		h.Write([]byte(":"))
+		h.Write(b)
+	}
+	writeField([]byte(normalizeScript(req.QueryStr)))
+	for _, f := range req.ExecFuncs {
+		b, err := proto.Marshal(f)
+		if err != nil {
+			return "", false
+		}
+		writeField(b)
+	}
+	// Nil messages are written as empty fields, so that the key stays unambiguous.
+	var configs, redact []byte
+	if req.Configs != nil {
+		if configs, err = proto.Marshal(req.Configs); err != nil {
+			return "", false
+		}
+	}
+	if redactOptions != nil {
+		if redact, err = proto.Marshal(redactOptions); err != nil {
+			return "", false
+		}
+	}
+	writeField(configs)
+	writeField(redact)
+	if restrictions != nil {
+		writeField([]byte(strings.Join(restrictions.AllowedNamespaces, ",")))
+		writeField([]byte(strings.Join(restrictions.AllowedTables, ",")))
+	} else {
+		writeField(nil)
+		writeField(nil)
+	}
+	if c.window > 0 {
+		writeField([]byte(strconv.FormatInt(c.now().Truncate(c.window).UnixNano(), 10)))
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// Get returns the cached responses for the given key, or nil if there are none.
+func (c *ResultCache) Get(key string) []*vizierpb.ExecuteScriptResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		queryResultCacheCounter.With(prometheus.Labels{"result": "miss"}).Inc()
+		return nil
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		queryResultCacheCounter.With(prometheus.Labels{"result": "miss"}).Inc()
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	queryResultCacheCounter.With(prometheus.Labels{"result": "hit"}).Inc()
+	return entry.responses
+}
+
+// Put stores the responses for the given key, evicting older entries if the cache is full.
+func (c *ResultCache) Put(key string, responses []*vizierpb.ExecuteScriptResponse) {
+	size := 0
+	for _, resp := range responses {
+		size += resp.Size()
+	}
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &resultCacheEntry{
+		key:       key,
+		responses: responses,
+		size:      size,
+		expiresAt: c.now().Add(c.ttl),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += size
+
+	for c.lru.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove must be called while holding the mutex.
+func (c *ResultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*resultCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// resultCacheRecorder passes results on to the wrapped consumer and keeps a copy of them to
+// store in the cache once the query finishes. It stops recording once the results no longer fit.
+type resultCacheRecorder struct {
+	c         QueryResultConsumer
+	maxBytes  int
+	size      int
+	overflow  bool
+	responses []*vizierpb.ExecuteScriptResponse
+}
+
+func newResultCacheRecorder(c QueryResultConsumer, maxBytes int) *resultCacheRecorder {
+	return &resultCacheRecorder{
+		c:        c,
+		maxBytes: maxBytes,
+	}
+}
+
+func (r *resultCacheRecorder) Consume(result *vizierpb.ExecuteScriptResponse) error {
+	if !r.overflow {
+		r.size += result.Size()
+		if r.size > r.maxBytes {
+			r.overflow = true
+			r.responses = nil
+		} else {
+			// The consumer may modify the result, for example to encrypt it.
+			r.responses = append(r.responses, proto.Clone(result).(*vizierpb.ExecuteScriptResponse))
+		}
+	}
+	return r.c.Consume(result)
+}
+
+// replayCachedResults sends the cached responses to the consumer as the results of a new query.
+func replayCachedResults(responses []*vizierpb.ExecuteScriptResponse, queryID string, consumer QueryResultConsumer) error {
+	for _, cached := range responses {
+		resp := proto.Clone(cached).(*vizierpb.ExecuteScriptResponse)
+		resp.QueryID = queryID
+		if err := consumer.Consume(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controllers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/carnot/planner/distributedpb"
+	"px.dev/pixie/src/vizier/services/query_broker/controllers"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestResultCache_Key(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := controllers.NewResultCache(
+		controllers.WithResultCacheWindow(10*time.Second),
+		controllers.WithResultCacheClock(clock.now),
+	)
+	req := &vizierpb.ExecuteScriptRequest{
+		QueryStr: "import px\n\ndf = px.DataFrame('http_events')\npx.display(df)\n",
+		ExecFuncs: []*vizierpb.ExecuteScriptRequest_FuncToExecute{
+			{
+				FuncName: "main",
+				ArgValues: []*vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue{
+					{Name: "start_time", Value: "-5m"},
+				},
+			},
+		},
+	}
+
+	key, ok := c.Key(req, nil, nil)
+	require.True(t, ok)
+
+	// Whitespace and comments don't change the key.
+	normalized := &vizierpb.ExecuteScriptRequest{
+		QueryStr:  "# A comment.\r\nimport px  \r\ndf = px.DataFrame('http_events')\r\n\r\npx.display(df)",
+		ExecFuncs: req.ExecFuncs,
+	}
+	k, ok := c.Key(normalized, nil, nil)
+	require.True(t, ok)
+	assert.Equal(t, key, k)
+
+	// Neither does the time within the same window.
+	clock.t = clock.t.Add(5 * time.Second)
+	k, _ = c.Key(req, nil, nil)
+	assert.Equal(t, key, k)
+
+	clock.t = clock.t.Add(5 * time.Second)
+	k, _ = c.Key(req, nil, nil)
+	assert.NotEqual(t, key, k)
+	key = k
+
+	otherArgs := &vizierpb.ExecuteScriptRequest{
+		QueryStr: req.QueryStr,
+		ExecFuncs: []*vizierpb.ExecuteScriptRequest_FuncToExecute{
+			{
+				FuncName: "main",
+				ArgValues: []*vizierpb.ExecuteScriptRequest_FuncToExecute_ArgValue{
+					{Name: "start_time", Value: "-10m"},
+				},
+			},
+		},
+	}
+	k, _ = c.Key(otherArgs, nil, nil)
+	assert.NotEqual(t, key, k)
+
+	k, _ = c.Key(req, &distributedpb.RedactionOptions{UseFullRedaction: true}, nil)
+	assert.NotEqual(t, key, k)
+
+	k, _ = c.Key(req, nil, &controllers.DataAccessRestrictions{AllowedNamespaces: []string{"team-a"}})
+	assert.NotEqual(t, key, k)
+
+	// Flags are part of the script, so they change the key.
+	withFlag := &vizierpb.ExecuteScriptRequest{
+		QueryStr:  "#px:set max_output_rows_per_table=10\n" + req.QueryStr,
+		ExecFuncs: req.ExecFuncs,
+	}
+	k, ok = c.Key(withFlag, nil, nil)
+	require.True(t, ok)
+	assert.NotEqual(t, key, k)
+}
+
+func TestResultCache_KeyNotCacheable(t *testing.T) {
+	c := controllers.NewResultCache()
+
+	tests := []struct {
+		name string
+		req  *vizierpb.ExecuteScriptRequest
+	}{
+		{
+			name: "opt out",
+			req:  &vizierpb.ExecuteScriptRequest{QueryStr: "#px:set result_cache=false\nimport px\n"},
+		},
+		{
+			name: "mutation",
+			req:  &vizierpb.ExecuteScriptRequest{QueryStr: "import px\n", Mutation: true},
+		},
+		{
+			name: "resumed query",
+			req:  &vizierpb.ExecuteScriptRequest{QueryStr: "import px\n", QueryID: "00000000-0000-0000-0000-000000000001"},
+		},
+		{
+			name: "invalid flags",
+			req:  &vizierpb.ExecuteScriptRequest{QueryStr: "#px:set result_cache=maybe\nimport px\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, ok := c.Key(test.req, nil, nil)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func cacheTestResponses(queryID string, numRows int64) []*vizierpb.ExecuteScriptResponse {
+	return []*vizierpb.ExecuteScriptResponse{
+		{
+			QueryID: queryID,
+			Result: &vizierpb.ExecuteScriptResponse_Data{
+				Data: &vizierpb.QueryData{
+					Batch: &vizierpb.RowBatchData{
+						TableID: "table",
+						NumRows: numRows,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestResultCache_TTL(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := controllers.NewResultCache(
+		controllers.WithResultCacheTTL(5*time.Second),
+		controllers.WithResultCacheClock(clock.now),
+	)
+
+	resps := cacheTestResponses("a", 1)
+	c.Put("key", resps)
+	assert.Equal(t, resps, c.Get("key"))
+	assert.Nil(t, c.Get("other"))
+
+	clock.t = clock.t.Add(5 * time.Second)
+	assert.Nil(t, c.Get("key"))
+}
+
+func TestResultCache_Eviction(t *testing.T) {
+	c := controllers.NewResultCache(controllers.WithResultCacheMaxEntries(2))
+
+	c.Put("a", cacheTestResponses("a", 1))
+	c.Put("b", cacheTestResponses("b", 1))
+	// Using a makes b the least recently used entry.
+	require.NotNil(t, c.Get("a"))
+	c.Put("c", cacheTestResponses("c", 1))
+
+	assert.NotNil(t, c.Get("a"))
+	assert.Nil(t, c.Get("b"))
+	assert.NotNil(t, c.Get("c"))
+}
+
+func TestResultCache_MaxBytes(t *testing.T) {
+	size := cacheTestResponses("a", 1)[0].Size()
+	c := controllers.NewResultCache(controllers.WithResultCacheMaxBytes(2*size + 1))
+
+	c.Put("a", cacheTestResponses("a", 1))
+	c.Put("b", cacheTestResponses("b", 1))
+	c.Put("c", cacheTestResponses("c", 1))
+	assert.Nil(t, c.Get("a"))
+	assert.NotNil(t, c.Get("b"))
+	assert.NotNil(t, c.Get("c"))
+
+	// Results that are larger than the whole cache are not stored.
+	c.Put("big", append(cacheTestResponses("big", 1), cacheTestResponses("big", 2)...))
+	assert.Nil(t, c.Get("big"))
+	assert.NotNil(t, c.Get("b"))
+}
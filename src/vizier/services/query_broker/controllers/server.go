@@ -20,6 +20,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -41,6 +42,7 @@ import (
 	"px.dev/pixie/src/carnot/planner/distributedpb"
 	"px.dev/pixie/src/carnot/planner/plannerpb"
 	"px.dev/pixie/src/carnot/udfspb"
+	"px.dev/pixie/src/shared/cvmsgspb"
 	serviceUtils "px.dev/pixie/src/shared/services/utils"
 	"px.dev/pixie/src/utils"
 	funcs "px.dev/pixie/src/vizier/funcs/go"
@@ -88,6 +90,15 @@ type Server struct {
 	planner Planner
 
 	queryExecFactory QueryExecutorFactory
+
+	// If nil, every query is admitted right away.
+	admission *AdmissionController
+	// If nil, query results are not cached.
+	resultCache *ResultCache
+	// If nil, queries are not audited.
+	auditor *QueryAuditor
+	// If nil, queries can't select agents by the labels of their nodes.
+	nodeLabeler NodeLabeler
 }
 
 // QueryExecutorFactory creates a new QueryExecutor.
@@ -137,6 +148,26 @@ func NewServerWithForwarderAndPlanner(env querybrokerenv.QueryBrokerEnv,
 	return s, nil
 }
 
+// SetAdmissionController sets the AdmissionController that limits how many queries run at once.
+func (s *Server) SetAdmissionController(ac *AdmissionController) {
+	s.admission = ac
+}
+
+// SetResultCache sets the ResultCache used to replay the results of recently executed scripts.
+func (s *Server) SetResultCache(c *ResultCache) {
+	s.resultCache = c
+}
+
+// SetQueryAuditor sets the QueryAuditor that records who ran which script.
+func (s *Server) SetQueryAuditor(a *QueryAuditor) {
+	s.auditor = a
+}
+
+// SetNodeLabeler sets the NodeLabeler that queries use to select agents by the labels of their nodes.
+func (s *Server) SetNodeLabeler(nl NodeLabeler) {
+	s.nodeLabeler = nl
+}
+
 // Close frees the planner memory in the server.
 func (s *Server) Close() {
 	s.healthcheckQuitOnce.Do(func() { close(s.healthcheckQuitCh) })
@@ -180,6 +211,12 @@ func (s *Server) CheckHealth(ctx context.Context) error {
 		receivedRows:       0,
 	}
 
+	release, err := s.admission.Admit(ctx, "healthcheck", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	queryExec := s.queryExecFactory(s, NewMutationExecutor)
 	if err := queryExec.Run(ctx, req, consumer); err != nil {
 		return err
@@ -320,13 +357,85 @@ func (s *Server) ExecuteScript(req *vizierpb.ExecuteScriptRequest, srv vizierpb.
 		}
 		consumer = c
 	}
+
+	// Resumed queries are already running, so they were admitted when they first started.
+	if req.QueryID == "" {
+		release, err := s.admission.Admit(ctx, principalFromContext(ctx), isInternalPrincipal(ctx))
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	var cacheKey string
+	var recorder *resultCacheRecorder
+	if s.resultCache != nil {
+		key, ok := s.resultCacheKey(ctx, req)
+		if ok {
+			if cached := s.resultCache.Get(key); cached != nil {
+				return s.replayFromCache(ctx, req, cached, consumer)
+			}
+			cacheKey = key
+			recorder = newResultCacheRecorder(consumer, s.resultCache.maxBytes)
+			consumer = recorder
+		}
+	}
+
 	queryExec := s.queryExecFactory(s, NewMutationExecutor)
 	if err := queryExec.Run(ctx, req, consumer); err != nil {
 		return err
 	}
 	log.Infof("Launched query: %s", queryExec.QueryID())
 
-	return queryExec.Wait()
+	if err := queryExec.Wait(); err != nil {
+		return err
+	}
+	if recorder != nil && !recorder.overflow {
+		s.resultCache.Put(cacheKey, recorder.responses)
+	}
+	return nil
+}
+
+// resultCacheKey returns the cache key of the request. The key covers the redaction and data access
+// restrictions that currently apply to the principal of the request, so cached results are only replayed
+// to requests that the same restrictions apply to.
+func (s *Server) resultCacheKey(ctx context.Context, req *vizierpb.ExecuteScriptRequest) (string, bool) {
+	redactOptions, err := s.dataPrivacy.RedactionOptions(ctx)
+	if err != nil {
+		return "", false
+	}
+	restrictions, err := s.dataPrivacy.AccessRestrictions(ctx)
+	if err != nil {
+		return "", false
+	}
+	return s.resultCache.Key(req, redactOptions, restrictions)
+}
+
+// replayFromCache sends cached results to the consumer as the results of a new query. Like a query
+// that runs, the replay is audited.
+func (s *Server) replayFromCache(ctx context.Context, req *vizierpb.ExecuteScriptRequest, cached []*vizierpb.ExecuteScriptResponse,
+	consumer QueryResultConsumer) error {
+	queryID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	queryName := req.QueryName
+	if queryName == "" {
+		queryName = "unnamed"
+	}
+	var auditEvent *cvmsgspb.QueryAuditEvent
+	if s.auditor != nil {
+		auditEvent = newQueryAuditEvent(ctx, queryName, principalFromContext(ctx), req, s.dataPrivacy)
+		auditEvent.QueryID = utils.ProtoFromUUID(queryID)
+		auditEvent.ResultCacheHit = true
+	}
+	log.WithField("query_id", queryID).Infof("Replaying cached results for query: %s", req.QueryName)
+	err = replayCachedResults(cached, queryID.String(), consumer)
+	if auditEvent != nil {
+		finishQueryAuditEvent(auditEvent, err)
+		s.auditor.Record(auditEvent)
+	}
+	return err
 }
 
 // GenerateOTelScript generates an OTel script for the given DataFrame script.
@@ -353,6 +462,71 @@ func (s *Server) GenerateOTelScript(ctx context.Context, req *vizierpb.GenerateO
 	}, nil
 }
 
+func parseQueryID(queryID string) (uuid.UUID, error) {
+	id, err := uuid.FromString(queryID)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid query ID %q", queryID)
+	}
+	return id, nil
+}
+
+// ListQueries lists the queries that are currently running.
+func (s *Server) ListQueries(ctx context.Context, req *vizierpb.ListQueriesRequest) (*vizierpb.ListQueriesResponse, error) {
+	return &vizierpb.ListQueriesResponse{
+		Queries: s.resultForwarder.ListQueries(),
+	}, nil
+}
+
+// GetQuery returns the state of a running query.
+func (s *Server) GetQuery(ctx context.Context, req *vizierpb.GetQueryRequest) (*vizierpb.GetQueryResponse, error) {
+	queryID, err := parseQueryID(req.QueryID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.resultForwarder.GetQuery(queryID)
+	if errors.Is(err, ErrQueryNotFound) {
+		return nil, status.Errorf(codes.NotFound, "query %s is not running", queryID.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &vizierpb.GetQueryResponse{Query: info}, nil
+}
+
+// CancelQuery cancels a running query.
+func (s *Server) CancelQuery(ctx context.Context, req *vizierpb.CancelQueryRequest) (*vizierpb.CancelQueryResponse, error) {
+	queryID, err := parseQueryID(req.QueryID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.resultForwarder.GetQuery(queryID)
+	if errors.Is(err, ErrQueryNotFound) {
+		return nil, status.Errorf(codes.NotFound, "query %s is not running", queryID.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Only the principal that started the query, or a Pixie service, may cancel it.
+	principal := principalFromContext(ctx)
+	if !isInternalPrincipal(ctx) && principal != info.Principal {
+		return nil, status.Errorf(codes.PermissionDenied, "query %s was not started by %s", queryID.String(), principal)
+	}
+
+	cancelErr := status.Errorf(codes.Canceled, "query %s was cancelled", queryID.String())
+	if principal != "" {
+		cancelErr = status.Errorf(codes.Canceled, "query %s was cancelled by %s", queryID.String(), principal)
+	}
+	err = s.resultForwarder.CancelQuery(queryID, cancelErr)
+	if errors.Is(err, ErrQueryNotFound) {
+		return nil, status.Errorf(codes.NotFound, "query %s is not running", queryID.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("query_id", queryID).WithField("principal", principal).Info("Cancelled query")
+	return &vizierpb.CancelQueryResponse{}, nil
+}
+
 // TransferResultChunk implements the API that allows the query broker receive streamed results
 // from Carnot instances.
 func (s *Server) TransferResultChunk(srv carnotpb.ResultSinkService_TransferResultChunkServer) error {
@@ -29,6 +29,8 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
@@ -37,6 +39,8 @@ import (
 	"px.dev/pixie/src/carnot/planner/distributedpb"
 	"px.dev/pixie/src/carnot/queryresultspb"
 	"px.dev/pixie/src/shared/services/authcontext"
+	"px.dev/pixie/src/shared/services/jwtpb"
+	srvutils "px.dev/pixie/src/shared/services/utils"
 	"px.dev/pixie/src/table_store/schemapb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/utils/testingutils"
@@ -431,6 +435,171 @@ func TestExecuteScript(t *testing.T) {
 	}
 }
 
+func TestExecuteScript_ResultCache(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	qe := &fakeQueryExecutor{
+		ResultsToSend: buildExecuteScriptSuccessResponses(queryID),
+		queryID:       queryID,
+	}
+	numRuns := 0
+	queryExecFactory := func(*controllers.Server, controllers.MutationExecFactory) controllers.QueryExecutor {
+		numRuns++
+		return qe
+	}
+
+	dp := &fakeDataPrivacy{}
+	s, err := controllers.NewServerWithForwarderAndPlanner(nil, nil, dp, nil, nil, nil, nil, nil, queryExecFactory)
+	require.NoError(t, err)
+	s.SetResultCache(controllers.NewResultCache())
+	sink := &fakeAuditSink{}
+	s.SetQueryAuditor(controllers.NewQueryAuditor(sink))
+	ac := controllers.NewAdmissionController(controllers.WithMaxQueriesPerPrincipal(1), controllers.WithMaxQueuedQueries(0))
+	s.SetAdmissionController(ac)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := mock_vizierpb.NewMockVizierService_ExecuteScriptServer(ctrl)
+	ctx := authcontext.NewContext(context.Background(), authcontext.New())
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+
+	var resps []*vizierpb.ExecuteScriptResponse
+	srv.EXPECT().
+		Send(gomock.Any()).
+		DoAndReturn(func(arg *vizierpb.ExecuteScriptResponse) error {
+			resps = append(resps, arg)
+			return nil
+		}).
+		AnyTimes()
+
+	req := &vizierpb.ExecuteScriptRequest{QueryStr: "import px"}
+	require.NoError(t, s.ExecuteScript(req, srv))
+	require.NoError(t, s.ExecuteScript(req, srv))
+	assert.Equal(t, 1, numRuns)
+
+	// The replayed results belong to a new query.
+	expected := buildExecuteScriptSuccessResponses(queryID)
+	require.Equal(t, 2*len(expected), len(resps))
+	replayedID := resps[len(expected)].QueryID
+	assert.NotEqual(t, queryID.String(), replayedID)
+	for i, expectedResp := range expected {
+		assert.Equal(t, expectedResp, resps[i])
+		expectedResp.QueryID = replayedID
+		assert.Equal(t, expectedResp, resps[len(expected)+i])
+	}
+
+	// Cache hits are audited.
+	require.Len(t, sink.events, 1)
+	assert.True(t, sink.events[0].ResultCacheHit)
+	assert.Equal(t, replayedID, utils.UUIDFromProtoOrNil(sink.events[0].QueryID).String())
+	assert.Equal(t, "OK", sink.events[0].Status)
+
+	// Cache hits count against the admission limits.
+	release, err := ac.Admit(ctx, "", false)
+	require.NoError(t, err)
+	err = s.ExecuteScript(req, srv)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Len(t, sink.events, 1)
+	release()
+
+	// Queries that opt out of the cache always run.
+	optOut := &vizierpb.ExecuteScriptRequest{QueryStr: "#px:set result_cache=false\nimport px"}
+	require.NoError(t, s.ExecuteScript(optOut, srv))
+	require.NoError(t, s.ExecuteScript(optOut, srv))
+	assert.Equal(t, 3, numRuns)
+}
+
+func clusterClaimsForUser(email string) *jwtpb.JWTClaims {
+	claims := srvutils.GenerateJWTForCluster("vizier_cluster", "vizier")
+	if email != "" {
+		claims.GetClusterClaims().ForwardedUser = &jwtpb.UserJWTClaims{
+			UserID: "user-" + email,
+			Email:  email,
+		}
+	}
+	return claims
+}
+
+func TestExecuteScript_AdmissionPrincipal(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	queryExecFactory := func(*controllers.Server, controllers.MutationExecFactory) controllers.QueryExecutor {
+		return &fakeQueryExecutor{queryID: queryID}
+	}
+	s, err := controllers.NewServerWithForwarderAndPlanner(nil, nil, &fakeDataPrivacy{}, nil, nil, nil, nil, nil, queryExecFactory)
+	require.NoError(t, err)
+	ac := controllers.NewAdmissionController(controllers.WithMaxQueriesPerPrincipal(1), controllers.WithMaxQueuedQueries(0))
+	s.SetAdmissionController(ac)
+
+	executeAs := func(claims *jwtpb.JWTClaims) error {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		srv := mock_vizierpb.NewMockVizierService_ExecuteScriptServer(ctrl)
+		srv.EXPECT().Context().Return(contextWithClaims(claims)).AnyTimes()
+		return s.ExecuteScript(&vizierpb.ExecuteScriptRequest{QueryStr: "import px"}, srv)
+	}
+
+	// Queries proxied through the cloud count against the user that the cluster token was issued for.
+	release, err := ac.Admit(context.Background(), "user:alice@test.com", false)
+	require.NoError(t, err)
+	err = executeAs(clusterClaimsForUser("alice@test.com"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	err = executeAs(testingutils.GenerateTestClaimsWithEmail(t, "alice@test.com"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NoError(t, executeAs(clusterClaimsForUser("bob@test.com")))
+	require.NoError(t, executeAs(clusterClaimsForUser("")))
+	release()
+
+	// Cluster tokens that don't name a user all share the cluster's limit.
+	release, err = ac.Admit(context.Background(), "cluster:vizier_cluster", false)
+	require.NoError(t, err)
+	err = executeAs(clusterClaimsForUser(""))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NoError(t, executeAs(clusterClaimsForUser("alice@test.com")))
+	release()
+}
+
+func TestCancelQuery(t *testing.T) {
+	queryID := uuid.Must(uuid.NewV4())
+	rf := controllers.NewQueryResultForwarder()
+	require.NoError(t, rf.RegisterQuery(queryID, map[string]string{"foo": "123"}, nil, 0, nil, "px/http_data",
+		"user:owner@test.com", controllers.QueryLimits{}))
+
+	s, err := controllers.NewServerWithForwarderAndPlanner(nil, nil, &fakeDataPrivacy{}, rf, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	req := &vizierpb.CancelQueryRequest{QueryID: queryID.String()}
+
+	// Other users may not cancel the query.
+	_, err = s.CancelQuery(contextWithClaims(testingutils.GenerateTestClaimsWithEmail(t, "other@test.com")), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.GetQuery(context.Background(), &vizierpb.GetQueryRequest{QueryID: queryID.String()})
+	require.NoError(t, err)
+
+	// The owner may cancel the query.
+	_, err = s.CancelQuery(contextWithClaims(testingutils.GenerateTestClaimsWithEmail(t, "owner@test.com")), req)
+	require.NoError(t, err)
+
+	// The owner may also cancel queries through the cloud proxy.
+	proxiedQueryID := uuid.Must(uuid.NewV4())
+	require.NoError(t, rf.RegisterQuery(proxiedQueryID, map[string]string{"foo": "123"}, nil, 0, nil, "px/http_data",
+		"user:owner@test.com", controllers.QueryLimits{}))
+	proxiedReq := &vizierpb.CancelQueryRequest{QueryID: proxiedQueryID.String()}
+	_, err = s.CancelQuery(contextWithClaims(clusterClaimsForUser("")), proxiedReq)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.CancelQuery(contextWithClaims(clusterClaimsForUser("owner@test.com")), proxiedReq)
+	require.NoError(t, err)
+
+	// Pixie services may cancel any query.
+	otherQueryID := uuid.Must(uuid.NewV4())
+	require.NoError(t, rf.RegisterQuery(otherQueryID, map[string]string{"foo": "123"}, nil, 0, nil, "px/http_data",
+		"user:owner@test.com", controllers.QueryLimits{}))
+	_, err = s.CancelQuery(contextWithClaims(testingutils.GenerateTestServiceClaims(t, "cron_script_runner")),
+		&vizierpb.CancelQueryRequest{QueryID: otherQueryID.String()})
+	require.NoError(t, err)
+
+	_, err = s.CancelQuery(context.Background(), &vizierpb.CancelQueryRequest{QueryID: uuid.Must(uuid.NewV4()).String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func TestTransferResultChunk_AgentStreamComplete(t *testing.T) {
 	nc, cleanup := testingutils.MustStartTestNATS(t)
 	defer cleanup()
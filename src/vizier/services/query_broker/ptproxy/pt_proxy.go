@@ -183,19 +183,35 @@ func (s *PassThroughProxy) runRequest(reqState *RequestState, msg *cvmsgspb.C2VA
 		stream = NewHealthCheckStream(s.vzClient)
 	case *cvmsgspb.C2VAPIStreamRequest_GenerateOTelScriptReq:
 		resp, err := s.vzClient.GenerateOTelScript(reqState.ctx, msg.GetGenerateOTelScriptReq())
-		if err != nil {
-			v2cResp := formatStatusMessage(reqState.requestID, status.Code(err), err.Error())
-			s.sendMessage(reqState.requestID, v2cResp)
-			return
-		}
-		// Wrap message in V2CAPIStreamResponse.
-		s.sendMessage(reqState.requestID, &cvmsgspb.V2CAPIStreamResponse{
-			RequestID: reqState.requestID,
+		s.sendUnaryResponse(reqState, err, &cvmsgspb.V2CAPIStreamResponse{
 			Msg: &cvmsgspb.V2CAPIStreamResponse_GenerateOTelScriptResp{
 				GenerateOTelScriptResp: resp,
 			},
 		})
-		s.sendMessage(reqState.requestID, formatStatusMessage(reqState.requestID, codes.OK, ""))
+		return
+	case *cvmsgspb.C2VAPIStreamRequest_ListQueriesReq:
+		resp, err := s.vzClient.ListQueries(reqState.ctx, msg.GetListQueriesReq())
+		s.sendUnaryResponse(reqState, err, &cvmsgspb.V2CAPIStreamResponse{
+			Msg: &cvmsgspb.V2CAPIStreamResponse_ListQueriesResp{
+				ListQueriesResp: resp,
+			},
+		})
+		return
+	case *cvmsgspb.C2VAPIStreamRequest_GetQueryReq:
+		resp, err := s.vzClient.GetQuery(reqState.ctx, msg.GetGetQueryReq())
+		s.sendUnaryResponse(reqState, err, &cvmsgspb.V2CAPIStreamResponse{
+			Msg: &cvmsgspb.V2CAPIStreamResponse_GetQueryResp{
+				GetQueryResp: resp,
+			},
+		})
+		return
+	case *cvmsgspb.C2VAPIStreamRequest_CancelQueryReq:
+		resp, err := s.vzClient.CancelQuery(reqState.ctx, msg.GetCancelQueryReq())
+		s.sendUnaryResponse(reqState, err, &cvmsgspb.V2CAPIStreamResponse{
+			Msg: &cvmsgspb.V2CAPIStreamResponse_CancelQueryResp{
+				CancelQueryResp: resp,
+			},
+		})
 		return
 	default:
 		s.sendMessage(reqState.requestID, formatStatusMessage(reqState.requestID, codes.InvalidArgument, fmt.Sprintf("Unknown request type %s", reflect.TypeOf(msg.Msg))))
@@ -233,6 +249,19 @@ func (s *PassThroughProxy) runRequest(reqState *RequestState, msg *cvmsgspb.C2VA
 	}
 }
 
+// sendUnaryResponse sends the response of a unary request followed by an OK status, or the
+// error status if the request failed.
+func (s *PassThroughProxy) sendUnaryResponse(reqState *RequestState, err error, resp *cvmsgspb.V2CAPIStreamResponse) {
+	if err != nil {
+		v2cResp := formatStatusMessage(reqState.requestID, status.Code(err), err.Error())
+		s.sendMessage(reqState.requestID, v2cResp)
+		return
+	}
+	resp.RequestID = reqState.requestID
+	s.sendMessage(reqState.requestID, resp)
+	s.sendMessage(reqState.requestID, formatStatusMessage(reqState.requestID, codes.OK, ""))
+}
+
 func formatStatusMessage(reqID string, code codes.Code, message string) *cvmsgspb.V2CAPIStreamResponse {
 	return &cvmsgspb.V2CAPIStreamResponse{
 		RequestID: reqID,
@@ -34,6 +34,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
@@ -109,6 +110,33 @@ func (m *MockVzServer) GenerateOTelScript(ctx context.Context, req *vizierpb.Gen
 	}, nil
 }
 
+func (m *MockVzServer) ListQueries(ctx context.Context, req *vizierpb.ListQueriesRequest) (*vizierpb.ListQueriesResponse, error) {
+	return &vizierpb.ListQueriesResponse{
+		Queries: []*vizierpb.QueryInfo{
+			{
+				QueryID:   "1",
+				QueryName: "px/cluster",
+			},
+		},
+	}, nil
+}
+
+func (m *MockVzServer) GetQuery(ctx context.Context, req *vizierpb.GetQueryRequest) (*vizierpb.GetQueryResponse, error) {
+	return &vizierpb.GetQueryResponse{
+		Query: &vizierpb.QueryInfo{
+			QueryID:   req.QueryID,
+			QueryName: "px/cluster",
+		},
+	}, nil
+}
+
+func (m *MockVzServer) CancelQuery(ctx context.Context, req *vizierpb.CancelQueryRequest) (*vizierpb.CancelQueryResponse, error) {
+	if req.QueryID != "1" {
+		return nil, status.Error(codes.NotFound, "query is not running")
+	}
+	return &vizierpb.CancelQueryResponse{}, nil
+}
+
 type testState struct {
 	t        *testing.T
 	lis      *bufconn.Listener
@@ -338,6 +366,119 @@ func TestPassThroughProxy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "list queries",
+			requestID: "1",
+			request: &cvmsgspb.C2VAPIStreamRequest{
+				Msg: &cvmsgspb.C2VAPIStreamRequest_ListQueriesReq{
+					ListQueriesReq: &vizierpb.ListQueriesRequest{},
+				},
+			},
+			expectedResps: []*cvmsgspb.V2CAPIStreamResponse{
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_ListQueriesResp{
+						ListQueriesResp: &vizierpb.ListQueriesResponse{
+							Queries: []*vizierpb.QueryInfo{
+								{
+									QueryID:   "1",
+									QueryName: "px/cluster",
+								},
+							},
+						},
+					},
+				},
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_Status{
+						Status: &vizierpb.Status{
+							Code: int32(codes.OK),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "get query",
+			requestID: "1",
+			request: &cvmsgspb.C2VAPIStreamRequest{
+				Msg: &cvmsgspb.C2VAPIStreamRequest_GetQueryReq{
+					GetQueryReq: &vizierpb.GetQueryRequest{
+						QueryID: "2",
+					},
+				},
+			},
+			expectedResps: []*cvmsgspb.V2CAPIStreamResponse{
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_GetQueryResp{
+						GetQueryResp: &vizierpb.GetQueryResponse{
+							Query: &vizierpb.QueryInfo{
+								QueryID:   "2",
+								QueryName: "px/cluster",
+							},
+						},
+					},
+				},
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_Status{
+						Status: &vizierpb.Status{
+							Code: int32(codes.OK),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "cancel query",
+			requestID: "1",
+			request: &cvmsgspb.C2VAPIStreamRequest{
+				Msg: &cvmsgspb.C2VAPIStreamRequest_CancelQueryReq{
+					CancelQueryReq: &vizierpb.CancelQueryRequest{
+						QueryID: "1",
+					},
+				},
+			},
+			expectedResps: []*cvmsgspb.V2CAPIStreamResponse{
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_CancelQueryResp{
+						CancelQueryResp: &vizierpb.CancelQueryResponse{},
+					},
+				},
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_Status{
+						Status: &vizierpb.Status{
+							Code: int32(codes.OK),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "cancel query: not found",
+			requestID: "1",
+			request: &cvmsgspb.C2VAPIStreamRequest{
+				Msg: &cvmsgspb.C2VAPIStreamRequest_CancelQueryReq{
+					CancelQueryReq: &vizierpb.CancelQueryRequest{
+						QueryID: "2",
+					},
+				},
+			},
+			expectedResps: []*cvmsgspb.V2CAPIStreamResponse{
+				{
+					RequestID: "1",
+					Msg: &cvmsgspb.V2CAPIStreamResponse_Status{
+						Status: &vizierpb.Status{
+							Code:    int32(codes.NotFound),
+							Message: "rpc error: code = NotFound desc = query is not running",
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "unknown message type",
 			requestID: "1",
@@ -32,6 +32,7 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	"k8s.io/client-go/rest"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/carnot/carnotpb"
@@ -40,6 +41,7 @@ import (
 	"px.dev/pixie/src/shared/services/httpmiddleware"
 	"px.dev/pixie/src/shared/services/metrics"
 	"px.dev/pixie/src/shared/services/server"
+	"px.dev/pixie/src/utils/shared/k8s"
 	"px.dev/pixie/src/vizier/services/metadata/metadatapb"
 	"px.dev/pixie/src/vizier/services/query_broker/controllers"
 	"px.dev/pixie/src/vizier/services/query_broker/ptproxy"
@@ -59,7 +61,27 @@ func init() {
 	pflag.String("mds_service", "vizier-metadata-svc", "The metadata service name")
 	pflag.String("mds_port", "50400", "The querybroker service port")
 	pflag.String("pod_namespace", "pl", "The namespace this pod runs in.")
-	pflag.StringArray("cron_script_sources", scriptrunner.DefaultSources, "Where to find cron scripts (cloud, configmaps)")
+	pflag.StringArray("cron_script_sources", scriptrunner.DefaultSources, "Where to find cron scripts (cloud, configmaps, directory, git)")
+	pflag.String("cron_script_dir", "/etc/pixie/cron_scripts", "The directory that the directory source reads cron scripts from")
+	pflag.String("cron_script_git_url", "", "The URL of the git repository that the git source reads cron scripts from")
+	pflag.String("cron_script_git_ref", "main", "The branch or ref of the git repository to read cron scripts from")
+	pflag.String("cron_script_git_path", "", "The directory of the git repository that contains the cron scripts")
+	pflag.Duration("cron_script_git_poll_interval", time.Minute, "How often to check the git repository for cron script changes")
+	pflag.Int("cron_script_max_concurrent_runs", scriptrunner.DefaultMaxConcurrentRuns, "The maximum number of cron script runs that execute at the same time")
+	pflag.Int("max_inflight_queries", 0, "The maximum number of queries that run at the same time (0 for no limit)")
+	pflag.Int("max_inflight_queries_per_principal", 0, "The maximum number of queries that a single user, API key or service runs at the same time (0 for no limit)")
+	pflag.Int("max_queued_queries", controllers.DefaultMaxQueuedQueries, "The maximum number of queries that wait for a slot before new queries are rejected")
+	pflag.Duration("query_queue_timeout", controllers.DefaultAdmissionQueueTimeout, "How long a query waits for a slot before it is rejected")
+	pflag.Int("priority_query_headroom", controllers.DefaultPriorityQueryHeadroom, "The number of healthcheck and cron script queries that may run beyond max_inflight_queries")
+	pflag.Bool("query_result_cache", false, "Whether to replay the results of recently executed scripts instead of running them again")
+	pflag.Int("query_result_cache_max_entries", controllers.DefaultResultCacheMaxEntries, "The maximum number of query results to cache")
+	pflag.Int("query_result_cache_max_bytes", controllers.DefaultResultCacheMaxBytes, "The maximum total size of the cached query results")
+	pflag.Duration("query_result_cache_ttl", controllers.DefaultResultCacheTTL, "How long a cached query result may be replayed for")
+	pflag.Duration("query_result_cache_window", controllers.DefaultResultCacheWindow, "The size of the time windows that cached query results are bucketed into")
+	pflag.String("query_audit_log_path", "", "The file to write query audit events to (empty to disable)")
+	pflag.Int64("query_audit_log_max_size_mb", 100, "The size at which the query audit log file is rotated")
+	pflag.Int("query_audit_log_max_backups", 5, "The number of rotated query audit log files to keep")
+	pflag.Bool("query_audit_nats", false, "Whether to publish query audit events on NATS, for the cloud connector to forward to Pixie Cloud")
 }
 
 // NewVizierServiceClient creates a new vz RPC client stub.
@@ -176,6 +198,49 @@ func main() {
 		log.WithError(err).Fatal("Failed to initialize GRPC server funcs.")
 	}
 	defer svr.Close()
+	svr.SetAdmissionController(controllers.NewAdmissionController(
+		controllers.WithMaxInFlightQueries(viper.GetInt("max_inflight_queries")),
+		controllers.WithMaxQueriesPerPrincipal(viper.GetInt("max_inflight_queries_per_principal")),
+		controllers.WithMaxQueuedQueries(viper.GetInt("max_queued_queries")),
+		controllers.WithAdmissionQueueTimeout(viper.GetDuration("query_queue_timeout")),
+		controllers.WithPriorityQueryHeadroom(viper.GetInt("priority_query_headroom")),
+	))
+	var auditSinks []controllers.AuditSink
+	if path := viper.GetString("query_audit_log_path"); path != "" {
+		fileSink, err := controllers.NewFileAuditSink(path, viper.GetInt64("query_audit_log_max_size_mb")*1024*1024, viper.GetInt("query_audit_log_max_backups"))
+		if err != nil {
+			log.WithError(err).Fatal("Failed to open query audit log.")
+		}
+		auditSinks = append(auditSinks, fileSink)
+	}
+	if viper.GetBool("query_audit_nats") {
+		auditSinks = append(auditSinks, controllers.NewNATSAuditSink(natsConn))
+	}
+	if len(auditSinks) > 0 {
+		auditor := controllers.NewQueryAuditor(auditSinks...)
+		defer auditor.Close()
+		svr.SetQueryAuditor(auditor)
+	}
+	kubeConfig, err := rest.InClusterConfig()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to get in-cluster config.")
+	}
+	nodeWatcherStopCh := make(chan struct{})
+	defer close(nodeWatcherStopCh)
+	nodeLabeler, err := controllers.NewNodeLabelWatcher(k8s.GetClientset(kubeConfig), nodeWatcherStopCh)
+	if err != nil {
+		log.WithError(err).Error("Failed to watch nodes, queries can't select agents by node labels.")
+	} else {
+		svr.SetNodeLabeler(nodeLabeler)
+	}
+	if viper.GetBool("query_result_cache") {
+		svr.SetResultCache(controllers.NewResultCache(
+			controllers.WithResultCacheMaxEntries(viper.GetInt("query_result_cache_max_entries")),
+			controllers.WithResultCacheMaxBytes(viper.GetInt("query_result_cache_max_bytes")),
+			controllers.WithResultCacheTTL(viper.GetDuration("query_result_cache_ttl")),
+			controllers.WithResultCacheWindow(viper.GetDuration("query_result_cache_window")),
+		))
+	}
 
 	// For query broker we bump up the max message size since resuls might be larger than 4mb.
 	maxMsgSize := grpc.MaxRecvMsgSize(8 * 1024 * 1024)
@@ -213,8 +278,16 @@ func main() {
 		viper.GetString("jwt_signing_key"),
 		viper.GetString("pod_namespace"),
 		viper.GetStringSlice("cron_script_sources"),
+		viper.GetString("cron_script_dir"),
+		scriptrunner.GitSourceConfig{
+			URL:          viper.GetString("cron_script_git_url"),
+			Ref:          viper.GetString("cron_script_git_ref"),
+			Path:         viper.GetString("cron_script_git_path"),
+			PollInterval: viper.GetDuration("cron_script_git_poll_interval"),
+		},
 	)
 	sr := scriptrunner.New(csClient, vzServiceClient, viper.GetString("jwt_signing_key"), sources...)
+	sr.SetMaxConcurrentRuns(viper.GetInt("cron_script_max_concurrent_runs"))
 
 	// Load the scripts and start the background sync.
 	go func() {
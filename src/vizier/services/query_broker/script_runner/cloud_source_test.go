@@ -790,6 +790,12 @@ type stubCronScriptStore struct {
 
 	GetAllExecutionResultsResponse *metadatapb.GetAllExecutionResultsResponse
 	GetAllExecutionResultsError    error
+
+	GetScriptCheckpointResponse *metadatapb.GetScriptCheckpointResponse
+	GetScriptCheckpointError    error
+
+	SetScriptCheckpointResponse *metadatapb.SetScriptCheckpointResponse
+	SetScriptCheckpointError    error
 }
 
 func (s *stubCronScriptStore) GetScripts(_ context.Context, _ *metadatapb.GetScriptsRequest, _ ...grpc.CallOption) (*metadatapb.GetScriptsResponse, error) {
@@ -816,6 +822,14 @@ func (s *stubCronScriptStore) GetAllExecutionResults(_ context.Context, _ *metad
 	return s.GetAllExecutionResultsResponse, s.GetAllExecutionResultsError
 }
 
+func (s *stubCronScriptStore) GetScriptCheckpoint(_ context.Context, _ *metadatapb.GetScriptCheckpointRequest, _ ...grpc.CallOption) (*metadatapb.GetScriptCheckpointResponse, error) {
+	return s.GetScriptCheckpointResponse, s.GetScriptCheckpointError
+}
+
+func (s *stubCronScriptStore) SetScriptCheckpoint(_ context.Context, _ *metadatapb.SetScriptCheckpointRequest, _ ...grpc.CallOption) (*metadatapb.SetScriptCheckpointResponse, error) {
+	return s.SetScriptCheckpointResponse, s.SetScriptCheckpointError
+}
+
 func setupChecksumSubscription(t *testing.T, nc *nats.Conn, cloudScripts map[string]*cvmsgspb.CronScript) (*nats.Subscription, chan struct{}) {
 	gotChecksumReq := make(chan struct{}, 1)
 	checksumSub, err := nc.Subscribe(CronScriptChecksumRequestChannel, func(msg *nats.Msg) {
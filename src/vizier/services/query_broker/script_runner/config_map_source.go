@@ -25,7 +25,6 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -36,7 +35,6 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"px.dev/pixie/src/shared/cvmsgspb"
-	"px.dev/pixie/src/utils"
 )
 
 // ConfigMapSource pulls cron scripts from config maps.
@@ -49,7 +47,7 @@ type ConfigMapSource struct {
 // Each config map must contain
 //   - a script.pxl with the pixel script
 //   - a configs.yaml which will be stored in the Configs field of [cvmsgspb.CronScript]
-//   - a cron.yaml that contains a "frequency_s" key
+//   - a cron.yaml that contains a "frequency_s" or a "cron_expression" key
 func NewConfigMapSource(client kubernetes.Interface, namespace string) *ConfigMapSource {
 	return &ConfigMapSource{
 		informer: informers.NewSharedInformerFactoryWithOptions(
@@ -166,20 +164,14 @@ func logCronScriptParseError(err error) {
 
 func configmapToCronScript(configmap *corev1.ConfigMap) (string, *cvmsgspb.CronScript, error) {
 	id := string(configmap.UID)
-	cronScript := &cvmsgspb.CronScript{
-		ID:      utils.ProtoFromUUIDStrOrNil(id),
-		Script:  configmap.Data["script.pxl"],
-		Configs: configmap.Data["configs.yaml"],
-	}
-	var cronData cronYAML
-	err := yaml.Unmarshal([]byte(configmap.Data["cron.yaml"]), &cronData)
+	cronScript, err := scriptFromFiles(id, configmap.Data)
 	if err != nil {
 		return "", nil, err
 	}
-	cronScript.FrequencyS = cronData.FrequencyS
 	return id, cronScript, nil
 }
 
 type cronYAML struct {
-	FrequencyS int64 `yaml:"frequency_s"`
+	FrequencyS     int64  `yaml:"frequency_s"`
+	CronExpression string `yaml:"cron_expression"`
 }
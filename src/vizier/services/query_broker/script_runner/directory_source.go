@@ -0,0 +1,181 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+)
+
+// directoryReloadDelay is how long the DirectorySource waits for file changes to settle before reloading the scripts.
+const directoryReloadDelay = 100 * time.Millisecond
+
+// DirectorySource pulls cron scripts from a local directory.
+type DirectorySource struct {
+	dir     string
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+	scripts map[string]*cvmsgspb.CronScript
+	stop    func()
+}
+
+// NewDirectorySource constructs a [Source] that extracts cron scripts from the subdirectories of dir, such as a mounted
+// volume. The ID of each script is derived from the name of its subdirectory, which must contain
+//   - a script.pxl with the pixel script
+//   - a configs.yaml which will be stored in the Configs field of [cvmsgspb.CronScript]
+//   - a cron.yaml that contains a "frequency_s" or a "cron_expression" key
+func NewDirectorySource(dir string) *DirectorySource {
+	return &DirectorySource{
+		dir: dir,
+	}
+}
+
+// Start watches dir for changes to the scripts and sends resulting updates on updatesCh.
+func (source *DirectorySource) Start(ctx context.Context, updatesCh chan<- *cvmsgspb.CronScriptUpdate) (map[string]*cvmsgspb.CronScript, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	source.watcher = watcher
+	source.watched = map[string]bool{}
+	scripts, err := source.load()
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	source.scripts = scripts
+
+	stopCh := make(chan struct{})
+	source.stop = func() {
+		close(stopCh)
+		watcher.Close()
+	}
+	go source.watch(ctx, stopCh, updatesCh)
+	return scripts, nil
+}
+
+// load watches dir and its script subdirectories, and reads the scripts they contain.
+func (source *DirectorySource) load() (map[string]*cvmsgspb.CronScript, error) {
+	dirs, err := readScriptDirs(source.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mounted volumes replace their files through symlinks, so the watches are renewed on every reload.
+	for path := range source.watched {
+		_ = source.watcher.Remove(path)
+	}
+	source.watched = map[string]bool{}
+	paths := []string{source.dir}
+	for name := range dirs {
+		paths = append(paths, filepath.Join(source.dir, name))
+	}
+	for _, path := range paths {
+		if err := source.watcher.Add(path); err != nil {
+			return nil, err
+		}
+		source.watched[path] = true
+	}
+	return scriptsFromDirs(dirs), nil
+}
+
+func (source *DirectorySource) watch(ctx context.Context, stopCh <-chan struct{}, updatesCh chan<- *cvmsgspb.CronScriptUpdate) {
+	reloadTimer := time.NewTimer(directoryReloadDelay)
+	reloadTimer.Stop()
+	defer reloadTimer.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case _, ok := <-source.watcher.Events:
+			if !ok {
+				return
+			}
+			reloadTimer.Reset(directoryReloadDelay)
+		case err, ok := <-source.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.WithError(err).Error("Error while watching cron script directory")
+		case <-reloadTimer.C:
+			scripts, err := source.load()
+			if err != nil {
+				log.WithError(err).WithField("dir", source.dir).Error("Failed to reload cron scripts")
+				continue
+			}
+			for _, update := range scriptUpdates(source.scripts, scripts) {
+				select {
+				case <-stopCh:
+					return
+				case updatesCh <- update:
+				}
+			}
+			source.scripts = scripts
+		}
+	}
+}
+
+// Stop stops further updates from being sent.
+func (source *DirectorySource) Stop() {
+	source.stop()
+}
+
+// readScriptDirs returns the contents of the cron script files in each subdirectory of dir, keyed by subdirectory name.
+func readScriptDirs(dir string) (map[string]map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	dirs := map[string]map[string]string{}
+	for _, entry := range entries {
+		// Skip hidden entries, such as the "..data" directories of mounted config maps.
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		// Stat follows symlinks, which entry doesn't.
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		files := map[string]string{}
+		for _, name := range []string{scriptFileName, configsFileName, cronFileName} {
+			contents, err := os.ReadFile(filepath.Join(path, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			files[name] = string(contents)
+		}
+		dirs[entry.Name()] = files
+	}
+	return dirs, nil
+}
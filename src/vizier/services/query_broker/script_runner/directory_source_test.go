@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/utils"
+)
+
+func TestDirectoryScriptsSource(t *testing.T) {
+	t.Run("returns the initial scripts from a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeScriptDir(t, dir, "cron-script-1", cronScriptFiles())
+		source := NewDirectorySource(dir)
+		initialScripts, err := source.Start(context.Background(), nil)
+		require.NoError(t, err)
+		defer source.Stop()
+
+		require.Len(t, initialScripts, 1)
+		initialScript := initialScripts[scriptIDFromName("cron-script-1")]
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-1")), initialScript.ID)
+		require.Equal(t, "px.display()", initialScript.Script)
+		require.Equal(t, "otelEndpointConfig: {url: example.com}", initialScript.Configs)
+		require.Equal(t, int64(1), initialScript.FrequencyS)
+	})
+
+	t.Run("excludes incomplete and hidden directories", func(t *testing.T) {
+		dir := t.TempDir()
+		files := cronScriptFiles()
+		delete(files, "cron.yaml")
+		writeScriptDir(t, dir, "no-cron", files)
+		writeScriptDir(t, dir, "..data", cronScriptFiles())
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "script.pxl"), []byte("px.display()"), 0644))
+		source := NewDirectorySource(dir)
+		initialScripts, err := source.Start(context.Background(), nil)
+		require.NoError(t, err)
+		defer source.Stop()
+
+		require.Len(t, initialScripts, 0)
+	})
+
+	t.Run("fails if the directory doesn't exist", func(t *testing.T) {
+		source := NewDirectorySource(filepath.Join(t.TempDir(), "missing"))
+		_, err := source.Start(context.Background(), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("Stop causes no further updates to be sent", func(t *testing.T) {
+		dir := t.TempDir()
+		updatesCh := mockUpdatesCh()
+		source := NewDirectorySource(dir)
+		_, _ = source.Start(context.Background(), updatesCh)
+		source.Stop()
+
+		writeScriptDir(t, dir, "cron-script-1", cronScriptFiles())
+
+		requireNoReceive(t, updatesCh, 2*directoryReloadDelay)
+	})
+
+	t.Run("sends updates for created scripts", func(t *testing.T) {
+		dir := t.TempDir()
+		updatesCh := mockUpdatesCh()
+		source := NewDirectorySource(dir)
+		_, _ = source.Start(context.Background(), updatesCh)
+		defer source.Stop()
+
+		writeScriptDir(t, dir, "cron-script-1", cronScriptFiles())
+
+		cronScript := requireReceiveWithin(t, updatesCh, time.Second).GetUpsertReq().GetScript()
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-1")), cronScript.GetID())
+		require.Equal(t, "px.display()", cronScript.GetScript())
+		require.Equal(t, "otelEndpointConfig: {url: example.com}", cronScript.GetConfigs())
+		require.Equal(t, int64(1), cronScript.GetFrequencyS())
+	})
+
+	t.Run("sends updates for updated scripts", func(t *testing.T) {
+		dir := t.TempDir()
+		files := cronScriptFiles()
+		writeScriptDir(t, dir, "cron-script-1", files)
+		updatesCh := mockUpdatesCh()
+		source := NewDirectorySource(dir)
+		_, _ = source.Start(context.Background(), updatesCh)
+		defer source.Stop()
+
+		files["script.pxl"] += "2"
+		writeScriptDir(t, dir, "cron-script-1", files)
+
+		cronScript := requireReceiveWithin(t, updatesCh, time.Second).GetUpsertReq().GetScript()
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-1")), cronScript.GetID())
+		require.Equal(t, files["script.pxl"], cronScript.GetScript())
+		requireNoReceive(t, updatesCh, 2*directoryReloadDelay)
+	})
+
+	t.Run("sends updates for deleted scripts", func(t *testing.T) {
+		dir := t.TempDir()
+		writeScriptDir(t, dir, "cron-script-1", cronScriptFiles())
+		updatesCh := mockUpdatesCh()
+		source := NewDirectorySource(dir)
+		_, _ = source.Start(context.Background(), updatesCh)
+		defer source.Stop()
+
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "cron-script-1")))
+
+		deletedID := requireReceiveWithin(t, updatesCh, time.Second).GetDeleteReq().ScriptID
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-1")), deletedID)
+	})
+}
+
+func cronScriptFiles() map[string]string {
+	return map[string]string{
+		"script.pxl":   "px.display()",
+		"configs.yaml": "otelEndpointConfig: {url: example.com}",
+		"cron.yaml":    "frequency_s: 1",
+	}
+}
+
+func writeScriptDir(t *testing.T, dir string, name string, files map[string]string) {
+	t.Helper()
+	scriptDir := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(scriptDir, 0755))
+	for fileName, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(scriptDir, fileName), []byte(contents), 0644))
+	}
+}
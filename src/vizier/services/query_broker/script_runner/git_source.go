@@ -0,0 +1,215 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+)
+
+// gitSourceRef is the local ref that the GitSource fetches the configured ref into.
+const gitSourceRef = "refs/remotes/origin/cron-scripts"
+
+// GitSourceConfig specifies where a GitSource reads cron scripts from.
+type GitSourceConfig struct {
+	// URL is the URL of the repository.
+	URL string
+	// Ref is the branch or full ref name to read the scripts from, such as "main" or "refs/tags/v1".
+	Ref string
+	// Path is the directory of the repository that contains the scripts. Defaults to the root of the repository.
+	Path string
+	// PollInterval is how often the repository is checked for changes to Ref.
+	PollInterval time.Duration
+}
+
+// GitSource pulls cron scripts from a Git repository.
+type GitSource struct {
+	config  GitSourceConfig
+	repo    *git.Repository
+	commit  plumbing.Hash
+	scripts map[string]*cvmsgspb.CronScript
+	stop    func()
+}
+
+// NewGitSource constructs a [Source] that extracts cron scripts from the subdirectories of a directory in a Git
+// repository, at a fixed ref. The repository is polled for changes to the ref. Script directories follow the same
+// layout as the ones of [NewDirectorySource].
+func NewGitSource(config GitSourceConfig) *GitSource {
+	if config.Ref == "" {
+		config.Ref = "main"
+	}
+	if !strings.HasPrefix(config.Ref, "refs/") {
+		config.Ref = "refs/heads/" + config.Ref
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	return &GitSource{
+		config: config,
+	}
+}
+
+// Start fetches the scripts from the repository, then polls it and sends resulting updates on updatesCh.
+func (source *GitSource) Start(ctx context.Context, updatesCh chan<- *cvmsgspb.CronScriptUpdate) (map[string]*cvmsgspb.CronScript, error) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{source.config.URL},
+	})
+	if err != nil {
+		return nil, err
+	}
+	source.repo = repo
+
+	_, scripts, err := source.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	source.scripts = scripts
+
+	stopCh := make(chan struct{})
+	source.stop = func() { close(stopCh) }
+	go source.poll(ctx, stopCh, updatesCh)
+	return scripts, nil
+}
+
+func (source *GitSource) poll(ctx context.Context, stopCh <-chan struct{}, updatesCh chan<- *cvmsgspb.CronScriptUpdate) {
+	ticker := time.NewTicker(source.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, scripts, err := source.fetch(ctx)
+		if err != nil {
+			log.WithError(err).WithField("url", source.config.URL).Error("Failed to fetch cron scripts")
+			continue
+		}
+		if !changed {
+			continue
+		}
+		for _, update := range scriptUpdates(source.scripts, scripts) {
+			select {
+			case <-stopCh:
+				return
+			case updatesCh <- update:
+			}
+		}
+		source.scripts = scripts
+	}
+}
+
+// fetch fetches the ref and reads the scripts of the commit it points to. It returns false if the ref still points to
+// the commit of the previous fetch.
+func (source *GitSource) fetch(ctx context.Context) (bool, map[string]*cvmsgspb.CronScript, error) {
+	err := source.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", source.config.Ref, gitSourceRef))},
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return false, nil, err
+	}
+	ref, err := source.repo.Reference(gitSourceRef, true)
+	if err != nil {
+		return false, nil, err
+	}
+	if ref.Hash() == source.commit {
+		return false, nil, nil
+	}
+
+	commit, err := source.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return false, nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, nil, err
+	}
+	if path := strings.Trim(source.config.Path, "/"); path != "" {
+		tree, err = tree.Tree(path)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to find %s in commit %s: %w", path, ref.Hash(), err)
+		}
+	}
+	dirs, err := readScriptTrees(tree)
+	if err != nil {
+		return false, nil, err
+	}
+	source.commit = ref.Hash()
+	return true, scriptsFromDirs(dirs), nil
+}
+
+// Stop stops further updates from being sent.
+func (source *GitSource) Stop() {
+	source.stop()
+}
+
+// readScriptTrees returns the contents of the cron script files in each subtree of tree, keyed by subtree name.
+func readScriptTrees(tree *object.Tree) (map[string]map[string]string, error) {
+	dirs := map[string]map[string]string{}
+	for _, entry := range tree.Entries {
+		if entry.Mode != filemode.Dir || strings.HasPrefix(entry.Name, ".") {
+			continue
+		}
+		subtree, err := tree.Tree(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		files := map[string]string{}
+		for _, fileEntry := range subtree.Entries {
+			switch fileEntry.Name {
+			case scriptFileName, configsFileName, cronFileName:
+			default:
+				continue
+			}
+			file, err := subtree.TreeEntryFile(&fileEntry)
+			if err != nil {
+				return nil, err
+			}
+			contents, err := file.Contents()
+			if err != nil {
+				return nil, err
+			}
+			files[fileEntry.Name] = contents
+		}
+		dirs[entry.Name] = files
+	}
+	return dirs, nil
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/server"
+
+	"px.dev/pixie/src/utils"
+)
+
+func TestGitScriptsSource(t *testing.T) {
+	t.Run("returns the initial scripts from the ref", func(t *testing.T) {
+		repo := newTestGitRepo(t)
+		repo.commit(t, "main", map[string]map[string]string{
+			"scripts/cron-script-1": cronScriptFiles(),
+			"other/cron-script-2":   cronScriptFiles(),
+		})
+		source := NewGitSource(GitSourceConfig{URL: repo.url, Ref: "main", Path: "scripts"})
+		initialScripts, err := source.Start(context.Background(), nil)
+		require.NoError(t, err)
+		defer source.Stop()
+
+		require.Len(t, initialScripts, 1)
+		initialScript := initialScripts[scriptIDFromName("cron-script-1")]
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-1")), initialScript.ID)
+		require.Equal(t, "px.display()", initialScript.Script)
+		require.Equal(t, "otelEndpointConfig: {url: example.com}", initialScript.Configs)
+		require.Equal(t, int64(1), initialScript.FrequencyS)
+	})
+
+	t.Run("fails if the ref doesn't exist", func(t *testing.T) {
+		repo := newTestGitRepo(t)
+		repo.commit(t, "main", map[string]map[string]string{
+			"cron-script-1": cronScriptFiles(),
+		})
+		source := NewGitSource(GitSourceConfig{URL: repo.url, Ref: "refs/tags/missing"})
+		_, err := source.Start(context.Background(), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("sends updates when the ref changes", func(t *testing.T) {
+		repo := newTestGitRepo(t)
+		repo.commit(t, "main", map[string]map[string]string{
+			"cron-script-1": cronScriptFiles(),
+			"cron-script-2": cronScriptFiles(),
+		})
+		updatesCh := mockUpdatesCh()
+		source := NewGitSource(GitSourceConfig{URL: repo.url, Ref: "main", PollInterval: 10 * time.Millisecond})
+		_, err := source.Start(context.Background(), updatesCh)
+		require.NoError(t, err)
+		defer source.Stop()
+
+		// Commits to other refs are ignored.
+		updated := cronScriptFiles()
+		updated["script.pxl"] += "2"
+		repo.commit(t, "dev", map[string]map[string]string{
+			"cron-script-1": updated,
+		})
+		requireNoReceive(t, updatesCh, 100*time.Millisecond)
+
+		repo.commit(t, "main", map[string]map[string]string{
+			"cron-script-1": updated,
+			"cron-script-3": cronScriptFiles(),
+		})
+
+		upserted := map[string]string{}
+		for i := 0; i < 2; i++ {
+			script := requireReceiveWithin(t, updatesCh, time.Second).GetUpsertReq().GetScript()
+			require.NotNil(t, script)
+			upserted[utils.UUIDFromProtoOrNil(script.ID).String()] = script.Script
+		}
+		require.Equal(t, map[string]string{
+			scriptIDFromName("cron-script-1"): updated["script.pxl"],
+			scriptIDFromName("cron-script-3"): "px.display()",
+		}, upserted)
+		deletedID := requireReceiveWithin(t, updatesCh, time.Second).GetDeleteReq().ScriptID
+		require.Equal(t, utils.ProtoFromUUIDStrOrNil(scriptIDFromName("cron-script-2")), deletedID)
+		requireNoReceive(t, updatesCh, 100*time.Millisecond)
+	})
+}
+
+var installFileProtocol sync.Once
+
+// testGitRepo is a local bare repository that commits are pushed to from a separate work repository.
+type testGitRepo struct {
+	url  string
+	work *git.Repository
+	dir  string
+}
+
+func newTestGitRepo(t *testing.T) *testGitRepo {
+	// Serve file:// URLs in process, rather than with the git binaries.
+	installFileProtocol.Do(func() {
+		client.InstallProtocol("file", server.NewClient(server.DefaultLoader))
+	})
+
+	bareDir := filepath.Join(t.TempDir(), "scripts.git")
+	_, err := git.PlainInit(bareDir, true)
+	require.NoError(t, err)
+
+	workDir := t.TempDir()
+	work, err := git.PlainInit(workDir, false)
+	require.NoError(t, err)
+	_, err = work.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{bareDir},
+	})
+	require.NoError(t, err)
+	return &testGitRepo{url: bareDir, work: work, dir: workDir}
+}
+
+// commit replaces the contents of the work repository with dirs, and pushes them to branch in the bare repository.
+func (r *testGitRepo) commit(t *testing.T, branch string, dirs map[string]map[string]string) {
+	t.Helper()
+	wt, err := r.work.Worktree()
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(r.dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Name() != ".git" {
+			require.NoError(t, os.RemoveAll(filepath.Join(r.dir, entry.Name())))
+		}
+	}
+	var paths []string
+	for dir, files := range dirs {
+		writeScriptDir(t, r.dir, dir, files)
+		for name := range files {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		_, err = wt.Add(path)
+		require.NoError(t, err)
+	}
+	// Stage the files that were removed.
+	status, err := wt.Status()
+	require.NoError(t, err)
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Deleted {
+			_, err = wt.Remove(path)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = wt.Commit("Update cron scripts", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	err = r.work.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec("+refs/heads/master:refs/heads/" + branch)},
+	})
+	require.NoError(t, err)
+}
@@ -21,11 +21,13 @@ package scriptrunner
 import (
 	"context"
 	"errors"
+	"io"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/types"
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
@@ -101,6 +103,9 @@ type fakeCronStore struct {
 	scriptsMu               sync.Mutex
 	scripts                 map[uuid.UUID]*cvmsgspb.CronScript
 	receivedResultRequestCh chan<- *metadatapb.RecordExecutionResultRequest
+
+	checkpointsMu sync.Mutex
+	checkpoints   map[uuid.UUID]*types.Timestamp
 }
 
 // GetScripts fetches all scripts in the cron script store.
@@ -178,6 +183,32 @@ func (s *fakeCronStore) GetAllExecutionResults(ctx context.Context, req *metadat
 	return &metadatapb.GetAllExecutionResultsResponse{}, nil
 }
 
+// GetScriptCheckpoint returns the end of the last time window that a cron script was run for.
+func (s *fakeCronStore) GetScriptCheckpoint(ctx context.Context, req *metadatapb.GetScriptCheckpointRequest, opts ...grpc.CallOption) (*metadatapb.GetScriptCheckpointResponse, error) {
+	s.checkpointsMu.Lock()
+	defer s.checkpointsMu.Unlock()
+	return &metadatapb.GetScriptCheckpointResponse{
+		Checkpoint: s.checkpoints[utils.UUIDFromProtoOrNil(req.ScriptID)],
+	}, nil
+}
+
+// SetScriptCheckpoint stores the end of the last time window that a cron script was run for.
+func (s *fakeCronStore) SetScriptCheckpoint(ctx context.Context, req *metadatapb.SetScriptCheckpointRequest, opts ...grpc.CallOption) (*metadatapb.SetScriptCheckpointResponse, error) {
+	s.checkpointsMu.Lock()
+	defer s.checkpointsMu.Unlock()
+	if s.checkpoints == nil {
+		s.checkpoints = map[uuid.UUID]*types.Timestamp{}
+	}
+	s.checkpoints[utils.UUIDFromProtoOrNil(req.ScriptID)] = req.Checkpoint
+	return &metadatapb.SetScriptCheckpointResponse{}, nil
+}
+
+func (s *fakeCronStore) Checkpoint(id uuid.UUID) *types.Timestamp {
+	s.checkpointsMu.Lock()
+	defer s.checkpointsMu.Unlock()
+	return s.checkpoints[id]
+}
+
 type fakeExecuteScriptClient struct {
 	// The error to send if not nil. The informer does not send responses if this is not nil.
 	err       error
@@ -196,6 +227,22 @@ func (es *fakeExecuteScriptClient) Recv() (*vizierpb.ExecuteScriptResponse, erro
 	return resp, nil
 }
 
+// blockingExecuteScriptClient blocks until release is closed or the context of the request is done.
+type blockingExecuteScriptClient struct {
+	ctx     context.Context
+	release <-chan struct{}
+	grpc.ClientStream
+}
+
+func (es *blockingExecuteScriptClient) Recv() (*vizierpb.ExecuteScriptResponse, error) {
+	select {
+	case <-es.release:
+		return nil, io.EOF
+	case <-es.ctx.Done():
+		return nil, es.ctx.Err()
+	}
+}
+
 type fakeVizierServiceClient struct {
 	responses []*vizierpb.ExecuteScriptResponse
 	err       error
@@ -213,6 +260,18 @@ func (vs *fakeVizierServiceClient) GenerateOTelScript(ctx context.Context, req *
 	return nil, errors.New("Not implemented")
 }
 
+func (vs *fakeVizierServiceClient) ListQueries(ctx context.Context, req *vizierpb.ListQueriesRequest, opts ...grpc.CallOption) (*vizierpb.ListQueriesResponse, error) {
+	return nil, errors.New("Not implemented")
+}
+
+func (vs *fakeVizierServiceClient) GetQuery(ctx context.Context, req *vizierpb.GetQueryRequest, opts ...grpc.CallOption) (*vizierpb.GetQueryResponse, error) {
+	return nil, errors.New("Not implemented")
+}
+
+func (vs *fakeVizierServiceClient) CancelQuery(ctx context.Context, req *vizierpb.CancelQueryRequest, opts ...grpc.CallOption) (*vizierpb.CancelQueryResponse, error) {
+	return nil, errors.New("Not implemented")
+}
+
 type FuncMatcher[T any] struct {
 	name    string
 	matches func(x T) bool
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/shared/scripts"
+)
+
+// everySchedule runs a script at a fixed frequency.
+type everySchedule struct {
+	period time.Duration
+}
+
+// Next implements the cron.Schedule interface.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.period)
+}
+
+// parseSchedule returns when the cron script should run, or nil if it isn't scheduled. The cron expression takes
+// precedence over the frequency, and is evaluated in the configured time zone unless it specifies its own CRON_TZ.
+func parseSchedule(script *cvmsgspb.CronScript, config *scripts.ScheduleConfig) (cron.Schedule, error) {
+	expr := strings.TrimSpace(script.CronExpression)
+	if expr == "" {
+		if script.FrequencyS <= 0 {
+			return nil, nil
+		}
+		return everySchedule{period: time.Duration(script.FrequencyS) * time.Second}, nil
+	}
+
+	if !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		tz := "UTC"
+		if config.Timezone != "" {
+			tz = config.Timezone
+		}
+		expr = fmt.Sprintf("CRON_TZ=%s %s", tz, expr)
+	}
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': %w", script.CronExpression, err)
+	}
+	return sched, nil
+}
+
+// catchUpStart returns the start of the first missed window to replay after the checkpoint. If more than maxWindows
+// windows have ended since the checkpoint, the oldest ones are skipped.
+func catchUpStart(sched cron.Schedule, checkpoint time.Time, now time.Time, maxWindows int) time.Time {
+	if maxWindows <= 0 {
+		return checkpoint
+	}
+	// Keep track of the starts of the last maxWindows windows.
+	starts := make([]time.Time, maxWindows)
+	n := 0
+	for start := checkpoint; ; n++ {
+		end := sched.Next(start)
+		if end.IsZero() || end.After(now) {
+			break
+		}
+		starts[n%maxWindows] = start
+		start = end
+	}
+	if n <= maxWindows {
+		return checkpoint
+	}
+	return starts[n%maxWindows]
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/shared/scripts"
+)
+
+func TestParseSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	now := time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		script   *cvmsgspb.CronScript
+		config   *scripts.ScheduleConfig
+		expected time.Time
+		err      bool
+		disabled bool
+	}{
+		{
+			name:     "frequency",
+			script:   &cvmsgspb.CronScript{FrequencyS: 10},
+			config:   &scripts.ScheduleConfig{},
+			expected: now.Add(10 * time.Second),
+		},
+		{
+			name:     "not scheduled",
+			script:   &cvmsgspb.CronScript{},
+			config:   &scripts.ScheduleConfig{},
+			disabled: true,
+		},
+		{
+			name:     "cron expression defaults to UTC",
+			script:   &cvmsgspb.CronScript{CronExpression: "0 12 * * *", FrequencyS: 10},
+			config:   &scripts.ScheduleConfig{},
+			expected: time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "cron expression in configured time zone",
+			script:   &cvmsgspb.CronScript{CronExpression: "0 12 * * *"},
+			config:   &scripts.ScheduleConfig{Timezone: "Europe/Berlin"},
+			expected: time.Date(2023, 1, 2, 12, 0, 0, 0, berlin),
+		},
+		{
+			name:     "cron expression time zone takes precedence",
+			script:   &cvmsgspb.CronScript{CronExpression: "CRON_TZ=Europe/Berlin 0 12 * * *"},
+			config:   &scripts.ScheduleConfig{Timezone: "America/New_York"},
+			expected: time.Date(2023, 1, 2, 12, 0, 0, 0, berlin),
+		},
+		{
+			name:     "descriptor",
+			script:   &cvmsgspb.CronScript{CronExpression: "@hourly"},
+			config:   &scripts.ScheduleConfig{},
+			expected: time.Date(2023, 1, 2, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "invalid cron expression",
+			script: &cvmsgspb.CronScript{CronExpression: "0 12 * *"},
+			config: &scripts.ScheduleConfig{},
+			err:    true,
+		},
+		{
+			name:   "invalid time zone",
+			script: &cvmsgspb.CronScript{CronExpression: "0 12 * * *"},
+			config: &scripts.ScheduleConfig{Timezone: "Not/AZone"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sched, err := parseSchedule(test.script, test.config)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if test.disabled {
+				require.Nil(t, sched)
+				return
+			}
+			require.True(t, test.expected.Equal(sched.Next(now)), "expected %s, got %s", test.expected, sched.Next(now))
+		})
+	}
+}
+
+func TestCatchUpStart(t *testing.T) {
+	sched := everySchedule{period: time.Minute}
+	checkpoint := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	now := checkpoint.Add(5*time.Minute + 30*time.Second)
+
+	// Unlimited.
+	require.Equal(t, checkpoint, catchUpStart(sched, checkpoint, now, 0))
+	// Fewer windows were missed than the limit.
+	require.Equal(t, checkpoint, catchUpStart(sched, checkpoint, now, 5))
+	require.Equal(t, checkpoint, catchUpStart(sched, checkpoint, now, 10))
+	// Only the last 2 of the 5 missed windows are replayed.
+	require.Equal(t, checkpoint.Add(3*time.Minute), catchUpStart(sched, checkpoint, now, 2))
+	require.Equal(t, checkpoint.Add(4*time.Minute), catchUpStart(sched, checkpoint, now, 1))
+}
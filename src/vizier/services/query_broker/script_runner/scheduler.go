@@ -0,0 +1,58 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+// DefaultMaxConcurrentRuns is the default number of script runs that execute at the same time, across all scripts.
+const DefaultMaxConcurrentRuns = 16
+
+// Scheduler limits the number of script runs that execute at the same time. A nil Scheduler doesn't limit runs.
+type Scheduler struct {
+	slots chan struct{}
+}
+
+// NewScheduler creates a scheduler that executes at most maxConcurrentRuns script runs at the same time.
+func NewScheduler(maxConcurrentRuns int) *Scheduler {
+	if maxConcurrentRuns <= 0 {
+		maxConcurrentRuns = DefaultMaxConcurrentRuns
+	}
+	return &Scheduler{
+		slots: make(chan struct{}, maxConcurrentRuns),
+	}
+}
+
+// acquire blocks until a run can start. It returns false if done is closed first.
+func (s *Scheduler) acquire(done <-chan struct{}) bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case <-done:
+		return false
+	case s.slots <- struct{}{}:
+		return true
+	}
+}
+
+// release marks a run that was started by acquire as completed.
+func (s *Scheduler) release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
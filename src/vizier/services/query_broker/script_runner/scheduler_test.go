@@ -0,0 +1,54 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(2)
+	done := make(chan struct{})
+	require.True(t, s.acquire(done))
+	require.True(t, s.acquire(done))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- s.acquire(done)
+	}()
+	requireNoReceive(t, acquired, 100*time.Millisecond)
+
+	s.release()
+	require.True(t, requireReceiveWithin(t, acquired, time.Second))
+
+	go func() {
+		acquired <- s.acquire(done)
+	}()
+	close(done)
+	require.False(t, requireReceiveWithin(t, acquired, time.Second))
+}
+
+func TestScheduler_Nil(t *testing.T) {
+	var s *Scheduler
+	require.True(t, s.acquire(nil))
+	s.release()
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/utils"
+)
+
+// Names of the files that make up a cron script.
+const (
+	scriptFileName  = "script.pxl"
+	configsFileName = "configs.yaml"
+	cronFileName    = "cron.yaml"
+)
+
+// scriptIDNamespace is the namespace of the IDs of cron scripts that are named after their directory.
+var scriptIDNamespace = uuid.Must(uuid.FromString("4c8e6f5e-2b0a-4f7a-9d0e-3f6b1c2a7d59"))
+
+// scriptIDFromName returns a stable ID for the cron script stored in the directory with the given name.
+func scriptIDFromName(name string) string {
+	return uuid.NewV5(scriptIDNamespace, name).String()
+}
+
+// scriptFromFiles creates the cron script with the given ID from the contents of its script.pxl, configs.yaml and
+// cron.yaml files.
+func scriptFromFiles(id string, files map[string]string) (*cvmsgspb.CronScript, error) {
+	cronScript := &cvmsgspb.CronScript{
+		ID:      utils.ProtoFromUUIDStrOrNil(id),
+		Script:  files[scriptFileName],
+		Configs: files[configsFileName],
+	}
+	var cronData cronYAML
+	err := yaml.Unmarshal([]byte(files[cronFileName]), &cronData)
+	if err != nil {
+		return nil, err
+	}
+	cronScript.FrequencyS = cronData.FrequencyS
+	cronScript.CronExpression = cronData.CronExpression
+	return cronScript, nil
+}
+
+// scriptsFromDirs creates the cron scripts from the files of each script directory, keyed by directory name. Directories
+// that don't contain a valid cron script are skipped.
+func scriptsFromDirs(dirs map[string]map[string]string) map[string]*cvmsgspb.CronScript {
+	scripts := map[string]*cvmsgspb.CronScript{}
+	for name, files := range dirs {
+		script, err := scriptFromDir(name, files)
+		if err != nil {
+			log.WithError(err).WithField("script", name).Error("Failed to read cron script")
+			continue
+		}
+		scripts[scriptIDFromName(name)] = script
+	}
+	return scripts
+}
+
+func scriptFromDir(name string, files map[string]string) (*cvmsgspb.CronScript, error) {
+	if _, ok := files[scriptFileName]; !ok {
+		return nil, fmt.Errorf("missing %s", scriptFileName)
+	}
+	if _, ok := files[cronFileName]; !ok {
+		return nil, fmt.Errorf("missing %s", cronFileName)
+	}
+	script, err := scriptFromFiles(scriptIDFromName(name), files)
+	if err != nil {
+		return nil, err
+	}
+	if script.FrequencyS <= 0 && script.CronExpression == "" {
+		return nil, errors.New("cron.yaml must set frequency_s or cron_expression")
+	}
+	return script, nil
+}
+
+// scriptUpdates returns the updates that turn oldScripts into newScripts.
+func scriptUpdates(oldScripts map[string]*cvmsgspb.CronScript, newScripts map[string]*cvmsgspb.CronScript) []*cvmsgspb.CronScriptUpdate {
+	var updates []*cvmsgspb.CronScriptUpdate
+	for _, id := range sortedScriptIDs(newScripts) {
+		script := newScripts[id]
+		if oldScript, ok := oldScripts[id]; ok && proto.Equal(oldScript, script) {
+			continue
+		}
+		updates = append(updates, &cvmsgspb.CronScriptUpdate{
+			Msg: &cvmsgspb.CronScriptUpdate_UpsertReq{
+				UpsertReq: &cvmsgspb.RegisterOrUpdateCronScriptRequest{
+					Script: script,
+				},
+			},
+			Timestamp: time.Now().Unix(),
+		})
+	}
+	for _, id := range sortedScriptIDs(oldScripts) {
+		if _, ok := newScripts[id]; ok {
+			continue
+		}
+		updates = append(updates, &cvmsgspb.CronScriptUpdate{
+			Msg: &cvmsgspb.CronScriptUpdate_DeleteReq{
+				DeleteReq: &cvmsgspb.DeleteCronScriptRequest{
+					ScriptID: oldScripts[id].ID,
+				},
+			},
+			Timestamp: time.Now().Unix(),
+		})
+	}
+	return updates
+}
+
+func sortedScriptIDs(scripts map[string]*cvmsgspb.CronScript) []string {
+	ids := make([]string, 0, len(scripts))
+	for id := range scripts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/types"
+	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -62,6 +64,10 @@ var (
 	CronScriptUpdatesResponseChannel = messagebus.V2CTopic(cvmsgs.CronScriptUpdatesResponseChannel)
 	natsWaitTimeout                  = 2 * time.Minute
 	defaultOTelTimeoutS              = int64(5)
+
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
 )
 
 // ScriptRunner tracks registered cron scripts and runs them according to schedule.
@@ -78,6 +84,7 @@ type ScriptRunner struct {
 	updatesCh  chan *cvmsgspb.CronScriptUpdate
 	baseCtx    context.Context
 	sources    []Source
+	scheduler  *Scheduler
 }
 
 // New creates a new script runner.
@@ -100,9 +107,16 @@ func New(
 		updatesCh:  make(chan *cvmsgspb.CronScriptUpdate, 4096),
 		baseCtx:    baseCtx,
 		sources:    scriptSources,
+		scheduler:  NewScheduler(DefaultMaxConcurrentRuns),
 	}
 }
 
+// SetMaxConcurrentRuns limits the number of script runs that execute at the same time, across all scripts. It must be
+// called before SyncScripts.
+func (s *ScriptRunner) SetMaxConcurrentRuns(maxConcurrentRuns int) {
+	s.scheduler = NewScheduler(maxConcurrentRuns)
+}
+
 // Stop performs any necessary cleanup before shutdown.
 func (s *ScriptRunner) Stop() {
 	s.once.Do(func() {
@@ -160,7 +174,7 @@ func (s *ScriptRunner) upsertScript(id uuid.UUID, script *cvmsgspb.CronScript) {
 		v.stop()
 		delete(s.runnerMap, id)
 	}
-	r := newRunner(script, s.vzClient, s.signingKey, id, s.csClient)
+	r := newRunner(script, s.vzClient, s.signingKey, id, s.csClient, s.scheduler)
 	s.runnerMap[id] = r
 	go r.start()
 }
@@ -181,25 +195,37 @@ type runner struct {
 	cronScript *cvmsgspb.CronScript
 	config     *scripts.Config
 
-	lastRun time.Time
-
 	csClient   metadatapb.CronScriptStoreServiceClient
 	vzClient   vizierpb.VizierServiceClient
 	signingKey string
 
 	done chan struct{}
 	once sync.Once
+	// runsMu makes sure that no run starts after the runner is stopped, so that runs can be waited for.
+	runsMu sync.Mutex
+	// runs tracks the runs that are in progress, which have to complete before the sinks are closed.
+	runs sync.WaitGroup
 
 	scriptID uuid.UUID
+
+	scheduler *Scheduler
+	// slots holds a token for each run of the script that is in progress.
+	slots chan struct{}
+
+	sinks []*configuredSink
 }
 
-func newRunner(script *cvmsgspb.CronScript, vzClient vizierpb.VizierServiceClient, signingKey string, id uuid.UUID, csClient metadatapb.CronScriptStoreServiceClient) *runner {
+func newRunner(script *cvmsgspb.CronScript, vzClient vizierpb.VizierServiceClient, signingKey string, id uuid.UUID, csClient metadatapb.CronScriptStoreServiceClient, scheduler *Scheduler) *runner {
 	// Parse config YAML into struct.
 	var config scripts.Config
 	err := yaml.Unmarshal([]byte(script.Configs), &config)
 	if err != nil {
 		log.WithError(err).Error("Failed to parse config YAML")
 	}
+	if config.Schedule == nil {
+		config.Schedule = &scripts.ScheduleConfig{}
+	}
+	setExecutionDefaults(&config)
 
 	return &runner{
 		cronScript: script,
@@ -210,7 +236,50 @@ func newRunner(script *cvmsgspb.CronScript, vzClient vizierpb.VizierServiceClien
 		signingKey: signingKey,
 		config:     &config,
 		scriptID:   id,
+		scheduler:  scheduler,
+		slots:      make(chan struct{}, config.Execution.MaxConcurrentRuns),
+		sinks:      newSinks(id, config.Sinks),
+	}
+}
+
+func setExecutionDefaults(config *scripts.Config) {
+	if config.Execution == nil {
+		config.Execution = &scripts.ExecutionConfig{}
+	}
+	execution := config.Execution
+	if execution.MaxConcurrentRuns <= 0 {
+		execution.MaxConcurrentRuns = 1
 	}
+	switch execution.OverlapPolicy {
+	case scripts.OverlapPolicySkip, scripts.OverlapPolicyQueue:
+	case "":
+		execution.OverlapPolicy = scripts.OverlapPolicySkip
+	default:
+		log.WithField("overlap_policy", execution.OverlapPolicy).Error("Unknown overlap policy, skipping overlapping runs")
+		execution.OverlapPolicy = scripts.OverlapPolicySkip
+	}
+
+	execution.Retry = setRetryDefaults(execution.Retry)
+}
+
+// setRetryDefaults fills in the unset fields of retry, which may be nil.
+func setRetryDefaults(retry *scripts.RetryConfig) *scripts.RetryConfig {
+	if retry == nil {
+		retry = &scripts.RetryConfig{}
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if retry.InitialBackoff <= 0 {
+		retry.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if retry.MaxBackoff < retry.InitialBackoff {
+		retry.MaxBackoff = retry.InitialBackoff
+	}
+	return retry
 }
 
 // VizierStatusToStatus converts the Vizier status to the internal storable version statuspb.Status
@@ -245,14 +314,91 @@ func VizierStatusToStatus(s *vizierpb.Status) (*statuspb.Status, error) {
 	}, nil
 }
 
-func (r *runner) runScript(scriptPeriod time.Duration) {
+// serviceContext returns a context that is authorized to make requests to the other vizier services.
+func (r *runner) serviceContext() (context.Context, context.CancelFunc) {
 	claims := svcutils.GenerateJWTForService("query_broker", "vizier")
 	token, _ := svcutils.SignJWTClaims(claims, r.signingKey)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 	ctx = metadata.AppendToOutgoingContext(ctx, "authorization",
 		fmt.Sprintf("bearer %s", token))
+	return ctx, cancel
+}
+
+// runOutcome is the outcome of the run of a window.
+type runOutcome int
+
+const (
+	runSucceeded runOutcome = iota
+	// runFailed is a failure that may go away if the window is run again.
+	runFailed
+	// runFailedPermanently is a failure that running the window again won't fix, ie. a compile error or an invalid
+	// argument.
+	runFailedPermanently
+)
+
+// failureOutcome classifies a failed run by its status code.
+func failureOutcome(code statuspb.Code) runOutcome {
+	if code == statuspb.INVALID_ARGUMENT {
+		return runFailedPermanently
+	}
+	return runFailed
+}
+
+// runScript runs the script over the [windowStart, windowEnd) time window and records the result. Runs that fail
+// because vizier is unavailable are retried according to the retry config of the script.
+func (r *runner) runScript(windowStart time.Time, windowEnd time.Time) runOutcome {
+	// We set the time 1 second in the past to cover colletor latency and request latencies
+	// which can cause data overlaps or cause data to be missed.
+	startTime := windowStart.Add(-time.Second)
+	endTime := windowEnd.Add(-time.Second)
+
+	retry := r.config.Execution.Retry
+	backoff := retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := r.executeScript(startTime, endTime)
+		if err == nil {
+			if result == nil {
+				return runSucceeded
+			}
+			r.recordResult(startTime, result)
+			if result.GetError() != nil {
+				return failureOutcome(result.GetError().ErrCode)
+			}
+			return runSucceeded
+		}
+
+		grpcStatus, _ := status.FromError(err)
+		if grpcStatus.Code() != codes.Unavailable || attempt >= retry.MaxAttempts {
+			r.recordError(startTime, statusCode(grpcStatus.Code()), grpcStatus.Message())
+			return failureOutcome(statusCode(grpcStatus.Code()))
+		}
+		r.recordError(startTime, statusCode(grpcStatus.Code()),
+			fmt.Sprintf("attempt %d of %d failed, retrying in %s: %s", attempt, retry.MaxAttempts, backoff, grpcStatus.Message()))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-r.done:
+			timer.Stop()
+			return runFailed
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > retry.MaxBackoff {
+			backoff = retry.MaxBackoff
+		}
+	}
+}
+
+// executeScript runs the script once over the [startTime, endTime) time range. It returns the result to record, which
+// is nil if the script didn't produce any, or an error if the script couldn't be run.
+func (r *runner) executeScript(startTime time.Time, endTime time.Time) (*metadatapb.RecordExecutionResultRequest, error) {
+	ctx, cancel := r.serviceContext()
+	defer cancel()
+	if r.config.Execution.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.config.Execution.Timeout)
+		defer cancel()
+	}
 
 	var otelEndpoint *vizierpb.Configs_OTelEndpointConfig
 	if r.config != nil && r.config.OtelEndpointConfig != nil {
@@ -264,11 +410,6 @@ func (r *runner) runScript(scriptPeriod time.Duration) {
 		}
 	}
 
-	// We set the time 1 second in the past to cover colletor latency and request latencies
-	// which can cause data overlaps or cause data to be missed.
-	startTime := r.lastRun.Add(-time.Second)
-	endTime := startTime.Add(scriptPeriod)
-	r.lastRun = time.Now()
 	execScriptClient, err := r.vzClient.ExecuteScript(ctx, &vizierpb.ExecuteScriptRequest{
 		QueryStr: r.cronScript.Script,
 		Configs: &vizierpb.Configs{
@@ -281,78 +422,62 @@ func (r *runner) runScript(scriptPeriod time.Duration) {
 		QueryName: "cron_" + r.scriptID.String(),
 	})
 	if err != nil {
-		log.WithError(err).Error("Failed to execute cronscript")
-		return
+		return nil, r.executionError(ctx, err)
 	}
-	for {
-		resp, err := execScriptClient.Recv()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			grpcStatus, _ := status.FromError(err)
-
-			tsPb, err := types.TimestampProto(startTime)
-			if err != nil {
-				log.WithError(err).Error("Error while creating timestamp proto")
-			}
 
-			_, err = r.csClient.RecordExecutionResult(ctx, &metadatapb.RecordExecutionResultRequest{
-				ScriptID:  utils.ProtoFromUUID(r.scriptID),
-				Timestamp: tsPb,
+	results := newSinkRun(r.sinks, startTime)
+	// finish completes the export of the results to the sinks. A failed export fails the run.
+	finish := func(result *metadatapb.RecordExecutionResultRequest) *metadatapb.RecordExecutionResultRequest {
+		if err := results.finish(ctx); err != nil {
+			return &metadatapb.RecordExecutionResultRequest{
 				Result: &metadatapb.RecordExecutionResultRequest_Error{
 					Error: &statuspb.Status{
-						ErrCode: statuspb.Code(grpcStatus.Code()),
-						Msg:     grpcStatus.Message(),
+						ErrCode: statuspb.INTERNAL,
+						Msg:     err.Error(),
 					},
 				},
-			})
-			if err != nil {
-				grpcStatus, ok := status.FromError(err)
-				if !ok || grpcStatus.Code() != codes.Unavailable {
-					log.WithError(err).Error("Error while recording cron script execution error")
-				}
 			}
-			break
+		}
+		return result
+	}
+	for {
+		resp, err := execScriptClient.Recv()
+		if err == io.EOF {
+			return finish(nil), nil
+		}
+		if err != nil {
+			_ = results.finish(ctx)
+			return nil, r.executionError(ctx, err)
 		}
 
 		if vzStatus := resp.GetStatus(); vzStatus != nil {
-			tsPb, err := types.TimestampProto(startTime)
-			if err != nil {
-				log.WithError(err).Error("Error while creating timestamp proto")
+			_ = results.finish(ctx)
+			if codes.Code(vzStatus.Code) == codes.Unavailable {
+				return nil, status.Error(codes.Unavailable, vzStatus.Message)
 			}
 			st, err := VizierStatusToStatus(vzStatus)
 			if err != nil {
 				log.WithError(err).Error("Error converting status")
 			}
-
-			_, err = r.csClient.RecordExecutionResult(ctx, &metadatapb.RecordExecutionResultRequest{
-				ScriptID:  utils.ProtoFromUUID(r.scriptID),
-				Timestamp: tsPb,
+			return &metadatapb.RecordExecutionResultRequest{
 				Result: &metadatapb.RecordExecutionResultRequest_Error{
 					Error: st,
 				},
-			})
-			if err != nil {
-				grpcStatus, ok := status.FromError(err)
-				if !ok || grpcStatus.Code() != codes.Unavailable {
-					log.WithError(err).Error("Error while recording cron script execution error")
-				}
-			}
-			break
+			}, nil
+		}
+		if md := resp.GetMetaData(); md != nil {
+			results.handleMetadata(ctx, md)
+			continue
 		}
 		if data := resp.GetData(); data != nil {
-			tsPb, err := types.TimestampProto(startTime)
-			if err != nil {
-				log.WithError(err).Error("Error while creating timestamp proto")
+			if batch := data.GetBatch(); batch != nil {
+				results.handleBatch(ctx, batch)
 			}
 			stats := data.GetExecutionStats()
 			if stats == nil {
 				continue
 			}
-			_, err = r.csClient.RecordExecutionResult(ctx, &metadatapb.RecordExecutionResultRequest{
-				ScriptID:  utils.ProtoFromUUID(r.scriptID),
-				Timestamp: tsPb,
+			return finish(&metadatapb.RecordExecutionResultRequest{
 				Result: &metadatapb.RecordExecutionResultRequest_ExecutionStats{
 					ExecutionStats: &metadatapb.ExecutionStats{
 						ExecutionTimeNs:   stats.Timing.ExecutionTimeNs,
@@ -361,41 +486,306 @@ func (r *runner) runScript(scriptPeriod time.Duration) {
 						RecordsProcessed:  stats.RecordsProcessed,
 					},
 				},
-			})
-			if err != nil {
-				grpcStatus, ok := status.FromError(err)
-				if !ok || grpcStatus.Code() != codes.Unavailable {
-					log.WithError(err).Error("Error recording execution stats")
-				}
-			}
-			break
+			}), nil
+		}
+	}
+}
+
+// executionError converts errors caused by the run exceeding its deadline into a DeadlineExceeded error.
+func (r *runner) executionError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Errorf(codes.DeadlineExceeded, "timed out after %s", r.config.Execution.Timeout)
+	}
+	return err
+}
+
+// statusCode converts a gRPC code to the status code that is recorded for a run.
+func statusCode(code codes.Code) statuspb.Code {
+	if code == codes.Unavailable {
+		return statuspb.RESOURCE_UNAVAILABLE
+	}
+	return statuspb.Code(code)
+}
+
+func (r *runner) recordError(startTime time.Time, code statuspb.Code, msg string) {
+	r.recordResult(startTime, &metadatapb.RecordExecutionResultRequest{
+		Result: &metadatapb.RecordExecutionResultRequest_Error{
+			Error: &statuspb.Status{
+				ErrCode: code,
+				Msg:     msg,
+			},
+		},
+	})
+}
+
+// recordResult records the result of the run that started at startTime.
+func (r *runner) recordResult(startTime time.Time, req *metadatapb.RecordExecutionResultRequest) {
+	ctx, cancel := r.serviceContext()
+	defer cancel()
+
+	tsPb, err := types.TimestampProto(startTime)
+	if err != nil {
+		log.WithError(err).Error("Error while creating timestamp proto")
+	}
+	req.ScriptID = utils.ProtoFromUUID(r.scriptID)
+	req.Timestamp = tsPb
+	_, err = r.csClient.RecordExecutionResult(ctx, req)
+	if err != nil {
+		grpcStatus, ok := status.FromError(err)
+		if !ok || grpcStatus.Code() != codes.Unavailable {
+			log.WithError(err).Error("Error while recording cron script execution result")
 		}
 	}
 }
 
 func (r *runner) start() {
-	if r.cronScript.FrequencyS <= 0 {
+	sched, err := parseSchedule(r.cronScript, r.config.Schedule)
+	if err != nil {
+		log.WithError(err).WithField("script_id", r.scriptID).Error("Failed to parse cron script schedule")
 		return
 	}
-	scriptPeriod := time.Duration(r.cronScript.FrequencyS) * time.Second
-	ticker := time.NewTicker(scriptPeriod)
-	r.lastRun = time.Now()
-
-	go func() {
-		defer ticker.Stop()
-		for {
-			select {
-			case <-r.done:
-				return
-			case <-ticker.C:
-				r.runScript(scriptPeriod)
+	if sched == nil {
+		return
+	}
+
+	go r.run(sched)
+}
+
+// run runs the script at the end of every window of the schedule, until the runner is stopped.
+func (r *runner) run(sched cron.Schedule) {
+	windowStart := r.initialWindowStart(sched)
+	// attempts is the number of failed runs of the current window, which are only retried in catch up mode.
+	attempts := 0
+	for {
+		if r.stopped() {
+			return
+		}
+
+		now := time.Now()
+		windowEnd := sched.Next(windowStart)
+		if !r.config.Schedule.CatchUp && r.config.Execution.OverlapPolicy == scripts.OverlapPolicySkip {
+			// Skip the windows that were missed while waiting for a previous run to start.
+			for !windowEnd.IsZero() && !windowEnd.After(now) {
+				r.recordSkipped(windowStart, "waited too long for a previous run to start")
+				windowStart = windowEnd
+				windowEnd = sched.Next(windowStart)
+			}
+		}
+		if windowEnd.IsZero() {
+			log.WithField("script_id", r.scriptID).Warn("Cron script schedule has no upcoming runs")
+			return
+		}
+
+		// Windows that have already ended are replayed right away.
+		wait := windowEnd.Sub(now)
+		if wait > 0 {
+			wait += r.jitter()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ok, outcome := r.dispatch(windowStart, windowEnd)
+		if !ok {
+			return
+		}
+		if outcome != runSucceeded && r.stopped() {
+			// The run may have been interrupted by the stop, so the window must be run again after a restart.
+			return
+		}
+		logger := log.WithField("script_id", r.scriptID).WithField("window_start", windowStart)
+		switch outcome {
+		case runFailed:
+			attempts++
+			if attempts < r.config.Execution.Retry.MaxAttempts {
+				logger.Warn("Cron script run failed, retrying the window")
+				if !r.sleep(r.config.Execution.Retry.MaxBackoff) {
+					return
+				}
+				continue
 			}
+			logger.Error("Cron script run failed too many times, moving on to the next window")
+		case runFailedPermanently:
+			logger.Error("Cron script run failed with an error that retries won't fix, moving on to the next window")
 		}
-	}()
+		// The failed result of the window has been recorded, so the checkpoint can move past it.
+		if r.config.Schedule.CatchUp {
+			r.saveCheckpoint(windowEnd)
+		}
+		attempts = 0
+		windowStart = windowEnd
+	}
+}
+
+// stopped returns whether the runner has been stopped.
+func (r *runner) stopped() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d, and returns false if the runner was stopped in the meantime.
+func (r *runner) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-r.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// dispatch starts the run of the window once the concurrency limits of the script and the scheduler allow it. In catch
+// up mode, the run completes before dispatch returns so that windows are run and checkpointed in order. Returns false
+// if the runner was stopped before the run could start, and the outcome of the run, which is always runSucceeded
+// outside of catch up mode.
+func (r *runner) dispatch(windowStart time.Time, windowEnd time.Time) (bool, runOutcome) {
+	catchUp := r.config.Schedule.CatchUp
+	if catchUp || r.config.Execution.OverlapPolicy == scripts.OverlapPolicyQueue {
+		select {
+		case <-r.done:
+			return false, runSucceeded
+		case r.slots <- struct{}{}:
+		}
+	} else {
+		select {
+		case r.slots <- struct{}{}:
+		default:
+			r.recordSkipped(windowStart, "previous run is still in progress")
+			return true, runSucceeded
+		}
+	}
+	if !r.scheduler.acquire(r.done) {
+		<-r.slots
+		return false, runSucceeded
+	}
+	if !r.trackRun() {
+		r.scheduler.release()
+		<-r.slots
+		return false, runSucceeded
+	}
+
+	run := func() runOutcome {
+		defer func() {
+			r.scheduler.release()
+			<-r.slots
+			r.runs.Done()
+		}()
+		return r.runScript(windowStart, windowEnd)
+	}
+	if !catchUp {
+		go run()
+		return true, runSucceeded
+	}
+	return true, run()
+}
+
+// recordSkipped records that the run of the window starting at windowStart was skipped.
+func (r *runner) recordSkipped(windowStart time.Time, reason string) {
+	log.WithField("script_id", r.scriptID).WithField("reason", reason).Warn("Skipped cron script run")
+	// Runs are recorded with the start time of their query, which is 1 second before the start of the window.
+	r.recordError(windowStart.Add(-time.Second), statuspb.CANCELLED, "skipped: "+reason)
+}
+
+// initialWindowStart returns the start of the first window to run. In catch up mode, this is the checkpoint of the
+// last window that was run, so that the windows that were missed in between get replayed.
+func (r *runner) initialWindowStart(sched cron.Schedule) time.Time {
+	now := time.Now()
+	if !r.config.Schedule.CatchUp {
+		return now
+	}
+	checkpoint, err := r.loadCheckpoint()
+	if err != nil {
+		log.WithError(err).WithField("script_id", r.scriptID).Error("Failed to load cron script checkpoint, missed windows will not be replayed")
+		return now
+	}
+	if checkpoint.IsZero() {
+		return now
+	}
+	start := catchUpStart(sched, checkpoint, now, r.config.Schedule.MaxCatchUpWindows)
+	if start != checkpoint {
+		log.WithField("script_id", r.scriptID).
+			WithField("checkpoint", checkpoint).
+			WithField("start", start).
+			Warn("Too many missed cron script windows, skipping the oldest ones")
+	}
+	return start
+}
+
+func (r *runner) jitter() time.Duration {
+	if r.config.Schedule.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(r.config.Schedule.Jitter)))
+}
+
+// loadCheckpoint returns the end of the last window that was run, or the zero time if there is none.
+func (r *runner) loadCheckpoint() (time.Time, error) {
+	ctx, cancel := r.serviceContext()
+	defer cancel()
+
+	resp, err := r.csClient.GetScriptCheckpoint(ctx, &metadatapb.GetScriptCheckpointRequest{
+		ScriptID: utils.ProtoFromUUID(r.scriptID),
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if resp.Checkpoint == nil {
+		return time.Time{}, nil
+	}
+	return types.TimestampFromProto(resp.Checkpoint)
+}
+
+func (r *runner) saveCheckpoint(windowEnd time.Time) {
+	ctx, cancel := r.serviceContext()
+	defer cancel()
+
+	tsPb, err := types.TimestampProto(windowEnd)
+	if err != nil {
+		log.WithError(err).Error("Error while creating timestamp proto")
+		return
+	}
+	_, err = r.csClient.SetScriptCheckpoint(ctx, &metadatapb.SetScriptCheckpointRequest{
+		ScriptID:   utils.ProtoFromUUID(r.scriptID),
+		Checkpoint: tsPb,
+	})
+	if err != nil {
+		log.WithError(err).Error("Error while saving cron script checkpoint")
+	}
+}
+
+// trackRun registers a run that is about to start. Returns false if the runner is stopped.
+func (r *runner) trackRun() bool {
+	r.runsMu.Lock()
+	defer r.runsMu.Unlock()
+	select {
+	case <-r.done:
+		return false
+	default:
+	}
+	r.runs.Add(1)
+	return true
 }
 
 func (r *runner) stop() {
 	r.once.Do(func() {
+		r.runsMu.Lock()
 		close(r.done)
+		r.runsMu.Unlock()
+		// Runs that are in progress may still export to the sinks, so the sinks are closed once they complete.
+		go func() {
+			r.runs.Wait()
+			for _, s := range r.sinks {
+				s.sink.close()
+			}
+		}()
 	})
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/gogo/protobuf/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -493,7 +494,7 @@ func TestScriptRunner_StoreResults(t *testing.T) {
 
 			id := uuid.FromStringOrNil("223e4567-e89b-12d3-a456-426655440000")
 			fvs := &fakeVizierServiceClient{responses: test.execScriptResponses, err: test.err}
-			Runner := newRunner(script, fvs, "test", id, fcs)
+			Runner := newRunner(script, fvs, "test", id, fcs, nil)
 			Runner.start()
 
 			result := requireReceiveWithin(t, receivedResultRequestCh, 10*time.Second)
@@ -507,3 +508,413 @@ func TestScriptRunner_StoreResults(t *testing.T) {
 		})
 	}
 }
+
+func TestScriptRunner_CatchUp(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	tests := []struct {
+		name    string
+		configs string
+		catchUp bool
+	}{
+		{
+			name:    "replays missed windows from the checkpoint",
+			configs: "schedule: {catchUp: true}",
+			catchUp: true,
+		},
+		{
+			name:    "ignores the checkpoint without catch up",
+			configs: "otelEndpointConfig: {url: example.com}",
+			catchUp: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			requests := make(chan *vizierpb.ExecuteScriptRequest, 10)
+			mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+			mvs.EXPECT().
+				ExecuteScript(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+					requests <- req
+					return &fakeExecuteScriptClient{err: io.EOF}, nil
+				}).
+				AnyTimes()
+
+			checkpoint := time.Now().Add(-3500 * time.Millisecond)
+			checkpointPb, err := types.TimestampProto(checkpoint)
+			require.NoError(t, err)
+			fcs := &fakeCronStore{
+				scripts:     map[uuid.UUID]*cvmsgspb.CronScript{},
+				checkpoints: map[uuid.UUID]*types.Timestamp{id: checkpointPb},
+			}
+
+			script := &cvmsgspb.CronScript{
+				ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+				Script:     "px.display()",
+				Configs:    test.configs,
+				FrequencyS: 1,
+			}
+			r := newRunner(script, mvs, "test", id, fcs, nil)
+			r.start()
+			defer r.stop()
+
+			if !test.catchUp {
+				req := requireReceiveWithin(t, requests, 10*time.Second)
+				require.Greater(t, req.Configs.PluginConfig.StartTimeNs, checkpoint.UnixNano())
+				return
+			}
+
+			// The three windows that ended since the checkpoint are replayed back to back.
+			for i := 0; i < 3; i++ {
+				req := requireReceiveWithin(t, requests, 500*time.Millisecond)
+				start := checkpoint.Add(time.Duration(i-1) * time.Second)
+				require.Equal(t, start.UnixNano(), req.Configs.PluginConfig.StartTimeNs)
+				require.Equal(t, start.Add(time.Second).UnixNano(), req.Configs.PluginConfig.EndTimeNs)
+			}
+			expectedCheckpoint, err := types.TimestampProto(checkpoint.Add(3 * time.Second))
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				return expectedCheckpoint.Equal(fcs.Checkpoint(id))
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestScriptRunner_CatchUpRetriesFailedWindows(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	ctrl := gomock.NewController(t)
+	requests := make(chan *vizierpb.ExecuteScriptRequest, 10)
+	mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	gomock.InOrder(
+		mvs.EXPECT().
+			ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+				requests <- req
+				return &fakeExecuteScriptClient{
+					responses: []*vizierpb.ExecuteScriptResponse{
+						{Status: &vizierpb.Status{Code: int32(codes.Internal), Message: "failed"}},
+					},
+				}, nil
+			}),
+		mvs.EXPECT().
+			ExecuteScript(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+				requests <- req
+				return &fakeExecuteScriptClient{err: io.EOF}, nil
+			}).
+			AnyTimes(),
+	)
+
+	checkpoint := time.Now().Add(-2500 * time.Millisecond)
+	checkpointPb, err := types.TimestampProto(checkpoint)
+	require.NoError(t, err)
+	results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+	fcs := &fakeCronStore{
+		scripts:                 map[uuid.UUID]*cvmsgspb.CronScript{},
+		checkpoints:             map[uuid.UUID]*types.Timestamp{id: checkpointPb},
+		receivedResultRequestCh: results,
+	}
+
+	script := &cvmsgspb.CronScript{
+		ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+		Script:     "px.display()",
+		Configs:    "{schedule: {catchUp: true}, execution: {retry: {initialBackoff: 10ms, maxBackoff: 10ms}}}",
+		FrequencyS: 1,
+	}
+	r := newRunner(script, mvs, "test", id, fcs, nil)
+	r.start()
+	defer r.stop()
+
+	// The first window fails, so it is run again before the next window.
+	first := requireReceiveWithin(t, requests, 500*time.Millisecond)
+	result := requireReceiveWithin(t, results, 500*time.Millisecond)
+	require.Equal(t, "failed", result.GetError().Msg)
+	retried := requireReceiveWithin(t, requests, 500*time.Millisecond)
+	require.Equal(t, first.Configs.PluginConfig.StartTimeNs, retried.Configs.PluginConfig.StartTimeNs)
+	next := requireReceiveWithin(t, requests, 500*time.Millisecond)
+	require.Equal(t, retried.Configs.PluginConfig.EndTimeNs, next.Configs.PluginConfig.StartTimeNs)
+
+	expectedCheckpoint, err := types.TimestampProto(checkpoint.Add(2 * time.Second))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return expectedCheckpoint.Equal(fcs.Checkpoint(id))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestScriptRunner_CatchUpMovesPastFailedWindows(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	tests := []struct {
+		name     string
+		code     codes.Code
+		attempts int
+	}{
+		{
+			name:     "stops retrying after max attempts",
+			code:     codes.Internal,
+			attempts: 2,
+		},
+		{
+			name:     "does not retry invalid arguments",
+			code:     codes.InvalidArgument,
+			attempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			requests := make(chan *vizierpb.ExecuteScriptRequest, 10)
+			mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+			mvs.EXPECT().
+				ExecuteScript(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+					requests <- req
+					return &fakeExecuteScriptClient{
+						responses: []*vizierpb.ExecuteScriptResponse{
+							{Status: &vizierpb.Status{Code: int32(test.code), Message: "failed"}},
+						},
+					}, nil
+				}).
+				AnyTimes()
+
+			checkpoint := time.Now().Add(-2500 * time.Millisecond)
+			checkpointPb, err := types.TimestampProto(checkpoint)
+			require.NoError(t, err)
+			results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+			fcs := &fakeCronStore{
+				scripts:                 map[uuid.UUID]*cvmsgspb.CronScript{},
+				checkpoints:             map[uuid.UUID]*types.Timestamp{id: checkpointPb},
+				receivedResultRequestCh: results,
+			}
+
+			script := &cvmsgspb.CronScript{
+				ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+				Script:     "px.display()",
+				Configs:    "{schedule: {catchUp: true}, execution: {retry: {maxAttempts: 2, initialBackoff: 10ms, maxBackoff: 10ms}}}",
+				FrequencyS: 1,
+			}
+			r := newRunner(script, mvs, "test", id, fcs, nil)
+			r.start()
+			defer r.stop()
+
+			first := requireReceiveWithin(t, requests, 500*time.Millisecond)
+			for i := 0; i < test.attempts; i++ {
+				if i > 0 {
+					retried := requireReceiveWithin(t, requests, 500*time.Millisecond)
+					require.Equal(t, first.Configs.PluginConfig.StartTimeNs, retried.Configs.PluginConfig.StartTimeNs)
+				}
+				result := requireReceiveWithin(t, results, 500*time.Millisecond)
+				require.Equal(t, "failed", result.GetError().Msg)
+			}
+
+			// The failed window is given up on, so the next window is run and the checkpoint moves past it.
+			next := requireReceiveWithin(t, requests, 500*time.Millisecond)
+			require.Equal(t, first.Configs.PluginConfig.EndTimeNs, next.Configs.PluginConfig.StartTimeNs)
+			expectedCheckpoint, err := types.TimestampProto(time.Unix(0, first.Configs.PluginConfig.EndTimeNs).Add(time.Second))
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				cp := fcs.Checkpoint(id)
+				return cp != nil && cp.Compare(expectedCheckpoint) >= 0
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestScriptRunner_OverlappingRuns(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	tests := []struct {
+		name    string
+		configs string
+		queue   bool
+	}{
+		{
+			name:    "skips overlapping runs by default",
+			configs: "",
+			queue:   false,
+		},
+		{
+			name:    "queues overlapping runs",
+			configs: "execution: {overlapPolicy: queue}",
+			queue:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			release := make(chan struct{})
+			requests := make(chan *vizierpb.ExecuteScriptRequest, 10)
+			mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+			mvs.EXPECT().
+				ExecuteScript(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+					requests <- req
+					return &blockingExecuteScriptClient{ctx: ctx, release: release}, nil
+				}).
+				AnyTimes()
+
+			results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+			fcs := &fakeCronStore{scripts: map[uuid.UUID]*cvmsgspb.CronScript{}, receivedResultRequestCh: results}
+			script := &cvmsgspb.CronScript{
+				ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+				Script:     "px.display()",
+				Configs:    test.configs,
+				FrequencyS: 1,
+			}
+			r := newRunner(script, mvs, "test", id, fcs, nil)
+			r.start()
+			defer r.stop()
+
+			first := requireReceiveWithin(t, requests, 2*time.Second)
+			if !test.queue {
+				result := requireReceiveWithin(t, results, 2*time.Second)
+				require.Equal(t, statuspb.CANCELLED, result.GetError().ErrCode)
+				require.Equal(t, "skipped: previous run is still in progress", result.GetError().Msg)
+				requireNoReceive(t, requests, 100*time.Millisecond)
+				close(release)
+				return
+			}
+
+			requireNoReceive(t, results, 1500*time.Millisecond)
+			require.Len(t, requests, 0)
+			close(release)
+			second := requireReceiveWithin(t, requests, time.Second)
+			require.Equal(t, first.Configs.PluginConfig.EndTimeNs, second.Configs.PluginConfig.StartTimeNs)
+		})
+	}
+}
+
+func TestScriptRunner_Retries(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	tests := []struct {
+		name            string
+		configs         string
+		failures        int
+		expectedResults []*statuspb.Status
+	}{
+		{
+			name:     "retries when vizier is unavailable",
+			configs:  "execution: {retry: {initialBackoff: 10ms}}",
+			failures: 1,
+			expectedResults: []*statuspb.Status{
+				{
+					ErrCode: statuspb.RESOURCE_UNAVAILABLE,
+					Msg:     "attempt 1 of 3 failed, retrying in 10ms: not ready",
+				},
+				nil,
+			},
+		},
+		{
+			name:     "gives up after the max attempts",
+			configs:  "execution: {retry: {maxAttempts: 2, initialBackoff: 10ms}}",
+			failures: 2,
+			expectedResults: []*statuspb.Status{
+				{
+					ErrCode: statuspb.RESOURCE_UNAVAILABLE,
+					Msg:     "attempt 1 of 2 failed, retrying in 10ms: not ready",
+				},
+				{
+					ErrCode: statuspb.RESOURCE_UNAVAILABLE,
+					Msg:     "not ready",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+			mvs.EXPECT().
+				ExecuteScript(gomock.Any(), gomock.Any()).
+				Return(nil, status.Error(codes.Unavailable, "not ready")).
+				Times(test.failures)
+			mvs.EXPECT().
+				ExecuteScript(gomock.Any(), gomock.Any()).
+				Return(&fakeExecuteScriptClient{
+					responses: []*vizierpb.ExecuteScriptResponse{
+						{
+							Result: &vizierpb.ExecuteScriptResponse_Data{
+								Data: &vizierpb.QueryData{
+									ExecutionStats: &vizierpb.QueryExecutionStats{
+										Timing:           &vizierpb.QueryTimingInfo{},
+										RecordsProcessed: 1,
+									},
+								},
+							},
+						},
+					},
+				}, nil).
+				AnyTimes()
+
+			results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+			fcs := &fakeCronStore{scripts: map[uuid.UUID]*cvmsgspb.CronScript{}, receivedResultRequestCh: results}
+			script := &cvmsgspb.CronScript{
+				ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+				Script:     "px.display()",
+				Configs:    test.configs,
+				FrequencyS: 1,
+			}
+			r := newRunner(script, mvs, "test", id, fcs, nil)
+			r.start()
+			defer r.stop()
+
+			var timestamp *types.Timestamp
+			for _, expected := range test.expectedResults {
+				result := requireReceiveWithin(t, results, 2*time.Second)
+				require.Equal(t, expected, result.GetError())
+				if expected == nil {
+					require.Equal(t, int64(1), result.GetExecutionStats().RecordsProcessed)
+				}
+				// All attempts are recorded for the same run.
+				if timestamp != nil {
+					require.Equal(t, timestamp, result.Timestamp)
+				}
+				timestamp = result.Timestamp
+			}
+		})
+	}
+}
+
+func TestScriptRunner_Timeout(t *testing.T) {
+	const scriptID = "223e4567-e89b-12d3-a456-426655440000"
+	id := uuid.FromStringOrNil(scriptID)
+
+	ctrl := gomock.NewController(t)
+	mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	mvs.EXPECT().
+		ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			return &blockingExecuteScriptClient{ctx: ctx}, nil
+		}).
+		AnyTimes()
+
+	results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+	fcs := &fakeCronStore{scripts: map[uuid.UUID]*cvmsgspb.CronScript{}, receivedResultRequestCh: results}
+	script := &cvmsgspb.CronScript{
+		ID:         utils.ProtoFromUUIDStrOrNil(scriptID),
+		Script:     "px.display()",
+		Configs:    "execution: {timeout: 100ms}",
+		FrequencyS: 1,
+	}
+	r := newRunner(script, mvs, "test", id, fcs, nil)
+	r.start()
+	defer r.stop()
+
+	result := requireReceiveWithin(t, results, 2*time.Second)
+	require.Equal(t, &statuspb.Status{
+		ErrCode: statuspb.DEADLINE_EXCEEDED,
+		Msg:     "timed out after 100ms",
+	}, result.GetError())
+}
@@ -0,0 +1,467 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gofrs/uuid"
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/formatters"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	"px.dev/pixie/src/shared/scripts"
+)
+
+const (
+	defaultSinkBatchSize = 1000
+	sinkHTTPTimeout      = 30 * time.Second
+	kafkaContentType     = "application/vnd.kafka.json.v2+json"
+)
+
+// A sink exports the result tables of the runs of a cron script.
+type sink interface {
+	// tableHandler returns the handler that exports a result table of the run that started at startTime.
+	tableHandler(md types.TableMetadata, startTime time.Time) (pxapi.TableRecordHandler, error)
+	// close releases the resources held by the sink.
+	close()
+}
+
+// configuredSink is a sink along with the config it was created from.
+type configuredSink struct {
+	config *scripts.SinkConfig
+	tables map[string]bool
+	sink   sink
+}
+
+func (s *configuredSink) acceptsTable(name string) bool {
+	return len(s.tables) == 0 || s.tables[name]
+}
+
+// newSinks creates the sinks of a script. Sinks with an invalid config are skipped.
+func newSinks(scriptID uuid.UUID, configs []*scripts.SinkConfig) []*configuredSink {
+	var sinks []*configuredSink
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+		s, err := newSink(scriptID, config)
+		if err != nil {
+			log.WithError(err).WithField("script_id", scriptID).WithField("sink", config.Type).Error("Invalid cron script sink")
+			continue
+		}
+		tables := map[string]bool{}
+		for _, table := range config.Tables {
+			tables[table] = true
+		}
+		sinks = append(sinks, &configuredSink{config: config, tables: tables, sink: s})
+	}
+	return sinks
+}
+
+func newSink(scriptID uuid.UUID, config *scripts.SinkConfig) (sink, error) {
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultSinkBatchSize
+	}
+	retry := setRetryDefaults(config.Retry)
+	newBatchSink := func(send func(ctx context.Context, batch *resultBatch) error) *batchSink {
+		return &batchSink{scriptID: scriptID, batchSize: batchSize, retry: retry, send: send}
+	}
+	client := &http.Client{Timeout: sinkHTTPTimeout}
+
+	switch config.Type {
+	case scripts.SinkTypeWebhook:
+		c := config.Webhook
+		if c == nil || c.URL == "" {
+			return nil, errors.New("webhook sink requires a url")
+		}
+		return newBatchSink(func(ctx context.Context, batch *resultBatch) error {
+			body, err := json.Marshal(batch)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			return postJSON(ctx, client, c.URL, "application/json", c.Headers, body)
+		}), nil
+	case scripts.SinkTypeKafka:
+		c := config.Kafka
+		if c == nil || c.URL == "" || c.Topic == "" {
+			return nil, errors.New("kafka sink requires a url and a topic")
+		}
+		topicURL, err := url.JoinPath(c.URL, "topics", c.Topic)
+		if err != nil {
+			return nil, err
+		}
+		return newBatchSink(func(ctx context.Context, batch *resultBatch) error {
+			records := make([]kafkaRecord, len(batch.Rows))
+			for i, row := range batch.Rows {
+				records[i] = kafkaRecord{Key: batch.Table, Value: row}
+			}
+			body, err := json.Marshal(&kafkaRecords{Records: records})
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			return postJSON(ctx, client, topicURL, kafkaContentType, c.Headers, body)
+		}), nil
+	case scripts.SinkTypeNATS:
+		c := config.NATS
+		if c == nil || c.URL == "" || c.Subject == "" {
+			return nil, errors.New("nats sink requires a url and a subject")
+		}
+		publisher := &jetStreamPublisher{url: c.URL, subject: c.Subject}
+		s := newBatchSink(publisher.publish)
+		s.closeFn = publisher.close
+		return s, nil
+	case scripts.SinkTypeFile:
+		c := config.File
+		if c == nil || c.Dir == "" {
+			return nil, errors.New("file sink requires a dir")
+		}
+		switch c.Format {
+		case "", scripts.FileFormatNDJSON, scripts.FileFormatParquet:
+		default:
+			return nil, fmt.Errorf("unknown file format %q", c.Format)
+		}
+		return &fileSink{dir: filepath.Join(c.Dir, scriptID.String()), format: c.Format}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", config.Type)
+	}
+}
+
+// resultBatch is a batch of rows of a result table, as sent by the webhook and nats sinks.
+type resultBatch struct {
+	ScriptID  string            `json:"script_id"`
+	Table     string            `json:"table"`
+	StartTime time.Time         `json:"start_time"`
+	Rows      []json.RawMessage `json:"rows"`
+}
+
+// kafkaRecords is the body of a produce request of the Kafka REST proxy v2 API.
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// postJSON posts the body to url. Client errors other than 429 are not retried.
+func postJSON(ctx context.Context, client *http.Client, url string, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s responded with %s", url, resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+	return err
+}
+
+// jetStreamPublisher publishes to a JetStream subject, connecting to the server on first use.
+type jetStreamPublisher struct {
+	url     string
+	subject string
+
+	mu sync.Mutex
+	nc *nats.Conn
+	js nats.JetStreamContext
+}
+
+func (p *jetStreamPublisher) publish(ctx context.Context, batch *resultBatch) error {
+	p.mu.Lock()
+	if p.js == nil {
+		nc, err := nats.Connect(p.url)
+		if err != nil {
+			p.mu.Unlock()
+			return err
+		}
+		js, err := nc.JetStream()
+		if err != nil {
+			nc.Close()
+			p.mu.Unlock()
+			return err
+		}
+		p.nc, p.js = nc, js
+	}
+	js := p.js
+	p.mu.Unlock()
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = js.Publish(p.subject, data, nats.Context(ctx))
+	return err
+}
+
+func (p *jetStreamPublisher) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.nc != nil {
+		p.nc.Close()
+	}
+}
+
+// batchSink sends the rows of each result table as batches of JSON objects, retrying batches that fail to send.
+type batchSink struct {
+	scriptID  uuid.UUID
+	batchSize int
+	retry     *scripts.RetryConfig
+	send      func(ctx context.Context, batch *resultBatch) error
+	closeFn   func()
+}
+
+func (s *batchSink) tableHandler(md types.TableMetadata, startTime time.Time) (pxapi.TableRecordHandler, error) {
+	buf := &bytes.Buffer{}
+	formatter, err := formatters.NewJSONFormatter(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &batchHandler{sink: s, startTime: startTime, formatter: formatter, buf: buf}, nil
+}
+
+func (s *batchSink) sendWithRetry(ctx context.Context, batch *resultBatch) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = s.retry.InitialBackoff
+	bo.MaxInterval = s.retry.MaxBackoff
+	bo.MaxElapsedTime = 0
+	return backoff.Retry(func() error {
+		return s.send(ctx, batch)
+	}, backoff.WithContext(backoff.WithMaxRetries(bo, uint64(s.retry.MaxAttempts-1)), ctx))
+}
+
+func (s *batchSink) close() {
+	if s.closeFn != nil {
+		s.closeFn()
+	}
+}
+
+// batchHandler collects the rows of a table as JSON objects and sends them once a batch is full.
+type batchHandler struct {
+	sink      *batchSink
+	startTime time.Time
+	table     string
+	formatter *formatters.JSONFormatter
+	// buf holds the rows of the current batch, one JSON object per line.
+	buf  *bytes.Buffer
+	rows int
+}
+
+// HandleInit is called when the table metadata is available.
+func (h *batchHandler) HandleInit(ctx context.Context, md types.TableMetadata) error {
+	h.table = md.Name
+	return h.formatter.HandleInit(ctx, md)
+}
+
+// HandleRecord is called for each record of the table.
+func (h *batchHandler) HandleRecord(ctx context.Context, record *types.Record) error {
+	if err := h.formatter.HandleRecord(ctx, record); err != nil {
+		return err
+	}
+	h.rows++
+	if h.rows < h.sink.batchSize {
+		return nil
+	}
+	return h.flush(ctx)
+}
+
+// HandleDone is called when all data has been streamed.
+func (h *batchHandler) HandleDone(ctx context.Context) error {
+	if h.rows == 0 {
+		return nil
+	}
+	return h.flush(ctx)
+}
+
+func (h *batchHandler) flush(ctx context.Context) error {
+	lines := bytes.Split(bytes.TrimSuffix(h.buf.Bytes(), []byte("\n")), []byte("\n"))
+	rows := make([]json.RawMessage, len(lines))
+	for i, line := range lines {
+		rows[i] = line
+	}
+	err := h.sink.sendWithRetry(ctx, &resultBatch{
+		ScriptID:  h.sink.scriptID.String(),
+		Table:     h.table,
+		StartTime: h.startTime,
+		Rows:      rows,
+	})
+	h.buf.Reset()
+	h.rows = 0
+	return err
+}
+
+// fileSink writes each result table of a run to a file in "<dir>/<run start time>/".
+type fileSink struct {
+	dir    string
+	format scripts.FileFormat
+}
+
+func (s *fileSink) tableHandler(md types.TableMetadata, startTime time.Time) (pxapi.TableRecordHandler, error) {
+	dir := filepath.Join(s.dir, startTime.UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if s.format == scripts.FileFormatParquet {
+		return formatters.ParquetFileHandlerFunc(dir)(md)
+	}
+	return formatters.NDJSONFileHandlerFunc(dir)(md)
+}
+
+func (s *fileSink) close() {}
+
+// sinkRun routes the result tables of a run to the sinks of the script. A sink that fails to handle a table doesn't
+// receive the rest of the table, and the first failure is reported by finish.
+type sinkRun struct {
+	sinks     []*configuredSink
+	startTime time.Time
+	tables    map[string]*sinkTable
+	err       error
+}
+
+type sinkTable struct {
+	md       types.TableMetadata
+	handlers []*sinkTableHandler
+}
+
+type sinkTableHandler struct {
+	sinkType scripts.SinkType
+	handler  pxapi.TableRecordHandler
+	failed   bool
+}
+
+func newSinkRun(sinks []*configuredSink, startTime time.Time) *sinkRun {
+	return &sinkRun{
+		sinks:     sinks,
+		startTime: startTime,
+		tables:    map[string]*sinkTable{},
+	}
+}
+
+func (r *sinkRun) fail(h *sinkTableHandler, err error) {
+	h.failed = true
+	if r.err == nil {
+		r.err = fmt.Errorf("failed to send results to %s sink: %w", h.sinkType, err)
+	}
+}
+
+func (r *sinkRun) handleMetadata(ctx context.Context, qmd *vizierpb.QueryMetadata) {
+	if len(r.sinks) == 0 {
+		return
+	}
+	table := &sinkTable{md: types.NewTableMetadata(qmd.Name, qmd.Relation)}
+	for _, s := range r.sinks {
+		if !s.acceptsTable(qmd.Name) {
+			continue
+		}
+		h := &sinkTableHandler{sinkType: s.config.Type}
+		table.handlers = append(table.handlers, h)
+		handler, err := s.sink.tableHandler(table.md, r.startTime)
+		if err != nil {
+			r.fail(h, err)
+			continue
+		}
+		h.handler = handler
+		if err := handler.HandleInit(ctx, table.md); err != nil {
+			r.fail(h, err)
+		}
+	}
+	if len(table.handlers) > 0 {
+		r.tables[qmd.ID] = table
+	}
+}
+
+func (r *sinkRun) handleBatch(ctx context.Context, b *vizierpb.RowBatchData) {
+	table, ok := r.tables[b.TableID]
+	if !ok {
+		return
+	}
+	batch, err := types.NewRowBatch(&table.md, b.NumRows, b.Cols)
+	if err != nil {
+		for _, h := range table.handlers {
+			if !h.failed {
+				r.fail(h, err)
+			}
+		}
+		return
+	}
+	for _, h := range table.handlers {
+		if h.failed {
+			continue
+		}
+		if err := pxapi.DispatchBatch(ctx, h.handler, batch); err != nil {
+			r.fail(h, err)
+		}
+	}
+	if b.Eos {
+		r.done(ctx, table)
+		delete(r.tables, b.TableID)
+	}
+}
+
+func (r *sinkRun) done(ctx context.Context, table *sinkTable) {
+	for _, h := range table.handlers {
+		if h.handler == nil {
+			continue
+		}
+		// Done is called on failed handlers too, so that they release their files.
+		if err := h.handler.HandleDone(ctx); err != nil && !h.failed {
+			r.fail(h, err)
+		}
+	}
+}
+
+// finish completes the tables that are still open and returns the first error of the sinks.
+func (r *sinkRun) finish(ctx context.Context) error {
+	for id, table := range r.tables {
+		r.done(ctx, table)
+		delete(r.tables, id)
+	}
+	return r.err
+}
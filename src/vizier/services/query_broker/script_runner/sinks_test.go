@@ -0,0 +1,376 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scriptrunner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"px.dev/pixie/src/api/go/pxapi"
+	"px.dev/pixie/src/api/go/pxapi/types"
+	"px.dev/pixie/src/api/proto/vizierpb"
+	mock_vizierpb "px.dev/pixie/src/api/proto/vizierpb/mock"
+	"px.dev/pixie/src/common/base/statuspb"
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/shared/scripts"
+	"px.dev/pixie/src/utils"
+	"px.dev/pixie/src/utils/testingutils"
+	"px.dev/pixie/src/vizier/services/metadata/metadatapb"
+)
+
+const sinkScriptID = "223e4567-e89b-12d3-a456-426655440000"
+
+// sinkTestResponses returns the responses of a query that outputs the rows (1, "a"), (2, "b"), (3, "c") to the table
+// "output", in two batches.
+func sinkTestResponses() []*vizierpb.ExecuteScriptResponse {
+	batch := func(ints []int64, strs []string, eos bool) *vizierpb.ExecuteScriptResponse {
+		strData := make([][]byte, len(strs))
+		for i, s := range strs {
+			strData[i] = []byte(s)
+		}
+		return &vizierpb.ExecuteScriptResponse{
+			Result: &vizierpb.ExecuteScriptResponse_Data{
+				Data: &vizierpb.QueryData{
+					Batch: &vizierpb.RowBatchData{
+						TableID: "output_id",
+						NumRows: int64(len(ints)),
+						Eos:     eos,
+						Cols: []*vizierpb.Column{
+							{ColData: &vizierpb.Column_Int64Data{Int64Data: &vizierpb.Int64Column{Data: ints}}},
+							{ColData: &vizierpb.Column_StringData{StringData: &vizierpb.StringColumn{Data: strData}}},
+						},
+					},
+				},
+			},
+		}
+	}
+	return []*vizierpb.ExecuteScriptResponse{
+		{
+			Result: &vizierpb.ExecuteScriptResponse_MetaData{
+				MetaData: &vizierpb.QueryMetadata{
+					Name: "output",
+					ID:   "output_id",
+					Relation: &vizierpb.Relation{
+						Columns: []*vizierpb.Relation_ColumnInfo{
+							{ColumnName: "count", ColumnType: vizierpb.INT64},
+							{ColumnName: "name", ColumnType: vizierpb.STRING},
+						},
+					},
+				},
+			},
+		},
+		batch([]int64{1, 2}, []string{"a", "b"}, false),
+		batch([]int64{3}, []string{"c"}, true),
+		{
+			Result: &vizierpb.ExecuteScriptResponse_Data{
+				Data: &vizierpb.QueryData{
+					ExecutionStats: &vizierpb.QueryExecutionStats{
+						Timing:           &vizierpb.QueryTimingInfo{},
+						RecordsProcessed: 3,
+					},
+				},
+			},
+		},
+	}
+}
+
+// runSinks sends the responses of sinkTestResponses to sinks created from configs, and returns the error of the run.
+func runSinks(t *testing.T, startTime time.Time, configs ...*scripts.SinkConfig) error {
+	sinks := newSinks(uuid.FromStringOrNil(sinkScriptID), configs)
+	require.Len(t, sinks, len(configs))
+	defer func() {
+		for _, s := range sinks {
+			s.sink.close()
+		}
+	}()
+
+	ctx := context.Background()
+	run := newSinkRun(sinks, startTime)
+	for _, resp := range sinkTestResponses() {
+		if md := resp.GetMetaData(); md != nil {
+			run.handleMetadata(ctx, md)
+		}
+		if batch := resp.GetData().GetBatch(); batch != nil {
+			run.handleBatch(ctx, batch)
+		}
+	}
+	return run.finish(ctx)
+}
+
+var expectedSinkRows = []string{
+	`{"_tableName_":"output","count":"1","name":"a"}`,
+	`{"_tableName_":"output","count":"2","name":"b"}`,
+	`{"_tableName_":"output","count":"3","name":"c"}`,
+}
+
+func rawRows(rows ...string) []json.RawMessage {
+	raw := make([]json.RawMessage, len(rows))
+	for i, row := range rows {
+		raw[i] = json.RawMessage(row)
+	}
+	return raw
+}
+
+// requestRecorder is a stand-in HTTP server that records the requests it receives, and fails the first failures of them.
+type requestRecorder struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rr *requestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if rr.failures > 0 {
+		rr.failures--
+		w.WriteHeader(rr.status)
+		return
+	}
+	rr.requests = append(rr.requests, r)
+	rr.bodies = append(rr.bodies, body)
+}
+
+func TestSinks_Webhook(t *testing.T) {
+	recorder := &requestRecorder{failures: 1, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	startTime := time.Unix(1700000000, 0).UTC()
+	err := runSinks(t, startTime, &scripts.SinkConfig{
+		Type:      scripts.SinkTypeWebhook,
+		BatchSize: 2,
+		Retry:     &scripts.RetryConfig{InitialBackoff: time.Millisecond},
+		Webhook: &scripts.WebhookSinkConfig{
+			URL:     server.URL + "/hook",
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.requests, 2)
+	for _, req := range recorder.requests {
+		require.Equal(t, "/hook", req.URL.Path)
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	}
+	var batches []resultBatch
+	for _, body := range recorder.bodies {
+		var batch resultBatch
+		require.NoError(t, json.Unmarshal(body, &batch))
+		batches = append(batches, batch)
+	}
+	require.Equal(t, []resultBatch{
+		{ScriptID: sinkScriptID, Table: "output", StartTime: startTime, Rows: rawRows(expectedSinkRows[:2]...)},
+		{ScriptID: sinkScriptID, Table: "output", StartTime: startTime, Rows: rawRows(expectedSinkRows[2])},
+	}, batches)
+}
+
+func TestSinks_WebhookClientError(t *testing.T) {
+	recorder := &requestRecorder{failures: 1, status: http.StatusBadRequest}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	err := runSinks(t, time.Now(), &scripts.SinkConfig{
+		Type:    scripts.SinkTypeWebhook,
+		Retry:   &scripts.RetryConfig{InitialBackoff: time.Millisecond},
+		Webhook: &scripts.WebhookSinkConfig{URL: server.URL},
+	})
+	require.EqualError(t, err, "failed to send results to webhook sink: "+server.URL+" responded with 400 Bad Request")
+	// Client errors are not retried.
+	require.Len(t, recorder.requests, 0)
+}
+
+func TestSinks_Kafka(t *testing.T) {
+	recorder := &requestRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	err := runSinks(t, time.Now(), &scripts.SinkConfig{
+		Type:   scripts.SinkTypeKafka,
+		Tables: []string{"output"},
+		Kafka:  &scripts.KafkaSinkConfig{URL: server.URL, Topic: "cron"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.requests, 1)
+	require.Equal(t, "/topics/cron", recorder.requests[0].URL.Path)
+	require.Equal(t, kafkaContentType, recorder.requests[0].Header.Get("Content-Type"))
+	var records kafkaRecords
+	require.NoError(t, json.Unmarshal(recorder.bodies[0], &records))
+	require.Equal(t, kafkaRecords{
+		Records: []kafkaRecord{
+			{Key: "output", Value: json.RawMessage(expectedSinkRows[0])},
+			{Key: "output", Value: json.RawMessage(expectedSinkRows[1])},
+			{Key: "output", Value: json.RawMessage(expectedSinkRows[2])},
+		},
+	}, records)
+}
+
+func TestSinks_NATS(t *testing.T) {
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "cron", Subjects: []string{"cron.results"}})
+	require.NoError(t, err)
+
+	err = runSinks(t, time.Now(), &scripts.SinkConfig{
+		Type: scripts.SinkTypeNATS,
+		NATS: &scripts.NATSSinkConfig{URL: nc.ConnectedUrl(), Subject: "cron.results"},
+	})
+	require.NoError(t, err)
+
+	sub, err := js.SubscribeSync("cron.results")
+	require.NoError(t, err)
+	msg, err := sub.NextMsg(time.Second)
+	require.NoError(t, err)
+	var batch resultBatch
+	require.NoError(t, json.Unmarshal(msg.Data, &batch))
+	require.Equal(t, "output", batch.Table)
+	require.Equal(t, rawRows(expectedSinkRows...), batch.Rows)
+}
+
+func TestSinks_File(t *testing.T) {
+	dir := t.TempDir()
+	startTime := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	err := runSinks(t, startTime,
+		&scripts.SinkConfig{
+			Type: scripts.SinkTypeFile,
+			File: &scripts.FileSinkConfig{Dir: filepath.Join(dir, "ndjson")},
+		},
+		&scripts.SinkConfig{
+			Type: scripts.SinkTypeFile,
+			File: &scripts.FileSinkConfig{Dir: filepath.Join(dir, "parquet"), Format: scripts.FileFormatParquet},
+		},
+	)
+	require.NoError(t, err)
+
+	ndjson, err := os.ReadFile(filepath.Join(dir, "ndjson", sinkScriptID, "20231114T221320Z", "output.00000.ndjson"))
+	require.NoError(t, err)
+	require.Equal(t, expectedSinkRows[0]+"\n"+expectedSinkRows[1]+"\n"+expectedSinkRows[2]+"\n", string(ndjson))
+
+	info, err := os.Stat(filepath.Join(dir, "parquet", sinkScriptID, "20231114T221320Z", "output.parquet"))
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+}
+
+func TestSinks_InvalidConfigs(t *testing.T) {
+	sinks := newSinks(uuid.FromStringOrNil(sinkScriptID), []*scripts.SinkConfig{
+		{Type: "unknown"},
+		{Type: scripts.SinkTypeWebhook},
+		{Type: scripts.SinkTypeKafka, Kafka: &scripts.KafkaSinkConfig{URL: "http://localhost"}},
+		{Type: scripts.SinkTypeFile, File: &scripts.FileSinkConfig{Dir: "/tmp", Format: "csv"}},
+	})
+	require.Len(t, sinks, 0)
+}
+
+func TestScriptRunner_Sinks(t *testing.T) {
+	recorder := &requestRecorder{failures: 1, status: http.StatusBadRequest}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+	fcs := &fakeCronStore{scripts: map[uuid.UUID]*cvmsgspb.CronScript{}, receivedResultRequestCh: results}
+	fvs := &fakeVizierServiceClient{responses: sinkTestResponses()}
+	script := &cvmsgspb.CronScript{
+		ID:         utils.ProtoFromUUIDStrOrNil(sinkScriptID),
+		Script:     "px.display()",
+		Configs:    "sinks: [{type: webhook, webhook: {url: " + server.URL + "}}]",
+		FrequencyS: 1,
+	}
+	r := newRunner(script, fvs, "test", uuid.FromStringOrNil(sinkScriptID), fcs, nil)
+	r.start()
+	defer r.stop()
+
+	// The first run fails to export its results, which fails the run.
+	result := requireReceiveWithin(t, results, 2*time.Second)
+	require.Equal(t, &statuspb.Status{
+		ErrCode: statuspb.INTERNAL,
+		Msg:     "failed to send results to webhook sink: " + server.URL + " responded with 400 Bad Request",
+	}, result.GetError())
+
+	result = requireReceiveWithin(t, results, 2*time.Second)
+	require.Equal(t, int64(3), result.GetExecutionStats().RecordsProcessed)
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.bodies, 1)
+}
+
+// closeRecordingSink is a sink without tables that records when it is closed.
+type closeRecordingSink struct {
+	closed chan struct{}
+}
+
+func (s *closeRecordingSink) tableHandler(md types.TableMetadata, startTime time.Time) (pxapi.TableRecordHandler, error) {
+	return nil, errors.New("no tables expected")
+}
+
+func (s *closeRecordingSink) close() {
+	close(s.closed)
+}
+
+func TestScriptRunner_StopWaitsForRunsBeforeClosingSinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	mvs := mock_vizierpb.NewMockVizierServiceClient(ctrl)
+	mvs.EXPECT().
+		ExecuteScript(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *vizierpb.ExecuteScriptRequest, _ ...grpc.CallOption) (vizierpb.VizierService_ExecuteScriptClient, error) {
+			started <- struct{}{}
+			return &blockingExecuteScriptClient{ctx: ctx, release: release}, nil
+		})
+
+	results := make(chan *metadatapb.RecordExecutionResultRequest, 10)
+	fcs := &fakeCronStore{scripts: map[uuid.UUID]*cvmsgspb.CronScript{}, receivedResultRequestCh: results}
+	script := &cvmsgspb.CronScript{
+		ID:         utils.ProtoFromUUIDStrOrNil(sinkScriptID),
+		Script:     "px.display()",
+		FrequencyS: 1,
+	}
+	r := newRunner(script, mvs, "test", uuid.FromStringOrNil(sinkScriptID), fcs, nil)
+	s := &closeRecordingSink{closed: make(chan struct{})}
+	r.sinks = []*configuredSink{{config: &scripts.SinkConfig{Type: scripts.SinkTypeWebhook}, sink: s}}
+	r.start()
+
+	requireReceiveWithin(t, started, 2*time.Second)
+	r.stop()
+	// The run is still in progress, so the sink stays open.
+	requireNoReceive(t, s.closed, 100*time.Millisecond)
+	close(release)
+	requireReceiveWithin(t, s.closed, time.Second)
+}
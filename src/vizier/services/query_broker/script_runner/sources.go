@@ -31,13 +31,16 @@ import (
 const (
 	CloudSourceName     = "cloud"
 	ConfigMapSourceName = "configmaps"
+	DirectorySourceName = "directory"
+	GitSourceName       = "git"
 )
 
 // DefaultSources is a list of sources enabled by default
 var DefaultSources = []string{CloudSourceName}
 
-// Sources initializes multiple sources based on the set of sourceNames provided.
-func Sources(nc *nats.Conn, csClient metadatapb.CronScriptStoreServiceClient, signingKey string, namespace string, sourceNames []string) []Source {
+// Sources initializes multiple sources based on the set of sourceNames provided. The directory source reads scripts from
+// scriptDir, and the git source from the repository in gitConfig.
+func Sources(nc *nats.Conn, csClient metadatapb.CronScriptStoreServiceClient, signingKey string, namespace string, sourceNames []string, scriptDir string, gitConfig GitSourceConfig) []Source {
 	var sources []Source
 	for _, selectedName := range sourceNames {
 		switch selectedName {
@@ -50,6 +53,13 @@ func Sources(nc *nats.Conn, csClient metadatapb.CronScriptStoreServiceClient, si
 			}
 			client := k8s.GetClientset(kubeConfig)
 			sources = append(sources, NewConfigMapSource(client, namespace))
+		case DirectorySourceName:
+			sources = append(sources, NewDirectorySource(scriptDir))
+		case GitSourceName:
+			if gitConfig.URL == "" {
+				log.Fatal("A git repository URL is required for the git cron script source")
+			}
+			sources = append(sources, NewGitSource(gitConfig))
 		default:
 			log.Errorf(`Unknown source "%s"`, selectedName)
 		}
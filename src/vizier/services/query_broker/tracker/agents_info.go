@@ -41,15 +41,19 @@ type AgentsInfo interface {
 	ClearPendingState()
 	UpdateAgentsInfo(update *metadatapb.AgentUpdatesResponse) error
 	DistributedState() distributedpb.DistributedState
+	// AgentHostnames returns the hostname of each agent in the current distributed state.
+	AgentHostnames() map[uuid.UUID]string
 }
 
 // AgentsInfoImpl implements AgentsInfo to track information about the distributed state of the system.
 type AgentsInfoImpl struct {
-	ds distributedpb.DistributedState
-	// Controls access to ds.
+	ds        distributedpb.DistributedState
+	hostnames map[uuid.UUID]string
+	// Controls access to ds and hostnames.
 	dsMutex sync.Mutex
 
-	pendingDs *distributedpb.DistributedState
+	pendingDs        *distributedpb.DistributedState
+	pendingHostnames map[uuid.UUID]string
 }
 
 // NewAgentsInfo creates an empty agents info.
@@ -60,6 +64,7 @@ func NewAgentsInfo() AgentsInfo {
 			SchemaInfo: []*distributedpb.SchemaInfo{},
 			CarnotInfo: []*distributedpb.CarnotInfo{},
 		},
+		pendingHostnames: make(map[uuid.UUID]string),
 	}
 }
 
@@ -71,6 +76,15 @@ func NewTestAgentsInfo(ds *distributedpb.DistributedState) AgentsInfo {
 	}
 }
 
+// NewTestAgentsInfoWithHostnames creates an agents info from a passed in distributed state and agent hostnames.
+func NewTestAgentsInfoWithHostnames(ds *distributedpb.DistributedState, hostnames map[uuid.UUID]string) AgentsInfo {
+	return &AgentsInfoImpl{
+		ds:        *(ds),
+		hostnames: hostnames,
+		pendingDs: nil,
+	}
+}
+
 // ClearPendingState clears the pending agents info state (the upcoming version).
 func (a *AgentsInfoImpl) ClearPendingState() {
 	log.Infof("Clearing distributed state")
@@ -78,6 +92,7 @@ func (a *AgentsInfoImpl) ClearPendingState() {
 		SchemaInfo: []*distributedpb.SchemaInfo{},
 		CarnotInfo: []*distributedpb.CarnotInfo{},
 	}
+	a.pendingHostnames = make(map[uuid.UUID]string)
 }
 
 // UpdateAgentsInfo creates a new agent info.
@@ -117,6 +132,7 @@ func (a *AgentsInfoImpl) UpdateAgentsInfo(update *metadatapb.AgentUpdatesRespons
 			} else {
 				createdAgents++
 			}
+			a.pendingHostnames[agentUUID] = agent.Info.GetHostInfo().GetHostname()
 
 			if agent.Info.Capabilities == nil || agent.Info.Capabilities.CollectsData {
 				var metadataInfo *distributedpb.MetadataInfo
@@ -151,6 +167,7 @@ func (a *AgentsInfoImpl) UpdateAgentsInfo(update *metadatapb.AgentUpdatesRespons
 		if agentUpdate.GetDeleted() {
 			deletedAgents++
 			delete(carnotInfoMap, agentUUID)
+			delete(a.pendingHostnames, agentUUID)
 		}
 	}
 
@@ -167,8 +184,13 @@ func (a *AgentsInfoImpl) UpdateAgentsInfo(update *metadatapb.AgentUpdatesRespons
 	// If we have reached the end of version, promote the pending DistributedState to the current external-facing
 	// distributed state accessible by clients of `Agents`.
 	if update.EndOfVersion {
+		hostnames := make(map[uuid.UUID]string, len(a.pendingHostnames))
+		for agentID, hostname := range a.pendingHostnames {
+			hostnames[agentID] = hostname
+		}
 		a.dsMutex.Lock()
 		a.ds = *(a.pendingDs)
+		a.hostnames = hostnames
 		a.dsMutex.Unlock()
 	}
 
@@ -184,6 +206,14 @@ func (a *AgentsInfoImpl) DistributedState() distributedpb.DistributedState {
 	return a.ds
 }
 
+// AgentHostnames returns the hostname of each agent in the current distributed state.
+// The returned map must not be modified.
+func (a *AgentsInfoImpl) AgentHostnames() map[uuid.UUID]string {
+	a.dsMutex.Lock()
+	defer a.dsMutex.Unlock()
+	return a.hostnames
+}
+
 func makeAgentCarnotInfo(agentID uuid.UUID, asid uint32, agentMetadata *distributedpb.MetadataInfo) *distributedpb.CarnotInfo {
 	return &distributedpb.CarnotInfo{
 		QueryBrokerAddress:   agentID.String(),
@@ -269,6 +269,10 @@ func TestAgentsInfo_UpdateAgentsInfo(t *testing.T) {
 	assert.Equal(t, 2, len(agentsMap))
 	assert.Equal(t, expectedPEM1Info, agentsMap[uuids[0]])
 	assert.Equal(t, expectedKelvinInfo, agentsMap[uuids[1]])
+	assert.Equal(t, map[uuid.UUID]string{
+		uuids[0]: "test_pem1",
+		uuids[1]: "test_kelvin",
+	}, agentsInfo.AgentHostnames())
 
 	// Update agent 1, and add table metadata for another agent,
 	// create an agent, and delete an agent.
@@ -339,6 +343,10 @@ func TestAgentsInfo_UpdateAgentsInfo(t *testing.T) {
 	assert.Equal(t, expectedPEM1Info, agentsMap[uuids[0]])
 	// Agent 3 should be created.
 	assert.Equal(t, expectedPEM2Info, agentsMap[uuids[2]])
+	assert.Equal(t, map[uuid.UUID]string{
+		uuids[0]: "test_pem1",
+		uuids[2]: "test_pem2",
+	}, agentsInfo.AgentHostnames())
 
 	// Test the case where the schema is updated to be fully empty.
 	err = agentsInfo.UpdateAgentsInfo(&metadatapb.AgentUpdatesResponse{
@@ -18,7 +18,14 @@
 
 package datastore
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTxnConflict is returned when a transaction's comparisons don't hold. None of its writes are applied.
+var ErrTxnConflict = errors.New("transaction comparison failed")
 
 // Getter is a datastore that implements a simple way to get values.
 type Getter interface {
@@ -56,15 +63,66 @@ type MultiDeleter interface {
 	DeleteWithPrefix(prefix string) error
 }
 
+// Txn is a set of writes that are applied to the datastore atomically, in the order they were added.
+// Either all of the writes are applied, or none of them are.
+type Txn interface {
+	// Set puts the given key and value in the datastore.
+	Set(key string, value string)
+	// SetWithTTL puts the given key and value into the datastore with a TTL.
+	SetWithTTL(key string, value string, ttl time.Duration)
+	// Delete deletes the value for the given key from the datastore.
+	Delete(key string)
+	// DeleteWithPrefix deletes all keys and values with the given prefix.
+	DeleteWithPrefix(prefix string)
+	// Compare makes the transaction conditional on the current value of the key. A nil value requires
+	// the key to not exist. Commit returns ErrTxnConflict if any of the comparisons don't hold.
+	Compare(key string, value []byte)
+	// Commit applies the writes.
+	Commit() error
+}
+
+// Transactor is a datastore that can apply several writes atomically.
+type Transactor interface {
+	Txn() Txn
+}
+
+// EventType is the type of change made to a key.
+type EventType int
+
+const (
+	// EventTypePut is a key that was set.
+	EventTypePut EventType = iota
+	// EventTypeDelete is a key that was deleted, or that expired.
+	EventTypeDelete
+)
+
+// Event is a change to a key in the datastore.
+type Event struct {
+	Type EventType
+	Key  string
+	// Value is the new value of the key. It is nil for deletes.
+	Value []byte
+}
+
+// Watcher is a datastore that streams the changes made to its keys.
+type Watcher interface {
+	// Watch streams the changes to keys with the given prefix that are made after it is called. The
+	// channel is closed once the context is cancelled, or if the watch fails, in which case the
+	// caller should re-read the keys and watch again.
+	Watch(ctx context.Context, prefix string) (<-chan *Event, error)
+}
+
 // Closer is a datastore that can be closed commit changes and cleanup any pending resources.
 type Closer interface {
 	Close() error
 }
 
-// MultiGetterSetterDeleterCloser combines MultiGetter, TTLSetter, MultiDeleter, and Closer.
+// MultiGetterSetterDeleterCloser combines MultiGetter, TTLSetter, MultiDeleter, Transactor, Watcher, and Closer.
 type MultiGetterSetterDeleterCloser interface {
 	MultiGetter
 	TTLSetter
 	MultiDeleter
+	Transactor
+	Watcher
 	Closer
 }
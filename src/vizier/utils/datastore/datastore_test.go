@@ -16,10 +16,13 @@
  * SPDX-License-Identifier: Apache-2.0
  */
 
-package datastore
+package datastore_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,11 +32,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"px.dev/pixie/src/utils/testingutils"
+	"px.dev/pixie/src/vizier/utils/datastore"
 	"px.dev/pixie/src/vizier/utils/datastore/etcd"
 	"px.dev/pixie/src/vizier/utils/datastore/pebbledb"
 )
 
-func setupDatastore(t *testing.T, db Setter) {
+func setupDatastore(t *testing.T, db datastore.Setter) {
 	err := db.Set("jam1", "neg")
 	require.NoError(t, err)
 	err = db.Set("key1", "val1")
@@ -64,7 +68,7 @@ func TestDatastore(t *testing.T) {
 	defer cleanup()
 
 	tests := []struct {
-		db          MultiGetterSetterDeleterCloser
+		db          datastore.MultiGetterSetterDeleterCloser
 		name        string
 		runTTLTests bool
 	}{
@@ -254,6 +258,154 @@ func TestDatastore(t *testing.T) {
 				})
 			}
 
+			t.Run("Txn", func(t *testing.T) {
+				setupDatastore(t, db)
+				txn := db.Txn()
+				txn.Set("key1", "txn1")
+				txn.Delete("key2")
+				txn.DeleteWithPrefix("lim")
+				txn.SetWithTTL("txnTTL", "timed", time.Hour)
+
+				// Writes aren't applied until the transaction is committed.
+				v, err := db.Get("key1")
+				require.NoError(t, err)
+				assert.Equal(t, "val1", string(v))
+
+				require.NoError(t, txn.Commit())
+
+				v, err = db.Get("key1")
+				require.NoError(t, err)
+				assert.Equal(t, "txn1", string(v))
+				v, err = db.Get("key2")
+				require.NoError(t, err)
+				assert.Nil(t, v)
+				v, err = db.Get("lim1")
+				require.NoError(t, err)
+				assert.Nil(t, v)
+				v, err = db.Get("txnTTL")
+				require.NoError(t, err)
+				assert.Equal(t, "timed", string(v))
+				v, err = db.Get("key3")
+				require.NoError(t, err)
+				assert.Equal(t, "val3", string(v))
+			})
+
+			t.Run("TxnCompare", func(t *testing.T) {
+				setupDatastore(t, db)
+				txn := db.Txn()
+				txn.Compare("key1", []byte("val1"))
+				txn.Compare("nonexistent", nil)
+				txn.Set("key3", "swapped")
+				require.NoError(t, txn.Commit())
+				v, err := db.Get("key3")
+				require.NoError(t, err)
+				assert.Equal(t, "swapped", string(v))
+
+				txn = db.Txn()
+				txn.Compare("key1", []byte("wrong"))
+				txn.Set("key3", "conflict")
+				txn.Delete("key2")
+				assert.ErrorIs(t, txn.Commit(), datastore.ErrTxnConflict)
+				v, err = db.Get("key3")
+				require.NoError(t, err)
+				assert.Equal(t, "swapped", string(v))
+				v, err = db.Get("key2")
+				require.NoError(t, err)
+				assert.Equal(t, "val2", string(v))
+			})
+
+			t.Run("TxnCompareConcurrent", func(t *testing.T) {
+				require.NoError(t, db.Set("counter", "0"))
+
+				// Each increment retries until its comparison holds, so none of them are lost.
+				increment := func() error {
+					for {
+						v, err := db.Get("counter")
+						if err != nil {
+							return err
+						}
+						var count int
+						_, err = fmt.Sscan(string(v), &count)
+						if err != nil {
+							return err
+						}
+						txn := db.Txn()
+						txn.Compare("counter", v)
+						txn.Set("counter", fmt.Sprint(count+1))
+						err = txn.Commit()
+						if !errors.Is(err, datastore.ErrTxnConflict) {
+							return err
+						}
+					}
+				}
+
+				numIncrements := 20
+				var wg sync.WaitGroup
+				errs := make(chan error, numIncrements)
+				for i := 0; i < numIncrements; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						errs <- increment()
+					}()
+				}
+				wg.Wait()
+				close(errs)
+				for err := range errs {
+					require.NoError(t, err)
+				}
+
+				v, err := db.Get("counter")
+				require.NoError(t, err)
+				assert.Equal(t, fmt.Sprint(numIncrements), string(v))
+			})
+
+			t.Run("Watch", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				ch, err := db.Watch(ctx, "watch/")
+				require.NoError(t, err)
+
+				require.NoError(t, db.Set("watch/a", "1"))
+				require.NoError(t, db.Set("unwatched", "1"))
+				require.NoError(t, db.Delete("watch/a"))
+				require.NoError(t, db.Set("watch/b", "2"))
+				txn := db.Txn()
+				txn.Set("watch/c", "3")
+				txn.DeleteWithPrefix("watch/b")
+				require.NoError(t, txn.Commit())
+
+				expected := []*datastore.Event{
+					{Type: datastore.EventTypePut, Key: "watch/a", Value: []byte("1")},
+					{Type: datastore.EventTypeDelete, Key: "watch/a"},
+					{Type: datastore.EventTypePut, Key: "watch/b", Value: []byte("2")},
+					{Type: datastore.EventTypePut, Key: "watch/c", Value: []byte("3")},
+					{Type: datastore.EventTypeDelete, Key: "watch/b"},
+				}
+				for _, e := range expected {
+					select {
+					case actual := <-ch:
+						assert.Equal(t, e, actual)
+					case <-time.After(10 * time.Second):
+						t.Fatalf("timed out waiting for event on %s", e.Key)
+					}
+				}
+
+				// The channel is closed once the watch is cancelled.
+				cancel()
+				timeout := time.After(10 * time.Second)
+				for {
+					select {
+					case _, ok := <-ch:
+						if !ok {
+							return
+						}
+					case <-timeout:
+						t.Fatal("timed out waiting for watch to close")
+					}
+				}
+			})
+
 			err := db.Close()
 			assert.NoError(t, err)
 
@@ -20,11 +20,14 @@ package etcd
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"px.dev/pixie/src/vizier/utils/datastore"
 )
 
 // DataStore wraps a clientv3 datastore.
@@ -129,6 +132,150 @@ func (w *DataStore) DeleteWithPrefix(prefix string) error {
 	return err
 }
 
+// Txn creates a transaction that is applied as a single etcd transaction. Etcd limits the number of
+// operations in a transaction, and doesn't allow a transaction to write the same key more than once.
+func (w *DataStore) Txn() datastore.Txn {
+	return &txn{client: w.client}
+}
+
+// txnWrite is a write in a transaction. Writes with a TTL are converted to an operation on commit, once
+// their lease has been granted.
+type txnWrite struct {
+	op     clientv3.Op
+	leased bool
+	key    string
+	value  string
+	ttl    time.Duration
+}
+
+type txn struct {
+	client *clientv3.Client
+	writes []txnWrite
+	cmps   []clientv3.Cmp
+}
+
+// Set puts the given key and value in the datastore.
+func (t *txn) Set(key string, value string) {
+	t.writes = append(t.writes, txnWrite{op: clientv3.OpPut(key, value)})
+}
+
+// SetWithTTL puts the given key and value into the datastore with a TTL.
+func (t *txn) SetWithTTL(key string, value string, ttl time.Duration) {
+	t.writes = append(t.writes, txnWrite{leased: true, key: key, value: value, ttl: ttl})
+}
+
+// Delete deletes the value for the given key from the datastore.
+func (t *txn) Delete(key string) {
+	t.writes = append(t.writes, txnWrite{op: clientv3.OpDelete(key)})
+}
+
+// DeleteWithPrefix deletes all keys and values with the given prefix.
+func (t *txn) DeleteWithPrefix(prefix string) {
+	t.writes = append(t.writes, txnWrite{op: clientv3.OpDelete(prefix, clientv3.WithPrefix())})
+}
+
+// Compare makes the transaction conditional on the current value of the key. A nil value requires
+// the key to not exist.
+func (t *txn) Compare(key string, value []byte) {
+	if value == nil {
+		t.cmps = append(t.cmps, clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+		return
+	}
+	t.cmps = append(t.cmps, clientv3.Compare(clientv3.Value(key), "=", string(value)))
+}
+
+// Commit applies the writes.
+func (t *txn) Commit() error {
+	if len(t.writes)+len(t.cmps) > maxTxnOps {
+		return fmt.Errorf("transaction has %d operations, more than the maximum of %d", len(t.writes)+len(t.cmps), maxTxnOps)
+	}
+
+	// Writes with the same TTL share a lease. The leases have to be granted before the transaction, so
+	// they are revoked if the transaction isn't applied.
+	leases := make(map[time.Duration]clientv3.LeaseID)
+	committed := false
+	defer func() {
+		if !committed {
+			t.revokeLeases(leases)
+		}
+	}()
+
+	ops := make([]clientv3.Op, len(t.writes))
+	for i, wr := range t.writes {
+		if !wr.leased {
+			ops[i] = wr.op
+			continue
+		}
+		leaseID, ok := leases[wr.ttl]
+		if !ok {
+			resp, err := t.client.Grant(context.Background(), int64(wr.ttl.Seconds()))
+			if err != nil {
+				return err
+			}
+			leaseID = resp.ID
+			leases[wr.ttl] = leaseID
+		}
+		ops[i] = clientv3.OpPut(wr.key, wr.value, clientv3.WithLease(leaseID))
+	}
+
+	resp, err := t.client.Txn(context.Background()).If(t.cmps...).Then(ops...).Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return datastore.ErrTxnConflict
+	}
+	committed = true
+	return nil
+}
+
+// revokeLeases revokes the leases granted for a transaction that wasn't applied. A lease that fails to be
+// revoked isn't attached to any keys, and is removed by etcd once its TTL expires.
+func (t *txn) revokeLeases(leases map[time.Duration]clientv3.LeaseID) {
+	for _, leaseID := range leases {
+		_, _ = t.client.Revoke(context.Background(), leaseID)
+	}
+}
+
+// Watch streams the changes to keys with the given prefix that are made after it is called. The
+// channel is closed once the context is cancelled, or if the watch fails.
+func (w *DataStore) Watch(ctx context.Context, prefix string) (<-chan *datastore.Event, error) {
+	// Start the watch from the current revision, so that changes made right after Watch returns
+	// aren't missed while the watch is being created.
+	resp, err := w.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return nil, err
+	}
+	wch := w.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix(), clientv3.WithRev(resp.Header.Revision+1))
+
+	ch := make(chan *datastore.Event)
+	go func() {
+		defer close(ch)
+		for wresp := range wch {
+			if wresp.Err() != nil {
+				return
+			}
+			for _, ev := range wresp.Events {
+				e := &datastore.Event{
+					Type: datastore.EventTypePut,
+					Key:  string(ev.Kv.Key),
+				}
+				if ev.Type == mvccpb.DELETE {
+					e.Type = datastore.EventTypeDelete
+				} else {
+					e.Value = ev.Kv.Value
+				}
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
 // Close closes the underlying datastore.
 // All other operations will fail after calling Close.
 func (w *DataStore) Close() error {
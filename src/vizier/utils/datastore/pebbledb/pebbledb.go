@@ -19,19 +19,30 @@
 package pebbledb
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cockroachdb/pebble"
+
+	"px.dev/pixie/src/vizier/utils/datastore"
 )
 
 const (
 	ttlByKeyPrefix  = "___ttl___"
 	ttlByTimePrefix = "___ttl_time___"
+	// watchBufferSize is the number of events that are buffered for each watcher. A watcher that falls
+	// further behind is closed.
+	watchBufferSize = 1024
 )
 
+func isTTLKey(key string) bool {
+	return strings.HasPrefix(key, ttlByKeyPrefix) || strings.HasPrefix(key, ttlByTimePrefix)
+}
+
 func getKeyForTTLByKey(key string) string {
 	return fmt.Sprintf("%s/%s", ttlByKeyPrefix, key)
 }
@@ -55,19 +66,35 @@ func getKeyToDeleteFromTTLByTime(ttlByTimeKey string) (string, error) {
 	return ttlByTimeKey[prefixLen:], nil
 }
 
+// watcher streams the changes to keys with a prefix.
+type watcher struct {
+	prefix string
+	ch     chan *datastore.Event
+	done   chan struct{}
+}
+
 // DataStore wraps a pebbledb datastore.
 type DataStore struct {
 	db *pebble.DB
 
 	done chan struct{}
 	once sync.Once
+
+	// writeMu is held while a write is applied and its events are published, so that watchers see the
+	// changes in the order they were made, and so that transactions can evaluate their comparisons
+	// atomically with their writes.
+	writeMu sync.Mutex
+
+	watchersMu sync.Mutex
+	watchers   map[*watcher]struct{}
 }
 
 // New creates a new pebbledb for use as a KVStore.
 func New(db *pebble.DB, ttlReaperDuration time.Duration) *DataStore {
 	wrap := &DataStore{
-		db:   db,
-		done: make(chan struct{}),
+		db:       db,
+		done:     make(chan struct{}),
+		watchers: make(map[*watcher]struct{}),
 	}
 
 	go wrap.ttlWatcher(ttlReaperDuration)
@@ -144,22 +171,27 @@ func (w *DataStore) ttlWatcher(ttlReaperDuration time.Duration) {
 
 // Set puts the given key and value in the datastore.
 func (w *DataStore) Set(key string, value string) error {
-	return w.db.Set([]byte(key), []byte(value), pebble.Sync)
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	err := w.db.Set([]byte(key), []byte(value), pebble.Sync)
+	if err != nil {
+		return err
+	}
+	w.publish(putEvent(key, value))
+	return nil
 }
 
-// SetWithTTL puts the given key and value into the datastore with a TTL.
-// Once the TTL expires the datastore is expected to delete the given key and value.
-func (w *DataStore) SetWithTTL(key string, value string, ttl time.Duration) error {
-	batch := w.db.NewBatch()
+// setWithTTL adds the given key and value to the batch, along with the keys that the TTL watcher
+// uses to expire it.
+func setWithTTL(batch *pebble.Batch, key string, value string, ttl time.Duration) error {
 	expiresAt := time.Now().Add(ttl)
 	encodedExpiry, err := expiresAt.MarshalBinary()
 	if err != nil {
-		batch.Close()
 		return err
 	}
 	err = batch.Set([]byte(key), []byte(value), pebble.Sync)
 	if err != nil {
-		batch.Close()
 		return err
 	}
 
@@ -168,15 +200,30 @@ func (w *DataStore) SetWithTTL(key string, value string, ttl time.Duration) erro
 
 	err = batch.Set([]byte(ttlByKey), encodedExpiry, pebble.Sync)
 	if err != nil {
-		batch.Close()
 		return err
 	}
-	err = batch.Set([]byte(ttlByTime), nil, pebble.Sync)
+	return batch.Set([]byte(ttlByTime), nil, pebble.Sync)
+}
+
+// SetWithTTL puts the given key and value into the datastore with a TTL.
+// Once the TTL expires the datastore is expected to delete the given key and value.
+func (w *DataStore) SetWithTTL(key string, value string, ttl time.Duration) error {
+	batch := w.db.NewBatch()
+	defer batch.Close()
+	err := setWithTTL(batch, key, value, ttl)
+	if err != nil {
+		return err
+	}
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	err = batch.Commit(pebble.Sync)
 	if err != nil {
-		batch.Close()
 		return err
 	}
-	return batch.Commit(pebble.Sync)
+	w.publish(putEvent(key, value))
+	return nil
 }
 
 // Get gets the value for the given key from the datastore.
@@ -226,19 +273,38 @@ func (w *DataStore) GetWithPrefix(prefix string) ([]string, [][]byte, error) {
 
 // Delete deletes the value for the given key from the datastore.
 func (w *DataStore) Delete(key string) error {
-	return w.db.Delete([]byte(key), pebble.Sync)
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	err := w.db.Delete([]byte(key), pebble.Sync)
+	if err != nil {
+		return err
+	}
+	w.publish(deleteEvent(key))
+	return nil
 }
 
 // DeleteAll deletes all of the given keys and corresponding values in the datastore if they exist.
 func (w *DataStore) DeleteAll(keys []string) error {
 	batch := w.db.NewBatch()
-	for _, key := range keys {
+	events := make([]*datastore.Event, len(keys))
+	for i, key := range keys {
 		err := batch.Delete([]byte(key), pebble.Sync)
 		if err != nil {
 			return err
 		}
+		events[i] = deleteEvent(key)
+	}
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	err := batch.Commit(pebble.Sync)
+	if err != nil {
+		return err
 	}
-	return batch.Commit(pebble.Sync)
+	w.publish(events...)
+	return nil
 }
 
 // DeleteWithPrefix deletes all keys and values with the given prefix.
@@ -247,7 +313,253 @@ func (w *DataStore) DeleteWithPrefix(prefix string) error {
 	if ub == nil {
 		return fmt.Errorf("unsupported prefix: %x", prefix)
 	}
-	return w.db.DeleteRange([]byte(prefix), ub, pebble.Sync)
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	events, err := w.deleteEventsForPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	err = w.db.DeleteRange([]byte(prefix), ub, pebble.Sync)
+	if err != nil {
+		return err
+	}
+	w.publish(events...)
+	return nil
+}
+
+// Txn creates a transaction that applies its writes in a single pebble batch. Its comparisons are
+// evaluated while holding the write lock, so they are atomic with respect to all other writes made
+// through the DataStore.
+func (w *DataStore) Txn() datastore.Txn {
+	return &txn{
+		w:     w,
+		batch: w.db.NewBatch(),
+	}
+}
+
+// txnWrite is a write in a transaction. The keys deleted by a prefix are only known when the
+// transaction is committed.
+type txnWrite struct {
+	event  *datastore.Event
+	prefix string
+}
+
+type txnCompare struct {
+	key   string
+	value []byte
+}
+
+type txn struct {
+	w      *DataStore
+	batch  *pebble.Batch
+	writes []*txnWrite
+	cmps   []*txnCompare
+	err    error
+}
+
+// Set puts the given key and value in the datastore.
+func (t *txn) Set(key string, value string) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.batch.Set([]byte(key), []byte(value), pebble.Sync)
+	t.writes = append(t.writes, &txnWrite{event: putEvent(key, value)})
+}
+
+// SetWithTTL puts the given key and value into the datastore with a TTL.
+func (t *txn) SetWithTTL(key string, value string, ttl time.Duration) {
+	if t.err != nil {
+		return
+	}
+	t.err = setWithTTL(t.batch, key, value, ttl)
+	t.writes = append(t.writes, &txnWrite{event: putEvent(key, value)})
+}
+
+// Delete deletes the value for the given key from the datastore.
+func (t *txn) Delete(key string) {
+	if t.err != nil {
+		return
+	}
+	t.err = t.batch.Delete([]byte(key), pebble.Sync)
+	t.writes = append(t.writes, &txnWrite{event: deleteEvent(key)})
+}
+
+// DeleteWithPrefix deletes all keys and values with the given prefix.
+func (t *txn) DeleteWithPrefix(prefix string) {
+	if t.err != nil {
+		return
+	}
+	ub := KeyUpperBound([]byte(prefix))
+	if ub == nil {
+		t.err = fmt.Errorf("unsupported prefix: %x", prefix)
+		return
+	}
+	t.err = t.batch.DeleteRange([]byte(prefix), ub, pebble.Sync)
+	t.writes = append(t.writes, &txnWrite{prefix: prefix})
+}
+
+// Compare makes the transaction conditional on the current value of the key. A nil value requires
+// the key to not exist.
+func (t *txn) Compare(key string, value []byte) {
+	t.cmps = append(t.cmps, &txnCompare{key: key, value: value})
+}
+
+// compare checks that the comparisons hold. The write lock must be held.
+func (t *txn) compare() error {
+	for _, c := range t.cmps {
+		v, err := t.w.Get(c.key)
+		if err != nil {
+			return err
+		}
+		if (v == nil) != (c.value == nil) || !bytes.Equal(v, c.value) {
+			return datastore.ErrTxnConflict
+		}
+	}
+	return nil
+}
+
+// events gets the events for the writes. The write lock must be held, so that the keys deleted by a
+// prefix don't change before the batch is committed.
+func (t *txn) events() ([]*datastore.Event, error) {
+	var events []*datastore.Event
+	for _, wr := range t.writes {
+		if wr.prefix == "" {
+			events = append(events, wr.event)
+			continue
+		}
+		deletes, err := t.w.deleteEventsForPrefix(wr.prefix)
+		if err != nil {
+			return nil, err
+		}
+		// The keys that were set earlier in the transaction are also deleted.
+		for _, e := range events {
+			if e.Type == datastore.EventTypePut && strings.HasPrefix(e.Key, wr.prefix) {
+				deletes = append(deletes, deleteEvent(e.Key))
+			}
+		}
+		events = append(events, deletes...)
+	}
+	return events, nil
+}
+
+// Commit applies the writes. It returns datastore.ErrTxnConflict, and applies none of the writes, if
+// any of the comparisons don't hold.
+func (t *txn) Commit() error {
+	defer t.batch.Close()
+	if t.err != nil {
+		return t.err
+	}
+
+	t.w.writeMu.Lock()
+	defer t.w.writeMu.Unlock()
+
+	err := t.compare()
+	if err != nil {
+		return err
+	}
+	events, err := t.events()
+	if err != nil {
+		return err
+	}
+	err = t.batch.Commit(pebble.Sync)
+	if err != nil {
+		return err
+	}
+	t.w.publish(events...)
+	return nil
+}
+
+func putEvent(key string, value string) *datastore.Event {
+	return &datastore.Event{Type: datastore.EventTypePut, Key: key, Value: []byte(value)}
+}
+
+func deleteEvent(key string) *datastore.Event {
+	return &datastore.Event{Type: datastore.EventTypeDelete, Key: key}
+}
+
+// deleteEventsForPrefix gets the events for deleting the keys with the given prefix. The keys are
+// only read if there are watchers, since pebble doesn't report which keys a range delete removed.
+func (w *DataStore) deleteEventsForPrefix(prefix string) ([]*datastore.Event, error) {
+	w.watchersMu.Lock()
+	numWatchers := len(w.watchers)
+	w.watchersMu.Unlock()
+	if numWatchers == 0 {
+		return nil, nil
+	}
+
+	keys, _, err := w.GetWithPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*datastore.Event, len(keys))
+	for i, key := range keys {
+		events[i] = deleteEvent(key)
+	}
+	return events, nil
+}
+
+// Watch streams the changes to keys with the given prefix that are made after it is called. The
+// channel is closed once the context is cancelled, the datastore is closed, or if the caller falls
+// too far behind in reading the changes.
+func (w *DataStore) Watch(ctx context.Context, prefix string) (<-chan *datastore.Event, error) {
+	wt := &watcher{
+		prefix: prefix,
+		ch:     make(chan *datastore.Event, watchBufferSize),
+		done:   make(chan struct{}),
+	}
+
+	w.watchersMu.Lock()
+	w.watchers[wt] = struct{}{}
+	w.watchersMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.done:
+		case <-wt.done:
+			return
+		}
+		w.watchersMu.Lock()
+		defer w.watchersMu.Unlock()
+		w.removeWatcherLocked(wt)
+	}()
+
+	return wt.ch, nil
+}
+
+func (w *DataStore) removeWatcherLocked(wt *watcher) {
+	if _, ok := w.watchers[wt]; !ok {
+		return
+	}
+	delete(w.watchers, wt)
+	close(wt.ch)
+	close(wt.done)
+}
+
+// publish sends the events to the watchers of their keys. The keys used to track TTLs are internal
+// to the datastore, so they aren't sent.
+func (w *DataStore) publish(events ...*datastore.Event) {
+	w.watchersMu.Lock()
+	defer w.watchersMu.Unlock()
+
+	for wt := range w.watchers {
+		for _, e := range events {
+			if isTTLKey(e.Key) || !strings.HasPrefix(e.Key, wt.prefix) {
+				continue
+			}
+			select {
+			case wt.ch <- e:
+				continue
+			default:
+			}
+			// The watcher fell too far behind. Close it, so that it re-reads the keys instead of
+			// missing changes.
+			w.removeWatcherLocked(wt)
+			break
+		}
+	}
 }
 
 // Close stops the TTL watcher, and closes the underlying datastore.